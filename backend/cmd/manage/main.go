@@ -5,82 +5,215 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
-	"github.com/joho/godotenv"
+	"github.com/mokan/flame-crm-backend/internal/config"
 	"github.com/mokan/flame-crm-backend/internal/db"
+	"github.com/mokan/flame-crm-backend/internal/importer"
+	"github.com/mokan/flame-crm-backend/internal/migrate"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gopkg.in/yaml.v3"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 func main() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Error loading .env file, using OS env vars or defaults")
-	}
-
-	action := flag.String("action", "", "Action to perform: createdb, seed")
+	action := flag.String("action", "", "Action to perform: createdb, migrate, seed, import")
+	entity := flag.String("entity", service.ImportEntityCustomers, "Import: entity to import (customers or companies)")
+	file := flag.String("file", "", "Import: path to the CSV file")
+	mapping := flag.String("mapping", "", "Import: column mapping as \"CSV column=field,...\"")
+	dedupe := flag.String("dedupe", "", "Import: field used to match existing records")
+	dryRun := flag.Bool("dry-run", false, "Import: report changes without writing them")
+	errorsPath := flag.String("errors", "", "Import: write rejected rows to this CSV file")
 	flag.Parse()
 
 	cmd := *action
-	if cmd == "" {
-		if len(os.Args) > 1 {
-			cmd = os.Args[1]
+	if cmd == "" && flag.NArg() > 0 {
+		cmd = flag.Arg(0)
+		if err := flag.CommandLine.Parse(flag.Args()[1:]); err != nil {
+			log.Fatal(err)
 		}
 	}
 
 	if cmd == "" {
-		fmt.Println("Usage: go run cmd/manage/main.go [createdb|seed]")
+		fmt.Println("Usage: go run cmd/manage/main.go [createdb|migrate|seed|import|config-check]")
 		return
 	}
 
+	if cmd == "config-check" {
+		os.Exit(configCheck())
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Invalid configuration:\n%v", err)
+	}
+
 	switch cmd {
 	case "createdb":
-		createDB()
+		createDB(cfg.Database)
+	case "migrate":
+		runMigrate(cfg.Database, flag.Args())
 	case "seed":
-		db.ConnectDatabase()
-		db.Seed(db.DB)
+		db.Seed(db.ConnectDatabase(cfg.Database))
+	case "import":
+		opts := service.ImportOptions{Entity: *entity, DedupeKey: *dedupe, DryRun: *dryRun, Role: models.RoleAdmin}
+		importCSV(cfg.Database, *file, *mapping, *errorsPath, opts)
 	default:
 		fmt.Printf("Unknown action: %s\n", cmd)
-		fmt.Println("Available actions: createdb, seed")
+		fmt.Println("Available actions: createdb, migrate, seed, import, config-check")
 	}
 }
 
-func createDB() {
-	targetDBName := os.Getenv("DB_NAME")
-	if targetDBName == "" {
-		targetDBName = "flame_crm"
+// configCheck prints the effective configuration with secrets redacted,
+// followed by any warnings and errors, and returns the exit status.
+func configCheck() int {
+	cfg, err := config.Load()
+	out, marshalErr := yaml.Marshal(cfg.Redacted())
+	if marshalErr != nil {
+		log.Fatal(marshalErr)
 	}
+	fmt.Print(string(out))
 
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	port := os.Getenv("DB_PORT")
-	sslmode := os.Getenv("DB_SSLMODE")
-	timezone := os.Getenv("DB_TIMEZONE")
+	for _, warning := range cfg.Warnings() {
+		fmt.Printf("warning: %s\n", warning)
+	}
+	if err != nil {
+		for _, line := range strings.Split(err.Error(), "\n") {
+			fmt.Printf("error: %s\n", line)
+		}
+		return 1
+	}
+	fmt.Printf("Configuration is valid for %s.\n", cfg.Env)
+	return 0
+}
 
-	if host == "" {
-		host = "localhost"
+// runMigrate handles "migrate up|down|status|to <version>".
+func runMigrate(database config.Database, args []string) {
+	if len(args) == 0 {
+		fmt.Println("Usage: go run cmd/manage/main.go migrate [up|down|status|to <version>]")
+		return
 	}
-	if user == "" {
-		user = "postgres"
+
+	conn, err := db.Open(database)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
 	}
-	if password == "" {
-		password = "postgres"
+	migrator, err := migrate.New(conn)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
 	}
-	if port == "" {
-		port = "5432"
+
+	var ran []migrate.Migration
+	switch args[0] {
+	case "up":
+		ran, err = migrator.Up()
+	case "down":
+		var m *migrate.Migration
+		m, err = migrator.Down()
+		if m != nil {
+			ran = append(ran, *m)
+		}
+	case "to":
+		if len(args) < 2 {
+			log.Fatal("migrate to requires a version")
+		}
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			log.Fatalf("Invalid version %q", args[1])
+		}
+		ran, err = migrator.To(version)
+	case "status":
+		printMigrationStatus(migrator)
+		return
+	default:
+		log.Fatalf("Unknown migrate command: %s", args[0])
 	}
-	if sslmode == "" {
-		sslmode = "disable"
+
+	for _, m := range ran {
+		fmt.Printf("Ran migration %d_%s\n", m.Version, m.Name)
+	}
+	if err != nil {
+		log.Fatal("Migration failed: ", err)
 	}
-	if timezone == "" {
-		timezone = "UTC"
+	if len(ran) == 0 {
+		fmt.Println("Nothing to do.")
 	}
+}
+
+func printMigrationStatus(migrator *migrate.Migrator) {
+	statuses, err := migrator.Status()
+	if err != nil {
+		log.Fatal("Failed to read schema version:", err)
+	}
+	for _, s := range statuses {
+		state := "pending"
+		switch {
+		case s.Unknown:
+			state = "applied " + s.AppliedAt.Format(time.RFC3339) + " (unknown to this build)"
+		case s.Applied():
+			state = "applied " + s.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%04d_%s\t%s\n", s.Version, s.Name, state)
+	}
+}
+
+func importCSV(database config.Database, path, mapping, errorsPath string, opts service.ImportOptions) {
+	if path == "" {
+		log.Fatal("Import requires -file")
+	}
+	var err error
+	opts.Mapping, err = importer.ParseMapping(mapping)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		log.Fatal("Failed to open CSV file:", err)
+	}
+	defer file.Close()
+
+	report, err := service.NewImports(repository.NewGorm(db.ConnectDatabase(database))).Run(file, opts)
+	if err != nil {
+		log.Fatal("Import failed: ", err)
+	}
+
+	for _, row := range report.Rows {
+		if row.Action == importer.ActionError {
+			fmt.Printf("line %d: %s\n", row.Line, strings.Join(row.Errors, "; "))
+		}
+	}
+	mode := ""
+	if report.DryRun {
+		mode = " (dry run, nothing written)"
+	}
+	fmt.Printf("Imported %s%s: %d rows, %d created, %d updated, %d rejected\n",
+		report.Entity, mode, report.Total, report.Created, report.Updated, report.Failed)
+
+	if errorsPath != "" && report.HasErrors() {
+		out, err := os.Create(errorsPath)
+		if err != nil {
+			log.Fatal("Failed to create error report:", err)
+		}
+		defer out.Close()
+		if err := report.WriteErrors(out); err != nil {
+			log.Fatal("Failed to write error report:", err)
+		}
+		fmt.Printf("Rejected rows written to %s\n", errorsPath)
+	}
+}
 
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=postgres port=%s sslmode=%s TimeZone=%s",
-		host, user, password, port, sslmode, timezone)
+func createDB(database config.Database) {
+	targetDBName := database.Name
+	maintenance := database
+	maintenance.Name = "postgres"
 
-	maintenanceDB, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	maintenanceDB, err := gorm.Open(postgres.Open(maintenance.DSN()), &gorm.Config{})
 	if err != nil {
 		log.Fatal("Failed to connect to database instance (postgres):", err)
 	}
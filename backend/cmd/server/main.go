@@ -2,53 +2,37 @@ package main
 
 import (
 	"log"
+	"net"
 
-	"github.com/gin-gonic/gin"
-	"github.com/gin-contrib/cors"
-	"github.com/joho/godotenv"
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/config"
 	"github.com/mokan/flame-crm-backend/internal/db"
-	"github.com/mokan/flame-crm-backend/internal/handlers"
-	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"github.com/mokan/flame-crm-backend/internal/router"
 )
 
 func main() {
-	err := godotenv.Load()
+	cfg, err := config.Load()
 	if err != nil {
-		log.Println("Error loading .env file, using OS env vars or defaults")
+		log.Fatalf("Invalid configuration:\n%v", err)
 	}
+	for _, warning := range cfg.Warnings() {
+		log.Printf("Configuration warning: %s", warning)
+	}
+	auth.Configure(cfg.JWTSecret, cfg.APIKeys)
 
-	db.ConnectDatabase()
-
-	r := gin.Default()
-
-	config := cors.DefaultConfig()
-	config.AllowAllOrigins = true
-	config.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization"}
-	r.Use(cors.New(config))
-
-	r.POST("/register", handlers.Register)
-	r.POST("/login", handlers.Login)
-
-	protected := r.Group("/api")
-	protected.Use(middleware.AuthMiddleware())
-	{
-		protected.GET("/companies", handlers.GetCompanies)
-		protected.POST("/companies", handlers.CreateCompany)
-		protected.PUT("/companies/:id", handlers.UpdateCompany)
-
-		protected.GET("/users", handlers.GetUsers)
-		protected.POST("/users", handlers.CreateUser)
-		protected.PUT("/users/:id", handlers.UpdateUser)
-
-		protected.GET("/customers", handlers.GetCustomers)
-		protected.POST("/customers", handlers.CreateCustomer)
-		protected.PUT("/customers/:id", handlers.UpdateCustomer)
+	database := db.ConnectDatabase(cfg.Database)
 
-		protected.GET("/funnels", handlers.GetFunnels)
-		protected.POST("/funnels", handlers.CreateFunnel)
-		protected.PUT("/funnels/:id", handlers.UpdateFunnel)
-		protected.DELETE("/funnels/:id", handlers.DeleteFunnel)
+	lis, err := net.Listen("tcp", cfg.GRPCAddr)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", cfg.GRPCAddr, err)
 	}
-
-	r.Run(":8080")
-}
\ No newline at end of file
+	go func() {
+		if err := router.NewGRPC(cfg, database).Serve(lis); err != nil {
+			log.Fatalf("gRPC server stopped: %v", err)
+		}
+	}()
+
+	if err := router.New(cfg, database).Run(cfg.HTTPAddr); err != nil {
+		log.Fatalf("HTTP server stopped: %v", err)
+	}
+}
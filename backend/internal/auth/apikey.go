@@ -0,0 +1,36 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"strings"
+)
+
+type apiKey struct {
+	key  string
+	role string
+}
+
+func parseAPIKeys(value string) []apiKey {
+	var keys []apiKey
+	for _, entry := range strings.Split(value, ",") {
+		key, role, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" || role == "" {
+			continue
+		}
+		keys = append(keys, apiKey{key: key, role: role})
+	}
+	return keys
+}
+
+// ValidateAPIKey returns claims for a service key passed to Configure, e.g.
+// "billing-7f3a=admin,support-91c2=sales".
+// Services are not users, so UserID is zero and only the role applies.
+func ValidateAPIKey(key string) (*Claims, error) {
+	for _, k := range apiKeys {
+		if subtle.ConstantTimeCompare([]byte(k.key), []byte(key)) == 1 {
+			return &Claims{Role: k.role}, nil
+		}
+	}
+	return nil, errors.New("invalid api key")
+}
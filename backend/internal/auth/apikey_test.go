@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys := parseAPIKeys(" billing=admin, support=sales,broken,=admin,empty=")
+	assert.Equal(t, []apiKey{{key: "billing", role: "admin"}, {key: "support", role: "sales"}}, keys)
+}
+
+func TestValidateAPIKey(t *testing.T) {
+	Configure("test-secret", "billing=admin,support=sales")
+	t.Cleanup(func() { Configure("test-secret", "") })
+
+	claims, err := ValidateAPIKey("support")
+	assert.NoError(t, err)
+	assert.Equal(t, "sales", claims.Role)
+	assert.Zero(t, claims.UserID)
+
+	_, err = ValidateAPIKey("billing-typo")
+	assert.Error(t, err)
+	_, err = ValidateAPIKey("")
+	assert.Error(t, err)
+}
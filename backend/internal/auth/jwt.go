@@ -1,19 +1,26 @@
 package auth
 
 import (
+	"context"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtKey = []byte(os.Getenv("JWT_SECRET"))
+var (
+	jwtKey  []byte
+	apiKeys []apiKey
+)
 
-func init() {
-	if len(jwtKey) == 0 {
-		jwtKey = []byte("very_secret_key")
-	}
+var ErrNotConfigured = errors.New("auth is not configured")
+
+// Configure sets the JWT signing secret and the service API keys, given as
+// comma-separated key=role pairs. It must run before tokens are issued or
+// checked.
+func Configure(jwtSecret, keys string) {
+	jwtKey = []byte(jwtSecret)
+	apiKeys = parseAPIKeys(keys)
 }
 
 type Claims struct {
@@ -32,11 +39,17 @@ func GenerateToken(userID uint, role string) (string, error) {
 		},
 	}
 
+	if len(jwtKey) == 0 {
+		return "", ErrNotConfigured
+	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtKey)
 }
 
 func ValidateToken(tokenString string) (*Claims, error) {
+	if len(jwtKey) == 0 {
+		return nil, ErrNotConfigured
+	}
 	claims := &Claims{}
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
 		return jwtKey, nil
@@ -52,3 +65,16 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return claims, nil
 }
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated caller, for
+// code paths outside gin such as GraphQL resolvers and gRPC services.
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -8,6 +9,11 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+func TestMain(m *testing.M) {
+	Configure("test-secret", "")
+	os.Exit(m.Run())
+}
+
 func TestGenerateToken(t *testing.T) {
 	userID := uint(1)
 	role := "admin"
@@ -69,3 +75,15 @@ func TestValidateToken_Expired(t *testing.T) {
 	assert.Nil(t, validatedClaims)
 	assert.Contains(t, err.Error(), "token is expired")
 }
+
+func TestTokensRequireConfigure(t *testing.T) {
+	key := jwtKey
+	t.Cleanup(func() { jwtKey = key })
+	tokenString, _ := GenerateToken(1, "admin")
+
+	jwtKey = nil
+	_, err := GenerateToken(1, "admin")
+	assert.ErrorIs(t, err, ErrNotConfigured)
+	_, err = ValidateToken(tokenString)
+	assert.ErrorIs(t, err, ErrNotConfigured)
+}
@@ -0,0 +1,94 @@
+// Package changefeed reads the append-only log of writes to the synced
+// tables, so clients can ask what changed since they last looked. The log
+// and the triggers that fill it, which see writes from every code path
+// including batch updates and raw SQL, are created by migration
+// 0003_change_feed.
+package changefeed
+
+import (
+	"encoding/base64"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EntityCompany  = "company"
+	EntityCustomer = "customer"
+	EntityUser     = "user"
+	EntityFunnel   = "funnel"
+
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionDeleted = "deleted"
+
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Change is one row of the log. Seq increases in commit order.
+type Change struct {
+	Seq       uint64    `json:"seq"`
+	Entity    string    `json:"entity"`
+	EntityID  uint      `json:"entity_id"`
+	Action    string    `json:"action"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type Options struct {
+	After    uint64
+	Entities []string
+	Limit    int
+}
+
+// Since returns up to opts.Limit changes after opts.After, oldest first, and
+// whether more are waiting.
+func Since(tx *gorm.DB, opts Options) ([]Change, bool, error) {
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	q := tx.Table("changes").Where("seq > ?", opts.After)
+	if len(opts.Entities) > 0 {
+		q = q.Where("entity IN ?", opts.Entities)
+	}
+	var changes []Change
+	if err := q.Order("seq").Limit(limit + 1).Find(&changes).Error; err != nil {
+		return nil, false, err
+	}
+	more := len(changes) > limit
+	if more {
+		changes = changes[:limit]
+	}
+	if changes == nil {
+		changes = []Change{}
+	}
+	return changes, more, nil
+}
+
+func EncodeCursor(seq uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(seq, 10)))
+}
+
+func DecodeCursor(cursor string) (uint64, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	seq, err := strconv.ParseUint(string(raw), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	return seq, nil
+}
@@ -0,0 +1,154 @@
+package changefeed
+
+import (
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/migrate"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupChangesDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	// Stop before the change feed, so rows created now are already there
+	// when migrateChangesDB creates it.
+	m, err := migrate.New(database)
+	require.NoError(t, err)
+	_, err = m.To(12)
+	require.NoError(t, err)
+	return database
+}
+
+// migrateChangesDB applies the migrations, which log the rows already there
+// as created.
+func migrateChangesDB(t *testing.T, database *gorm.DB) {
+	m, err := migrate.New(database)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+}
+
+func actions(changes []Change) []string {
+	var result []string
+	for _, change := range changes {
+		result = append(result, change.Entity+" "+change.Action)
+	}
+	return result
+}
+
+func TestSinceRecordsEveryWrite(t *testing.T) {
+	database := setupChangesDB(t)
+
+	existing := models.Company{Name: "Existing"}
+	require.NoError(t, database.Create(&existing).Error)
+	migrateChangesDB(t, database)
+	migrateChangesDB(t, database)
+
+	changes, more, err := Since(database, Options{})
+	require.NoError(t, err)
+	assert.False(t, more)
+	assert.Equal(t, []string{"company created"}, actions(changes))
+	after := changes[0].Seq
+
+	customer := models.Customer{Name: "Ada", Email: "ada@example.com", CompanyID: existing.ID}
+	require.NoError(t, database.Create(&customer).Error)
+	require.NoError(t, database.Model(&models.Customer{}).Where("company_id = ?", existing.ID).Update("funnel_stage", "Won").Error)
+	require.NoError(t, database.Delete(&customer).Error)
+	require.NoError(t, database.Unscoped().Model(&customer).Update("deleted_at", nil).Error)
+	require.NoError(t, database.Exec("DELETE FROM companies WHERE id = ?", existing.ID).Error)
+
+	changes, _, err = Since(database, Options{After: after})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer created", "customer updated", "customer deleted", "customer created", "company deleted"}, actions(changes))
+	for i, change := range changes {
+		assert.Greater(t, change.Seq, after)
+		if i > 0 {
+			assert.Greater(t, change.Seq, changes[i-1].Seq)
+		}
+	}
+	assert.Equal(t, customer.ID, changes[0].EntityID)
+	assert.False(t, changes[0].ChangedAt.IsZero())
+
+	changes, _, err = Since(database, Options{After: after, Entities: []string{EntityCompany}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"company deleted"}, actions(changes))
+}
+
+func TestSinceLogsTagsAndMembershipsAsCustomerUpdates(t *testing.T) {
+	database := setupChangesDB(t)
+	migrateChangesDB(t, database)
+
+	funnel := models.Funnel{Name: "Lead"}
+	require.NoError(t, database.Create(&funnel).Error)
+	customer := models.Customer{Name: "Ada", Email: "ada@example.com"}
+	require.NoError(t, database.Create(&customer).Error)
+	tag := models.Tag{Name: "vip"}
+	require.NoError(t, database.Create(&tag).Error)
+	changes, _, err := Since(database, Options{})
+	require.NoError(t, err)
+	after := changes[len(changes)-1].Seq
+
+	require.NoError(t, database.Exec("INSERT INTO customer_tags (customer_id, tag_id) VALUES (?, ?)", customer.ID, tag.ID).Error)
+	membership := models.CustomerFunnel{CustomerID: customer.ID, Pipeline: "sales", FunnelID: funnel.ID}
+	require.NoError(t, database.Create(&membership).Error)
+	require.NoError(t, database.Model(&membership).Update("funnel_stage", "Qualified").Error)
+	require.NoError(t, database.Exec("DELETE FROM customer_tags WHERE customer_id = ?", customer.ID).Error)
+
+	changes, _, err = Since(database, Options{After: after, Entities: []string{EntityCustomer}})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer updated", "customer updated", "customer updated", "customer updated"}, actions(changes))
+	for _, change := range changes {
+		assert.Equal(t, customer.ID, change.EntityID)
+	}
+
+	require.NoError(t, database.Delete(&customer).Error)
+	require.NoError(t, database.Delete(&membership).Error)
+	changes, _, err = Since(database, Options{After: changes[len(changes)-1].Seq})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"customer deleted"}, actions(changes))
+}
+
+func TestSincePages(t *testing.T) {
+	database := setupChangesDB(t)
+	migrateChangesDB(t, database)
+	for _, name := range []string{"A", "B", "C"} {
+		require.NoError(t, database.Create(&models.Funnel{Name: name}).Error)
+	}
+
+	var seen []uint
+	var after uint64
+	for {
+		changes, more, err := Since(database, Options{After: after, Limit: 2})
+		require.NoError(t, err)
+		for _, change := range changes {
+			seen = append(seen, change.EntityID)
+			after = change.Seq
+		}
+		if !more {
+			break
+		}
+	}
+	assert.Len(t, seen, 3)
+}
+
+func TestCursor(t *testing.T) {
+	seq, err := DecodeCursor(EncodeCursor(42))
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(42), seq)
+
+	seq, err = DecodeCursor("")
+	assert.NoError(t, err)
+	assert.Zero(t, seq)
+
+	for _, cursor := range []string{"%%%", EncodeCursor(1) + "x", "YWJj"} {
+		_, err := DecodeCursor(cursor)
+		assert.ErrorIs(t, err, ErrInvalidCursor, cursor)
+	}
+}
@@ -0,0 +1,284 @@
+// Package config loads the server settings from defaults, an optional YAML
+// file, a .env file and the environment, and checks them before anything
+// starts.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvTest        = "test"
+	EnvProduction  = "production"
+
+	// DevelopmentJWTSecret signs tokens when JWT_SECRET is not set and
+	// APP_ENV is explicitly development or test. Production refuses to start
+	// with it.
+	DevelopmentJWTSecret = "very_secret_key"
+
+	minProductionSecretLength = 32
+)
+
+// insecureSecrets are values that ship with the code or the docs and must
+// not be used in production.
+var insecureSecrets = []string{DevelopmentJWTSecret, "supersecretjwtkey", "secret", "changeme", "postgres"}
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+	TimeZone string `yaml:"timezone"`
+	// AutoMigrate applies pending migrations on start instead of refusing
+	// to start.
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+// DSN is the PostgreSQL connection string for d.
+func (d Database) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%d sslmode=%s TimeZone=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode, d.TimeZone)
+}
+
+type Config struct {
+	Env      string `yaml:"env"`
+	HTTPAddr string `yaml:"http_addr"`
+	GRPCAddr string `yaml:"grpc_addr"`
+	// JWTSecret signs the tokens issued by /login.
+	JWTSecret string `yaml:"jwt_secret"`
+	// APIKeys lists gRPC service keys as comma-separated key=role pairs.
+	APIKeys        string        `yaml:"api_keys"`
+	IdempotencyTTL time.Duration `yaml:"idempotency_ttl"`
+	Database       Database      `yaml:"database"`
+}
+
+func Default() Config {
+	return Config{
+		Env:            EnvDevelopment,
+		HTTPAddr:       ":8080",
+		GRPCAddr:       ":9090",
+		IdempotencyTTL: 24 * time.Hour,
+		Database: Database{
+			Host:     "localhost",
+			Port:     5432,
+			User:     "postgres",
+			Password: "postgres",
+			Name:     "flame_crm",
+			SSLMode:  "disable",
+			TimeZone: "UTC",
+		},
+	}
+}
+
+// envVars maps each environment variable to the setting it overrides.
+var envVars = []struct {
+	name string
+	set  func(c *Config, value string) error
+}{
+	{"APP_ENV", func(c *Config, v string) error { c.Env = v; return nil }},
+	{"HTTP_ADDR", func(c *Config, v string) error { c.HTTPAddr = v; return nil }},
+	{"GRPC_ADDR", func(c *Config, v string) error { c.GRPCAddr = v; return nil }},
+	{"JWT_SECRET", func(c *Config, v string) error { c.JWTSecret = v; return nil }},
+	{"API_KEYS", func(c *Config, v string) error { c.APIKeys = v; return nil }},
+	{"IDEMPOTENCY_TTL", func(c *Config, v string) (err error) { c.IdempotencyTTL, err = time.ParseDuration(v); return err }},
+	{"DB_HOST", func(c *Config, v string) error { c.Database.Host = v; return nil }},
+	{"DB_PORT", func(c *Config, v string) (err error) { c.Database.Port, err = strconv.Atoi(v); return err }},
+	{"DB_USER", func(c *Config, v string) error { c.Database.User = v; return nil }},
+	{"DB_PASSWORD", func(c *Config, v string) error { c.Database.Password = v; return nil }},
+	{"DB_NAME", func(c *Config, v string) error { c.Database.Name = v; return nil }},
+	{"DB_SSLMODE", func(c *Config, v string) error { c.Database.SSLMode = v; return nil }},
+	{"DB_TIMEZONE", func(c *Config, v string) error { c.Database.TimeZone = v; return nil }},
+	{"DB_AUTO_MIGRATE", func(c *Config, v string) (err error) { c.Database.AutoMigrate, err = strconv.ParseBool(v); return err }},
+}
+
+// Load reads .env into the environment, without overriding variables that
+// are already set, and then builds the config with FromEnv.
+func Load() (Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("reading .env: %w", err)
+	}
+	return FromEnv(os.LookupEnv)
+}
+
+// FromEnv builds the config from defaults, then the YAML file named by
+// CONFIG_FILE, then the variables in envVars. Empty variables count as
+// unset. The returned error lists every problem found, not just the first.
+func FromEnv(lookup func(string) (string, bool)) (Config, error) {
+	cfg := Default()
+	// Env starts empty so that only an explicit development or test setting
+	// enables the development JWT secret.
+	cfg.Env = ""
+	if path, _ := lookup("CONFIG_FILE"); path != "" {
+		if err := cfg.readYAML(path); err != nil {
+			return cfg, err
+		}
+	}
+
+	var errs []error
+	for _, v := range envVars {
+		value, _ := lookup(v.name)
+		if value == "" {
+			continue
+		}
+		if err := v.set(&cfg, value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q", v.name, value))
+		}
+	}
+	switch {
+	case cfg.Env == "":
+		cfg.Env = EnvDevelopment
+	case cfg.JWTSecret == "" && (cfg.Env == EnvDevelopment || cfg.Env == EnvTest):
+		cfg.JWTSecret = DevelopmentJWTSecret
+	}
+	if err := cfg.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	return cfg, errors.Join(errs...)
+}
+
+func (c *Config) readYAML(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	defer file.Close()
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports every invalid setting, including a missing JWT secret. In
+// production it also rejects short or well-known secrets.
+func (c Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if !slices.Contains([]string{EnvDevelopment, EnvTest, EnvProduction}, c.Env) {
+		fail("APP_ENV must be one of development, test, production, got %q", c.Env)
+	}
+	for _, addr := range [][2]string{{"HTTP_ADDR", c.HTTPAddr}, {"GRPC_ADDR", c.GRPCAddr}} {
+		if _, port, err := net.SplitHostPort(addr[1]); err != nil || !validPort(port) {
+			fail("%s must be host:port, got %q", addr[0], addr[1])
+		}
+	}
+	if c.IdempotencyTTL <= 0 {
+		fail("IDEMPOTENCY_TTL must be positive, got %s", c.IdempotencyTTL)
+	}
+	if err := validateAPIKeys(c.APIKeys); err != nil {
+		errs = append(errs, err)
+	}
+
+	db := c.Database
+	for _, setting := range [][2]string{{"DB_HOST", db.Host}, {"DB_USER", db.User}, {"DB_NAME", db.Name}, {"DB_TIMEZONE", db.TimeZone}} {
+		if setting[1] == "" {
+			fail("%s is required", setting[0])
+		}
+	}
+	if db.Port < 1 || db.Port > 65535 {
+		fail("DB_PORT must be between 1 and 65535, got %d", db.Port)
+	}
+	if !slices.Contains(sslModes, db.SSLMode) {
+		fail("DB_SSLMODE must be one of %s, got %q", strings.Join(sslModes, ", "), db.SSLMode)
+	}
+
+	if c.JWTSecret == "" {
+		fail("JWT_SECRET is required unless APP_ENV is development or test")
+	}
+	if c.Env == EnvProduction {
+		switch {
+		case c.JWTSecret == "":
+			// Already reported.
+		case slices.Contains(insecureSecrets, c.JWTSecret):
+			fail("JWT_SECRET is a published default and cannot be used in production")
+		case len(c.JWTSecret) < minProductionSecretLength:
+			fail("JWT_SECRET must be at least %d characters in production", minProductionSecretLength)
+		}
+		if db.Password == "" || slices.Contains(insecureSecrets, db.Password) {
+			fail("DB_PASSWORD is empty or a published default and cannot be used in production")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Warnings lists settings that are accepted outside production but would
+// stop a production start.
+func (c Config) Warnings() []string {
+	if c.Env == EnvProduction {
+		return nil
+	}
+	var warnings []string
+	if slices.Contains(insecureSecrets, c.JWTSecret) {
+		warnings = append(warnings, "JWT_SECRET is not set or is a published default; tokens can be forged")
+	}
+	if slices.Contains(insecureSecrets, c.Database.Password) {
+		warnings = append(warnings, "DB_PASSWORD is a published default")
+	}
+	return warnings
+}
+
+// Redacted returns a copy of c that is safe to print.
+func (c Config) Redacted() Config {
+	c.JWTSecret = redact(c.JWTSecret)
+	c.Database.Password = redact(c.Database.Password)
+	if c.APIKeys != "" {
+		var keys []string
+		for _, entry := range strings.Split(c.APIKeys, ",") {
+			_, role, _ := strings.Cut(strings.TrimSpace(entry), "=")
+			keys = append(keys, redact("key")+"="+role)
+		}
+		c.APIKeys = strings.Join(keys, ",")
+	}
+	return c
+}
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[redacted]"
+}
+
+func validateAPIKeys(value string) error {
+	if value == "" {
+		return nil
+	}
+	roles := []string{string(models.RoleAdmin), string(models.RoleSales), string(models.RoleHeadOfSales)}
+	for i, entry := range strings.Split(value, ",") {
+		key, role, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || key == "" {
+			return fmt.Errorf("API_KEYS entry %d must be key=role", i+1)
+		}
+		if !slices.Contains(roles, role) {
+			return fmt.Errorf("API_KEYS entry %d has unknown role %q", i+1, role)
+		}
+	}
+	return nil
+}
+
+func validPort(port string) bool {
+	n, err := strconv.Atoi(port)
+	return err == nil && n >= 0 && n <= 65535
+}
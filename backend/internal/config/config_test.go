@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func lookup(env map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		value, ok := env[name]
+		return value, ok
+	}
+}
+
+func TestFromEnvDefaults(t *testing.T) {
+	cfg, err := FromEnv(lookup(map[string]string{"APP_ENV": EnvDevelopment}))
+	require.NoError(t, err)
+	assert.Equal(t, EnvDevelopment, cfg.Env)
+	assert.Equal(t, ":8080", cfg.HTTPAddr)
+	assert.Equal(t, 24*time.Hour, cfg.IdempotencyTTL)
+	assert.False(t, cfg.Database.AutoMigrate)
+	assert.Equal(t, DevelopmentJWTSecret, cfg.JWTSecret)
+	assert.Len(t, cfg.Warnings(), 2)
+	assert.Equal(t, "host=localhost user=postgres password=postgres dbname=flame_crm port=5432 sslmode=disable TimeZone=UTC", cfg.Database.DSN())
+}
+
+func TestFromEnvLayers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flame.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("http_addr: \":9000\"\nidempotency_ttl: 2h\ndatabase:\n  host: db.internal\n  name: from_yaml\n"), 0o600))
+
+	cfg, err := FromEnv(lookup(map[string]string{
+		"CONFIG_FILE":     path,
+		"DB_NAME":         "from_env",
+		"DB_AUTO_MIGRATE": "true",
+		"GRPC_ADDR":       "",
+		"JWT_SECRET":      "signing-secret",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, ":9000", cfg.HTTPAddr)
+	assert.Equal(t, ":9090", cfg.GRPCAddr)
+	assert.Equal(t, 2*time.Hour, cfg.IdempotencyTTL)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, "from_env", cfg.Database.Name)
+	assert.True(t, cfg.Database.AutoMigrate)
+
+	require.NoError(t, os.WriteFile(path, []byte("databse:\n  host: typo\n"), 0o600))
+	_, err = FromEnv(lookup(map[string]string{"CONFIG_FILE": path}))
+	assert.ErrorContains(t, err, "databse")
+
+	_, err = FromEnv(lookup(map[string]string{"CONFIG_FILE": filepath.Join(t.TempDir(), "missing.yaml")}))
+	assert.Error(t, err)
+}
+
+func TestJWTSecretFallbackNeedsExplicitEnv(t *testing.T) {
+	cfg, err := FromEnv(lookup(nil))
+	assert.ErrorContains(t, err, "JWT_SECRET is required unless APP_ENV is development or test")
+	assert.Equal(t, EnvDevelopment, cfg.Env)
+	assert.Empty(t, cfg.JWTSecret)
+
+	cfg, err = FromEnv(lookup(map[string]string{"APP_ENV": EnvTest}))
+	require.NoError(t, err)
+	assert.Equal(t, DevelopmentJWTSecret, cfg.JWTSecret)
+
+	path := filepath.Join(t.TempDir(), "flame.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("env: development\n"), 0o600))
+	cfg, err = FromEnv(lookup(map[string]string{"CONFIG_FILE": path}))
+	require.NoError(t, err)
+	assert.Equal(t, DevelopmentJWTSecret, cfg.JWTSecret)
+}
+
+func TestFromEnvReportsEveryProblem(t *testing.T) {
+	_, err := FromEnv(lookup(map[string]string{
+		"APP_ENV":         "staging",
+		"HTTP_ADDR":       "8080",
+		"DB_PORT":         "five",
+		"DB_SSLMODE":      "on",
+		"IDEMPOTENCY_TTL": "soon",
+		"API_KEYS":        "billing=root",
+		"DB_AUTO_MIGRATE": "sometimes",
+	}))
+	require.Error(t, err)
+	for _, want := range []string{"APP_ENV", "HTTP_ADDR", "DB_PORT", "DB_SSLMODE", "IDEMPOTENCY_TTL", "API_KEYS", "DB_AUTO_MIGRATE", "JWT_SECRET"} {
+		assert.Contains(t, err.Error(), want)
+	}
+}
+
+func TestProductionRejectsDefaultSecrets(t *testing.T) {
+	_, err := FromEnv(lookup(map[string]string{"APP_ENV": EnvProduction}))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "JWT_SECRET is required")
+	assert.Contains(t, err.Error(), "DB_PASSWORD")
+
+	for _, secret := range []string{DevelopmentJWTSecret, "supersecretjwtkey", "too-short"} {
+		_, err = FromEnv(lookup(map[string]string{"APP_ENV": EnvProduction, "JWT_SECRET": secret, "DB_PASSWORD": "s3cret-db-password"}))
+		assert.ErrorContains(t, err, "JWT_SECRET", secret)
+	}
+
+	cfg, err := FromEnv(lookup(map[string]string{
+		"APP_ENV":     EnvProduction,
+		"JWT_SECRET":  strings.Repeat("k", 32),
+		"DB_PASSWORD": "s3cret-db-password",
+	}))
+	require.NoError(t, err)
+	assert.Empty(t, cfg.Warnings())
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Default()
+	cfg.JWTSecret = "signing-secret"
+	cfg.APIKeys = "billing-7f3a=admin,support-91c2=sales"
+
+	out, err := yaml.Marshal(cfg.Redacted())
+	require.NoError(t, err)
+	for _, secret := range []string{"signing-secret", "billing-7f3a", "support-91c2", "password: postgres"} {
+		assert.NotContains(t, string(out), secret)
+	}
+	assert.Contains(t, string(out), "=sales")
+	assert.Contains(t, string(out), "idempotency_ttl: 24h0m0s")
+	assert.Equal(t, "signing-secret", cfg.JWTSecret)
+}
@@ -3,38 +3,57 @@ package db
 import (
 	"fmt"
 	"log"
-	"os"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
-	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/config"
+	"github.com/mokan/flame-crm-backend/internal/migrate"
 )
 
-var DB *gorm.DB
-
-func ConnectDatabase() {
-	host := os.Getenv("DB_HOST")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-	port := os.Getenv("DB_PORT")
-	sslmode := os.Getenv("DB_SSLMODE")
-	timezone := os.Getenv("DB_TIMEZONE")
-
-	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		host, user, password, dbname, port, sslmode, timezone)
+// Open connects to the database without touching the schema.
+func Open(cfg config.Database) (*gorm.DB, error) {
+	return gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{TranslateError: true})
+}
 
-	database, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+// ConnectDatabase opens the database and checks that every migration is
+// applied, applying pending ones first when cfg.AutoMigrate is set.
+func ConnectDatabase(cfg config.Database) *gorm.DB {
+	database, err := Open(cfg)
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
 
-	err = database.AutoMigrate(&models.Company{}, &models.User{}, &models.Customer{}, &models.Funnel{})
+	migrator, err := migrate.New(database)
+	if err != nil {
+		log.Fatal("Failed to load migrations:", err)
+	}
+	if cfg.AutoMigrate {
+		ran, err := migrator.Up()
+		if err != nil {
+			log.Fatal("Failed to migrate database:", err)
+		}
+		for _, m := range ran {
+			fmt.Printf("Applied migration %d_%s\n", m.Version, m.Name)
+		}
+	}
+	statuses, err := migrator.Status()
 	if err != nil {
-		log.Fatal("Failed to migrate database:", err)
+		log.Fatal("Failed to read schema version:", err)
+	}
+	pending := 0
+	for _, s := range statuses {
+		switch {
+		case s.Unknown:
+			log.Printf("Warning: database has migration %d_%s, which this build does not know", s.Version, s.Name)
+		case !s.Applied():
+			pending++
+		}
+	}
+	if pending > 0 {
+		log.Fatalf("Database schema is behind: %d pending migrations. Run `go run ./cmd/manage migrate up` or set DB_AUTO_MIGRATE=true", pending)
 	}
 
 	fmt.Println("Database connection successfully opened")
-	DB = database
+	return database
 }
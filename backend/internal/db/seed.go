@@ -9,11 +9,6 @@ import (
 )
 
 func Seed(db *gorm.DB) {
-	if err := db.AutoMigrate(&models.Company{}, &models.User{}, &models.Customer{}, &models.Funnel{}); err != nil {
-		fmt.Println("Error running migrations during seed:", err)
-		return
-	}
-
 	var count int64
 	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
 		fmt.Println("Error checking user count:", err)
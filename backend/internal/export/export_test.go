@@ -0,0 +1,61 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(FormatCSV, &buf, "customers")
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteHeader([]string{"ID", "Name", "Phone", "Created"}))
+	created := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.NoError(t, w.WriteRow([]interface{}{int64(1), []byte("Ada, Countess"), "+1 (555) 010", created}))
+	assert.NoError(t, w.WriteRow([]interface{}{int64(2), "=HYPERLINK(\"x\")", nil, created}))
+	assert.NoError(t, w.Close())
+
+	assert.Equal(t, "ID,Name,Phone,Created\n"+
+		"1,\"Ada, Countess\",+1 (555) 010,2026-03-04 05:06:07\n"+
+		"2,\"'=HYPERLINK(\"\"x\"\")\",,2026-03-04 05:06:07\n", buf.String())
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := New(FormatXLSX, &buf, "customers")
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.WriteHeader([]string{"ID", "Name"}))
+	assert.NoError(t, w.WriteRow([]interface{}{int64(42), "Ada <& Co>"}))
+	assert.NoError(t, w.Close())
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := map[string]string{}
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(r)
+		files[f.Name] = string(body)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="customers"`)
+	sheet := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, sheet, `<c r="A2"><v>42</v></c>`)
+	assert.Contains(t, sheet, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">Ada &lt;&amp; Co&gt;</t></is></c>`)
+	assert.Contains(t, sheet, `</sheetData></worksheet>`)
+}
+
+func TestUnsupportedFormat(t *testing.T) {
+	_, err := New("pdf", io.Discard, "x")
+	assert.Error(t, err)
+	assert.Equal(t, "AA", columnName(26))
+	assert.Equal(t, "A", columnName(0))
+}
@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+)
+
+// GetAPIUsage reports requests per API version and route since the server
+// started.
+func GetAPIUsage(c *gin.Context) {
+	if currentRole(c) != models.RoleAdmin {
+		problem.Forbidden(c, "Only admins can view API usage")
+		return
+	}
+	c.JSON(http.StatusOK, middleware.VersionUsageStats())
+}
@@ -1,13 +1,14 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mokan/flame-crm-backend/internal/auth"
-	"github.com/mokan/flame-crm-backend/internal/db"
 	"github.com/mokan/flame-crm-backend/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/service"
 )
 
 type RegisterInput struct {
@@ -21,64 +22,56 @@ type LoginInput struct {
 	Password string `json:"password" binding:"required"`
 }
 
-func Register(c *gin.Context) {
+type RegisterResponse struct {
+	Message string      `json:"message"`
+	User    models.User `json:"user"`
+}
+
+type LoginResponse struct {
+	Token  string      `json:"token"`
+	Role   models.Role `json:"role"`
+	UserID uint        `json:"user_id"`
+	Name   string      `json:"name"`
+}
+
+func (h *Handler) Register(c *gin.Context) {
 	var input RegisterInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		problem.Bind(c, err)
 		return
 	}
 
-	var count int64
-	db.DB.Model(&models.User{}).Count(&count)
-	role := models.RoleSales
-	if count == 0 {
-		role = models.RoleAdmin
-	}
-
-	user := models.User{
-		Name:     input.Name,
-		Email:    input.Email,
-		Password: string(hashedPassword),
-		Role:     role,
-	}
-
-	if err := db.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already exists or invalid data"})
+	user := models.User{Name: input.Name, Email: input.Email}
+	if err := h.users(h.db).Register(&user, input.Password); err != nil {
+		userSaveFailed(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "User registered successfully", "user": user})
+	c.JSON(http.StatusOK, RegisterResponse{Message: "User registered successfully", User: user})
 }
 
-func Login(c *gin.Context) {
+func (h *Handler) Login(c *gin.Context) {
 	var input LoginInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
-	var user models.User
-	if err := db.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	user, err := h.users(h.db).Authenticate(input.Email, input.Password)
+	if errors.Is(err, service.ErrInvalidCredentials) {
+		problem.Respond(c, http.StatusUnauthorized, problem.CodeInvalidCredentials, "Invalid credentials")
 		return
 	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	if err != nil {
+		problem.Internal(c, err)
 		return
 	}
 
 	token, err := auth.GenerateToken(user.ID, string(user.Role))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		problem.Internal(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"token": token, "role": user.Role, "user_id": user.ID, "name": user.Name})
+	c.JSON(http.StatusOK, LoginResponse{Token: token, Role: user.Role, UserID: user.ID, Name: user.Name})
 }
@@ -21,17 +21,17 @@ func TestRegister_InvalidInput(t *testing.T) {
 		{
 			name: "Missing Name",
 			body: `{"email": "test@example.com", "password": "password123"}`,
-			expectedBody: "Error:Field validation for 'Name' failed on the 'required' tag",
+			expectedBody: `{"field":"name","code":"required","message":"is required"}`,
 		},
 		{
 			name: "Invalid Email",
 			body: `{"name": "Test", "email": "not-an-email", "password": "password123"}`,
-			expectedBody: "Error:Field validation for 'Email' failed on the 'email' tag",
+			expectedBody: `{"field":"email","code":"email","message":"must be a valid email address"}`,
 		},
 		{
 			name: "Short Password",
 			body: `{"name": "Test", "email": "test@example.com", "password": "123"}`,
-			expectedBody: "Error:Field validation for 'Password' failed on the 'min' tag",
+			expectedBody: `{"field":"password","code":"min","message":"must be at least 6 characters long"}`,
 		},
 	}
 
@@ -44,9 +44,11 @@ func TestRegister_InvalidInput(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			c.Request = req
 
-			Register(c)
+			testHandler.Register(c)
 
 			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Contains(t, w.Body.String(), `"code":"validation_failed"`)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
 		})
 	}
@@ -63,17 +65,17 @@ func TestLogin_InvalidInput(t *testing.T) {
 		{
 			name: "Missing Email",
 			body: `{"password": "password123"}`,
-			expectedBody: "Error:Field validation for 'Email' failed on the 'required' tag",
+			expectedBody: `{"field":"email","code":"required","message":"is required"}`,
 		},
 		{
 			name: "Invalid Email Format",
 			body: `{"email": "bad-email", "password": "password123"}`,
-			expectedBody: "Error:Field validation for 'Email' failed on the 'email' tag",
+			expectedBody: `{"field":"email","code":"email","message":"must be a valid email address"}`,
 		},
 		{
 			name: "Missing Password",
 			body: `{"email": "test@example.com"}`,
-			expectedBody: "Error:Field validation for 'Password' failed on the 'required' tag",
+			expectedBody: `{"field":"password","code":"required","message":"is required"}`,
 		},
 	}
 
@@ -86,10 +88,25 @@ func TestLogin_InvalidInput(t *testing.T) {
 			c, _ := gin.CreateTestContext(w)
 			c.Request = req
 
-			Login(c)
+			testHandler.Login(c)
 
 			assert.Equal(t, http.StatusBadRequest, w.Code)
 			assert.Contains(t, w.Body.String(), tt.expectedBody)
 		})
 	}
 }
+
+func TestRegister_DuplicateEmail(t *testing.T) {
+	clearTable(t)
+
+	r := gin.Default()
+	r.POST("/register", testHandler.Register)
+
+	body := map[string]string{"name": "Test", "email": "dup@example.com", "password": "password123"}
+	w := performRequest(r, "POST", "/register", body)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "POST", "/register", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"already_exists"`)
+}
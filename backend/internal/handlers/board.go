@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultBoardColumnLimit = 20
+	maxBoardColumnLimit     = 100
+
+	// boardPositionGap spaces cards out so that a move only rewrites the
+	// moved card's position, not its neighbours'.
+	boardPositionGap = 1024
+)
+
+var errWIPLimitReached = errors.New("Work-in-progress limit reached for target stage")
+
+type BoardColumn struct {
+	FunnelID   uint              `json:"funnel_id"`
+	Name       string            `json:"name"`
+	Total      int64             `json:"total"`
+	WIPLimit   *int              `json:"wip_limit"`
+	WIPMode    string            `json:"wip_mode,omitempty"`
+	OverLimit  bool              `json:"over_limit"`
+	Customers  []models.Customer `json:"customers"`
+	NextOffset *int              `json:"next_offset"`
+}
+
+type FunnelBoard struct {
+	FunnelID uint          `json:"funnel_id"`
+	Columns  []BoardColumn `json:"columns"`
+}
+
+type MoveBoardCardResult struct {
+	Customer models.Customer `json:"customer"`
+	Warnings []string        `json:"warnings"`
+}
+
+type MoveBoardCardInput struct {
+	CustomerID uint `json:"customer_id" binding:"required"`
+	ToFunnelID uint `json:"to_funnel_id" binding:"required"`
+	Position   int  `json:"position" binding:"min=0"`
+}
+
+func (h *Handler) GetFunnelBoard(c *gin.Context) {
+	root, stages, ok := h.loadBoardStages(c)
+	if !ok {
+		return
+	}
+
+	limit, offset := boardPage(c)
+	columns := make([]BoardColumn, 0, len(stages))
+	for _, stage := range stages {
+		column, err := h.loadBoardColumn(stage, limit, offset)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		columns = append(columns, column)
+	}
+
+	c.JSON(http.StatusOK, FunnelBoard{FunnelID: root.ID, Columns: columns})
+}
+
+func (h *Handler) GetFunnelBoardColumn(c *gin.Context) {
+	_, stages, ok := h.loadBoardStages(c)
+	if !ok {
+		return
+	}
+
+	stage := findBoardStage(stages, c.Param("stage_id"))
+	if stage == nil {
+		problem.NotFound(c, "Stage is not part of this board")
+		return
+	}
+
+	limit, offset := boardPage(c)
+	column, err := h.loadBoardColumn(*stage, limit, offset)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, column)
+}
+
+func (h *Handler) MoveBoardCard(c *gin.Context) {
+	_, stages, ok := h.loadBoardStages(c)
+	if !ok {
+		return
+	}
+
+	var input MoveBoardCardInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	target := findBoardStage(stages, strconv.FormatUint(uint64(input.ToFunnelID), 10))
+	if target == nil {
+		problem.BadRequest(c, "Target stage is not part of this board")
+		return
+	}
+
+	var customer models.Customer
+	if err := h.db.First(&customer, input.CustomerID).Error; err != nil {
+		problem.NotFound(c, "Customer not found")
+		return
+	}
+
+	if ifMatchFailed(c, customer.UpdatedAt) {
+		preconditionFailed(c, customer.UpdatedAt, customer)
+		return
+	}
+
+	var warnings []string
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the target stage first serialises moves into it, so two
+		// moves cannot both pass the WIP count, and takes the same lock
+		// DeleteFunnel does before it reads the stage's cards.
+		if err := tx.Scopes(forUpdate).First(target, target.ID).Error; err != nil {
+			return problem.New(http.StatusBadRequest, problem.CodeBadRequest, "Target stage is not part of this board")
+		}
+		if err := claimWrite(c, tx, &models.Customer{}, customer.ID, customer.UpdatedAt); err != nil {
+			return err
+		}
+		if err := tx.Scopes(forUpdate).First(&customer, customer.ID).Error; err != nil {
+			return problem.New(http.StatusNotFound, problem.CodeNotFound, "Customer not found")
+		}
+
+		changingStage := customer.FunnelID == nil || *customer.FunnelID != target.ID
+		if changingStage {
+			if customer.FunnelID != nil {
+				funnels := h.funnels(tx)
+				version, err := funnels.PinnedVersion(customer.FunnelVersionID)
+				if err != nil {
+					return err
+				}
+				if err := funnels.CheckTransition(version, *customer.FunnelID, target.ID); err != nil {
+					return err
+				}
+				customer.FunnelVersionID = version
+			}
+
+			if target.WIPLimit != nil {
+				var count int64
+				if err := tx.Model(&models.Customer{}).Where("funnel_id = ?", target.ID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count >= int64(*target.WIPLimit) {
+					if target.WIPMode == models.WIPModeWarn {
+						warnings = append(warnings, errWIPLimitReached.Error())
+					} else {
+						return errWIPLimitReached
+					}
+				}
+			}
+
+			if customer.FunnelID != nil {
+				move := models.FunnelMove{EntityType: models.FunnelMoveEntityCustomer, EntityID: customer.ID, FromFunnelID: *customer.FunnelID, ToFunnelID: target.ID, Reason: "board_move"}
+				if err := repository.NewGorm(tx).Funnels().RecordMove(&move); err != nil {
+					return err
+				}
+			}
+			customer.FunnelID = &target.ID
+		}
+
+		position, err := boardSlot(tx, target.ID, customer.ID, input.Position)
+		if err != nil {
+			return err
+		}
+		return tx.Model(&customer).Updates(map[string]interface{}{
+			"funnel_id":         customer.FunnelID,
+			"board_position":    position,
+			"funnel_version_id": customer.FunnelVersionID,
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errPreconditionFailed):
+			var current models.Customer
+			h.db.First(&current, customer.ID)
+			preconditionFailed(c, current.UpdatedAt, current)
+		case errors.Is(err, errWIPLimitReached):
+			problem.Write(c, problem.New(http.StatusConflict, problem.CodeWIPLimitReached, err.Error()).
+				With("funnel_id", target.ID).
+				With("wip_limit", *target.WIPLimit))
+		default:
+			problem.Write(c, problemFor(err))
+		}
+		return
+	}
+
+	h.db.First(&customer, customer.ID)
+	setETag(c, customer.UpdatedAt)
+	c.JSON(http.StatusOK, MoveBoardCardResult{Customer: customer, Warnings: warnings})
+}
+
+// boardSlot returns the board_position that puts a card at index among the
+// other cards of a column. When the neighbours at index have no room
+// between them, the column is spaced out again first. That rewrite leaves
+// updated_at alone, so the other cards keep their ETags.
+func boardSlot(tx *gorm.DB, funnelID, customerID uint, index int) (int, error) {
+	var positions []int
+	column := tx.Model(&models.Customer{}).Where("funnel_id = ? AND id <> ?", funnelID, customerID)
+	if err := column.Order("board_position, id").Pluck("board_position", &positions).Error; err != nil {
+		return 0, err
+	}
+	if index > len(positions) {
+		index = len(positions)
+	}
+
+	switch {
+	case len(positions) == 0:
+		return 0, nil
+	case index == 0:
+		return positions[0] - boardPositionGap, nil
+	case index == len(positions):
+		return positions[index-1] + boardPositionGap, nil
+	}
+	if before, after := positions[index-1], positions[index]; after-before > 1 {
+		return before + (after-before)/2, nil
+	}
+
+	var ids []uint
+	column = tx.Model(&models.Customer{}).Where("funnel_id = ? AND id <> ?", funnelID, customerID)
+	if err := column.Order("board_position, id").Pluck("id", &ids).Error; err != nil {
+		return 0, err
+	}
+	for i, id := range ids {
+		slot := i
+		if i >= index {
+			slot = i + 1
+		}
+		if err := tx.Model(&models.Customer{}).Where("id = ?", id).UpdateColumn("board_position", slot*boardPositionGap).Error; err != nil {
+			return 0, err
+		}
+	}
+	return index * boardPositionGap, nil
+}
+
+func (h *Handler) loadBoardStages(c *gin.Context) (*models.Funnel, []models.Funnel, bool) {
+	id := c.Param("id")
+	var root models.Funnel
+	if err := h.db.First(&root, id).Error; err != nil {
+		problem.NotFound(c, "Funnel not found")
+		return nil, nil, false
+	}
+
+	stages, err := h.reachableFunnels(root)
+	if err != nil {
+		problem.Internal(c, err)
+		return nil, nil, false
+	}
+	return &root, stages, true
+}
+
+func (h *Handler) reachableFunnels(root models.Funnel) ([]models.Funnel, error) {
+	stages := []models.Funnel{root}
+	seen := map[uint]bool{root.ID: true}
+	queue := []uint{root.ID}
+
+	for len(queue) > 0 {
+		var current models.Funnel
+		if err := h.db.Preload("NextFunnels", func(tx *gorm.DB) *gorm.DB {
+			return tx.Order("id")
+		}).First(&current, queue[0]).Error; err != nil {
+			return nil, err
+		}
+		queue = queue[1:]
+
+		for _, next := range current.NextFunnels {
+			if seen[next.ID] {
+				continue
+			}
+			seen[next.ID] = true
+			stages = append(stages, *next)
+			queue = append(queue, next.ID)
+		}
+	}
+
+	for i := range stages {
+		stages[i].NextFunnels = nil
+	}
+	return stages, nil
+}
+
+func findBoardStage(stages []models.Funnel, id string) *models.Funnel {
+	for i := range stages {
+		if strconv.FormatUint(uint64(stages[i].ID), 10) == id {
+			return &stages[i]
+		}
+	}
+	return nil
+}
+
+func (h *Handler) loadBoardColumn(stage models.Funnel, limit, offset int) (BoardColumn, error) {
+	column := BoardColumn{
+		FunnelID: stage.ID,
+		Name:     stage.Name,
+		WIPLimit: stage.WIPLimit,
+	}
+	if stage.WIPLimit != nil {
+		column.WIPMode = stage.WIPMode
+		if column.WIPMode == "" {
+			column.WIPMode = models.WIPModeBlock
+		}
+	}
+
+	if err := h.db.Model(&models.Customer{}).Where("funnel_id = ?", stage.ID).Count(&column.Total).Error; err != nil {
+		return column, err
+	}
+	column.OverLimit = stage.WIPLimit != nil && column.Total > int64(*stage.WIPLimit)
+
+	column.Customers = []models.Customer{}
+	if err := h.db.Where("funnel_id = ?", stage.ID).
+		Order("board_position, id").
+		Limit(limit).
+		Offset(offset).
+		Find(&column.Customers).Error; err != nil {
+		return column, err
+	}
+
+	if next := offset + len(column.Customers); int64(next) < column.Total {
+		column.NextOffset = &next
+	}
+	return column, nil
+}
+
+func boardPage(c *gin.Context) (int, int) {
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultBoardColumnLimit
+	}
+	if limit > maxBoardColumnLimit {
+		limit = maxBoardColumnLimit
+	}
+
+	offset, err := strconv.Atoi(c.Query("offset"))
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+	return limit, offset
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupBoardRouter() *gin.Engine {
+	r := gin.Default()
+	r.GET("/funnels/:id/board", testHandler.GetFunnelBoard)
+	r.GET("/funnels/:id/board/columns/:stage_id", testHandler.GetFunnelBoardColumn)
+	r.POST("/funnels/:id/board/move", testHandler.MoveBoardCard)
+	return r
+}
+
+func TestFunnelBoard(t *testing.T) {
+	r := setupBoardRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	limit := 1
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won", WIPLimit: &limit}
+	lost := models.Funnel{Name: "Lost"}
+	for _, f := range []*models.Funnel{&lead, &won, &lost} {
+		assert.NoError(t, testDB.Create(f).Error)
+	}
+	assert.NoError(t, testDB.Model(&lead).Association("NextFunnels").Replace([]*models.Funnel{&won}))
+
+	var customers []models.Customer
+	for i := 0; i < 3; i++ {
+		customer := models.Customer{Name: fmt.Sprintf("Card %d", i), CompanyID: company.ID, FunnelID: &lead.ID, BoardPosition: i}
+		assert.NoError(t, testDB.Create(&customer).Error)
+		customers = append(customers, customer)
+	}
+
+	w := performRequest(r, "GET", fmt.Sprintf("/funnels/%d/board?limit=2", lead.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var board struct {
+		Columns []BoardColumn `json:"columns"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &board))
+	assert.Len(t, board.Columns, 2)
+	assert.Equal(t, lead.ID, board.Columns[0].FunnelID)
+	assert.Equal(t, int64(3), board.Columns[0].Total)
+	assert.Len(t, board.Columns[0].Customers, 2)
+	assert.Equal(t, 2, *board.Columns[0].NextOffset)
+
+	move := MoveBoardCardInput{CustomerID: customers[2].ID, ToFunnelID: lead.ID, Position: 0}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "GET", fmt.Sprintf("/funnels/%d/board/columns/%d", lead.ID, lead.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var column BoardColumn
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &column))
+	assert.Equal(t, []uint{customers[2].ID, customers[0].ID, customers[1].ID},
+		[]uint{column.Customers[0].ID, column.Customers[1].ID, column.Customers[2].ID})
+
+	move = MoveBoardCardInput{CustomerID: customers[2].ID, ToFunnelID: lead.ID, Position: 1}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = performRequest(r, "GET", fmt.Sprintf("/funnels/%d/board/columns/%d", lead.ID, lead.ID), nil)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &column))
+	assert.Equal(t, []uint{customers[0].ID, customers[2].ID, customers[1].ID},
+		[]uint{column.Customers[0].ID, column.Customers[1].ID, column.Customers[2].ID})
+	for _, neighbour := range []models.Customer{customers[0], customers[1]} {
+		var reloaded models.Customer
+		assert.NoError(t, testDB.First(&reloaded, neighbour.ID).Error)
+		assert.True(t, neighbour.UpdatedAt.Equal(reloaded.UpdatedAt), "card %d changed updated_at", neighbour.ID)
+	}
+
+	move = MoveBoardCardInput{CustomerID: customers[0].ID, ToFunnelID: lost.ID}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	move = MoveBoardCardInput{CustomerID: customers[0].ID, ToFunnelID: won.ID}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	move = MoveBoardCardInput{CustomerID: customers[1].ID, ToFunnelID: won.ID}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "Work-in-progress limit")
+
+	won.WIPMode = models.WIPModeWarn
+	assert.NoError(t, testDB.Save(&won).Error)
+	w = performRequest(r, "POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), move)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "Work-in-progress limit")
+}
+
+func TestMoveBoardCardHonoursIfMatch(t *testing.T) {
+	r := setupBoardRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won"}
+	for _, f := range []*models.Funnel{&lead, &won} {
+		assert.NoError(t, testDB.Create(f).Error)
+	}
+	assert.NoError(t, testDB.Model(&lead).Association("NextFunnels").Replace([]*models.Funnel{&won}))
+	customer := models.Customer{Name: "Card", Email: "card@example.com", CompanyID: company.ID, FunnelID: &lead.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+	etag := resourceETag(customer.UpdatedAt)
+
+	move := func(ifMatch string) *httptest.ResponseRecorder {
+		body := fmt.Sprintf(`{"customer_id": %d, "to_funnel_id": %d}`, customer.ID, won.ID)
+		req, _ := http.NewRequest("POST", fmt.Sprintf("/funnels/%d/board/move", lead.ID), bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	assert.NoError(t, testDB.Model(&customer).Update("phone", "555-0100").Error)
+	w := move(etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+
+	var reloaded models.Customer
+	assert.NoError(t, testDB.First(&reloaded, customer.ID).Error)
+	assert.Equal(t, lead.ID, *reloaded.FunnelID)
+
+	w = move(resourceETag(reloaded.UpdatedAt))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	assert.NoError(t, testDB.First(&reloaded, customer.ID).Error)
+	assert.Equal(t, won.ID, *reloaded.FunnelID)
+	assert.Equal(t, "555-0100", reloaded.Phone)
+	assert.Equal(t, "card@example.com", reloaded.Email)
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+const (
+	BulkModeAtomic     = "atomic"
+	BulkModeBestEffort = "best_effort"
+
+	BulkOpCreate = "create"
+	BulkOpUpdate = "update"
+	BulkOpDelete = "delete"
+)
+
+var errBulkAborted = errors.New("Bulk request aborted")
+
+type BulkOperation struct {
+	Op   string          `json:"op" binding:"required,oneof=create update delete"`
+	ID   uint            `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+type BulkInput struct {
+	Mode       string          `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []BulkOperation `json:"operations" binding:"required,min=1,max=1000,dive"`
+}
+
+type BulkResult struct {
+	Index  int              `json:"index"`
+	Op     string           `json:"op"`
+	Status int              `json:"status"`
+	ID     uint             `json:"id,omitempty"`
+	Data   interface{}      `json:"data,omitempty"`
+	Error  *problem.Problem `json:"error,omitempty"`
+}
+
+type BulkResponse struct {
+	Mode      string       `json:"mode"`
+	Succeeded int          `json:"succeeded"`
+	Failed    int          `json:"failed"`
+	Results   []BulkResult `json:"results"`
+}
+
+type bulkApplier func(tx *gorm.DB, op BulkOperation) (uint, interface{}, error)
+
+func (h *Handler) BulkCustomers(c *gin.Context) {
+	h.runBulk(c, h.applyCustomerOperation)
+}
+
+func (h *Handler) BulkCompanies(c *gin.Context) {
+	role := currentRole(c)
+	h.runBulk(c, func(tx *gorm.DB, op BulkOperation) (uint, interface{}, error) {
+		return applyCompanyOperation(tx, op, role)
+	})
+}
+
+func (h *Handler) BulkTags(c *gin.Context) {
+	h.runBulk(c, applyTagOperation)
+}
+
+func (h *Handler) runBulk(c *gin.Context, apply bulkApplier) {
+	var input BulkInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+	if input.Mode == "" {
+		input.Mode = BulkModeAtomic
+	}
+
+	response := BulkResponse{Mode: input.Mode, Results: make([]BulkResult, len(input.Operations))}
+	for i, op := range input.Operations {
+		response.Results[i] = BulkResult{Index: i, Op: op.Op}
+	}
+
+	run := func(tx *gorm.DB, i int) error {
+		op := input.Operations[i]
+		result := &response.Results[i]
+		id, data, err := apply(tx, op)
+		if err != nil {
+			result.Error = problemFor(err)
+			result.Status = result.Error.Status
+			result.ID = op.ID
+			return err
+		}
+		result.ID = id
+		result.Data = data
+		result.Status = http.StatusOK
+		if op.Op == BulkOpCreate {
+			result.Status = http.StatusCreated
+		}
+		if op.Op == BulkOpDelete {
+			result.Status = http.StatusNoContent
+		}
+		return nil
+	}
+
+	if input.Mode == BulkModeBestEffort {
+		for i := range input.Operations {
+			if err := h.db.Transaction(func(tx *gorm.DB) error { return run(tx, i) }); err != nil {
+				response.Failed++
+				continue
+			}
+			response.Succeeded++
+		}
+		c.JSON(http.StatusOK, response)
+		return
+	}
+
+	failedAt := -1
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for i := range input.Operations {
+			if err := run(tx, i); err != nil {
+				failedAt = i
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && failedAt < 0 {
+		problem.Internal(c, err)
+		return
+	}
+	if failedAt >= 0 {
+		for i := range response.Results {
+			result := &response.Results[i]
+			if i == failedAt {
+				continue
+			}
+			result.Status = http.StatusFailedDependency
+			result.Data = nil
+			if i < failedAt {
+				result.Error = problem.New(http.StatusFailedDependency, problem.CodeRolledBack, "Rolled back because another operation failed")
+			} else {
+				result.Error = problem.New(http.StatusFailedDependency, problem.CodeNotAttempted, "Not attempted because another operation failed")
+			}
+		}
+		response.Failed = len(response.Results)
+		p := problem.New(response.Results[failedAt].Status, problem.CodeBulkFailed,
+			fmt.Sprintf("Operation %d failed; no changes were applied", failedAt)).
+			With("failed_index", failedAt).
+			With("results", response.Results)
+		problem.Write(c, p)
+		return
+	}
+
+	response.Succeeded = len(response.Results)
+	c.JSON(http.StatusOK, response)
+}
+
+func problemFor(err error) *problem.Problem {
+	var p *problem.Problem
+	switch {
+	case errors.As(err, &p):
+		return p
+	case funnelProblem(err) != nil:
+		return funnelProblem(err)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, "Resource not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		return problem.New(http.StatusConflict, problem.CodeAlreadyExists, "A record with the same unique value already exists")
+	}
+	log.Printf("internal error: %v", err)
+	return problem.New(http.StatusInternalServerError, problem.CodeInternal, "An unexpected error occurred")
+}
+
+func bindBulkData(op BulkOperation, obj interface{}) error {
+	if len(op.Data) == 0 {
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "data is required for "+op.Op)
+	}
+	if err := binding.JSON.BindBody(op.Data, obj); err != nil {
+		return problem.FromBindError(err)
+	}
+	return nil
+}
+
+func requireBulkID(op BulkOperation) error {
+	if op.ID == 0 {
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "id is required for "+op.Op)
+	}
+	return nil
+}
+
+func (h *Handler) applyCustomerOperation(tx *gorm.DB, op BulkOperation) (uint, interface{}, error) {
+	if op.Op == BulkOpCreate {
+		var input models.CreateCustomerInput
+		if err := bindBulkData(op, &input); err != nil {
+			return 0, nil, err
+		}
+		customer := input.Customer()
+		if err := h.customers(tx).Create(&customer); err != nil {
+			return 0, nil, err
+		}
+		return customer.ID, customer, nil
+	}
+
+	if err := requireBulkID(op); err != nil {
+		return 0, nil, err
+	}
+	var customer models.Customer
+	if err := tx.First(&customer, op.ID).Error; err != nil {
+		return 0, nil, problem.New(http.StatusNotFound, problem.CodeNotFound, "Customer not found")
+	}
+
+	if op.Op == BulkOpDelete {
+		if err := tx.Where("customer_id = ?", customer.ID).Delete(&models.CustomerFunnel{}).Error; err != nil {
+			return 0, nil, err
+		}
+		if err := tx.Model(&customer).Association("Tags").Clear(); err != nil {
+			return 0, nil, err
+		}
+		return customer.ID, nil, tx.Delete(&customer).Error
+	}
+
+	var input models.UpdateCustomerInput
+	if err := bindBulkData(op, &input); err != nil {
+		return 0, nil, err
+	}
+	if err := h.customers(tx).Update(&customer, input); err != nil {
+		return 0, nil, err
+	}
+	customer.Tags = nil
+	if err := tx.Preload("Tags").Preload("Memberships").First(&customer, customer.ID).Error; err != nil {
+		return 0, nil, err
+	}
+	return customer.ID, customer, nil
+}
+
+func applyCompanyOperation(tx *gorm.DB, op BulkOperation, role models.Role) (uint, interface{}, error) {
+	if op.Op == BulkOpCreate {
+		var input models.CompanyInput
+		if err := bindBulkData(op, &input); err != nil {
+			return 0, nil, err
+		}
+		if _, err := companyUpdates(tx, input, role); err != nil {
+			return 0, nil, err
+		}
+		company := models.Company{Name: input.Name, Address: input.Address, FunnelID: input.FunnelID}
+		if err := tx.Create(&company).Error; err != nil {
+			return 0, nil, err
+		}
+		return company.ID, company, nil
+	}
+
+	if err := requireBulkID(op); err != nil {
+		return 0, nil, err
+	}
+	var company models.Company
+	if err := tx.First(&company, op.ID).Error; err != nil {
+		return 0, nil, problem.New(http.StatusNotFound, problem.CodeNotFound, "Company not found")
+	}
+
+	if op.Op == BulkOpDelete {
+		var customerCount, userCount int64
+		if err := tx.Model(&models.Customer{}).Where("company_id = ?", company.ID).Count(&customerCount).Error; err != nil {
+			return 0, nil, err
+		}
+		if err := tx.Model(&models.User{}).Where("company_id = ?", company.ID).Count(&userCount).Error; err != nil {
+			return 0, nil, err
+		}
+		if customerCount > 0 || userCount > 0 {
+			return 0, nil, problem.New(http.StatusConflict, problem.CodeConflict, "Company still has customers or users").
+				With("customers", customerCount).
+				With("users", userCount)
+		}
+		return company.ID, nil, tx.Delete(&company).Error
+	}
+
+	var input models.CompanyInput
+	if err := bindBulkData(op, &input); err != nil {
+		return 0, nil, err
+	}
+	updates, err := companyUpdates(tx, input, role)
+	if err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Model(&company).Updates(updates).Error; err != nil {
+		return 0, nil, err
+	}
+	return company.ID, company, nil
+}
+
+func tagNameTaken(tx *gorm.DB, name string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&models.Tag{}).Where("LOWER(name) = ? AND id <> ?", strings.ToLower(name), exceptID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return problem.New(http.StatusConflict, problem.CodeAlreadyExists, "Tag already exists")
+	}
+	return nil
+}
+
+func applyTagOperation(tx *gorm.DB, op BulkOperation) (uint, interface{}, error) {
+	if op.Op == BulkOpCreate {
+		var input models.Tag
+		if err := bindBulkData(op, &input); err != nil {
+			return 0, nil, err
+		}
+		input.Name = strings.TrimSpace(input.Name)
+		if err := tagNameTaken(tx, input.Name, 0); err != nil {
+			return 0, nil, err
+		}
+		if err := tx.Create(&input).Error; err != nil {
+			return 0, nil, err
+		}
+		return input.ID, input, nil
+	}
+
+	if err := requireBulkID(op); err != nil {
+		return 0, nil, err
+	}
+	var tag models.Tag
+	if err := tx.First(&tag, op.ID).Error; err != nil {
+		return 0, nil, problem.New(http.StatusNotFound, problem.CodeNotFound, "Tag not found")
+	}
+
+	if op.Op == BulkOpDelete {
+		if err := tx.Exec("DELETE FROM customer_tags WHERE tag_id = ?", tag.ID).Error; err != nil {
+			return 0, nil, err
+		}
+		return tag.ID, nil, tx.Unscoped().Delete(&tag).Error
+	}
+
+	var input models.Tag
+	if err := bindBulkData(op, &input); err != nil {
+		return 0, nil, err
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	if err := tagNameTaken(tx, input.Name, tag.ID); err != nil {
+		return 0, nil, err
+	}
+	if err := tx.Model(&tag).Update("name", input.Name).Error; err != nil {
+		return 0, nil, err
+	}
+	return tag.ID, tag, nil
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupBulkRouter() *gin.Engine {
+	r := gin.Default()
+	r.POST("/customers/bulk", testHandler.BulkCustomers)
+	r.POST("/companies/bulk", testHandler.BulkCompanies)
+	r.POST("/tags/bulk", testHandler.BulkTags)
+	return r
+}
+
+func TestBulkCustomersAtomicRollsBack(t *testing.T) {
+	r := setupBulkRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	w := performRequest(r, "POST", "/customers/bulk", gin.H{
+		"operations": []gin.H{
+			{"op": "create", "data": gin.H{"name": "First", "company_id": company.ID}},
+			{"op": "create", "data": gin.H{"email": "missing-name@example.com", "company_id": company.ID}},
+			{"op": "create", "data": gin.H{"name": "Third", "company_id": company.ID}},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var body struct {
+		Code        string       `json:"code"`
+		FailedIndex int          `json:"failed_index"`
+		Results     []BulkResult `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, "bulk_failed", body.Code)
+	assert.Equal(t, 1, body.FailedIndex)
+	assert.Len(t, body.Results, 3)
+	assert.Equal(t, "rolled_back", body.Results[0].Error.Code)
+	assert.Equal(t, "validation_failed", body.Results[1].Error.Code)
+	assert.Equal(t, "name", body.Results[1].Error.Errors[0].Field)
+	assert.Equal(t, "not_attempted", body.Results[2].Error.Code)
+
+	var count int64
+	testDB.Model(&models.Customer{}).Count(&count)
+	assert.Equal(t, int64(0), count)
+}
+
+func TestBulkCustomersBestEffort(t *testing.T) {
+	r := setupBulkRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won"}
+	assert.NoError(t, testDB.Create(&lead).Error)
+	assert.NoError(t, testDB.Create(&won).Error)
+	existing := models.Customer{Name: "Existing", CompanyID: company.ID, FunnelID: &lead.ID}
+	assert.NoError(t, testDB.Create(&existing).Error)
+
+	w := performRequest(r, "POST", "/customers/bulk", gin.H{
+		"mode": "best_effort",
+		"operations": []gin.H{
+			{"op": "create", "data": gin.H{"name": "New", "company_id": company.ID}},
+			{"op": "update", "id": existing.ID, "data": gin.H{"funnel_id": won.ID}},
+			{"op": "update", "id": existing.ID, "data": gin.H{"phone": "555", "funnel_id": lead.ID}},
+			{"op": "delete", "id": 9999},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response BulkResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, 2, response.Succeeded)
+	assert.Equal(t, 2, response.Failed)
+	assert.Equal(t, http.StatusCreated, response.Results[0].Status)
+	assert.NotZero(t, response.Results[0].ID)
+	assert.Equal(t, "invalid_funnel_transition", response.Results[1].Error.Code)
+	assert.Equal(t, http.StatusOK, response.Results[2].Status)
+	assert.Equal(t, http.StatusNotFound, response.Results[3].Status)
+
+	var reloaded models.Customer
+	assert.NoError(t, testDB.First(&reloaded, existing.ID).Error)
+	assert.Equal(t, lead.ID, *reloaded.FunnelID)
+	assert.Equal(t, "555", reloaded.Phone)
+}
+
+func TestBulkCompaniesAndTags(t *testing.T) {
+	r := setupBulkRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	w := performRequest(r, "POST", "/companies/bulk", gin.H{
+		"mode": "best_effort",
+		"operations": []gin.H{
+			{"op": "create", "data": gin.H{"name": "Globex"}},
+			{"op": "delete", "id": company.ID},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var companies BulkResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &companies))
+	assert.Equal(t, http.StatusCreated, companies.Results[0].Status)
+	assert.Equal(t, http.StatusConflict, companies.Results[1].Status)
+
+	w = performRequest(r, "POST", "/tags/bulk", gin.H{
+		"operations": []gin.H{
+			{"op": "create", "data": gin.H{"name": "vip"}},
+			{"op": "create", "data": gin.H{"name": "churn-risk"}},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var tags BulkResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.Equal(t, 2, tags.Succeeded)
+
+	w = performRequest(r, "POST", "/tags/bulk", gin.H{
+		"mode": "best_effort",
+		"operations": []gin.H{
+			{"op": "create", "data": gin.H{"name": "VIP"}},
+			{"op": "update", "id": tags.Results[1].ID, "data": gin.H{"name": "at-risk"}},
+			{"op": "delete", "id": tags.Results[0].ID},
+		},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &tags))
+	assert.Equal(t, http.StatusConflict, tags.Results[0].Status)
+	assert.Equal(t, http.StatusOK, tags.Results[1].Status)
+	assert.Equal(t, http.StatusNoContent, tags.Results[2].Status)
+
+	var names []string
+	testDB.Model(&models.Tag{}).Pluck("name", &names)
+	assert.Equal(t, []string{"at-risk"}, names)
+}
+
+func TestBulkRejectsOversizedBatch(t *testing.T) {
+	r := setupBulkRouter()
+	clearTable(t)
+
+	operations := make([]gin.H, 1001)
+	for i := range operations {
+		operations[i] = gin.H{"op": "delete", "id": i + 1}
+	}
+	w := performRequest(r, "POST", "/tags/bulk", gin.H{"operations": operations})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"operations"`)
+}
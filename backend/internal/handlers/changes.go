@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/changefeed"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+var changeTypes = map[string]string{
+	"companies": changefeed.EntityCompany,
+	"customers": changefeed.EntityCustomer,
+	"users":     changefeed.EntityUser,
+	"funnels":   changefeed.EntityFunnel,
+}
+
+type ChangeEntry struct {
+	changefeed.Change
+	// Record is the current state of the record, left out for deletes.
+	Record interface{} `json:"record,omitempty"`
+}
+
+type ChangeFeed struct {
+	Changes []ChangeEntry `json:"changes"`
+	// NextCursor is always set; passing it back returns only newer changes.
+	NextCursor string `json:"next_cursor"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// GetChanges lists created, updated and deleted records in commit order since
+// the cursor. Without a cursor it starts from the beginning of the log.
+func (h *Handler) GetChanges(c *gin.Context) {
+	opts := changefeed.Options{}
+	after, err := changefeed.DecodeCursor(c.Query("cursor"))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return
+	}
+	opts.After = after
+	if raw := c.Query("types"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			entity, ok := changeTypes[strings.TrimSpace(name)]
+			if !ok {
+				problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Unknown change type: "+name)
+				return
+			}
+			opts.Entities = append(opts.Entities, entity)
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "limit must be a positive integer")
+			return
+		}
+		opts.Limit = limit
+	}
+
+	changes, more, err := changefeed.Since(h.db, opts)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	records, err := loadChangedRecords(h.db, changes)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	feed := ChangeFeed{Changes: make([]ChangeEntry, 0, len(changes)), NextCursor: changefeed.EncodeCursor(after), HasMore: more}
+	for _, change := range changes {
+		entry := ChangeEntry{Change: change}
+		if change.Action != changefeed.ActionDeleted {
+			if record, ok := records[change.Entity][change.EntityID]; ok {
+				entry.Record = record
+			}
+		}
+		feed.Changes = append(feed.Changes, entry)
+		feed.NextCursor = changefeed.EncodeCursor(change.Seq)
+	}
+	c.JSON(http.StatusOK, feed)
+}
+
+// loadChangedRecords fetches the current state of the records in changes,
+// keyed by entity and id. Records deleted since are missing; a later change
+// in the log reports the delete.
+func loadChangedRecords(tx *gorm.DB, changes []changefeed.Change) (map[string]map[uint]interface{}, error) {
+	ids := map[string][]uint{}
+	for _, change := range changes {
+		if change.Action != changefeed.ActionDeleted {
+			ids[change.Entity] = append(ids[change.Entity], change.EntityID)
+		}
+	}
+
+	records := map[string]map[uint]interface{}{}
+	for entity, entityIDs := range ids {
+		byID := map[uint]interface{}{}
+		switch entity {
+		case changefeed.EntityCompany:
+			var rows []models.Company
+			if err := tx.Find(&rows, entityIDs).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				byID[row.ID] = row
+			}
+		case changefeed.EntityCustomer:
+			var rows []models.Customer
+			if err := tx.Preload("Tags").Preload("Memberships").Find(&rows, entityIDs).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				byID[row.ID] = row
+			}
+		case changefeed.EntityUser:
+			var rows []models.User
+			if err := tx.Find(&rows, entityIDs).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				byID[row.ID] = row
+			}
+		case changefeed.EntityFunnel:
+			var rows []models.Funnel
+			if err := tx.Find(&rows, entityIDs).Error; err != nil {
+				return nil, err
+			}
+			for _, row := range rows {
+				byID[row.ID] = row
+			}
+		default:
+			return nil, errors.New("unknown change entity " + entity)
+		}
+		records[entity] = byID
+	}
+	return records, nil
+}
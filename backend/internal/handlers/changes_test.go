@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getChanges(t *testing.T, r http.Handler, query string) ChangeFeed {
+	w := performRequest(r, "GET", "/changes"+query, nil)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var feed ChangeFeed
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &feed))
+	return feed
+}
+
+func TestGetChanges(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	r := gin.Default()
+	r.GET("/changes", testHandler.GetChanges)
+
+	feed := getChanges(t, r, "")
+	require.Len(t, feed.Changes, 2)
+	assert.Equal(t, "company", feed.Changes[0].Entity)
+	assert.Equal(t, "user", feed.Changes[1].Entity)
+	assert.NotContains(t, string(mustJSON(t, feed.Changes[1].Record)), "password")
+	cursor := feed.NextCursor
+
+	customer := models.Customer{Name: "Ada", Email: "ada@example.com", CompanyID: company.ID}
+	require.NoError(t, testDB.Create(&customer).Error)
+	require.NoError(t, testDB.Model(&customer).Update("name", "Ada Lovelace").Error)
+	require.NoError(t, testDB.Delete(&company).Error)
+
+	feed = getChanges(t, r, "?limit=2&cursor="+cursor)
+	assert.True(t, feed.HasMore)
+	require.Len(t, feed.Changes, 2)
+	assert.Equal(t, "created", feed.Changes[0].Action)
+	assert.Equal(t, "updated", feed.Changes[1].Action)
+	record, ok := feed.Changes[1].Record.(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, "Ada Lovelace", record["name"])
+
+	feed = getChanges(t, r, "?limit=2&cursor="+feed.NextCursor)
+	assert.False(t, feed.HasMore)
+	require.Len(t, feed.Changes, 1)
+	assert.Equal(t, "company", feed.Changes[0].Entity)
+	assert.Equal(t, "deleted", feed.Changes[0].Action)
+	assert.Equal(t, company.ID, feed.Changes[0].EntityID)
+	assert.Nil(t, feed.Changes[0].Record)
+
+	last := feed.NextCursor
+	feed = getChanges(t, r, "?cursor="+last)
+	assert.Empty(t, feed.Changes)
+	assert.Equal(t, last, feed.NextCursor)
+
+	feed = getChanges(t, r, "?types=customers&cursor="+cursor)
+	assert.Len(t, feed.Changes, 2)
+
+	for _, query := range []string{"?cursor=%25%25", "?types=tags", "?limit=0"} {
+		w := performRequest(r, "GET", "/changes"+query, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestGetChangesReportsTagChangesWithTags(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	r := gin.Default()
+	r.GET("/changes", testHandler.GetChanges)
+
+	customer := models.Customer{Name: "Ada", Email: "ada@example.com", CompanyID: company.ID}
+	require.NoError(t, testDB.Create(&customer).Error)
+	tag := models.Tag{Name: "vip"}
+	require.NoError(t, testDB.Create(&tag).Error)
+	cursor := getChanges(t, r, "").NextCursor
+
+	require.NoError(t, testDB.Exec("INSERT INTO customer_tags (customer_id, tag_id) VALUES (?, ?)", customer.ID, tag.ID).Error)
+
+	feed := getChanges(t, r, "?cursor="+cursor)
+	require.Len(t, feed.Changes, 1)
+	assert.Equal(t, "customer", feed.Changes[0].Entity)
+	assert.Equal(t, "updated", feed.Changes[0].Action)
+	assert.Contains(t, string(mustJSON(t, feed.Changes[0].Record)), `"name":"vip"`)
+}
+
+func mustJSON(t *testing.T, v interface{}) []byte {
+	data, err := json.Marshal(v)
+	require.NoError(t, err)
+	return data
+}
@@ -1,51 +1,195 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mokan/flame-crm-backend/internal/db"
 	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
 )
 
-func GetCompanies(c *gin.Context) {
+func (h *Handler) GetCompanies(c *gin.Context) {
+	params, ok := parseListQuery(c, companyQuerySpec, pagedVersion(c))
+	if !ok {
+		return
+	}
+	sel, ok := h.parseSelection(c, companySelection)
+	if !ok {
+		return
+	}
+
 	var companies []models.Company
-	if err := db.DB.Preload("Users").Preload("Customers").Preload("Funnel").Find(&companies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	meta, err := query.Find(sel.Apply(h.db, params.SortColumns()...), params, &companies)
+	if err != nil {
+		problem.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, companies)
+	setListMeta(c, meta)
+	renderSelection(c, sel, companies)
 }
 
-func CreateCompany(c *gin.Context) {
-	var input models.Company
+func (h *Handler) GetCompany(c *gin.Context) {
+	sel, ok := h.parseSelection(c, companySelection)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	var company models.Company
+	if err := sel.Apply(h.db, "updated_at").First(&company, id).Error; err != nil {
+		problem.NotFound(c, "Company not found")
+		return
+	}
+
+	if notModified(c, company.UpdatedAt) {
+		return
+	}
+	setETag(c, company.UpdatedAt)
+	renderSelection(c, sel, company)
+}
+
+func (h *Handler) CreateCompany(c *gin.Context) {
+	var input models.CompanyInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
+		return
+	}
+	if _, err := companyUpdates(h.db, input, currentRole(c)); err != nil {
+		problem.Write(c, problemFor(err))
 		return
 	}
 
-	if err := db.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	company := models.Company{Name: input.Name, Address: input.Address, FunnelID: input.FunnelID}
+	if err := h.db.Create(&company).Error; err != nil {
+		problem.Database(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, input)
+	setETag(c, company.UpdatedAt)
+	c.JSON(http.StatusOK, company)
 }
 
-func UpdateCompany(c *gin.Context) {
+func (h *Handler) UpdateCompany(c *gin.Context) {
 	id := c.Param("id")
 	var company models.Company
-	if err := db.DB.First(&company, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Company not found"})
+	if err := h.db.First(&company, id).Error; err != nil {
+		problem.NotFound(c, "Company not found")
+		return
+	}
+
+	if ifMatchFailed(c, company.UpdatedAt) {
+		preconditionFailed(c, company.UpdatedAt, company)
 		return
 	}
 
-	var input models.Company
+	var input models.CompanyInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
+		return
+	}
+
+	updates, err := companyUpdates(h.db, input, currentRole(c))
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Company{}, company.ID, company.UpdatedAt); err != nil {
+			return err
+		}
+		return tx.Model(&company).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			h.db.First(&company, company.ID)
+			preconditionFailed(c, company.UpdatedAt, company)
+			return
+		}
+		problem.Database(c, err)
 		return
 	}
 
-	db.DB.Model(&company).Updates(input)
+	h.db.First(&company, company.ID)
+	setETag(c, company.UpdatedAt)
 	c.JSON(http.StatusOK, company)
 }
+
+func (h *Handler) PatchCompany(c *gin.Context) {
+	id := c.Param("id")
+	var company models.Company
+	if err := h.db.First(&company, id).Error; err != nil {
+		problem.NotFound(c, "Company not found")
+		return
+	}
+
+	if ifMatchFailed(c, company.UpdatedAt) {
+		preconditionFailed(c, company.UpdatedAt, company)
+		return
+	}
+
+	updates, ok := bindMergePatch(c, service.CompanyFields)
+	if !ok {
+		return
+	}
+
+	if err := prepareCompanyPatch(h.db, updates); err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Company{}, company.ID, company.UpdatedAt); err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&company).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			h.db.First(&company, company.ID)
+			preconditionFailed(c, company.UpdatedAt, company)
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+
+	h.db.First(&company, company.ID)
+	setETag(c, company.UpdatedAt)
+	c.JSON(http.StatusOK, company)
+}
+
+func prepareCompanyPatch(tx *gorm.DB, updates map[string]interface{}) error {
+	return companyProblem(service.NewCompanies(repository.NewGorm(tx)).CheckUpdates(updates))
+}
+
+// companyUpdates checks a full company body against service.CompanyFields,
+// so PUT, bulk and import writes follow the same field and role rules as
+// PATCH. Empty fields are left unchanged.
+func companyUpdates(tx *gorm.DB, input models.CompanyInput, role models.Role) (map[string]interface{}, error) {
+	updates, err := service.NewCompanies(repository.NewGorm(tx)).Updates(input, role)
+	if err != nil {
+		return nil, companyProblem(err)
+	}
+	return updates, nil
+}
+
+func companyProblem(err error) error {
+	var fieldErr *patch.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		return patchProblem(err)
+	case errors.Is(err, service.ErrCompanyFunnelNotFound):
+		return problem.New(http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+	}
+	return err
+}
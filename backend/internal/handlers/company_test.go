@@ -2,12 +2,18 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/query"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCreateCompany_InvalidInput(t *testing.T) {
@@ -21,10 +27,10 @@ func TestCreateCompany_InvalidInput(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	CreateCompany(c)
+	testHandler.CreateCompany(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Error:Field validation for 'Name' failed on the 'required' tag")
+	assert.Contains(t, w.Body.String(), `{"field":"name","code":"required","message":"is required"}`)
 }
 
 func TestCreateCompany_MalformedJSON(t *testing.T) {
@@ -38,7 +44,200 @@ func TestCreateCompany_MalformedJSON(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	CreateCompany(c)
+	testHandler.CreateCompany(c)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"malformed_json"`)
+}
+
+func TestUpdateCompanyIfMatch(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+
+	r := gin.Default()
+	r.GET("/companies/:id", testHandler.GetCompany)
+	r.PUT("/companies/:id", testHandler.UpdateCompany)
+
+	w := performRequest(r, "GET", fmt.Sprintf("/companies/%d", company.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/companies/%d", company.ID), nil)
+	req.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	update := func(name, ifMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("PUT", fmt.Sprintf("/companies/%d", company.ID), bytes.NewBufferString(`{"name": "`+name+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", ifMatch)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	w = update("First Writer", etag)
+	assert.Equal(t, http.StatusOK, w.Code)
+	newETag := w.Header().Get("ETag")
+	assert.NotEqual(t, etag, newETag)
+
+	w = update("Second Writer", etag)
+	assert.Equal(t, http.StatusPreconditionFailed, w.Code)
+	assert.Equal(t, newETag, w.Header().Get("ETag"))
+	assert.Contains(t, w.Body.String(), "First Writer")
+
+	w = performRequest(r, "GET", fmt.Sprintf("/companies/%d", company.ID), nil)
+	assert.Equal(t, newETag, w.Header().Get("ETag"))
+
+	w = update("Second Writer", newETag)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetCompaniesFieldsAndIncludes(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+	assert.NoError(t, testDB.Model(&company).Update("funnel_id", funnel.ID).Error)
+	assert.NoError(t, testDB.Create(&models.Customer{Name: "Ada", CompanyID: company.ID}).Error)
+	assert.NoError(t, testDB.Create(&models.Company{Name: "Beta"}).Error)
+
+	r := gin.Default()
+	r.GET("/companies", testHandler.GetCompanies)
+	r.GET("/companies/:id", testHandler.GetCompany)
+	r.GET("/v2/companies", func(c *gin.Context) {
+		c.Set("api_version", "v2")
+		testHandler.GetCompanies(c)
+	})
+
+	decode := func(w *httptest.ResponseRecorder) []map[string]interface{} {
+		var rows []map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+		return rows
+	}
+
+	w := performRequest(r, "GET", "/companies", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	rows := decode(w)
+	assert.Contains(t, rows[0], "users")
+	assert.Contains(t, rows[0], "customers")
+	assert.Contains(t, rows[0], "funnel")
+
+	w = performRequest(r, "GET", "/v2/companies", nil)
+	rows = decode(w)
+	assert.NotContains(t, rows[0], "users")
+	assert.NotContains(t, rows[0], "customers")
+	assert.Equal(t, "Test Company", rows[0]["name"])
+
+	w = performRequest(r, "GET", "/companies?fields=name&include=funnel&sort=-name&cursor=&limit=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	rows = decode(w)
+	require.Len(t, rows, 1)
+	assert.Equal(t, map[string]interface{}{"ID": float64(company.ID), "name": "Test Company", "funnel": rows[0]["funnel"]}, rows[0])
+	assert.Equal(t, "Lead", rows[0]["funnel"].(map[string]interface{})["name"])
+	next := w.Header().Get("X-Next-Cursor")
+	assert.NotEmpty(t, next)
+
+	w = performRequest(r, "GET", "/companies?fields=name&sort=-name&limit=1&cursor="+next, nil)
+	rows = decode(w)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Beta", rows[0]["name"])
+
+	w = performRequest(r, "GET", fmt.Sprintf("/companies/%d?fields=address&include=customers", company.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+	var one map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &one))
+	assert.ElementsMatch(t, []string{"ID", "address", "customers"}, mapKeys(one))
+
+	w = performRequest(r, "GET", "/companies?fields=password", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown field \"password\"`)
+	w = performRequest(r, "GET", "/companies?include=tags", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetCompaniesLimits(t *testing.T) {
+	createTestCompanyAndUser(t)
+	companies := make([]models.Company, query.DefaultLimit+5)
+	for i := range companies {
+		companies[i].Name = fmt.Sprintf("Company %d", i)
+	}
+	require.NoError(t, testDB.Create(&companies).Error)
+
+	r := gin.Default()
+	r.GET("/companies", testHandler.GetCompanies)
+	r.GET("/v2/companies", func(c *gin.Context) {
+		c.Set("api_version", "v2")
+		testHandler.GetCompanies(c)
+	})
+
+	var rows []map[string]interface{}
+	w := performRequest(r, "GET", "/companies?fields=name", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Limit"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	assert.Len(t, rows, query.DefaultLimit+6)
+
+	w = performRequest(r, "GET", "/v2/companies?fields=name", nil)
+	assert.Equal(t, strconv.Itoa(query.DefaultLimit), w.Header().Get("X-Limit"))
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	assert.Len(t, rows, query.DefaultLimit)
+
+	w = performRequest(r, "GET", "/companies?nmae=Company%201", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `unknown filter field \"nmae\"`)
+}
+
+func mapKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func TestCompanyFullUpdatesFollowPatchRules(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Lead"}
+	require.NoError(t, testDB.Create(&funnel).Error)
+
+	role := string(models.RoleSales)
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", role)
+		c.Next()
+	})
+	r.PUT("/companies/:id", testHandler.UpdateCompany)
+	r.POST("/companies/bulk", testHandler.BulkCompanies)
+	path := fmt.Sprintf("/companies/%d", company.ID)
+
+	w := performRequest(r, "PUT", path, gin.H{"name": "Renamed", "funnel_id": funnel.ID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = performRequest(r, "POST", "/companies/bulk", gin.H{"mode": "best_effort", "operations": []gin.H{
+		{"op": "update", "id": company.ID, "data": gin.H{"name": "Renamed", "funnel_id": funnel.ID}},
+	}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var bulk BulkResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &bulk))
+	assert.Equal(t, http.StatusForbidden, bulk.Results[0].Status)
+
+	w = performRequest(r, "PUT", path, gin.H{"name": "Renamed", "source": "erp", "external_id": "C-1"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dbCompany models.Company
+	require.NoError(t, testDB.First(&dbCompany, company.ID).Error)
+	assert.Equal(t, "Renamed", dbCompany.Name)
+	assert.Nil(t, dbCompany.FunnelID)
+	assert.Nil(t, dbCompany.Source)
+	assert.Nil(t, dbCompany.ExternalID)
+
+	role = string(models.RoleHeadOfSales)
+	w = performRequest(r, "PUT", path, gin.H{"name": "Renamed", "funnel_id": 999999})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(r, "PUT", path, gin.H{"name": "Renamed", "funnel_id": funnel.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, testDB.First(&dbCompany, company.ID).Error)
+	assert.Equal(t, funnel.ID, *dbCompany.FunnelID)
 }
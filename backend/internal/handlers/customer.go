@@ -1,89 +1,258 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mokan/flame-crm-backend/internal/db"
 	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"github.com/mokan/flame-crm-backend/internal/sparse"
+	"gorm.io/gorm"
 )
 
-func GetCustomers(c *gin.Context) {
+func funnelProblem(err error) *problem.Problem {
+	var p *problem.Problem
+	switch {
+	case errors.Is(err, service.ErrInvalidFunnelTransition):
+		p = problem.New(http.StatusBadRequest, problem.CodeInvalidFunnelTransition, service.ErrInvalidFunnelTransition.Error())
+	case errors.Is(err, service.ErrFunnelStateInvalid):
+		p = problem.New(http.StatusBadRequest, problem.CodeFunnelStateInvalid, service.ErrFunnelStateInvalid.Error())
+	case errors.Is(err, service.ErrFunnelNotFound):
+		p = problem.New(http.StatusBadRequest, problem.CodeFunnelNotFound, service.ErrFunnelNotFound.Error())
+	case errors.Is(err, service.ErrMembershipFunnelMissing):
+		p = problem.New(http.StatusBadRequest, problem.CodeValidationFailed, service.ErrMembershipFunnelMissing.Error())
+	default:
+		return nil
+	}
+
+	var membershipErr *service.MembershipError
+	if errors.As(err, &membershipErr) {
+		p.With("pipeline", membershipErr.Pipeline)
+	}
+	return p
+}
+
+func (h *Handler) GetCustomers(c *gin.Context) {
+	customers, meta, sel, ok := h.findCustomers(c, pagedVersion(c))
+	if !ok {
+		return
+	}
+	setListMeta(c, meta)
+	renderSelection(c, sel, customers)
+}
+
+// CustomerPage is the v2 list shape: pagination moves from headers into the
+// body so clients that cannot read response headers still see it.
+type CustomerPage struct {
+	Data []models.Customer `json:"data"`
+	Meta query.Meta        `json:"meta"`
+}
+
+func (h *Handler) GetCustomersV2(c *gin.Context) {
+	customers, meta, sel, ok := h.findCustomers(c, true)
+	if !ok {
+		return
+	}
+	if customers == nil {
+		customers = []models.Customer{}
+	}
+	data, err := sel.Render(customers)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "meta": meta})
+}
+
+func (h *Handler) findCustomers(c *gin.Context, paged bool) ([]models.Customer, query.Meta, sparse.Selection, bool) {
+	params, ok := parseListQuery(c, customerQuerySpec, paged)
+	if !ok {
+		return nil, query.Meta{}, sparse.Selection{}, false
+	}
+	sel, ok := h.parseSelection(c, customerSelection)
+	if !ok {
+		return nil, query.Meta{}, sel, false
+	}
+
+	tx := sel.Apply(h.db, params.SortColumns()...)
+	if pipeline := c.Query("pipeline"); pipeline != "" {
+		tx = tx.Where("id IN (?)", h.db.Model(&models.CustomerFunnel{}).Select("customer_id").Where("pipeline = ?", pipeline))
+	}
+
 	var customers []models.Customer
-	if err := db.DB.Preload("Company").Find(&customers).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	meta, err := query.Find(tx, params, &customers)
+	if err != nil {
+		problem.Internal(c, err)
+		return nil, query.Meta{}, sel, false
+	}
+	return customers, meta, sel, true
+}
+
+func (h *Handler) GetCustomer(c *gin.Context) {
+	sel, ok := h.parseSelection(c, customerSelection)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	var customer models.Customer
+	if err := sel.Apply(h.db, "updated_at").First(&customer, id).Error; err != nil {
+		problem.NotFound(c, "Customer not found")
+		return
+	}
+
+	if notModified(c, customer.UpdatedAt) {
 		return
 	}
-	c.JSON(http.StatusOK, customers)
+	setETag(c, customer.UpdatedAt)
+	renderSelection(c, sel, customer)
 }
 
-func CreateCustomer(c *gin.Context) {
-	var input models.Customer
+func (h *Handler) CreateCustomer(c *gin.Context) {
+	var input models.CreateCustomerInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
-	if err := db.DB.Create(&input).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	customer := input.Customer()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		return h.customers(tx).Create(&customer)
+	})
+	if err != nil {
+		problem.Database(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, input)
+	setETag(c, customer.UpdatedAt)
+	c.JSON(http.StatusOK, customer)
 }
 
-func UpdateCustomer(c *gin.Context) {
+func (h *Handler) UpdateCustomer(c *gin.Context) {
 	id := c.Param("id")
 	var customer models.Customer
-	if err := db.DB.First(&customer, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Customer not found"})
+	if err := h.db.First(&customer, id).Error; err != nil {
+		problem.NotFound(c, "Customer not found")
+		return
+	}
+
+	if ifMatchFailed(c, customer.UpdatedAt) {
+		preconditionFailed(c, customer.UpdatedAt, customer)
 		return
 	}
 
 	var input models.UpdateCustomerInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
-	if input.Name != "" {
-		customer.Name = input.Name
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Customer{}, customer.ID, customer.UpdatedAt); err != nil {
+			return err
+		}
+		return h.customers(tx).Update(&customer, input)
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			var current models.Customer
+			h.db.Preload("Tags").Preload("Memberships").First(&current, customer.ID)
+			preconditionFailed(c, current.UpdatedAt, current)
+			return
+		}
+		if p := funnelProblem(err); p != nil {
+			problem.Write(c, p)
+			return
+		}
+		problem.Internal(c, err)
+		return
 	}
-	if input.Email != "" {
-		customer.Email = input.Email
+
+	customer.Tags = nil
+	h.db.Preload("Tags").Preload("Memberships").First(&customer, customer.ID)
+	setETag(c, customer.UpdatedAt)
+	c.JSON(http.StatusOK, customer)
+}
+
+func (h *Handler) PatchCustomer(c *gin.Context) {
+	id := c.Param("id")
+	var customer models.Customer
+	if err := h.db.First(&customer, id).Error; err != nil {
+		problem.NotFound(c, "Customer not found")
+		return
+	}
+
+	if ifMatchFailed(c, customer.UpdatedAt) {
+		preconditionFailed(c, customer.UpdatedAt, customer)
+		return
 	}
-	if input.Phone != "" {
-		customer.Phone = input.Phone
+
+	updates, ok := bindMergePatch(c, service.CustomerFields)
+	if !ok {
+		return
 	}
 
-	if input.FunnelID != nil && customer.FunnelID != nil {
-		if *input.FunnelID != *customer.FunnelID {
-			var currentFunnel models.Funnel
-			if err := db.DB.Preload("NextFunnels").First(&currentFunnel, *customer.FunnelID).Error; err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Current funnel state invalid"})
-				return
-			}
+	if err := h.prepareCustomerPatch(h.db, &customer, updates); err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
 
-			valid := false
-			for _, next := range currentFunnel.NextFunnels {
-				if next.ID == *input.FunnelID {
-					valid = true
-					break
-				}
-			}
-			if !valid {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid funnel transition"})
-				return
-			}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Customer{}, customer.ID, customer.UpdatedAt); err != nil {
+			return err
 		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&customer).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			var current models.Customer
+			h.db.Preload("Tags").Preload("Memberships").First(&current, customer.ID)
+			preconditionFailed(c, current.UpdatedAt, current)
+			return
+		}
+		problem.Internal(c, err)
+		return
 	}
 
-	customer.FunnelID = input.FunnelID
+	var current models.Customer
+	h.db.Preload("Tags").Preload("Memberships").First(&current, customer.ID)
+	setETag(c, current.UpdatedAt)
+	c.JSON(http.StatusOK, current)
+}
 
-	if input.FunnelStage != "" {
-		customer.FunnelStage = input.FunnelStage
+// prepareCustomerPatch checks references and the funnel transition of a merge
+// patch, pinning the customer's funnel version when the funnel changes.
+func (h *Handler) prepareCustomerPatch(tx *gorm.DB, customer *models.Customer, updates map[string]interface{}) error {
+	if companyID, ok := updates["company_id"].(uint); ok {
+		if err := tx.First(&models.Company{}, companyID).Error; err != nil {
+			return problem.New(http.StatusBadRequest, problem.CodeBadRequest, "Company not found")
+		}
 	}
 
-	db.DB.Save(&customer)
-	c.JSON(http.StatusOK, customer)
+	funnelID, ok := updates["funnel_id"].(uint)
+	if !ok {
+		return nil
+	}
+	if err := tx.First(&models.Funnel{}, funnelID).Error; err != nil {
+		return service.ErrFunnelNotFound
+	}
+
+	funnels := h.funnels(tx)
+	version, err := funnels.PinnedVersion(customer.FunnelVersionID)
+	if err != nil {
+		return err
+	}
+	if customer.FunnelID != nil {
+		if err := funnels.CheckTransition(version, *customer.FunnelID, funnelID); err != nil {
+			return err
+		}
+	}
+	updates["funnel_version_id"] = version
+	return nil
 }
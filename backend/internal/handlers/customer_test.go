@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPatchCustomerClearsFields(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+	customer := models.Customer{Name: "Jan", Email: "jan@example.com", Phone: "123", CompanyID: company.ID, FunnelID: &funnel.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", string(models.RoleSales))
+		c.Next()
+	})
+	r.PATCH("/customers/:id", testHandler.PatchCustomer)
+	path := fmt.Sprintf("/customers/%d", customer.ID)
+
+	w := patchRequest(r, path, `{"email": null, "phone": "", "funnel_id": null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dbCustomer models.Customer
+	assert.NoError(t, testDB.First(&dbCustomer, customer.ID).Error)
+	assert.Equal(t, "Jan", dbCustomer.Name)
+	assert.Empty(t, dbCustomer.Email)
+	assert.Empty(t, dbCustomer.Phone)
+	assert.Nil(t, dbCustomer.FunnelID)
+
+	w = patchRequest(r, path, `{"name": null}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = patchRequest(r, path, `{"company_id": 42}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = patchRequest(r, path, `{"email": "not-an-email"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGetCustomersV2(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	for _, name := range []string{"Ada", "Grace", "Linus"} {
+		assert.NoError(t, testDB.Create(&models.Customer{Name: name, CompanyID: company.ID}).Error)
+	}
+
+	r := gin.Default()
+	r.GET("/customers", testHandler.GetCustomersV2)
+
+	w := performRequest(r, "GET", "/customers?limit=2&sort=name", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-Total-Count"))
+
+	var page CustomerPage
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.EqualValues(t, 3, page.Meta.Total)
+	assert.Equal(t, 2, page.Meta.Limit)
+	if assert.Len(t, page.Data, 2) {
+		assert.Equal(t, "Ada", page.Data[0].Name)
+	}
+
+	w = performRequest(r, "GET", "/customers?name=Nobody", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data": [], "meta": {"total": 0, "limit": 100, "offset": 0}}`, w.Body.String())
+}
+
+func TestUpdateCustomerChecksTransitionOnIsolatedDatabase(t *testing.T) {
+	t.Parallel()
+	h, database := newIsolatedHandler(t)
+
+	contacted := models.Funnel{Name: "Contacted"}
+	assert.NoError(t, database.Create(&contacted).Error)
+	lead := models.Funnel{Name: "Lead", NextFunnels: []*models.Funnel{&contacted}}
+	assert.NoError(t, database.Create(&lead).Error)
+	won := models.Funnel{Name: "Won"}
+	assert.NoError(t, database.Create(&won).Error)
+	customer := models.Customer{Name: "Ada", FunnelID: &lead.ID}
+	assert.NoError(t, database.Create(&customer).Error)
+
+	r := gin.New()
+	r.PUT("/customers/:id", h.UpdateCustomer)
+	path := fmt.Sprintf("/customers/%d", customer.ID)
+
+	w := performRequest(r, "PUT", path, models.UpdateCustomerInput{FunnelID: &won.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid funnel transition")
+
+	w = performRequest(r, "PUT", path, models.UpdateCustomerInput{FunnelID: &contacted.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stored models.Customer
+	assert.NoError(t, database.First(&stored, customer.ID).Error)
+	assert.Equal(t, contacted.ID, *stored.FunnelID)
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+type EnrollResult struct {
+	CustomersScanned   int `json:"customers_scanned"`
+	FunnelsAssigned    int `json:"funnels_assigned"`
+	MembershipsCreated int `json:"memberships_created"`
+}
+
+type MessageResponse struct {
+	Message string `json:"message"`
+}
+
+func (h *Handler) GetEnrollmentRules(c *gin.Context) {
+	sel, ok := h.parseSelection(c, enrollmentRuleSelection)
+	if !ok {
+		return
+	}
+
+	var rules []models.EnrollmentRule
+	if err := sel.Apply(h.db, "priority").Order("priority desc, id").Find(&rules).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	renderSelection(c, sel, rules)
+}
+
+func (h *Handler) CreateEnrollmentRule(c *gin.Context) {
+	var input models.EnrollmentRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	var funnel models.Funnel
+	if err := h.db.First(&funnel, input.FunnelID).Error; err != nil {
+		problem.BadRequest(c, "Funnel not found")
+		return
+	}
+
+	if err := h.db.Create(&input).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, input)
+}
+
+func (h *Handler) UpdateEnrollmentRule(c *gin.Context) {
+	id := c.Param("id")
+	var rule models.EnrollmentRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		problem.NotFound(c, "Enrollment rule not found")
+		return
+	}
+
+	var input models.EnrollmentRule
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	var funnel models.Funnel
+	if err := h.db.First(&funnel, input.FunnelID).Error; err != nil {
+		problem.BadRequest(c, "Funnel not found")
+		return
+	}
+
+	input.Model = rule.Model
+	if err := h.db.Save(&input).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, input)
+}
+
+func (h *Handler) DeleteEnrollmentRule(c *gin.Context) {
+	id := c.Param("id")
+	var rule models.EnrollmentRule
+	if err := h.db.First(&rule, id).Error; err != nil {
+		problem.NotFound(c, "Enrollment rule not found")
+		return
+	}
+
+	if err := h.db.Delete(&rule).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "Enrollment rule deleted"})
+}
+
+func (h *Handler) EnrollCustomers(c *gin.Context) {
+	rulesQuery := h.db.Where("disabled = ?", false)
+	if ruleID := c.Query("rule_id"); ruleID != "" {
+		rulesQuery = rulesQuery.Where("id = ?", ruleID)
+	}
+	var rules []models.EnrollmentRule
+	if err := rulesQuery.Order("priority desc, id").Find(&rules).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	version, err := h.funnels(h.db).ActiveVersionID()
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	companyDefaults := c.Query("company_defaults") != "false"
+	scanned, assigned, created := 0, 0, 0
+	companies := map[uint]*models.Company{}
+
+	customerService := h.customers(h.db)
+	var customers []models.Customer
+	err = h.db.Preload("Tags").Preload("Memberships").FindInBatches(&customers, 500, func(tx *gorm.DB, batch int) error {
+		for i := range customers {
+			company, err := h.loadCompanyCached(companies, customers[i].CompanyID)
+			if err != nil {
+				return err
+			}
+			if !companyDefaults {
+				company = nil
+			}
+
+			result, err := customerService.Enroll(&customers[i], company, rules, version)
+			if err != nil {
+				return err
+			}
+			scanned++
+			if result.FunnelAssigned {
+				assigned++
+			}
+			created += result.MembershipsCreated
+		}
+		return nil
+	}).Error
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, EnrollResult{
+		CustomersScanned:   scanned,
+		FunnelsAssigned:    assigned,
+		MembershipsCreated: created,
+	})
+}
+
+func (h *Handler) loadCompanyCached(cache map[uint]*models.Company, companyID uint) (*models.Company, error) {
+	if companyID == 0 {
+		return nil, nil
+	}
+	if company, ok := cache[companyID]; ok {
+		return company, nil
+	}
+
+	var company models.Company
+	if err := h.db.First(&company, companyID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			cache[companyID] = nil
+			return nil, nil
+		}
+		return nil, err
+	}
+	cache[companyID] = &company
+	return &company, nil
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupEnrollmentRouter() *gin.Engine {
+	r := gin.Default()
+	r.POST("/customers", testHandler.CreateCustomer)
+	r.POST("/customers/enroll", testHandler.EnrollCustomers)
+	r.POST("/enrollment-rules", testHandler.CreateEnrollmentRule)
+	return r
+}
+
+func TestCreateCustomerAutoEnrollment(t *testing.T) {
+	r := setupEnrollmentRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	entry := models.Funnel{Name: "Entry"}
+	onboarding := models.Funnel{Name: "Onboarding"}
+	assert.NoError(t, testDB.Create(&entry).Error)
+	assert.NoError(t, testDB.Create(&onboarding).Error)
+	company.FunnelID = &entry.ID
+	assert.NoError(t, testDB.Save(&company).Error)
+
+	rule := models.EnrollmentRule{Name: "VIP onboarding", MatchTag: "vip", Pipeline: "onboarding", FunnelID: onboarding.ID}
+	w := performRequest(r, "POST", "/enrollment-rules", rule)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "POST", "/customers", gin.H{
+		"name":       "Tagged",
+		"company_id": company.ID,
+		"tags":       []gin.H{{"name": "VIP"}},
+	})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var created models.Customer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, entry.ID, *created.FunnelID)
+	assert.Len(t, created.Memberships, 1)
+	assert.Equal(t, "onboarding", created.Memberships[0].Pipeline)
+	assert.Equal(t, onboarding.ID, created.Memberships[0].FunnelID)
+
+	w = performRequest(r, "POST", "/customers", gin.H{"name": "Untagged", "company_id": company.ID})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var untagged models.Customer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &untagged))
+	assert.Equal(t, entry.ID, *untagged.FunnelID)
+	assert.Empty(t, untagged.Memberships)
+}
+
+func TestEnrollExistingCustomers(t *testing.T) {
+	r := setupEnrollmentRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	entry := models.Funnel{Name: "Entry"}
+	assert.NoError(t, testDB.Create(&entry).Error)
+
+	existing := models.Customer{Name: "Existing", CompanyID: company.ID, LeadSource: "webinar"}
+	assert.NoError(t, testDB.Create(&existing).Error)
+
+	company.FunnelID = &entry.ID
+	assert.NoError(t, testDB.Save(&company).Error)
+	rule := models.EnrollmentRule{Name: "Webinar", MatchLeadSource: "Webinar", Pipeline: "nurture", FunnelID: entry.ID}
+	assert.NoError(t, testDB.Create(&rule).Error)
+
+	w := performRequest(r, "POST", "/customers/enroll", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var result map[string]int
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 1, result["funnels_assigned"])
+	assert.Equal(t, 1, result["memberships_created"])
+
+	w = performRequest(r, "POST", "/customers/enroll", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Equal(t, 0, result["funnels_assigned"])
+	assert.Equal(t, 0, result["memberships_created"])
+
+	var dbCustomer models.Customer
+	assert.NoError(t, testDB.First(&dbCustomer, existing.ID).Error)
+	assert.Equal(t, entry.ID, *dbCustomer.FunnelID)
+}
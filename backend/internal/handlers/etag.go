@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+var errPreconditionFailed = errors.New("Resource was modified by another request")
+
+func resourceETag(updatedAt time.Time) string {
+	return fmt.Sprintf(`"%x"`, updatedAt.UnixMicro())
+}
+
+func setETag(c *gin.Context, updatedAt time.Time) {
+	c.Header("ETag", resourceETag(updatedAt))
+}
+
+func etagListMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+func ifMatchFailed(c *gin.Context, updatedAt time.Time) bool {
+	header := c.GetHeader("If-Match")
+	return header != "" && !etagListMatches(header, resourceETag(updatedAt))
+}
+
+func notModified(c *gin.Context, updatedAt time.Time) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" || !etagListMatches(header, resourceETag(updatedAt)) {
+		return false
+	}
+	setETag(c, updatedAt)
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+func preconditionFailed(c *gin.Context, updatedAt time.Time, current interface{}) {
+	setETag(c, updatedAt)
+	problem.Write(c, problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed, errPreconditionFailed.Error()).With("current", current))
+}
+
+func claimWrite(c *gin.Context, tx *gorm.DB, model interface{}, id uint, updatedAt time.Time) error {
+	if c.GetHeader("If-Match") == "" {
+		return nil
+	}
+
+	result := tx.Model(model).
+		Where("id = ? AND updated_at = ?", id, updatedAt).
+		UpdateColumn("updated_at", tx.NowFunc())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errPreconditionFailed
+	}
+	return nil
+}
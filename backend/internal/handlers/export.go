@@ -0,0 +1,231 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/export"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"gorm.io/gorm"
+)
+
+const exportFlushEvery = 500
+
+type exportColumn struct {
+	Key    string
+	Header string
+	Expr   string
+}
+
+type exportSpec struct {
+	Name     string
+	Model    interface{}
+	Query    query.Spec
+	Columns  []exportColumn
+	Defaults []string
+	Scope    func(c *gin.Context, tx *gorm.DB) *gorm.DB
+	// Params are the query parameters Scope reads.
+	Params []string
+}
+
+var (
+	companyExport = exportSpec{
+		Name:  "companies",
+		Model: &models.Company{},
+		Query: companyQuerySpec,
+		Columns: []exportColumn{
+			{"id", "ID", "companies.id"},
+			{"name", "Name", "companies.name"},
+			{"address", "Address", "companies.address"},
+			{"funnel_id", "Funnel ID", "companies.funnel_id"},
+			{"funnel_name", "Funnel", "(SELECT funnels.name FROM funnels WHERE funnels.id = companies.funnel_id AND funnels.deleted_at IS NULL)"},
+			{"customer_count", "Customers", "(SELECT COUNT(*) FROM customers WHERE customers.company_id = companies.id AND customers.deleted_at IS NULL)"},
+			{"user_count", "Users", "(SELECT COUNT(*) FROM users WHERE users.company_id = companies.id AND users.deleted_at IS NULL)"},
+			{"created_at", "Created At", "companies.created_at"},
+			{"updated_at", "Updated At", "companies.updated_at"},
+		},
+		Defaults: []string{"id", "name", "address", "funnel_name", "customer_count", "created_at"},
+	}
+
+	customerExport = exportSpec{
+		Name:  "customers",
+		Model: &models.Customer{},
+		Query: customerQuerySpec,
+		Columns: []exportColumn{
+			{"id", "ID", "customers.id"},
+			{"name", "Name", "customers.name"},
+			{"email", "Email", "customers.email"},
+			{"phone", "Phone", "customers.phone"},
+			{"lead_source", "Lead Source", "customers.lead_source"},
+			{"company_id", "Company ID", "customers.company_id"},
+			{"company_name", "Company", "(SELECT companies.name FROM companies WHERE companies.id = customers.company_id AND companies.deleted_at IS NULL)"},
+			{"funnel_id", "Funnel ID", "customers.funnel_id"},
+			{"funnel_name", "Funnel", "(SELECT funnels.name FROM funnels WHERE funnels.id = customers.funnel_id AND funnels.deleted_at IS NULL)"},
+			{"funnel_stage", "Funnel Stage", "customers.funnel_stage"},
+			{"created_at", "Created At", "customers.created_at"},
+			{"updated_at", "Updated At", "customers.updated_at"},
+		},
+		Defaults: []string{"id", "name", "email", "phone", "company_name", "funnel_name", "created_at"},
+		Scope: func(c *gin.Context, tx *gorm.DB) *gorm.DB {
+			if pipeline := c.Query("pipeline"); pipeline != "" {
+				return tx.Where("customers.id IN (?)", tx.Session(&gorm.Session{NewDB: true}).Model(&models.CustomerFunnel{}).Select("customer_id").Where("pipeline = ?", pipeline))
+			}
+			return tx
+		},
+		Params: []string{"pipeline"},
+	}
+
+	userExport = exportSpec{
+		Name:  "users",
+		Model: &models.User{},
+		Query: userQuerySpec,
+		Columns: []exportColumn{
+			{"id", "ID", "users.id"},
+			{"name", "Name", "users.name"},
+			{"email", "Email", "users.email"},
+			{"role", "Role", "users.role"},
+			{"company_id", "Company ID", "users.company_id"},
+			{"company_name", "Company", "(SELECT companies.name FROM companies WHERE companies.id = users.company_id AND companies.deleted_at IS NULL)"},
+			{"created_at", "Created At", "users.created_at"},
+			{"updated_at", "Updated At", "users.updated_at"},
+		},
+		Defaults: []string{"id", "name", "email", "role", "company_name", "created_at"},
+	}
+
+	funnelExport = exportSpec{
+		Name:  "funnels",
+		Model: &models.Funnel{},
+		Query: funnelQuerySpec,
+		Columns: []exportColumn{
+			{"id", "ID", "funnels.id"},
+			{"name", "Name", "funnels.name"},
+			{"wip_limit", "WIP Limit", "funnels.wip_limit"},
+			{"wip_mode", "WIP Mode", "funnels.wip_mode"},
+			{"customer_count", "Customers", "(SELECT COUNT(*) FROM customers WHERE customers.funnel_id = funnels.id AND customers.deleted_at IS NULL)"},
+			{"created_at", "Created At", "funnels.created_at"},
+			{"updated_at", "Updated At", "funnels.updated_at"},
+		},
+		Defaults: []string{"id", "name", "wip_limit", "customer_count", "created_at"},
+	}
+)
+
+func (h *Handler) ExportCompanies(c *gin.Context) {
+	h.exportList(c, companyExport)
+}
+
+func (h *Handler) ExportCustomers(c *gin.Context) {
+	h.exportList(c, customerExport)
+}
+
+func (h *Handler) ExportUsers(c *gin.Context) {
+	h.exportList(c, userExport)
+}
+
+func (h *Handler) ExportFunnels(c *gin.Context) {
+	h.exportList(c, funnelExport)
+}
+
+func (s exportSpec) resolve(raw string) ([]exportColumn, error) {
+	keys := s.Defaults
+	if raw != "" {
+		keys = strings.Split(raw, ",")
+	}
+
+	columns := make([]exportColumn, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		found := false
+		for _, column := range s.Columns {
+			if column.Key == key {
+				columns = append(columns, column)
+				found = true
+				break
+			}
+		}
+		if !found {
+			available := make([]string, len(s.Columns))
+			for i, column := range s.Columns {
+				available[i] = column.Key
+			}
+			return nil, fmt.Errorf("unknown export column %q, expected any of: %s", key, strings.Join(available, ", "))
+		}
+	}
+	return columns, nil
+}
+
+func (h *Handler) exportList(c *gin.Context, spec exportSpec) {
+	querySpec := spec.Query
+	querySpec.Params = append([]string{"columns", "format"}, spec.Params...)
+	params, ok := parseListQuery(c, querySpec, false)
+	if !ok {
+		return
+	}
+	columns, err := spec.resolve(c.Query("columns"))
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return
+	}
+	format := c.DefaultQuery("format", export.FormatCSV)
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "format must be csv or xlsx")
+		return
+	}
+
+	selects := make([]string, len(columns))
+	headers := make([]string, len(columns))
+	for i, column := range columns {
+		selects[i] = column.Expr + " AS " + column.Key
+		headers[i] = column.Header
+	}
+
+	tx := params.Apply(h.db.Model(spec.Model).Select(strings.Join(selects, ", ")))
+	if spec.Scope != nil {
+		tx = spec.Scope(c, tx)
+	}
+	rows, err := tx.Rows()
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	defer rows.Close()
+
+	filename := fmt.Sprintf("%s-%s.%s", spec.Name, time.Now().UTC().Format("20060102-150405"), format)
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Status(http.StatusOK)
+
+	writer, err := export.New(format, c.Writer, spec.Name)
+	if err == nil {
+		err = writer.WriteHeader(headers)
+	}
+
+	values := make([]interface{}, len(columns))
+	pointers := make([]interface{}, len(columns))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	for count := 1; err == nil && rows.Next(); count++ {
+		if err = rows.Scan(pointers...); err != nil {
+			break
+		}
+		err = writer.WriteRow(values)
+		if count%exportFlushEvery == 0 {
+			c.Writer.Flush()
+		}
+	}
+	if err == nil {
+		err = rows.Err()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		log.Printf("export of %s failed mid-stream: %v", spec.Name, err)
+	}
+}
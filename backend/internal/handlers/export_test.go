@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCustomersCSV(t *testing.T) {
+	r := gin.Default()
+	r.GET("/customers/export", testHandler.ExportCustomers)
+
+	company, _ := createTestCompanyAndUser(t)
+	lead := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&lead).Error)
+	for _, customer := range []models.Customer{
+		{Name: "Ada", Email: "ada@example.com", CompanyID: company.ID, FunnelID: &lead.ID, LeadSource: "web"},
+		{Name: "Bob", Email: "bob@example.com", CompanyID: company.ID, LeadSource: "web"},
+		{Name: "Cy", Email: "cy@example.com", CompanyID: company.ID, LeadSource: "referral"},
+	} {
+		assert.NoError(t, testDB.Create(&customer).Error)
+	}
+
+	w := performRequest(r, "GET", "/customers/export?lead_source=web&sort=-name&columns=name,company_name,funnel_name", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Header().Get("Content-Disposition"), `filename="customers-`)
+
+	records, err := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"Name", "Company", "Funnel"},
+		{"Bob", "Test Company", ""},
+		{"Ada", "Test Company", "Lead"},
+	}, records)
+
+	w = performRequest(r, "GET", "/customers/export?columns=password", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(r, "GET", "/customers/export?format=pdf", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = performRequest(r, "GET", "/customers/export?bogus[eq]=1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestExportCompaniesXLSX(t *testing.T) {
+	r := gin.Default()
+	r.GET("/companies/export", testHandler.ExportCompanies)
+	createTestCompanyAndUser(t)
+
+	w := performRequest(r, "GET", "/companies/export?format=xlsx", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", w.Header().Get("Content-Type"))
+
+	archive, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, archive.File, 5)
+}
+
+func TestExportFunnelsCSV(t *testing.T) {
+	r := gin.Default()
+	r.GET("/funnels/export", testHandler.ExportFunnels)
+
+	company, _ := createTestCompanyAndUser(t)
+	limit := 5
+	lead := models.Funnel{Name: "Lead", WIPLimit: &limit, WIPMode: models.WIPModeWarn}
+	assert.NoError(t, testDB.Create(&lead).Error)
+	assert.NoError(t, testDB.Create(&models.Funnel{Name: "Won"}).Error)
+	assert.NoError(t, testDB.Create(&models.Customer{Name: "Ada", CompanyID: company.ID, FunnelID: &lead.ID}).Error)
+
+	w := performRequest(r, "GET", "/funnels/export?sort=name", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	records, err := csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+	assert.NoError(t, err)
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, []string{"ID", "Name", "WIP Limit", "Customers", "Created At"}, records[0])
+		assert.Equal(t, []string{"Lead", "5", "1"}, records[1][1:4])
+		assert.Equal(t, []string{"Won", "", "0"}, records[2][1:4])
+	}
+
+	w = performRequest(r, "GET", "/funnels/export?columns=name,wip_mode", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	records, err = csv.NewReader(bytes.NewReader(w.Body.Bytes())).ReadAll()
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"Name", "WIP Mode"}, {"Lead", "warn"}, {"Won", ""}}, records)
+}
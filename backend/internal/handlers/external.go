@@ -0,0 +1,295 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+const maxExternalKeyLength = 255
+
+// externalKey reads the :source and :external_id path parameters that
+// identify a record in an integrated system.
+func externalKey(c *gin.Context) (string, string, bool) {
+	source, externalID := c.Param("source"), c.Param("external_id")
+	if len(source) > maxExternalKeyLength || len(externalID) > maxExternalKeyLength {
+		problem.BadRequest(c, "source and external_id must be at most 255 characters long")
+		return "", "", false
+	}
+	return source, externalID, true
+}
+
+func byExternalKey(source, externalID string) func(*gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("source = ? AND external_id = ?", source, externalID)
+	}
+}
+
+// upsertExternal runs write, which creates or updates one record, in a
+// transaction. Two requests creating the same new key race on the unique
+// index; the loser runs again and finds the winner's record to update.
+func (h *Handler) upsertExternal(write func(tx *gorm.DB) (bool, error)) (bool, error) {
+	var created bool
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		err = h.db.Transaction(func(tx *gorm.DB) error {
+			var writeErr error
+			created, writeErr = write(tx)
+			return writeErr
+		})
+		if !errors.Is(err, gorm.ErrDuplicatedKey) {
+			break
+		}
+	}
+	return created, err
+}
+
+// restoreDeleted undeletes a soft-deleted record, since the integrated
+// system still has it.
+func restoreDeleted(tx *gorm.DB, model interface{}, deletedAt gorm.DeletedAt) error {
+	if !deletedAt.Valid {
+		return nil
+	}
+	return tx.Unscoped().Model(model).Update("deleted_at", nil).Error
+}
+
+func respondUpserted(c *gin.Context, created bool, updatedAt time.Time, v interface{}) {
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	setETag(c, updatedAt)
+	c.JSON(status, v)
+}
+
+func (h *Handler) GetCompanyByExternalID(c *gin.Context) {
+	sel, ok := h.parseSelection(c, companySelection)
+	if !ok {
+		return
+	}
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+
+	var company models.Company
+	if err := sel.Apply(h.db, "updated_at").Scopes(byExternalKey(source, externalID)).First(&company).Error; err != nil {
+		problem.NotFound(c, "Company not found")
+		return
+	}
+
+	if notModified(c, company.UpdatedAt) {
+		return
+	}
+	setETag(c, company.UpdatedAt)
+	renderSelection(c, sel, company)
+}
+
+func (h *Handler) UpsertCompany(c *gin.Context) {
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+	var input models.CompanyInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+	role := currentRole(c)
+
+	var company models.Company
+	created, err := h.upsertExternal(func(tx *gorm.DB) (bool, error) {
+		updates, err := companyUpdates(tx, input, role)
+		if err != nil {
+			return false, err
+		}
+
+		company = models.Company{}
+		err = tx.Unscoped().Scopes(byExternalKey(source, externalID)).First(&company).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			company = models.Company{Name: input.Name, Address: input.Address, FunnelID: input.FunnelID, Source: &source, ExternalID: &externalID}
+			return true, tx.Create(&company).Error
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := restoreDeleted(tx, &company, company.DeletedAt); err != nil {
+			return false, err
+		}
+		if err := tx.Model(&company).Updates(updates).Error; err != nil {
+			return false, err
+		}
+		return false, tx.First(&company, company.ID).Error
+	})
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+	respondUpserted(c, created, company.UpdatedAt, company)
+}
+
+func (h *Handler) GetCustomerByExternalID(c *gin.Context) {
+	sel, ok := h.parseSelection(c, customerSelection)
+	if !ok {
+		return
+	}
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+
+	var customer models.Customer
+	if err := sel.Apply(h.db, "updated_at").Scopes(byExternalKey(source, externalID)).First(&customer).Error; err != nil {
+		problem.NotFound(c, "Customer not found")
+		return
+	}
+
+	if notModified(c, customer.UpdatedAt) {
+		return
+	}
+	setETag(c, customer.UpdatedAt)
+	renderSelection(c, sel, customer)
+}
+
+func (h *Handler) UpsertCustomer(c *gin.Context) {
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+	var input models.UpsertCustomerInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	var customer models.Customer
+	created, err := h.upsertExternal(func(tx *gorm.DB) (bool, error) {
+		customer = models.Customer{}
+		err := tx.Unscoped().Scopes(byExternalKey(source, externalID)).First(&customer).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			customer = models.Customer{
+				Name:        input.Name,
+				Email:       input.Email,
+				Phone:       input.Phone,
+				Source:      &source,
+				ExternalID:  &externalID,
+				CompanyID:   input.CompanyID,
+				FunnelID:    input.FunnelID,
+				FunnelStage: input.FunnelStage,
+				LeadSource:  input.LeadSource,
+			}
+			for _, name := range input.Tags {
+				customer.Tags = append(customer.Tags, models.Tag{Name: name})
+			}
+			customers := h.customers(tx)
+			if err := customers.Create(&customer); err != nil {
+				return true, err
+			}
+			return true, customers.ApplyMemberships(customer.ID, input.Memberships)
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := restoreDeleted(tx, &customer, customer.DeletedAt); err != nil {
+			return false, err
+		}
+		return false, h.customers(tx).Update(&customer, models.UpdateCustomerInput{
+			Name:        input.Name,
+			Email:       input.Email,
+			Phone:       input.Phone,
+			FunnelID:    input.FunnelID,
+			FunnelStage: input.FunnelStage,
+			LeadSource:  input.LeadSource,
+			Tags:        input.Tags,
+			Memberships: input.Memberships,
+		})
+	})
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+
+	customer.Tags = nil
+	h.db.Preload("Tags").Preload("Memberships").First(&customer, customer.ID)
+	respondUpserted(c, created, customer.UpdatedAt, customer)
+}
+
+func (h *Handler) GetUserByExternalID(c *gin.Context) {
+	sel, ok := h.parseSelection(c, userSelection)
+	if !ok {
+		return
+	}
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+
+	var user models.User
+	if err := sel.Apply(h.db, "updated_at").Scopes(byExternalKey(source, externalID)).First(&user).Error; err != nil {
+		problem.NotFound(c, "User not found")
+		return
+	}
+
+	if notModified(c, user.UpdatedAt) {
+		return
+	}
+	setETag(c, user.UpdatedAt)
+	renderSelection(c, sel, user)
+}
+
+// UpsertUser is limited to admins because it can change any user's role and
+// company.
+func (h *Handler) UpsertUser(c *gin.Context) {
+	if currentRole(c) != models.RoleAdmin {
+		problem.Forbidden(c, "Only admins can upsert users")
+		return
+	}
+	source, externalID, ok := externalKey(c)
+	if !ok {
+		return
+	}
+	var input UpsertUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	var user models.User
+	created, err := h.upsertExternal(func(tx *gorm.DB) (bool, error) {
+		user = models.User{}
+		err := tx.Unscoped().Scopes(byExternalKey(source, externalID)).First(&user).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if input.Password == "" {
+				p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Request body failed validation")
+				p.Errors = []problem.FieldError{{Field: "password", Code: "required", Message: "is required"}}
+				return true, p
+			}
+			user = models.User{Name: input.Name, Email: input.Email, Role: input.Role, CompanyID: input.CompanyID, Source: &source, ExternalID: &externalID}
+			return true, h.users(tx).Create(&user, input.Password)
+		}
+		if err != nil {
+			return false, err
+		}
+		if err := restoreDeleted(tx, &user, user.DeletedAt); err != nil {
+			return false, err
+		}
+
+		updates := map[string]interface{}{"name": input.Name, "email": input.Email, "role": input.Role, "company_id": input.CompanyID}
+		if input.Password != "" {
+			updates["password"] = input.Password
+		}
+		return false, h.users(tx).Update(&user, updates)
+	})
+	if err != nil {
+		userSaveFailed(c, err)
+		return
+	}
+
+	h.db.First(&user, user.ID)
+	respondUpserted(c, created, user.UpdatedAt, user)
+}
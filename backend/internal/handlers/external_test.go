@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupExternalRouter(role models.Role) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", string(role))
+		c.Next()
+	})
+	r.GET("/companies/:id", testHandler.GetCompany)
+	r.GET("/companies/external/:source/:external_id", testHandler.GetCompanyByExternalID)
+	r.PUT("/companies/external/:source/:external_id", testHandler.UpsertCompany)
+	r.GET("/customers/external/:source/:external_id", testHandler.GetCustomerByExternalID)
+	r.PUT("/customers/external/:source/:external_id", testHandler.UpsertCustomer)
+	r.GET("/users/external/:source/:external_id", testHandler.GetUserByExternalID)
+	r.PUT("/users/external/:source/:external_id", testHandler.UpsertUser)
+	return r
+}
+
+func TestUpsertCompanyByExternalID(t *testing.T) {
+	createTestCompanyAndUser(t)
+	r := setupExternalRouter(models.RoleSales)
+
+	w := performRequest(r, "PUT", "/companies/external/erp/C-1", map[string]interface{}{"name": "Acme", "address": "1 Main Street"})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Company
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, "erp", *created.Source)
+	assert.Equal(t, "C-1", *created.ExternalID)
+	assert.NotEmpty(t, w.Header().Get("ETag"))
+
+	w = performRequest(r, "PUT", "/companies/external/erp/C-1", map[string]interface{}{"name": "Acme Corp"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.Company
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "Acme Corp", updated.Name)
+	assert.Equal(t, "1 Main Street", updated.Address)
+
+	w = performRequest(r, "PUT", "/companies/external/crm/C-1", map[string]interface{}{"name": "Other Acme"})
+	require.Equal(t, http.StatusCreated, w.Code)
+
+	w = performRequest(r, "GET", "/companies/external/erp/C-1?fields=name", nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"ID":%d,"name":"Acme Corp"}`, created.ID), w.Body.String())
+
+	w = performRequest(r, "GET", "/companies/external/erp/C-2", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	require.NoError(t, testDB.Delete(&models.Company{}, created.ID).Error)
+	w = performRequest(r, "GET", "/companies/external/erp/C-1", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	w = performRequest(r, "PUT", "/companies/external/erp/C-1", map[string]interface{}{"name": "Acme"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, created.ID, updated.ID)
+	w = performRequest(r, "GET", "/companies/external/erp/C-1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "PUT", "/companies/external/erp/C-3", map[string]interface{}{"address": "No name"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpsertCompanyChecksFunnel(t *testing.T) {
+	createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Lead"}
+	require.NoError(t, testDB.Create(&funnel).Error)
+
+	w := performRequest(setupExternalRouter(models.RoleSales), "PUT", "/companies/external/erp/C-1", gin.H{"name": "Acme", "funnel_id": funnel.ID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	manager := setupExternalRouter(models.RoleHeadOfSales)
+	w = performRequest(manager, "PUT", "/companies/external/erp/C-1", gin.H{"name": "Acme", "funnel_id": 999999})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var count int64
+	testDB.Model(&models.Company{}).Where("external_id = ?", "C-1").Count(&count)
+	assert.Zero(t, count)
+
+	w = performRequest(manager, "PUT", "/companies/external/erp/C-1", gin.H{"name": "Acme", "funnel_id": funnel.ID})
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+}
+
+func TestCreateBodiesIgnoreExternalKey(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	r := gin.Default()
+	r.POST("/companies", testHandler.CreateCompany)
+	r.POST("/customers", testHandler.CreateCustomer)
+
+	w := performRequest(r, "POST", "/companies", gin.H{"name": "Acme", "source": "erp", "external_id": "C-1"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	w = performRequest(r, "POST", "/customers", gin.H{"name": "Ada", "company_id": company.ID, "source": "erp", "external_id": "P-1"})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var count int64
+	testDB.Model(&models.Company{}).Where("source IS NOT NULL").Count(&count)
+	assert.Zero(t, count)
+	testDB.Model(&models.Customer{}).Where("source IS NOT NULL").Count(&count)
+	assert.Zero(t, count)
+}
+
+func TestUpsertCustomerByExternalID(t *testing.T) {
+	company, _ := createTestCompanyAndUser(t)
+	r := setupExternalRouter(models.RoleSales)
+
+	body := map[string]interface{}{"name": "Ada", "email": "ada@example.com", "company_id": company.ID, "tags": []string{"vip"}}
+	w := performRequest(r, "PUT", "/customers/external/erp/42", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.Customer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	assert.Equal(t, company.ID, created.CompanyID)
+	require.Len(t, created.Tags, 1)
+	assert.Equal(t, "vip", created.Tags[0].Name)
+
+	body = map[string]interface{}{"name": "Ada Lovelace", "tags": []string{"vip", "partner"}}
+	w = performRequest(r, "PUT", "/customers/external/erp/42", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var updated models.Customer
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Equal(t, created.ID, updated.ID)
+	assert.Equal(t, "Ada Lovelace", updated.Name)
+	assert.Equal(t, "ada@example.com", updated.Email)
+	assert.Len(t, updated.Tags, 2)
+
+	var count int64
+	testDB.Model(&models.Customer{}).Where("source = ? AND external_id = ?", "erp", "42").Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	w = performRequest(r, "GET", "/customers/external/erp/42", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"external_id":"42"`)
+}
+
+func TestUpsertUserByExternalID(t *testing.T) {
+	createTestCompanyAndUser(t)
+	body := map[string]interface{}{"name": "Rep", "email": "rep@example.com", "role": "sales"}
+
+	w := performRequest(setupExternalRouter(models.RoleSales), "PUT", "/users/external/erp/U-1", body)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	r := setupExternalRouter(models.RoleAdmin)
+	w = performRequest(r, "PUT", "/users/external/erp/U-1", body)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"password"`)
+
+	body["password"] = "password123"
+	w = performRequest(r, "PUT", "/users/external/erp/U-1", body)
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var created models.User
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+	var stored models.User
+	require.NoError(t, testDB.First(&stored, created.ID).Error)
+	password := stored.Password
+
+	delete(body, "password")
+	body["role"] = "head_of_sales"
+	w = performRequest(r, "PUT", "/users/external/erp/U-1", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.NoError(t, testDB.First(&stored, created.ID).Error)
+	assert.Equal(t, models.RoleHeadOfSales, stored.Role)
+	assert.Equal(t, password, stored.Password)
+
+	body["email"] = "test@example.com"
+	w = performRequest(r, "PUT", "/users/external/erp/U-1", body)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	w = performRequest(r, "GET", "/users/external/erp/U-1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "password")
+}
@@ -1,142 +1,461 @@
 package handlers
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"slices"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mokan/flame-crm-backend/internal/db"
 	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type CreateFunnelInput struct {
 	Name              string `json:"name" binding:"required"`
 	NextFunnelIDs     []uint `json:"next_funnel_ids"`
 	PreviousFunnelIDs []uint `json:"previous_funnel_ids"`
+	WIPLimit          *int   `json:"wip_limit" binding:"omitempty,min=0"`
+	WIPMode           string `json:"wip_mode" binding:"omitempty,oneof=block warn"`
 }
 
 type UpdateFunnelInput struct {
-	Name              string `json:"name"`
-	NextFunnelIDs     []uint `json:"next_funnel_ids"`
-	PreviousFunnelIDs []uint `json:"previous_funnel_ids"`
+	Name              string      `json:"name"`
+	NextFunnelIDs     []uint      `json:"next_funnel_ids"`
+	PreviousFunnelIDs []uint      `json:"previous_funnel_ids"`
+	WIPLimit          optionalInt `json:"wip_limit,omitzero"`
+	WIPMode           string      `json:"wip_mode" binding:"omitempty,oneof=block warn"`
+}
+
+// optionalInt tells an explicit null apart from a missing field, so a PUT
+// can clear a value by sending null and leave it alone by omitting it.
+type optionalInt struct {
+	Set   bool
+	Value *int
+}
+
+func (o *optionalInt) UnmarshalJSON(data []byte) error {
+	o.Set = true
+	o.Value = nil
+	if string(data) == "null" {
+		return nil
+	}
+	return json.Unmarshal(data, &o.Value)
+}
+
+func (o optionalInt) MarshalJSON() ([]byte, error) {
+	return json.Marshal(o.Value)
+}
+
+type DeleteFunnelResult struct {
+	Message          string `json:"message"`
+	CustomersMoved   int64  `json:"customers_moved"`
+	CompaniesMoved   int64  `json:"companies_moved"`
+	MembershipsMoved int64  `json:"memberships_moved"`
 }
 
-func GetFunnels(c *gin.Context) {
+func (h *Handler) GetFunnels(c *gin.Context) {
+	params, ok := parseListQuery(c, funnelQuerySpec, pagedVersion(c))
+	if !ok {
+		return
+	}
+	sel, ok := h.parseSelection(c, funnelSelection)
+	if !ok {
+		return
+	}
+
 	var funnels []models.Funnel
-	if err := db.DB.Preload("NextFunnels").Preload("PreviousFunnels").Find(&funnels).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	meta, err := query.Find(sel.Apply(h.db, params.SortColumns()...), params, &funnels)
+	if err != nil {
+		problem.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, funnels)
+	setListMeta(c, meta)
+	renderSelection(c, sel, funnels)
 }
 
-func CreateFunnel(c *gin.Context) {
+func (h *Handler) GetFunnel(c *gin.Context) {
+	sel, ok := h.parseSelection(c, funnelSelection)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	var funnel models.Funnel
+	if err := sel.Apply(h.db, "updated_at").First(&funnel, id).Error; err != nil {
+		problem.NotFound(c, "Funnel not found")
+		return
+	}
+
+	if notModified(c, funnel.UpdatedAt) {
+		return
+	}
+	setETag(c, funnel.UpdatedAt)
+	renderSelection(c, sel, funnel)
+}
+
+func (h *Handler) CreateFunnel(c *gin.Context) {
 	var input CreateFunnelInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
+	var funnel *models.Funnel
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		funnel, err = h.createFunnel(tx, input)
+		return err
+	})
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+
+	setETag(c, funnel.UpdatedAt)
+	c.JSON(http.StatusOK, funnel)
+}
+
+func (h *Handler) createFunnel(tx *gorm.DB, input CreateFunnelInput) (*models.Funnel, error) {
 	funnel := models.Funnel{
-		Name: input.Name,
+		Name:     input.Name,
+		WIPLimit: input.WIPLimit,
+		WIPMode:  input.WIPMode,
 	}
 
 	if len(input.NextFunnelIDs) > 0 {
-		var nextFunnels []*models.Funnel
-		if err := db.DB.Where("id IN ?", input.NextFunnelIDs).Find(&nextFunnels).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid next funnel IDs"})
-			return
+		nextFunnels, err := h.findFunnels(input.NextFunnelIDs)
+		if err != nil {
+			return nil, invalidFunnelIDs("next_funnel_ids", "Invalid next funnel IDs")
 		}
 		funnel.NextFunnels = nextFunnels
 	}
 
 	if len(input.PreviousFunnelIDs) > 0 {
-		var prevFunnels []*models.Funnel
-		if err := db.DB.Where("id IN ?", input.PreviousFunnelIDs).Find(&prevFunnels).Error; err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid previous funnel IDs"})
-			return
+		prevFunnels, err := h.findFunnels(input.PreviousFunnelIDs)
+		if err != nil {
+			return nil, invalidFunnelIDs("previous_funnel_ids", "Invalid previous funnel IDs")
 		}
 		funnel.PreviousFunnels = prevFunnels
 	}
 
-	if err := db.DB.Create(&funnel).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+	if len(funnel.NextFunnels) > 0 || len(funnel.PreviousFunnels) > 0 {
+		if _, err := h.funnelVersions(tx).EnsureDraft(); err != nil {
+			return nil, err
+		}
 	}
+	if err := tx.Create(&funnel).Error; err != nil {
+		return nil, err
+	}
+	return &funnel, nil
+}
 
-	c.JSON(http.StatusOK, funnel)
+func invalidFunnelIDs(field, detail string) *problem.Problem {
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, detail)
+	p.Errors = []problem.FieldError{{Field: field, Code: "exists", Message: "must reference existing funnels"}}
+	return p
 }
 
-func UpdateFunnel(c *gin.Context) {
+func (h *Handler) UpdateFunnel(c *gin.Context) {
 	id := c.Param("id")
 	var funnel models.Funnel
-	if err := db.DB.Preload("NextFunnels").Preload("PreviousFunnels").First(&funnel, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Funnel not found"})
+	if err := h.db.Preload("NextFunnels").Preload("PreviousFunnels").First(&funnel, id).Error; err != nil {
+		problem.NotFound(c, "Funnel not found")
+		return
+	}
+
+	if ifMatchFailed(c, funnel.UpdatedAt) {
+		preconditionFailed(c, funnel.UpdatedAt, funnel)
 		return
 	}
 
 	var input UpdateFunnelInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
-	if input.Name != "" {
-		funnel.Name = input.Name
+	updates, err := funnelUpdates(input, currentRole(c))
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
 	}
 
-	if input.NextFunnelIDs != nil {
-		var nextFunnels []*models.Funnel
-		if len(input.NextFunnelIDs) > 0 {
-			if err := db.DB.Where("id IN ?", input.NextFunnelIDs).Find(&nextFunnels).Error; err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid next funnel IDs"})
-				return
-			}
+	var nextFunnels, prevFunnels []*models.Funnel
+	if len(input.NextFunnelIDs) > 0 {
+		found, err := h.findFunnels(input.NextFunnelIDs)
+		if err != nil {
+			problem.Validation(c, "Invalid next funnel IDs", problem.FieldError{Field: "next_funnel_ids", Code: "exists", Message: "must reference existing funnels"})
+			return
 		}
-		if err := db.DB.Model(&funnel).Association("NextFunnels").Replace(nextFunnels); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update next transitions"})
+		nextFunnels = found
+	}
+	if len(input.PreviousFunnelIDs) > 0 {
+		found, err := h.findFunnels(input.PreviousFunnelIDs)
+		if err != nil {
+			problem.Validation(c, "Invalid previous funnel IDs", problem.FieldError{Field: "previous_funnel_ids", Code: "exists", Message: "must reference existing funnels"})
 			return
 		}
+		prevFunnels = found
 	}
 
-	if input.PreviousFunnelIDs != nil {
-		var prevFunnels []*models.Funnel
-		if len(input.PreviousFunnelIDs) > 0 {
-			if err := db.DB.Where("id IN ?", input.PreviousFunnelIDs).Find(&prevFunnels).Error; err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid previous funnel IDs"})
-				return
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Funnel{}, funnel.ID, funnel.UpdatedAt); err != nil {
+			return err
+		}
+
+		if input.NextFunnelIDs != nil || input.PreviousFunnelIDs != nil {
+			if _, err := h.funnelVersions(tx).EnsureDraft(); err != nil {
+				return err
+			}
+		}
+
+		if input.NextFunnelIDs != nil {
+			if err := tx.Model(&funnel).Association("NextFunnels").Replace(nextFunnels); err != nil {
+				return errors.New("Failed to update next transitions")
 			}
 		}
-		if err := db.DB.Model(&funnel).Association("PreviousFunnels").Replace(prevFunnels); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update previous transitions"})
+
+		if input.PreviousFunnelIDs != nil {
+			if err := tx.Model(&funnel).Association("PreviousFunnels").Replace(prevFunnels); err != nil {
+				return errors.New("Failed to update previous transitions")
+			}
+		}
+
+		if len(updates) == 0 {
+			return tx.Model(&funnel).Update("updated_at", tx.NowFunc()).Error
+		}
+		return tx.Model(&funnel).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			var current models.Funnel
+			h.db.Preload("NextFunnels").Preload("PreviousFunnels").First(&current, funnel.ID)
+			preconditionFailed(c, current.UpdatedAt, current)
 			return
 		}
+		problem.Internal(c, err)
+		return
 	}
 
-	db.DB.Save(&funnel)
-	c.JSON(http.StatusOK, funnel)
+	var current models.Funnel
+	h.db.Preload("NextFunnels").Preload("PreviousFunnels").First(&current, funnel.ID)
+	setETag(c, current.UpdatedAt)
+	c.JSON(http.StatusOK, current)
 }
 
-func DeleteFunnel(c *gin.Context) {
+// funnelUpdates checks a full funnel body against service.FunnelFields, so PUT
+// follows the same field and role rules as PATCH. Transitions are not in the
+// allowlist but are held to the same managers-only rule.
+func funnelUpdates(input UpdateFunnelInput, role models.Role) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if input.Name != "" {
+		doc["name"] = input.Name
+	}
+	if input.WIPLimit.Set {
+		doc["wip_limit"] = nil
+		if input.WIPLimit.Value != nil {
+			doc["wip_limit"] = json.Number(strconv.Itoa(*input.WIPLimit.Value))
+		}
+	}
+	if input.WIPMode != "" {
+		doc["wip_mode"] = input.WIPMode
+	}
+	updates, err := patch.Apply(doc, service.FunnelFields, role)
+	if err != nil {
+		return nil, patchProblem(err)
+	}
+
+	if !slices.Contains(service.Managers, role) {
+		for field, ids := range map[string][]uint{"next_funnel_ids": input.NextFunnelIDs, "previous_funnel_ids": input.PreviousFunnelIDs} {
+			if ids != nil {
+				return nil, patchProblem(&patch.FieldError{Field: field, Message: "your role cannot modify this field", Forbidden: true})
+			}
+		}
+	}
+	return updates, nil
+}
+
+func (h *Handler) PatchFunnel(c *gin.Context) {
 	id := c.Param("id")
 	var funnel models.Funnel
-	if err := db.DB.First(&funnel, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Funnel not found"})
+	if err := h.db.First(&funnel, id).Error; err != nil {
+		problem.NotFound(c, "Funnel not found")
 		return
 	}
 
-	if err := db.DB.Model(&funnel).Association("NextFunnels").Clear(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear next transitions"})
+	if ifMatchFailed(c, funnel.UpdatedAt) {
+		preconditionFailed(c, funnel.UpdatedAt, funnel)
 		return
 	}
-	if err := db.DB.Model(&funnel).Association("PreviousFunnels").Clear(); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear previous transitions"})
+
+	updates, ok := bindMergePatch(c, service.FunnelFields)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.Funnel{}, funnel.ID, funnel.UpdatedAt); err != nil {
+			return err
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		return tx.Model(&funnel).Updates(updates).Error
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			h.db.First(&funnel, funnel.ID)
+			preconditionFailed(c, funnel.UpdatedAt, funnel)
+			return
+		}
+		problem.Internal(c, err)
 		return
 	}
 
-	if err := db.DB.Delete(&funnel).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var current models.Funnel
+	h.db.Preload("NextFunnels").Preload("PreviousFunnels").First(&current, funnel.ID)
+	setETag(c, current.UpdatedAt)
+	c.JSON(http.StatusOK, current)
+}
+
+func (h *Handler) DeleteFunnel(c *gin.Context) {
+	id := c.Param("id")
+	var funnel models.Funnel
+	if err := h.db.First(&funnel, id).Error; err != nil {
+		problem.NotFound(c, "Funnel not found")
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Funnel deleted"})
+	var customerCount, companyCount, membershipCount int64
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Locking the funnel and reading the references in the same
+		// transaction keeps a concurrent assignment from slipping in
+		// between the check and the delete.
+		if err := tx.Scopes(forUpdate).First(&funnel, funnel.ID).Error; err != nil {
+			return problem.New(http.StatusNotFound, problem.CodeNotFound, "Funnel not found")
+		}
+		// Soft-deleted rows count too: they keep their funnel_id and would
+		// point at a deleted funnel once restored.
+		if err := tx.Unscoped().Model(&models.Customer{}).Where("funnel_id = ?", funnel.ID).Count(&customerCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.CustomerFunnel{}).Where("funnel_id = ?", funnel.ID).Count(&membershipCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&models.Company{}).Where("funnel_id = ?", funnel.ID).Count(&companyCount).Error; err != nil {
+			return err
+		}
+
+		var target *models.Funnel
+		if targetID := c.Query("target_funnel_id"); targetID != "" {
+			target = &models.Funnel{}
+			if err := tx.Scopes(forUpdate).First(target, targetID).Error; err != nil {
+				return problem.New(http.StatusBadRequest, problem.CodeBadRequest, "Target funnel not found")
+			}
+			if target.ID == funnel.ID {
+				return problem.New(http.StatusBadRequest, problem.CodeBadRequest, "Target funnel must differ from the deleted funnel")
+			}
+		}
+
+		if target == nil && (customerCount > 0 || companyCount > 0 || membershipCount > 0) {
+			return problem.New(http.StatusConflict, problem.CodeConflict, "Funnel is still referenced; provide target_funnel_id to move them").
+				With("customers", customerCount).
+				With("companies", companyCount).
+				With("memberships", membershipCount)
+		}
+
+		if target != nil {
+			if err := moveFunnelReferences(tx, &models.Customer{}, models.FunnelMoveEntityCustomer, funnel.ID, target.ID); err != nil {
+				return err
+			}
+			if err := moveFunnelReferences(tx, &models.Company{}, models.FunnelMoveEntityCompany, funnel.ID, target.ID); err != nil {
+				return err
+			}
+			if err := moveFunnelReferences(tx, &models.CustomerFunnel{}, models.FunnelMoveEntityMember, funnel.ID, target.ID); err != nil {
+				return err
+			}
+		}
+
+		if _, err := h.funnelVersions(tx).EnsureDraft(); err != nil {
+			return err
+		}
+		if err := tx.Model(&funnel).Association("NextFunnels").Clear(); err != nil {
+			return err
+		}
+		if err := tx.Model(&funnel).Association("PreviousFunnels").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&funnel).Error
+	})
+	if err != nil {
+		problem.Write(c, problemFor(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, DeleteFunnelResult{
+		Message:          "Funnel deleted",
+		CustomersMoved:   customerCount,
+		CompaniesMoved:   companyCount,
+		MembershipsMoved: membershipCount,
+	})
+}
+
+// forUpdate locks the rows a query reads until the transaction ends. SQLite
+// has no row locks; its write transactions are already serialized.
+func forUpdate(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() == "postgres" {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	return tx
+}
+
+func (h *Handler) findFunnels(ids []uint) ([]*models.Funnel, error) {
+	var funnels []*models.Funnel
+	if err := h.db.Where("id IN ?", ids).Find(&funnels).Error; err != nil {
+		return nil, err
+	}
+
+	unique := map[uint]bool{}
+	for _, id := range ids {
+		unique[id] = true
+	}
+	if len(funnels) != len(unique) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return funnels, nil
+}
+
+// moveFunnelReferences moves every row of model on fromID to toID, including
+// soft-deleted ones, and records the moves.
+func moveFunnelReferences(tx *gorm.DB, model interface{}, entityType string, fromID, toID uint) error {
+	var ids []uint
+	if err := tx.Unscoped().Model(model).Where("funnel_id = ?", fromID).Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if err := tx.Unscoped().Model(model).Where("id IN ?", ids).Update("funnel_id", toID).Error; err != nil {
+		return err
+	}
+
+	moves := make([]models.FunnelMove, 0, len(ids))
+	for _, entityID := range ids {
+		moves = append(moves, models.FunnelMove{
+			EntityType:   entityType,
+			EntityID:     entityID,
+			FromFunnelID: fromID,
+			ToFunnelID:   toID,
+			Reason:       "funnel_deleted",
+		})
+	}
+	return tx.Create(&moves).Error
 }
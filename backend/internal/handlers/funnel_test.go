@@ -11,32 +11,34 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mokan/flame-crm-backend/internal/db"
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/migrate"
 	"github.com/mokan/flame-crm-backend/internal/models"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
-var testDB *gorm.DB
+// testDB and testHandler are shared by the tests that reset the database with
+// clearTable, so those tests must not run in parallel. Tests that need a
+// database of their own use newIsolatedHandler.
+var (
+	testDB      *gorm.DB
+	testHandler *Handler
+)
 
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 
 	var err error
-	testDB, err = gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
-	if err != nil {
-		fmt.Printf("Failed to connect to test database: %v\n", err)
-		os.Exit(1)
-	}
-
-	err = testDB.AutoMigrate(&models.Company{}, &models.User{}, &models.Customer{}, &models.Funnel{})
+	testDB, err = openTestDB("file::memory:?cache=shared")
 	if err != nil {
-		fmt.Printf("Failed to migrate test database: %v\n", err)
+		fmt.Printf("Failed to set up test database: %v\n", err)
 		os.Exit(1)
 	}
 
-	db.DB = testDB
+	testHandler = New(testDB)
+	auth.Configure("test-secret", "")
 
 	code := m.Run()
 
@@ -46,10 +48,71 @@ func TestMain(m *testing.M) {
 	os.Exit(code)
 }
 
+func openTestDB(dsn string) (*gorm.DB, error) {
+	database, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{TranslateError: true})
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := migrate.New(database)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		return nil, fmt.Errorf("migrating: %w", err)
+	}
+	return database, nil
+}
+
+// newIsolatedHandler returns a handler on a fresh in-memory database that
+// only the calling test sees, so the test can call t.Parallel.
+func newIsolatedHandler(t *testing.T) (*Handler, *gorm.DB) {
+	t.Helper()
+	database, err := openTestDB("file:" + t.Name() + "?mode=memory&cache=shared")
+	if err != nil {
+		t.Fatalf("Failed to set up test database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return New(database), database
+}
+
 func clearTable(t *testing.T) {
+	if err := testDB.Exec("DELETE FROM import_jobs;").Error; err != nil {
+		t.Fatalf("Failed to clear import_jobs: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM idempotency_keys;").Error; err != nil {
+		t.Fatalf("Failed to clear idempotency_keys: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM saved_views;").Error; err != nil {
+		t.Fatalf("Failed to clear saved_views: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM funnel_version_transitions;").Error; err != nil {
+		t.Fatalf("Failed to clear funnel_version_transitions: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM funnel_versions;").Error; err != nil {
+		t.Fatalf("Failed to clear funnel_versions: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM enrollment_rules;").Error; err != nil {
+		t.Fatalf("Failed to clear enrollment_rules: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM customer_tags;").Error; err != nil {
+		t.Fatalf("Failed to clear customer_tags: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM tags;").Error; err != nil {
+		t.Fatalf("Failed to clear tags: %v", err)
+	}
+	if err := testDB.Exec("DELETE FROM funnel_moves;").Error; err != nil {
+		t.Fatalf("Failed to clear funnel_moves: %v", err)
+	}
 	if err := testDB.Exec("DELETE FROM funnel_transitions;").Error; err != nil {
 		t.Fatalf("Failed to clear funnel_transitions: %v", err)
 	}
+	if err := testDB.Exec("DELETE FROM customer_funnels;").Error; err != nil {
+		t.Fatalf("Failed to clear customer_funnels: %v", err)
+	}
 	if err := testDB.Exec("DELETE FROM customers;").Error; err != nil {
 		t.Fatalf("Failed to clear customers: %v", err)
 	}
@@ -62,6 +125,9 @@ func clearTable(t *testing.T) {
 	if err := testDB.Exec("DELETE FROM companies;").Error; err != nil {
 		t.Fatalf("Failed to clear companies: %v", err)
 	}
+	if err := testDB.Exec("DELETE FROM changes;").Error; err != nil {
+		t.Fatalf("Failed to clear changes: %v", err)
+	}
 }
 
 func createTestCompanyAndUser(t *testing.T) (models.Company, models.User) {
@@ -96,11 +162,23 @@ func performRequest(r http.Handler, method, path string, body interface{}) *http
 }
 
 func setupRouter() *gin.Engine {
+	return setupRouterAs(models.RoleHeadOfSales)
+}
+
+func setupRouterAs(role models.Role) *gin.Engine {
 	r := gin.Default()
-	r.GET("/funnels", GetFunnels)
-	r.POST("/funnels", CreateFunnel)
-	r.PUT("/funnels/:id", UpdateFunnel)
-	r.PUT("/customers/:id", UpdateCustomer)
+	r.Use(func(c *gin.Context) {
+		c.Set("role", string(role))
+		c.Next()
+	})
+	r.GET("/funnels", testHandler.GetFunnels)
+	r.POST("/funnels", testHandler.CreateFunnel)
+	r.PUT("/funnels/:id", testHandler.UpdateFunnel)
+	r.DELETE("/funnels/:id", testHandler.DeleteFunnel)
+	r.POST("/funnel-versions/publish", testHandler.PublishFunnelVersion)
+	r.POST("/funnel-versions/:id/migrate", testHandler.MigrateFunnelVersion)
+	r.GET("/customers", testHandler.GetCustomers)
+	r.PUT("/customers/:id", testHandler.UpdateCustomer)
 	return r
 }
 
@@ -128,6 +206,39 @@ func TestFunnelCreationAndRetrieval(t *testing.T) {
 	assert.Equal(t, "Prospect", funnels[0].Name)
 }
 
+func TestFunnelListQuery(t *testing.T) {
+	r := setupRouter()
+	createTestCompanyAndUser(t)
+
+	for _, name := range []string{"Lead", "Qualified Lead", "Won", "Lost"} {
+		assert.NoError(t, testDB.Create(&models.Funnel{Name: name}).Error)
+	}
+
+	w := performRequest(r, "GET", "/funnels?name[contains]=lead&sort=-name&limit=1", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+
+	var funnels []models.Funnel
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &funnels))
+	assert.Len(t, funnels, 1)
+	assert.Equal(t, "Qualified Lead", funnels[0].Name)
+
+	w = performRequest(r, "GET", "/funnels?limit=3&cursor=", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	cursor := w.Header().Get("X-Next-Cursor")
+	assert.NotEmpty(t, cursor)
+
+	w = performRequest(r, "GET", "/funnels?limit=3&cursor="+cursor, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &funnels))
+	assert.Len(t, funnels, 1)
+	assert.Equal(t, "Lost", funnels[0].Name)
+	assert.Empty(t, w.Header().Get("X-Next-Cursor"))
+
+	w = performRequest(r, "GET", "/funnels?secret[eq]=1", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestFunnelUpdate(t *testing.T) {
 	r := setupRouter()
 	createTestCompanyAndUser(t)
@@ -185,6 +296,90 @@ func TestFunnelUpdate(t *testing.T) {
 	assert.Equal(t, funnel2.ID, dbFunnel.NextFunnels[0].ID)
 }
 
+func TestPatchFunnelWIPLimit(t *testing.T) {
+	createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Negotiation"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("role", string(models.RoleHeadOfSales))
+		c.Next()
+	})
+	r.PATCH("/funnels/:id", testHandler.PatchFunnel)
+	r.GET("/funnels", testHandler.GetFunnels)
+	path := fmt.Sprintf("/funnels/%d", funnel.ID)
+
+	w := patchRequest(r, path, `{"wip_limit": 3, "wip_mode": "warn"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dbFunnel models.Funnel
+	assert.NoError(t, testDB.First(&dbFunnel, funnel.ID).Error)
+	if assert.NotNil(t, dbFunnel.WIPLimit) {
+		assert.Equal(t, 3, *dbFunnel.WIPLimit)
+	}
+	assert.Equal(t, models.WIPModeWarn, dbFunnel.WIPMode)
+
+	w = performRequest(r, "GET", "/funnels?fields=wip_limit", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"wip_limit":3`)
+
+	w = patchRequest(r, path, `{"wip_limit": null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, testDB.First(&dbFunnel, funnel.ID).Error)
+	assert.Nil(t, dbFunnel.WIPLimit)
+}
+
+func TestUpdateFunnelClearsWIPLimit(t *testing.T) {
+	createTestCompanyAndUser(t)
+	limit := 4
+	funnel := models.Funnel{Name: "Qualified", WIPLimit: &limit}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+
+	r := setupRouter()
+	path := fmt.Sprintf("/funnels/%d", funnel.ID)
+
+	w := performRequest(r, "PUT", path, UpdateFunnelInput{Name: "Qualified Lead"})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var dbFunnel models.Funnel
+	assert.NoError(t, testDB.First(&dbFunnel, funnel.ID).Error)
+	if assert.NotNil(t, dbFunnel.WIPLimit) {
+		assert.Equal(t, 4, *dbFunnel.WIPLimit)
+	}
+
+	w = performRequest(r, "PUT", path, gin.H{"wip_limit": -1})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, "PUT", path, gin.H{"wip_limit": nil})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, testDB.First(&dbFunnel, funnel.ID).Error)
+	assert.Nil(t, dbFunnel.WIPLimit)
+}
+
+func TestUpdateFunnelRequiresManager(t *testing.T) {
+	createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Qualified"}
+	next := models.Funnel{Name: "Won"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+	assert.NoError(t, testDB.Create(&next).Error)
+
+	r := setupRouterAs(models.RoleSales)
+	path := fmt.Sprintf("/funnels/%d", funnel.ID)
+
+	w := performRequest(r, "PUT", path, UpdateFunnelInput{Name: "Renamed"})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequest(r, "PUT", path, gin.H{"wip_limit": 2})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequest(r, "PUT", path, UpdateFunnelInput{NextFunnelIDs: []uint{next.ID}})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	var dbFunnel models.Funnel
+	assert.NoError(t, testDB.Preload("NextFunnels").First(&dbFunnel, funnel.ID).Error)
+	assert.Equal(t, "Qualified", dbFunnel.Name)
+	assert.Nil(t, dbFunnel.WIPLimit)
+	assert.Empty(t, dbFunnel.NextFunnels)
+}
+
 func TestCustomerFunnelTransition(t *testing.T) {
 	r := setupRouter()
 	company, _ := createTestCompanyAndUser(t)
@@ -270,6 +465,147 @@ func TestCustomerFunnelTransition(t *testing.T) {
 	assert.Nil(t, updatedCustomer.FunnelID)
 }
 
+func TestCustomerMultiplePipelines(t *testing.T) {
+	r := setupRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won"}
+	kickoff := models.Funnel{Name: "Kickoff"}
+	live := models.Funnel{Name: "Live"}
+	for _, f := range []*models.Funnel{&lead, &won, &kickoff, &live} {
+		assert.NoError(t, testDB.Create(f).Error)
+	}
+	assert.NoError(t, testDB.Model(&lead).Association("NextFunnels").Replace([]*models.Funnel{&won}))
+	assert.NoError(t, testDB.Model(&kickoff).Association("NextFunnels").Replace([]*models.Funnel{&live}))
+
+	customer := models.Customer{Name: "Multi Pipeline", CompanyID: company.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+
+	input := models.UpdateCustomerInput{Memberships: []models.CustomerFunnelInput{
+		{Pipeline: "sales", FunnelID: &lead.ID},
+		{Pipeline: "onboarding", FunnelID: &kickoff.ID, FunnelStage: "scheduled"},
+	}}
+	w := performRequest(r, "PUT", fmt.Sprintf("/customers/%d", customer.ID), input)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var updated models.Customer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Len(t, updated.Memberships, 2)
+
+	input = models.UpdateCustomerInput{Memberships: []models.CustomerFunnelInput{
+		{Pipeline: "onboarding", FunnelID: &won.ID},
+	}}
+	w = performRequest(r, "PUT", fmt.Sprintf("/customers/%d", customer.ID), input)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid funnel transition")
+	assert.Contains(t, w.Body.String(), "onboarding")
+
+	input = models.UpdateCustomerInput{Memberships: []models.CustomerFunnelInput{
+		{Pipeline: "sales", FunnelID: &won.ID},
+	}}
+	w = performRequest(r, "PUT", fmt.Sprintf("/customers/%d", customer.ID), input)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var sales, onboarding models.CustomerFunnel
+	assert.NoError(t, testDB.Where("customer_id = ? AND pipeline = ?", customer.ID, "sales").First(&sales).Error)
+	assert.NoError(t, testDB.Where("customer_id = ? AND pipeline = ?", customer.ID, "onboarding").First(&onboarding).Error)
+	assert.Equal(t, won.ID, sales.FunnelID)
+	assert.Equal(t, kickoff.ID, onboarding.FunnelID)
+	assert.Equal(t, "scheduled", onboarding.FunnelStage)
+
+	w = performRequest(r, "GET", "/customers?pipeline=onboarding", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var customers []models.Customer
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &customers))
+	assert.Len(t, customers, 1)
+
+	input = models.UpdateCustomerInput{Memberships: []models.CustomerFunnelInput{
+		{Pipeline: "onboarding", Remove: true},
+	}}
+	w = performRequest(r, "PUT", fmt.Sprintf("/customers/%d", customer.ID), input)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &updated))
+	assert.Len(t, updated.Memberships, 1)
+	assert.Equal(t, "sales", updated.Memberships[0].Pipeline)
+}
+
+func TestFunnelVersionPinning(t *testing.T) {
+	r := setupRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	lead := models.Funnel{Name: "Lead"}
+	demo := models.Funnel{Name: "Demo"}
+	trial := models.Funnel{Name: "Trial"}
+	for _, f := range []*models.Funnel{&lead, &demo, &trial} {
+		assert.NoError(t, testDB.Create(f).Error)
+	}
+
+	w := performRequest(r, "PUT", fmt.Sprintf("/funnels/%d", lead.ID), UpdateFunnelInput{NextFunnelIDs: []uint{demo.ID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "POST", "/funnel-versions/publish", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var v1 models.FunnelVersion
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &v1))
+	assert.Equal(t, models.FunnelVersionActive, v1.Status)
+	assert.Len(t, v1.Transitions, 1)
+
+	w = performRequest(r, "POST", "/funnel-versions/publish", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	customer := models.Customer{Name: "Pinned", CompanyID: company.ID, FunnelID: &lead.ID, FunnelVersionID: &v1.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+
+	w = performRequest(r, "PUT", fmt.Sprintf("/funnels/%d", lead.ID), UpdateFunnelInput{NextFunnelIDs: []uint{trial.ID}})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	w = performRequest(r, "PUT", fmt.Sprintf("/customers/%d", customer.ID), models.UpdateCustomerInput{FunnelID: &trial.ID})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid funnel transition")
+
+	w = performRequest(r, "POST", "/funnel-versions/publish", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var v2 models.FunnelVersion
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &v2))
+	assert.Equal(t, 2, v2.Number)
+
+	var archived models.FunnelVersion
+	assert.NoError(t, testDB.First(&archived, v1.ID).Error)
+	assert.Equal(t, models.FunnelVersionArchived, archived.Status)
+
+	w = performRequest(r, "POST", fmt.Sprintf("/funnel-versions/%d/migrate", v1.ID), MigrateFunnelVersionInput{})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	sales := setupRouterAs(models.RoleSales)
+	w = performRequest(sales, "POST", "/funnel-versions/publish", nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequest(sales, "POST", fmt.Sprintf("/funnel-versions/%d/migrate", v2.ID), MigrateFunnelVersionInput{FromVersionID: &v1.ID})
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	lost := models.Funnel{Name: "Lost"}
+	assert.NoError(t, testDB.Create(&lost).Error)
+	for _, stageMap := range []map[uint]uint{{trial.ID: trial.ID}, {lead.ID: lost.ID}} {
+		w = performRequest(r, "POST", fmt.Sprintf("/funnel-versions/%d/migrate", v2.ID), MigrateFunnelVersionInput{FromVersionID: &v1.ID, StageMap: stageMap})
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), `"field":"stage_map"`)
+	}
+
+	migrate := MigrateFunnelVersionInput{FromVersionID: &v1.ID, StageMap: map[uint]uint{lead.ID: trial.ID}}
+	w = performRequest(r, "POST", fmt.Sprintf("/funnel-versions/%d/migrate", v2.ID), migrate)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"customers_migrated":1`)
+
+	var dbCustomer models.Customer
+	assert.NoError(t, testDB.First(&dbCustomer, customer.ID).Error)
+	assert.Equal(t, trial.ID, *dbCustomer.FunnelID)
+	assert.Equal(t, v2.ID, *dbCustomer.FunnelVersionID)
+
+	var move models.FunnelMove
+	assert.NoError(t, testDB.Where("entity_id = ? AND reason = ?", customer.ID, "version_migration").First(&move).Error)
+	assert.Equal(t, lead.ID, move.FromFunnelID)
+}
+
 func TestFunnelNotFound(t *testing.T) {
 	r := setupRouter()
 	createTestCompanyAndUser(t)
@@ -298,6 +634,86 @@ func TestInvalidNextFunnelIDs(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Invalid next funnel IDs")
 }
 
+func TestDeleteFunnelWithReferences(t *testing.T) {
+	r := setupRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	oldFunnel := models.Funnel{Name: "Old"}
+	newFunnel := models.Funnel{Name: "New"}
+	assert.NoError(t, testDB.Create(&oldFunnel).Error)
+	assert.NoError(t, testDB.Create(&newFunnel).Error)
+
+	company.FunnelID = &oldFunnel.ID
+	assert.NoError(t, testDB.Save(&company).Error)
+	customer := models.Customer{Name: "Referencing Customer", CompanyID: company.ID, FunnelID: &oldFunnel.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+
+	w := performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d", oldFunnel.ID), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "target_funnel_id")
+
+	w = performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d?target_funnel_id=%d", oldFunnel.ID, oldFunnel.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d?target_funnel_id=9999", oldFunnel.ID), nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Target funnel not found")
+
+	w = performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d?target_funnel_id=%d", oldFunnel.ID, newFunnel.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dbCustomer models.Customer
+	assert.NoError(t, testDB.First(&dbCustomer, customer.ID).Error)
+	assert.Equal(t, newFunnel.ID, *dbCustomer.FunnelID)
+
+	var dbCompany models.Company
+	assert.NoError(t, testDB.First(&dbCompany, company.ID).Error)
+	assert.Equal(t, newFunnel.ID, *dbCompany.FunnelID)
+
+	var moves []models.FunnelMove
+	assert.NoError(t, testDB.Order("entity_type").Find(&moves).Error)
+	assert.Len(t, moves, 2)
+	assert.Equal(t, models.FunnelMoveEntityCompany, moves[0].EntityType)
+	assert.Equal(t, models.FunnelMoveEntityCustomer, moves[1].EntityType)
+	assert.Equal(t, oldFunnel.ID, moves[1].FromFunnelID)
+	assert.Equal(t, newFunnel.ID, moves[1].ToFunnelID)
+
+	var remaining int64
+	testDB.Model(&models.Funnel{}).Where("id = ?", oldFunnel.ID).Count(&remaining)
+	assert.Equal(t, int64(0), remaining)
+
+	unused := models.Funnel{Name: "Unused"}
+	assert.NoError(t, testDB.Create(&unused).Error)
+	w = performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d", unused.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestDeleteFunnelMovesSoftDeletedReferences(t *testing.T) {
+	r := setupRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	oldFunnel := models.Funnel{Name: "Old"}
+	newFunnel := models.Funnel{Name: "New"}
+	assert.NoError(t, testDB.Create(&oldFunnel).Error)
+	assert.NoError(t, testDB.Create(&newFunnel).Error)
+
+	customer := models.Customer{Name: "Archived Customer", CompanyID: company.ID, FunnelID: &oldFunnel.ID}
+	assert.NoError(t, testDB.Create(&customer).Error)
+	assert.NoError(t, testDB.Delete(&customer).Error)
+
+	w := performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d", oldFunnel.ID), nil)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), `"customers":1`)
+
+	w = performRequest(r, "DELETE", fmt.Sprintf("/funnels/%d?target_funnel_id=%d", oldFunnel.ID, newFunnel.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var dbCustomer models.Customer
+	assert.NoError(t, testDB.Unscoped().First(&dbCustomer, customer.ID).Error)
+	assert.Equal(t, newFunnel.ID, *dbCustomer.FunnelID)
+	assert.True(t, dbCustomer.DeletedAt.Valid)
+}
+
 func generateDummyToken(userID uint) (string, error) {
 	return fmt.Sprintf("Bearer dummy-token-for-user-%d", userID), nil
 }
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
+)
+
+type MigrateFunnelVersionInput struct {
+	FromVersionID *uint         `json:"from_version_id"`
+	StageMap      map[uint]uint `json:"stage_map"`
+}
+
+type MigrateFunnelVersionResult struct {
+	CustomersMigrated   int `json:"customers_migrated"`
+	MembershipsMigrated int `json:"memberships_migrated"`
+}
+
+func (h *Handler) GetFunnelVersions(c *gin.Context) {
+	sel, ok := h.parseSelection(c, funnelVersionListSelection)
+	if !ok {
+		return
+	}
+
+	var versions []models.FunnelVersion
+	if err := sel.Apply(h.db).Order("number desc").Find(&versions).Error; err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	renderSelection(c, sel, versions)
+}
+
+func (h *Handler) GetFunnelVersion(c *gin.Context) {
+	sel, ok := h.parseSelection(c, funnelVersionSelection)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	var version models.FunnelVersion
+	if err := sel.Apply(h.db).First(&version, id).Error; err != nil {
+		problem.NotFound(c, "Funnel version not found")
+		return
+	}
+	renderSelection(c, sel, version)
+}
+
+func (h *Handler) PublishFunnelVersion(c *gin.Context) {
+	if !slices.Contains(service.Managers, currentRole(c)) {
+		problem.Forbidden(c, "Only admins and heads of sales can publish funnel versions")
+		return
+	}
+
+	var version *models.FunnelVersion
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		version, err = h.funnelVersions(tx).Publish()
+		return err
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrNothingToPublish) {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeNothingToPublish, err.Error())
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, version)
+}
+
+func (h *Handler) MigrateFunnelVersion(c *gin.Context) {
+	if !slices.Contains(service.Managers, currentRole(c)) {
+		problem.Forbidden(c, "Only admins and heads of sales can migrate funnel versions")
+		return
+	}
+
+	id, ok := pathID(c)
+	if !ok {
+		problem.NotFound(c, "Funnel version not found")
+		return
+	}
+	target, err := h.store().FunnelVersions().Get(id)
+	if err != nil {
+		problem.NotFound(c, "Funnel version not found")
+		return
+	}
+
+	var input MigrateFunnelVersionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	result, err := h.funnelVersions(h.db).Migrate(target, input.FromVersionID, input.StageMap)
+	if err != nil {
+		problem.Write(c, problemFor(migrationProblem(err)))
+		return
+	}
+
+	c.JSON(http.StatusOK, MigrateFunnelVersionResult{
+		CustomersMigrated:   result.CustomersMigrated,
+		MembershipsMigrated: result.MembershipsMigrated,
+	})
+}
+
+func migrationProblem(err error) error {
+	var fieldErr *service.MigrationError
+	switch {
+	case errors.As(err, &fieldErr):
+		p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Invalid funnel version migration")
+		p.Errors = []problem.FieldError{{Field: fieldErr.Field, Code: fieldErr.Code, Message: fieldErr.Message}}
+		return p
+	case errors.Is(err, service.ErrTargetVersionActive), errors.Is(err, service.ErrSameVersion):
+		return problem.New(http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+	}
+	return err
+}
@@ -0,0 +1,751 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
+)
+
+const graphQLSDL = `
+schema {
+	query: Query
+	mutation: Mutation
+}
+
+scalar Time
+
+enum Role {
+	admin
+	sales
+	head_of_sales
+}
+
+type Query {
+	companies(limit: Int, offset: Int, sort: String, filter: [Filter!]): [Company!]!
+	company(id: ID!): Company
+	users(limit: Int, offset: Int, sort: String, filter: [Filter!]): [User!]!
+	user(id: ID!): User
+	customers(limit: Int, offset: Int, sort: String, filter: [Filter!], pipeline: String): [Customer!]!
+	customer(id: ID!): Customer
+	funnels(limit: Int, offset: Int, sort: String, filter: [Filter!]): [Funnel!]!
+	funnel(id: ID!): Funnel
+}
+
+type Mutation {
+	createCompany(input: CreateCompanyInput!): Company!
+	updateCompany(id: ID!, input: CompanyPatch!): Company!
+	createUser(input: CreateUserInput!): User!
+	updateUser(id: ID!, input: UserPatch!): User!
+	createCustomer(input: CreateCustomerInput!): Customer!
+	updateCustomer(id: ID!, input: CustomerPatch!): Customer!
+	createFunnel(input: CreateFunnelInput!): Funnel!
+	updateFunnel(id: ID!, input: FunnelPatch!): Funnel!
+}
+
+# Same fields and operators as the REST list filters, e.g. {field: "name", op: "contains", value: "acme"}.
+input Filter {
+	field: String!
+	op: String! = "eq"
+	value: String!
+}
+
+type Company {
+	id: ID!
+	name: String!
+	address: String!
+	funnel: Funnel
+	users: [User!]!
+	customers: [Customer!]!
+	createdAt: Time!
+	updatedAt: Time!
+}
+
+type User {
+	id: ID!
+	name: String!
+	email: String!
+	role: Role!
+	company: Company
+	createdAt: Time!
+	updatedAt: Time!
+}
+
+type Customer {
+	id: ID!
+	name: String!
+	email: String!
+	phone: String!
+	leadSource: String!
+	funnelStage: String!
+	boardPosition: Int!
+	company: Company
+	funnel: Funnel
+	tags: [String!]!
+	memberships: [Membership!]!
+	createdAt: Time!
+	updatedAt: Time!
+}
+
+type Membership {
+	pipeline: String!
+	funnelStage: String!
+	funnel: Funnel
+}
+
+type Funnel {
+	id: ID!
+	name: String!
+	wipLimit: Int
+	wipMode: String!
+	nextFunnels: [Funnel!]!
+	previousFunnels: [Funnel!]!
+	createdAt: Time!
+	updatedAt: Time!
+}
+
+input CreateCompanyInput {
+	name: String!
+	address: String
+	funnelId: ID
+}
+
+input CompanyPatch {
+	name: String
+	address: String
+	funnelId: ID
+}
+
+input CreateUserInput {
+	name: String!
+	email: String!
+	password: String!
+	role: Role!
+	companyId: ID
+}
+
+input UserPatch {
+	name: String
+	email: String
+	password: String
+	role: Role
+	companyId: ID
+}
+
+input CreateCustomerInput {
+	name: String!
+	email: String
+	phone: String
+	leadSource: String
+	funnelStage: String
+	companyId: ID!
+	funnelId: ID
+	tags: [String!]
+}
+
+input CustomerPatch {
+	name: String
+	email: String
+	phone: String
+	leadSource: String
+	funnelStage: String
+	companyId: ID
+	funnelId: ID
+}
+
+input CreateFunnelInput {
+	name: String!
+	nextFunnelIds: [ID!]
+	previousFunnelIds: [ID!]
+	wipLimit: Int
+	wipMode: String
+}
+
+input FunnelPatch {
+	name: String
+	wipLimit: Int
+	wipMode: String
+}
+`
+
+const graphQLMaxDepth = 8
+
+func newGraphQLSchema(h *Handler) *graphql.Schema {
+	return graphql.MustParseSchema(graphQLSDL, &graphRoot{h: h}, graphql.MaxDepth(graphQLMaxDepth))
+}
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query" binding:"required"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+func (h *Handler) GraphQL(c *gin.Context) {
+	var input GraphQLRequest
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return
+	}
+
+	ctx := auth.NewContext(c.Request.Context(), &auth.Claims{UserID: currentUserID(c), Role: string(currentRole(c))})
+	ctx = context.WithValue(ctx, graphLoadersKey{}, newGraphLoaders(h.db))
+	c.JSON(http.StatusOK, h.graphQL.Exec(ctx, input.Query, input.OperationName, input.Variables))
+}
+
+type viewer struct {
+	ID   uint
+	Role models.Role
+}
+
+// viewerFrom returns the caller that auth.NewContext attached to ctx.
+func viewerFrom(ctx context.Context) viewer {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return viewer{}
+	}
+	return viewer{ID: claims.UserID, Role: models.Role(claims.Role)}
+}
+
+// graphQLError carries a problem's code and field errors in the GraphQL error
+// extensions, so clients see the same codes as the REST API.
+type graphQLError struct {
+	p *problem.Problem
+}
+
+func (e *graphQLError) Error() string {
+	return e.p.Detail
+}
+
+func (e *graphQLError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.p.Code, "status": e.p.Status}
+	for key, value := range e.p.Extensions {
+		extensions[key] = value
+	}
+	if len(e.p.Errors) > 0 {
+		extensions["errors"] = e.p.Errors
+	}
+	return extensions
+}
+
+func graphError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &graphQLError{p: problemFor(err)}
+}
+
+func parseGraphID(id graphql.ID) (uint, error) {
+	n, err := strconv.ParseUint(string(id), 10, 64)
+	if err != nil || n == 0 {
+		return 0, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Invalid ID "+strconv.Quote(string(id)))
+	}
+	return uint(n), nil
+}
+
+func validateInput(input interface{}) error {
+	if err := binding.Validator.ValidateStruct(input); err != nil {
+		return problem.FromBindError(err)
+	}
+	return nil
+}
+
+type listFilter struct {
+	Field string
+	Op    string
+	Value string
+}
+
+type listArgs struct {
+	Limit  *int32
+	Offset *int32
+	Sort   *string
+	Filter *[]listFilter
+}
+
+func (a listArgs) params(spec query.Spec) (query.Params, error) {
+	values := url.Values{}
+	if a.Limit != nil {
+		values.Set("limit", strconv.Itoa(int(*a.Limit)))
+	}
+	if a.Offset != nil {
+		values.Set("offset", strconv.Itoa(int(*a.Offset)))
+	}
+	if a.Sort != nil {
+		values.Set("sort", *a.Sort)
+	}
+	if a.Filter != nil {
+		for _, f := range *a.Filter {
+			values.Add(f.Field+"["+f.Op+"]", f.Value)
+		}
+	}
+
+	params, err := query.Parse(values, spec)
+	if err != nil {
+		return params, problem.New(http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+	}
+	return params, nil
+}
+
+func findList[T any](ctx context.Context, tx *gorm.DB, args listArgs, spec query.Spec) ([]T, query.Meta, error) {
+	params, err := args.params(spec)
+	if err != nil {
+		return nil, query.Meta{}, err
+	}
+	var rows []T
+	meta, err := query.Find(tx.WithContext(ctx), params, &rows)
+	if err != nil {
+		return nil, meta, err
+	}
+	return rows, meta, nil
+}
+
+// nullRole is graphql.NullString for the Role enum.
+type nullRole graphql.NullString
+
+func (nullRole) ImplementsGraphQLType(name string) bool {
+	return name == "Role"
+}
+
+func (r *nullRole) UnmarshalGraphQL(input any) error {
+	r.Set = true
+	if input == nil {
+		return nil
+	}
+	v, ok := input.(string)
+	if !ok {
+		return fmt.Errorf("wrong type for Role: %T", input)
+	}
+	r.Value = &v
+	return nil
+}
+
+func (r *nullRole) Nullable() {}
+
+// string, int and id copy optional GraphQL input fields into a patch document,
+// keeping explicit nulls and skipping fields the client left out.
+func (p patchDoc) string(key string, v graphql.NullString) {
+	if v.Set {
+		if v.Value == nil {
+			p[key] = nil
+		} else {
+			p[key] = *v.Value
+		}
+	}
+}
+
+func (p patchDoc) int(key string, v graphql.NullInt) {
+	if v.Set {
+		if v.Value == nil {
+			p[key] = nil
+		} else {
+			p[key] = json.Number(strconv.Itoa(int(*v.Value)))
+		}
+	}
+}
+
+func (p patchDoc) id(key string, v graphql.NullID) {
+	if v.Set {
+		if v.Value == nil {
+			p[key] = nil
+		} else {
+			p[key] = json.Number(*v.Value)
+		}
+	}
+}
+
+func (h *Handler) findGraphRecord(ctx context.Context, dest interface{}, id graphql.ID, name string) error {
+	n, err := parseGraphID(id)
+	if err != nil {
+		return err
+	}
+	if err := h.db.WithContext(ctx).First(dest, n).Error; err != nil {
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, name+" not found")
+	}
+	return nil
+}
+
+// Single-record queries resolve to null for missing records, as is usual in
+// GraphQL, but still report malformed IDs.
+func nullIfNotFound(err error) error {
+	var p *problem.Problem
+	if errors.As(err, &p) && p.Status == http.StatusNotFound {
+		return nil
+	}
+	return graphError(err)
+}
+
+type graphRoot struct {
+	h *Handler
+}
+
+func (r *graphRoot) Companies(ctx context.Context, args listArgs) ([]*graphCompany, error) {
+	rows, _, err := findList[models.Company](ctx, r.h.db, args, companyQuerySpec)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).companies(rows), nil
+}
+
+func (r *graphRoot) Company(ctx context.Context, args struct{ ID graphql.ID }) (*graphCompany, error) {
+	var company models.Company
+	if err := r.h.findGraphRecord(ctx, &company, args.ID, "Company"); err != nil {
+		return nil, nullIfNotFound(err)
+	}
+	return loadersFrom(ctx).companies([]models.Company{company})[0], nil
+}
+
+func (r *graphRoot) Users(ctx context.Context, args listArgs) ([]*graphUser, error) {
+	rows, _, err := findList[models.User](ctx, r.h.db, args, userQuerySpec)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).users(rows), nil
+}
+
+func (r *graphRoot) User(ctx context.Context, args struct{ ID graphql.ID }) (*graphUser, error) {
+	var user models.User
+	if err := r.h.findGraphRecord(ctx, &user, args.ID, "User"); err != nil {
+		return nil, nullIfNotFound(err)
+	}
+	return loadersFrom(ctx).users([]models.User{user})[0], nil
+}
+
+func (r *graphRoot) Customers(ctx context.Context, args struct {
+	listArgs
+	Pipeline *string
+}) ([]*graphCustomer, error) {
+	tx := r.h.db
+	if args.Pipeline != nil && *args.Pipeline != "" {
+		tx = tx.Where("id IN (?)", r.h.db.Model(&models.CustomerFunnel{}).Select("customer_id").Where("pipeline = ?", *args.Pipeline))
+	}
+	rows, _, err := findList[models.Customer](ctx, tx, args.listArgs, customerQuerySpec)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).customers(rows), nil
+}
+
+func (r *graphRoot) Customer(ctx context.Context, args struct{ ID graphql.ID }) (*graphCustomer, error) {
+	var customer models.Customer
+	if err := r.h.findGraphRecord(ctx, &customer, args.ID, "Customer"); err != nil {
+		return nil, nullIfNotFound(err)
+	}
+	return loadersFrom(ctx).customers([]models.Customer{customer})[0], nil
+}
+
+func (r *graphRoot) Funnels(ctx context.Context, args listArgs) ([]*graphFunnel, error) {
+	rows, _, err := findList[models.Funnel](ctx, r.h.db, args, funnelQuerySpec)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).funnels(rows), nil
+}
+
+func (r *graphRoot) Funnel(ctx context.Context, args struct{ ID graphql.ID }) (*graphFunnel, error) {
+	var funnel models.Funnel
+	if err := r.h.findGraphRecord(ctx, &funnel, args.ID, "Funnel"); err != nil {
+		return nil, nullIfNotFound(err)
+	}
+	return loadersFrom(ctx).funnels([]models.Funnel{funnel})[0], nil
+}
+
+func optionalGraphID(id *graphql.ID) (*uint, error) {
+	if id == nil {
+		return nil, nil
+	}
+	n, err := parseGraphID(*id)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func (r *graphRoot) CreateCompany(ctx context.Context, args struct {
+	Input struct {
+		Name     string
+		Address  *string
+		FunnelID *graphql.ID
+	}
+}) (*graphCompany, error) {
+	funnelID, err := optionalGraphID(args.Input.FunnelID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	company := models.Company{Name: args.Input.Name, Address: stringValue(args.Input.Address), FunnelID: funnelID}
+	if err := validateInput(&company); err != nil {
+		return nil, graphError(err)
+	}
+	if _, err := companyUpdates(r.h.db, models.CompanyInput{Name: company.Name, Address: company.Address, FunnelID: funnelID}, viewerFrom(ctx).Role); err != nil {
+		return nil, graphError(err)
+	}
+	if err := r.h.db.WithContext(ctx).Create(&company).Error; err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).companies([]models.Company{company})[0], nil
+}
+
+func (r *graphRoot) UpdateCompany(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Name     graphql.NullString
+		Address  graphql.NullString
+		FunnelID graphql.NullID
+	}
+}) (*graphCompany, error) {
+	var company models.Company
+	if err := r.h.findGraphRecord(ctx, &company, args.ID, "Company"); err != nil {
+		return nil, graphError(err)
+	}
+
+	doc := patchDoc{}
+	doc.string("name", args.Input.Name)
+	doc.string("address", args.Input.Address)
+	doc.id("funnel_id", args.Input.FunnelID)
+	err := r.h.applyPatchDoc(ctx, &company, nil, doc, service.CompanyFields, func(updates map[string]interface{}) error {
+		return prepareCompanyPatch(r.h.db, updates)
+	})
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	r.h.db.WithContext(ctx).First(&company, company.ID)
+	return loadersFrom(ctx).companies([]models.Company{company})[0], nil
+}
+
+func (r *graphRoot) CreateUser(ctx context.Context, args struct {
+	Input struct {
+		Name      string
+		Email     string
+		Password  string
+		Role      string
+		CompanyID *graphql.ID
+	}
+}) (*graphUser, error) {
+	companyID, err := optionalGraphID(args.Input.CompanyID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	input := CreateUserInput{
+		Name:      args.Input.Name,
+		Email:     args.Input.Email,
+		Password:  args.Input.Password,
+		Role:      models.Role(args.Input.Role),
+		CompanyID: companyID,
+	}
+	if err := validateInput(&input); err != nil {
+		return nil, graphError(err)
+	}
+
+	user, err := createUser(r.h.db.WithContext(ctx), input)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).users([]models.User{*user})[0], nil
+}
+
+func (r *graphRoot) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Name      graphql.NullString
+		Email     graphql.NullString
+		Password  graphql.NullString
+		Role      nullRole
+		CompanyID graphql.NullID
+	}
+}) (*graphUser, error) {
+	var user models.User
+	if err := r.h.findGraphRecord(ctx, &user, args.ID, "User"); err != nil {
+		return nil, graphError(err)
+	}
+	viewer := viewerFrom(ctx)
+	if viewer.Role != models.RoleAdmin && viewer.ID != user.ID {
+		return nil, graphError(problem.New(http.StatusForbidden, problem.CodeForbidden, "You can only modify your own account"))
+	}
+
+	doc := patchDoc{}
+	doc.string("name", args.Input.Name)
+	doc.string("email", args.Input.Email)
+	doc.string("password", args.Input.Password)
+	doc.string("role", graphql.NullString(args.Input.Role))
+	doc.id("company_id", args.Input.CompanyID)
+	if err := r.h.applyPatchDoc(ctx, &user, nil, doc, service.UserFields, service.PrepareUserUpdates); err != nil {
+		return nil, graphError(userSaveError(err))
+	}
+
+	r.h.db.WithContext(ctx).First(&user, user.ID)
+	return loadersFrom(ctx).users([]models.User{user})[0], nil
+}
+
+func (r *graphRoot) CreateCustomer(ctx context.Context, args struct {
+	Input struct {
+		Name        string
+		Email       *string
+		Phone       *string
+		LeadSource  *string
+		FunnelStage *string
+		CompanyID   graphql.ID
+		FunnelID    *graphql.ID
+		Tags        *[]string
+	}
+}) (*graphCustomer, error) {
+	companyID, err := parseGraphID(args.Input.CompanyID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	funnelID, err := optionalGraphID(args.Input.FunnelID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	customer := models.Customer{
+		Name:        args.Input.Name,
+		Email:       stringValue(args.Input.Email),
+		Phone:       stringValue(args.Input.Phone),
+		LeadSource:  stringValue(args.Input.LeadSource),
+		FunnelStage: stringValue(args.Input.FunnelStage),
+		CompanyID:   companyID,
+		FunnelID:    funnelID,
+	}
+	if args.Input.Tags != nil {
+		for _, name := range *args.Input.Tags {
+			customer.Tags = append(customer.Tags, models.Tag{Name: name})
+		}
+	}
+	if err := validateInput(&customer); err != nil {
+		return nil, graphError(err)
+	}
+
+	err = r.h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.h.customers(tx).Create(&customer)
+	})
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).customers([]models.Customer{customer})[0], nil
+}
+
+func (r *graphRoot) UpdateCustomer(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Name        graphql.NullString
+		Email       graphql.NullString
+		Phone       graphql.NullString
+		LeadSource  graphql.NullString
+		FunnelStage graphql.NullString
+		CompanyID   graphql.NullID
+		FunnelID    graphql.NullID
+	}
+}) (*graphCustomer, error) {
+	var customer models.Customer
+	if err := r.h.findGraphRecord(ctx, &customer, args.ID, "Customer"); err != nil {
+		return nil, graphError(err)
+	}
+
+	doc := patchDoc{}
+	doc.string("name", args.Input.Name)
+	doc.string("email", args.Input.Email)
+	doc.string("phone", args.Input.Phone)
+	doc.string("lead_source", args.Input.LeadSource)
+	doc.string("funnel_stage", args.Input.FunnelStage)
+	doc.id("company_id", args.Input.CompanyID)
+	doc.id("funnel_id", args.Input.FunnelID)
+	err := r.h.applyPatchDoc(ctx, &customer, nil, doc, service.CustomerFields, func(updates map[string]interface{}) error {
+		return r.h.prepareCustomerPatch(r.h.db, &customer, updates)
+	})
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	r.h.db.WithContext(ctx).First(&customer, customer.ID)
+	return loadersFrom(ctx).customers([]models.Customer{customer})[0], nil
+}
+
+func (r *graphRoot) CreateFunnel(ctx context.Context, args struct {
+	Input struct {
+		Name              string
+		NextFunnelIDs     *[]graphql.ID
+		PreviousFunnelIDs *[]graphql.ID
+		WIPLimit          *int32
+		WIPMode           *string
+	}
+}) (*graphFunnel, error) {
+	input := CreateFunnelInput{Name: args.Input.Name, WIPMode: stringValue(args.Input.WIPMode)}
+	if args.Input.WIPLimit != nil {
+		limit := int(*args.Input.WIPLimit)
+		input.WIPLimit = &limit
+	}
+	for _, ids := range []struct {
+		from *[]graphql.ID
+		to   *[]uint
+	}{{args.Input.NextFunnelIDs, &input.NextFunnelIDs}, {args.Input.PreviousFunnelIDs, &input.PreviousFunnelIDs}} {
+		if ids.from == nil {
+			continue
+		}
+		for _, id := range *ids.from {
+			n, err := parseGraphID(id)
+			if err != nil {
+				return nil, graphError(err)
+			}
+			*ids.to = append(*ids.to, n)
+		}
+	}
+	if err := validateInput(&input); err != nil {
+		return nil, graphError(err)
+	}
+
+	var funnel *models.Funnel
+	err := r.h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		funnel, err = r.h.createFunnel(tx, input)
+		return err
+	})
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return loadersFrom(ctx).funnels([]models.Funnel{*funnel})[0], nil
+}
+
+func (r *graphRoot) UpdateFunnel(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input struct {
+		Name     graphql.NullString
+		WIPLimit graphql.NullInt
+		WIPMode  graphql.NullString
+	}
+}) (*graphFunnel, error) {
+	var funnel models.Funnel
+	if err := r.h.findGraphRecord(ctx, &funnel, args.ID, "Funnel"); err != nil {
+		return nil, graphError(err)
+	}
+
+	doc := patchDoc{}
+	doc.string("name", args.Input.Name)
+	doc.int("wip_limit", args.Input.WIPLimit)
+	doc.string("wip_mode", args.Input.WIPMode)
+	if err := r.h.applyPatchDoc(ctx, &funnel, nil, doc, service.FunnelFields, nil); err != nil {
+		return nil, graphError(err)
+	}
+
+	r.h.db.WithContext(ctx).First(&funnel, funnel.ID)
+	return loadersFrom(ctx).funnels([]models.Funnel{funnel})[0], nil
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mokan/flame-crm-backend/internal/loader"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type graphLoadersKey struct{}
+
+// graphLoaders is created per request. Every batch of rows primes the loaders
+// for its children as soon as it is fetched, so every relation costs one query
+// per level of the document rather than one per row.
+type graphLoaders struct {
+	companyByID           *loader.Loader[uint, *models.Company]
+	funnelByID            *loader.Loader[uint, *models.Funnel]
+	usersByCompany        *loader.Loader[uint, []models.User]
+	customersByCompany    *loader.Loader[uint, []models.Customer]
+	tagsByCustomer        *loader.Loader[uint, []models.Tag]
+	membershipsByCustomer *loader.Loader[uint, []models.CustomerFunnel]
+	nextFunnels           *loader.Loader[uint, []models.Funnel]
+	previousFunnels       *loader.Loader[uint, []models.Funnel]
+}
+
+func newGraphLoaders(database *gorm.DB) *graphLoaders {
+	l := &graphLoaders{}
+	l.companyByID = loader.New(primed(loadByID(database, func(c *models.Company) uint { return c.ID }), func(c *models.Company) { l.primeCompany(*c) }))
+	l.funnelByID = loader.New(primed(loadByID(database, func(f *models.Funnel) uint { return f.ID }), func(f *models.Funnel) { l.primeFunnel(*f) }))
+	l.usersByCompany = loader.New(primed(loadGrouped(database, "company_id", func(u models.User) uint { return derefUint(u.CompanyID) }), each(l.primeUser)))
+	l.customersByCompany = loader.New(primed(loadGrouped(database, "company_id", func(c models.Customer) uint { return c.CompanyID }), each(l.primeCustomer)))
+	l.membershipsByCustomer = loader.New(primed(loadGrouped(database, "customer_id", func(m models.CustomerFunnel) uint { return m.CustomerID }), each(func(m models.CustomerFunnel) { l.funnelByID.Prime(m.FunnelID) })))
+	l.tagsByCustomer = loader.New(loadTags(database))
+	l.nextFunnels = loader.New(primed(loadTransitions(database, "from_funnel_id", "to_funnel_id"), each(l.primeFunnel)))
+	l.previousFunnels = loader.New(primed(loadTransitions(database, "to_funnel_id", "from_funnel_id"), each(l.primeFunnel)))
+	return l
+}
+
+// primed calls prime for every value of a fetched batch, before any resolver
+// sees it, so siblings resolved concurrently still share their child batches.
+func primed[V any](fetch loader.Fetch[uint, V], prime func(V)) loader.Fetch[uint, V] {
+	return func(ctx context.Context, keys []uint) (map[uint]V, error) {
+		values, err := fetch(ctx, keys)
+		for _, value := range values {
+			prime(value)
+		}
+		return values, err
+	}
+}
+
+func each[T any](prime func(T)) func([]T) {
+	return func(rows []T) {
+		for _, row := range rows {
+			prime(row)
+		}
+	}
+}
+
+func loadersFrom(ctx context.Context) *graphLoaders {
+	return ctx.Value(graphLoadersKey{}).(*graphLoaders)
+}
+
+func loadByID[T any](database *gorm.DB, key func(*T) uint) loader.Fetch[uint, *T] {
+	return func(ctx context.Context, ids []uint) (map[uint]*T, error) {
+		var rows []T
+		if err := database.WithContext(ctx).Where("id IN ?", ids).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		out := make(map[uint]*T, len(rows))
+		for i := range rows {
+			out[key(&rows[i])] = &rows[i]
+		}
+		return out, nil
+	}
+}
+
+func loadGrouped[T any](database *gorm.DB, column string, key func(T) uint) loader.Fetch[uint, []T] {
+	return func(ctx context.Context, ids []uint) (map[uint][]T, error) {
+		var rows []T
+		if err := database.WithContext(ctx).Where(column+" IN ?", ids).Order("id").Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		out := make(map[uint][]T, len(ids))
+		for _, row := range rows {
+			out[key(row)] = append(out[key(row)], row)
+		}
+		return out, nil
+	}
+}
+
+func loadTags(database *gorm.DB) loader.Fetch[uint, []models.Tag] {
+	return func(ctx context.Context, ids []uint) (map[uint][]models.Tag, error) {
+		var rows []struct {
+			models.Tag
+			CustomerID uint
+		}
+		err := database.WithContext(ctx).Model(&models.Tag{}).
+			Select("tags.*, customer_tags.customer_id AS customer_id").
+			Joins("JOIN customer_tags ON customer_tags.tag_id = tags.id").
+			Where("customer_tags.customer_id IN ?", ids).
+			Order("tags.name").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[uint][]models.Tag, len(ids))
+		for _, row := range rows {
+			out[row.CustomerID] = append(out[row.CustomerID], row.Tag)
+		}
+		return out, nil
+	}
+}
+
+func loadTransitions(database *gorm.DB, from, to string) loader.Fetch[uint, []models.Funnel] {
+	return func(ctx context.Context, ids []uint) (map[uint][]models.Funnel, error) {
+		var rows []struct {
+			models.Funnel
+			EdgeKey uint
+		}
+		err := database.WithContext(ctx).Model(&models.Funnel{}).
+			Select("funnels.*, funnel_transitions."+from+" AS edge_key").
+			Joins("JOIN funnel_transitions ON funnel_transitions."+to+" = funnels.id").
+			Where("funnel_transitions."+from+" IN ?", ids).
+			Where("funnels.deleted_at IS NULL").
+			Order("funnels.id").
+			Scan(&rows).Error
+		if err != nil {
+			return nil, err
+		}
+		out := make(map[uint][]models.Funnel, len(ids))
+		for _, row := range rows {
+			out[row.EdgeKey] = append(out[row.EdgeKey], row.Funnel)
+		}
+		return out, nil
+	}
+}
+
+func derefUint(v *uint) uint {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+func graphID(id uint) graphql.ID {
+	return graphql.ID(strconv.FormatUint(uint64(id), 10))
+}
+
+func (l *graphLoaders) primeCompany(c models.Company) {
+	l.usersByCompany.Prime(c.ID)
+	l.customersByCompany.Prime(c.ID)
+	if c.FunnelID != nil {
+		l.funnelByID.Prime(*c.FunnelID)
+	}
+}
+
+func (l *graphLoaders) primeUser(u models.User) {
+	if u.CompanyID != nil {
+		l.companyByID.Prime(*u.CompanyID)
+	}
+}
+
+func (l *graphLoaders) primeCustomer(c models.Customer) {
+	l.companyByID.Prime(c.CompanyID)
+	l.tagsByCustomer.Prime(c.ID)
+	l.membershipsByCustomer.Prime(c.ID)
+	if c.FunnelID != nil {
+		l.funnelByID.Prime(*c.FunnelID)
+	}
+}
+
+func (l *graphLoaders) primeFunnel(f models.Funnel) {
+	l.nextFunnels.Prime(f.ID)
+	l.previousFunnels.Prime(f.ID)
+}
+
+// companies, users, customers and funnels wrap rows for the resolvers. Rows
+// fetched outside a loader, such as top-level lists, are primed here.
+func (l *graphLoaders) companies(rows []models.Company) []*graphCompany {
+	out := make([]*graphCompany, len(rows))
+	for i := range rows {
+		l.primeCompany(rows[i])
+		out[i] = &graphCompany{m: rows[i], l: l}
+	}
+	return out
+}
+
+func (l *graphLoaders) users(rows []models.User) []*graphUser {
+	out := make([]*graphUser, len(rows))
+	for i := range rows {
+		l.primeUser(rows[i])
+		out[i] = &graphUser{m: rows[i], l: l}
+	}
+	return out
+}
+
+func (l *graphLoaders) customers(rows []models.Customer) []*graphCustomer {
+	out := make([]*graphCustomer, len(rows))
+	for i := range rows {
+		l.primeCustomer(rows[i])
+		out[i] = &graphCustomer{m: rows[i], l: l}
+	}
+	return out
+}
+
+func (l *graphLoaders) funnels(rows []models.Funnel) []*graphFunnel {
+	out := make([]*graphFunnel, len(rows))
+	for i := range rows {
+		l.primeFunnel(rows[i])
+		out[i] = &graphFunnel{m: rows[i], l: l}
+	}
+	return out
+}
+
+func (l *graphLoaders) company(ctx context.Context, id uint) (*graphCompany, error) {
+	company, err := l.companyByID.Load(ctx, id)
+	if err != nil || company == nil {
+		return nil, graphError(err)
+	}
+	return l.companies([]models.Company{*company})[0], nil
+}
+
+func (l *graphLoaders) funnel(ctx context.Context, id *uint) (*graphFunnel, error) {
+	if id == nil {
+		return nil, nil
+	}
+	funnel, err := l.funnelByID.Load(ctx, *id)
+	if err != nil || funnel == nil {
+		return nil, graphError(err)
+	}
+	return l.funnels([]models.Funnel{*funnel})[0], nil
+}
+
+type graphCompany struct {
+	m models.Company
+	l *graphLoaders
+}
+
+func (r *graphCompany) ID() graphql.ID          { return graphID(r.m.ID) }
+func (r *graphCompany) Name() string            { return r.m.Name }
+func (r *graphCompany) Address() string         { return r.m.Address }
+func (r *graphCompany) CreatedAt() graphql.Time { return graphql.Time{Time: r.m.CreatedAt} }
+func (r *graphCompany) UpdatedAt() graphql.Time { return graphql.Time{Time: r.m.UpdatedAt} }
+
+func (r *graphCompany) Funnel(ctx context.Context) (*graphFunnel, error) {
+	return r.l.funnel(ctx, r.m.FunnelID)
+}
+
+func (r *graphCompany) Users(ctx context.Context) ([]*graphUser, error) {
+	users, err := r.l.usersByCompany.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return r.l.users(users), nil
+}
+
+func (r *graphCompany) Customers(ctx context.Context) ([]*graphCustomer, error) {
+	customers, err := r.l.customersByCompany.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return r.l.customers(customers), nil
+}
+
+type graphUser struct {
+	m models.User
+	l *graphLoaders
+}
+
+func (r *graphUser) ID() graphql.ID          { return graphID(r.m.ID) }
+func (r *graphUser) Name() string            { return r.m.Name }
+func (r *graphUser) Email() string           { return r.m.Email }
+func (r *graphUser) Role() string            { return string(r.m.Role) }
+func (r *graphUser) CreatedAt() graphql.Time { return graphql.Time{Time: r.m.CreatedAt} }
+func (r *graphUser) UpdatedAt() graphql.Time { return graphql.Time{Time: r.m.UpdatedAt} }
+
+func (r *graphUser) Company(ctx context.Context) (*graphCompany, error) {
+	if r.m.CompanyID == nil {
+		return nil, nil
+	}
+	return r.l.company(ctx, *r.m.CompanyID)
+}
+
+type graphCustomer struct {
+	m models.Customer
+	l *graphLoaders
+}
+
+func (r *graphCustomer) ID() graphql.ID          { return graphID(r.m.ID) }
+func (r *graphCustomer) Name() string            { return r.m.Name }
+func (r *graphCustomer) Email() string           { return r.m.Email }
+func (r *graphCustomer) Phone() string           { return r.m.Phone }
+func (r *graphCustomer) LeadSource() string      { return r.m.LeadSource }
+func (r *graphCustomer) FunnelStage() string     { return r.m.FunnelStage }
+func (r *graphCustomer) BoardPosition() int32    { return int32(r.m.BoardPosition) }
+func (r *graphCustomer) CreatedAt() graphql.Time { return graphql.Time{Time: r.m.CreatedAt} }
+func (r *graphCustomer) UpdatedAt() graphql.Time { return graphql.Time{Time: r.m.UpdatedAt} }
+
+func (r *graphCustomer) Company(ctx context.Context) (*graphCompany, error) {
+	if r.m.CompanyID == 0 {
+		return nil, nil
+	}
+	return r.l.company(ctx, r.m.CompanyID)
+}
+
+func (r *graphCustomer) Funnel(ctx context.Context) (*graphFunnel, error) {
+	return r.l.funnel(ctx, r.m.FunnelID)
+}
+
+func (r *graphCustomer) Tags(ctx context.Context) ([]string, error) {
+	tags, err := r.l.tagsByCustomer.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	names := make([]string, len(tags))
+	for i, tag := range tags {
+		names[i] = tag.Name
+	}
+	return names, nil
+}
+
+func (r *graphCustomer) Memberships(ctx context.Context) ([]*graphMembership, error) {
+	memberships, err := r.l.membershipsByCustomer.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	out := make([]*graphMembership, len(memberships))
+	for i := range memberships {
+		out[i] = &graphMembership{m: memberships[i], l: r.l}
+	}
+	return out, nil
+}
+
+type graphMembership struct {
+	m models.CustomerFunnel
+	l *graphLoaders
+}
+
+func (r *graphMembership) Pipeline() string    { return r.m.Pipeline }
+func (r *graphMembership) FunnelStage() string { return r.m.FunnelStage }
+
+func (r *graphMembership) Funnel(ctx context.Context) (*graphFunnel, error) {
+	return r.l.funnel(ctx, &r.m.FunnelID)
+}
+
+type graphFunnel struct {
+	m models.Funnel
+	l *graphLoaders
+}
+
+func (r *graphFunnel) ID() graphql.ID          { return graphID(r.m.ID) }
+func (r *graphFunnel) Name() string            { return r.m.Name }
+func (r *graphFunnel) WIPMode() string         { return r.m.WIPMode }
+func (r *graphFunnel) CreatedAt() graphql.Time { return graphql.Time{Time: r.m.CreatedAt} }
+func (r *graphFunnel) UpdatedAt() graphql.Time { return graphql.Time{Time: r.m.UpdatedAt} }
+
+func (r *graphFunnel) WIPLimit() *int32 {
+	if r.m.WIPLimit == nil {
+		return nil
+	}
+	limit := int32(*r.m.WIPLimit)
+	return &limit
+}
+
+func (r *graphFunnel) NextFunnels(ctx context.Context) ([]*graphFunnel, error) {
+	funnels, err := r.l.nextFunnels.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return r.l.funnels(funnels), nil
+}
+
+func (r *graphFunnel) PreviousFunnels(ctx context.Context) ([]*graphFunnel, error) {
+	funnels, err := r.l.previousFunnels.Load(ctx, r.m.ID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	return r.l.funnels(funnels), nil
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type graphQLTestResponse struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func setupGraphQLRouter(userID uint, role models.Role) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", string(role))
+		c.Next()
+	})
+	r.POST("/graphql", testHandler.GraphQL)
+	return r
+}
+
+func execGraphQL(t *testing.T, r http.Handler, query string, variables map[string]interface{}) graphQLTestResponse {
+	w := performRequest(r, "POST", "/graphql", GraphQLRequest{Query: query, Variables: variables})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp graphQLTestResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	return resp
+}
+
+var (
+	queryCount     atomic.Int64
+	countQueryOnce sync.Once
+)
+
+func countQueries(t *testing.T) {
+	countQueryOnce.Do(func() {
+		count := func(*gorm.DB) { queryCount.Add(1) }
+		require.NoError(t, testDB.Callback().Query().Before("gorm:query").Register("test:count_query", count))
+		require.NoError(t, testDB.Callback().Row().Before("gorm:row").Register("test:count_row", count))
+	})
+	queryCount.Store(0)
+}
+
+func TestGraphQLNestedQuery(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won"}
+	assert.NoError(t, testDB.Create(&won).Error)
+	lead.NextFunnels = []*models.Funnel{&won}
+	assert.NoError(t, testDB.Create(&lead).Error)
+	assert.NoError(t, testDB.Model(&company).Update("funnel_id", lead.ID).Error)
+
+	customer := models.Customer{Name: "Alice", CompanyID: company.ID, FunnelID: &lead.ID}
+	assert.NoError(t, testHandler.customers(testDB).Create(&customer))
+	assert.NoError(t, testDB.Model(&customer).Association("Tags").Append(&models.Tag{Name: "vip"}))
+
+	r := setupGraphQLRouter(user.ID, models.RoleAdmin)
+	resp := execGraphQL(t, r, `{
+		company(id: "`+fmt.Sprint(company.ID)+`") {
+			name
+			funnel { name nextFunnels { name previousFunnels { name } } }
+			users { email role }
+			customers { name tags funnel { name } company { name } }
+		}
+	}`, nil)
+	require.Empty(t, resp.Errors)
+
+	var got struct {
+		Name   string
+		Funnel struct {
+			Name        string
+			NextFunnels []struct {
+				Name            string
+				PreviousFunnels []struct{ Name string }
+			}
+		}
+		Users     []struct{ Email, Role string }
+		Customers []struct {
+			Name    string
+			Tags    []string
+			Funnel  struct{ Name string }
+			Company struct{ Name string }
+		}
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["company"], &got))
+	assert.Equal(t, "Test Company", got.Name)
+	assert.Equal(t, "Lead", got.Funnel.Name)
+	require.Len(t, got.Funnel.NextFunnels, 1)
+	assert.Equal(t, "Won", got.Funnel.NextFunnels[0].Name)
+	assert.Equal(t, "Lead", got.Funnel.NextFunnels[0].PreviousFunnels[0].Name)
+	require.Len(t, got.Users, 1)
+	assert.Equal(t, "admin", got.Users[0].Role)
+	require.Len(t, got.Customers, 1)
+	assert.Equal(t, []string{"vip"}, got.Customers[0].Tags)
+	assert.Equal(t, "Lead", got.Customers[0].Funnel.Name)
+	assert.Equal(t, "Test Company", got.Customers[0].Company.Name)
+
+	resp = execGraphQL(t, r, `{ customer(id: "999999") { name } }`, nil)
+	assert.Empty(t, resp.Errors)
+	assert.JSONEq(t, "null", string(resp.Data["customer"]))
+}
+
+func TestGraphQLBatchesRelations(t *testing.T) {
+	_, user := createTestCompanyAndUser(t)
+	r := setupGraphQLRouter(user.ID, models.RoleAdmin)
+
+	const q = `{
+		companies {
+			funnel { name nextFunnels { name } }
+			users { email }
+			customers { tags memberships { funnel { name } } funnel { previousFunnels { name } } }
+		}
+	}`
+
+	addCompany := func(i int) {
+		funnel := models.Funnel{Name: fmt.Sprintf("Funnel %d", i)}
+		assert.NoError(t, testDB.Create(&funnel).Error)
+		company := models.Company{Name: fmt.Sprintf("Company %d", i), FunnelID: &funnel.ID}
+		assert.NoError(t, testDB.Create(&company).Error)
+		for j := 0; j < 3; j++ {
+			customer := models.Customer{Name: fmt.Sprintf("Customer %d-%d", i, j), CompanyID: company.ID, FunnelID: &funnel.ID, Tags: []models.Tag{{Name: fmt.Sprintf("tag-%d-%d", i, j)}}}
+			assert.NoError(t, testHandler.customers(testDB).Create(&customer))
+		}
+	}
+
+	addCompany(0)
+	countQueries(t)
+	resp := execGraphQL(t, r, q, nil)
+	require.Empty(t, resp.Errors)
+	few := queryCount.Load()
+
+	for i := 1; i < 6; i++ {
+		addCompany(i)
+	}
+	countQueries(t)
+	resp = execGraphQL(t, r, q, nil)
+	require.Empty(t, resp.Errors)
+	many := queryCount.Load()
+
+	var companies []json.RawMessage
+	require.NoError(t, json.Unmarshal(resp.Data["companies"], &companies))
+	assert.Len(t, companies, 7)
+	assert.Equal(t, few, many, "query count should not grow with the number of rows")
+}
+
+func TestGraphQLMutations(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+	r := setupGraphQLRouter(user.ID, models.RoleAdmin)
+
+	resp := execGraphQL(t, r, `mutation($input: CreateCustomerInput!) {
+		createCustomer(input: $input) { id name email tags funnel { name } }
+	}`, map[string]interface{}{"input": map[string]interface{}{
+		"name":      "Bob",
+		"email":     "bob@example.com",
+		"companyId": fmt.Sprint(company.ID),
+		"funnelId":  fmt.Sprint(funnel.ID),
+		"tags":      []string{"new"},
+	}})
+	require.Empty(t, resp.Errors)
+	var created struct {
+		ID     string
+		Name   string
+		Tags   []string
+		Funnel *struct{ Name string }
+	}
+	require.NoError(t, json.Unmarshal(resp.Data["createCustomer"], &created))
+	assert.Equal(t, "Bob", created.Name)
+	assert.Equal(t, []string{"new"}, created.Tags)
+	require.NotNil(t, created.Funnel)
+
+	resp = execGraphQL(t, r, `mutation($id: ID!) {
+		updateCustomer(id: $id, input: {funnelId: null, phone: "555"}) { phone funnel { name } }
+	}`, map[string]interface{}{"id": created.ID})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"phone": "555", "funnel": null}`, string(resp.Data["updateCustomer"]))
+
+	var customer models.Customer
+	assert.NoError(t, testDB.First(&customer, created.ID).Error)
+	assert.Nil(t, customer.FunnelID)
+	assert.Equal(t, "Bob", customer.Name)
+
+	resp = execGraphQL(t, r, `mutation {
+		createCustomer(input: {name: "", companyId: "`+fmt.Sprint(company.ID)+`"}) { id }
+	}`, nil)
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "validation_failed", resp.Errors[0].Extensions["code"])
+	assert.EqualValues(t, http.StatusBadRequest, resp.Errors[0].Extensions["status"])
+	fieldErrors, _ := resp.Errors[0].Extensions["errors"].([]interface{})
+	require.Len(t, fieldErrors, 1)
+	assert.Equal(t, "name", fieldErrors[0].(map[string]interface{})["field"])
+
+	resp = execGraphQL(t, r, `mutation($id: ID!) { updateCustomer(id: $id, input: {email: "not-an-email"}) { id } }`, map[string]interface{}{"id": created.ID})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "validation_failed", resp.Errors[0].Extensions["code"])
+}
+
+func TestGraphQLMutationRoles(t *testing.T) {
+	company, admin := createTestCompanyAndUser(t)
+	sales := models.User{Name: "Sales", Email: "sales@example.com", Password: "password123", CompanyID: &company.ID, Role: models.RoleSales}
+	assert.NoError(t, testDB.Create(&sales).Error)
+	r := setupGraphQLRouter(sales.ID, models.RoleSales)
+
+	resp := execGraphQL(t, r, `mutation($id: ID!) { updateUser(id: $id, input: {role: admin}) { role } }`, map[string]interface{}{"id": fmt.Sprint(sales.ID)})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "forbidden", resp.Errors[0].Extensions["code"])
+	assert.EqualValues(t, http.StatusForbidden, resp.Errors[0].Extensions["status"])
+
+	resp = execGraphQL(t, r, `mutation($id: ID!) { updateUser(id: $id, input: {name: "Renamed"}) { name } }`, map[string]interface{}{"id": fmt.Sprint(admin.ID)})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "forbidden", resp.Errors[0].Extensions["code"])
+
+	resp = execGraphQL(t, r, `mutation($id: ID!) { updateUser(id: $id, input: {name: "Renamed"}) { name role } }`, map[string]interface{}{"id": fmt.Sprint(sales.ID)})
+	require.Empty(t, resp.Errors)
+	assert.JSONEq(t, `{"name": "Renamed", "role": "sales"}`, string(resp.Data["updateUser"]))
+
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+	resp = execGraphQL(t, r, `mutation($id: ID!) { updateFunnel(id: $id, input: {wipLimit: 3}) { id } }`, map[string]interface{}{"id": fmt.Sprint(funnel.ID)})
+	require.Len(t, resp.Errors, 1)
+	assert.Equal(t, "forbidden", resp.Errors[0].Extensions["code"])
+}
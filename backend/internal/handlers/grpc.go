@@ -0,0 +1,592 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	flamev1 "github.com/mokan/flame-crm-backend/internal/pb/flame/v1"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// grpcServer implements the flame.v1 services on top of the same helpers as
+// the REST and GraphQL handlers. Callers are authenticated by the interceptors
+// in the middleware package.
+type grpcServer struct {
+	flamev1.UnimplementedCompanyServiceServer
+	flamev1.UnimplementedCustomerServiceServer
+	flamev1.UnimplementedUserServiceServer
+	flamev1.UnimplementedFunnelServiceServer
+	h *Handler
+}
+
+func (h *Handler) RegisterGRPC(s grpc.ServiceRegistrar) {
+	srv := &grpcServer{h: h}
+	flamev1.RegisterCompanyServiceServer(s, srv)
+	flamev1.RegisterCustomerServiceServer(s, srv)
+	flamev1.RegisterUserServiceServer(s, srv)
+	flamev1.RegisterFunnelServiceServer(s, srv)
+}
+
+// grpcError maps a problem onto a gRPC status. The problem code travels as
+// ErrorInfo.Reason and field errors as BadRequest violations.
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	p := problemFor(err)
+	st := status.New(grpcCode(p), p.Detail)
+
+	info := &errdetails.ErrorInfo{Reason: p.Code, Domain: "flame-crm"}
+	if len(p.Extensions) > 0 {
+		info.Metadata = make(map[string]string, len(p.Extensions))
+		for key, value := range p.Extensions {
+			info.Metadata[key] = fmt.Sprint(value)
+		}
+	}
+	details := []protoadapt.MessageV1{info}
+	if len(p.Errors) > 0 {
+		badRequest := &errdetails.BadRequest{}
+		for _, fe := range p.Errors {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: fe.Field, Description: fe.Message, Reason: fe.Code})
+		}
+		details = append(details, badRequest)
+	}
+
+	withDetails, derr := st.WithDetails(details...)
+	if derr != nil {
+		return st.Err()
+	}
+	return withDetails.Err()
+}
+
+func grpcCode(p *problem.Problem) codes.Code {
+	switch p.Status {
+	case http.StatusBadRequest:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		if p.Code == problem.CodeAlreadyExists {
+			return codes.AlreadyExists
+		}
+		return codes.Aborted
+	case http.StatusPreconditionFailed, http.StatusUnprocessableEntity:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	}
+	return codes.Internal
+}
+
+func grpcListArgs(limit, offset int32, sort string, filters []*flamev1.Filter) listArgs {
+	var args listArgs
+	if limit != 0 {
+		args.Limit = &limit
+	}
+	if offset != 0 {
+		args.Offset = &offset
+	}
+	if sort != "" {
+		args.Sort = &sort
+	}
+	if len(filters) > 0 {
+		list := make([]listFilter, len(filters))
+		for i, f := range filters {
+			op := f.GetOp()
+			if op == "" {
+				op = "eq"
+			}
+			list[i] = listFilter{Field: f.GetField(), Op: op, Value: f.GetValue()}
+		}
+		args.Filter = &list
+	}
+	return args
+}
+
+func findGRPCRecord(ctx context.Context, tx *gorm.DB, dest interface{}, id uint64, name string) error {
+	if id == 0 {
+		return problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "id is required")
+	}
+	if err := tx.WithContext(ctx).First(dest, id).Error; err != nil {
+		return problem.New(http.StatusNotFound, problem.CodeNotFound, name+" not found")
+	}
+	return nil
+}
+
+// expectedUpdatedAt checks a request's expected_updated_at against the
+// record's updated_at, the gRPC counterpart of If-Match. It returns the
+// updated_at the write must still find, or nil when the request set none.
+func expectedUpdatedAt(expected *timestamppb.Timestamp, updatedAt time.Time) (*time.Time, error) {
+	if expected == nil {
+		return nil, nil
+	}
+	if !expected.AsTime().Equal(updatedAt) {
+		return nil, problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed, errPreconditionFailed.Error())
+	}
+	return &updatedAt, nil
+}
+
+// maskDoc builds a patch document from the fields named in an update mask.
+// Paths that are not in fields still reach the allowlist, which rejects them.
+func maskDoc(mask *fieldmaskpb.FieldMask, fields map[string]interface{}) (patchDoc, error) {
+	if len(mask.GetPaths()) == 0 {
+		return nil, problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "update_mask is required")
+	}
+	doc := patchDoc{}
+	for _, path := range mask.GetPaths() {
+		doc[path] = fields[path]
+	}
+	return doc, nil
+}
+
+// requireMaskedIDs rejects a required, non-optional ID field that is named in
+// the mask but left at 0, which proto3 cannot tell apart from unset.
+func requireMaskedIDs(mask *fieldmaskpb.FieldMask, ids map[string]uint64) error {
+	for _, path := range mask.GetPaths() {
+		if id, ok := ids[path]; ok && id == 0 {
+			p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, path+" is required")
+			p.Errors = []problem.FieldError{{Field: path, Code: "required", Message: "must be set when named in update_mask"}}
+			return p
+		}
+	}
+	return nil
+}
+
+func idPatchValue(id *uint64) interface{} {
+	if id == nil {
+		return nil
+	}
+	return json.Number(strconv.FormatUint(*id, 10))
+}
+
+func optionalUint(id *uint64) *uint {
+	if id == nil {
+		return nil
+	}
+	n := uint(*id)
+	return &n
+}
+
+func optionalUint64(id *uint) *uint64 {
+	if id == nil {
+		return nil
+	}
+	n := uint64(*id)
+	return &n
+}
+
+var (
+	rolesToProto = map[models.Role]flamev1.Role{
+		models.RoleAdmin:       flamev1.Role_ROLE_ADMIN,
+		models.RoleSales:       flamev1.Role_ROLE_SALES,
+		models.RoleHeadOfSales: flamev1.Role_ROLE_HEAD_OF_SALES,
+	}
+	rolesFromProto = map[flamev1.Role]models.Role{
+		flamev1.Role_ROLE_ADMIN:         models.RoleAdmin,
+		flamev1.Role_ROLE_SALES:         models.RoleSales,
+		flamev1.Role_ROLE_HEAD_OF_SALES: models.RoleHeadOfSales,
+	}
+)
+
+func companyProto(c models.Company) *flamev1.Company {
+	return &flamev1.Company{
+		Id:        uint64(c.ID),
+		Name:      c.Name,
+		Address:   c.Address,
+		FunnelId:  optionalUint64(c.FunnelID),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(c.UpdatedAt),
+	}
+}
+
+func userProto(u models.User) *flamev1.User {
+	return &flamev1.User{
+		Id:        uint64(u.ID),
+		Name:      u.Name,
+		Email:     u.Email,
+		Role:      rolesToProto[u.Role],
+		CompanyId: optionalUint64(u.CompanyID),
+		CreatedAt: timestamppb.New(u.CreatedAt),
+		UpdatedAt: timestamppb.New(u.UpdatedAt),
+	}
+}
+
+func customerProto(c models.Customer) *flamev1.Customer {
+	out := &flamev1.Customer{
+		Id:            uint64(c.ID),
+		Name:          c.Name,
+		Email:         c.Email,
+		Phone:         c.Phone,
+		LeadSource:    c.LeadSource,
+		FunnelStage:   c.FunnelStage,
+		BoardPosition: int32(c.BoardPosition),
+		CompanyId:     uint64(c.CompanyID),
+		FunnelId:      optionalUint64(c.FunnelID),
+		CreatedAt:     timestamppb.New(c.CreatedAt),
+		UpdatedAt:     timestamppb.New(c.UpdatedAt),
+	}
+	for _, tag := range c.Tags {
+		out.Tags = append(out.Tags, tag.Name)
+	}
+	for _, m := range c.Memberships {
+		out.Memberships = append(out.Memberships, &flamev1.Membership{Pipeline: m.Pipeline, FunnelId: uint64(m.FunnelID), FunnelStage: m.FunnelStage})
+	}
+	return out
+}
+
+func funnelProto(f models.Funnel) *flamev1.Funnel {
+	out := &flamev1.Funnel{
+		Id:        uint64(f.ID),
+		Name:      f.Name,
+		WipMode:   f.WIPMode,
+		CreatedAt: timestamppb.New(f.CreatedAt),
+		UpdatedAt: timestamppb.New(f.UpdatedAt),
+	}
+	if f.WIPLimit != nil {
+		limit := int32(*f.WIPLimit)
+		out.WipLimit = &limit
+	}
+	for _, next := range f.NextFunnels {
+		out.NextFunnelIds = append(out.NextFunnelIds, uint64(next.ID))
+	}
+	for _, previous := range f.PreviousFunnels {
+		out.PreviousFunnelIds = append(out.PreviousFunnelIds, uint64(previous.ID))
+	}
+	return out
+}
+
+func (s *grpcServer) ListCompanies(ctx context.Context, req *flamev1.ListCompaniesRequest) (*flamev1.ListCompaniesResponse, error) {
+	rows, meta, err := findList[models.Company](ctx, s.h.db, grpcListArgs(req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetFilters()), companyQuerySpec)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &flamev1.ListCompaniesResponse{Total: meta.Total}
+	for _, row := range rows {
+		resp.Companies = append(resp.Companies, companyProto(row))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetCompany(ctx context.Context, req *flamev1.GetCompanyRequest) (*flamev1.Company, error) {
+	var company models.Company
+	if err := findGRPCRecord(ctx, s.h.db, &company, req.GetId(), "Company"); err != nil {
+		return nil, grpcError(err)
+	}
+	return companyProto(company), nil
+}
+
+func (s *grpcServer) CreateCompany(ctx context.Context, req *flamev1.CreateCompanyRequest) (*flamev1.Company, error) {
+	company := models.Company{Name: req.GetName(), Address: req.GetAddress(), FunnelID: optionalUint(req.FunnelId)}
+	if err := validateInput(&company); err != nil {
+		return nil, grpcError(err)
+	}
+	if _, err := companyUpdates(s.h.db, models.CompanyInput{Name: company.Name, Address: company.Address, FunnelID: company.FunnelID}, viewerFrom(ctx).Role); err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.h.db.WithContext(ctx).Create(&company).Error; err != nil {
+		return nil, grpcError(err)
+	}
+	return companyProto(company), nil
+}
+
+func (s *grpcServer) UpdateCompany(ctx context.Context, req *flamev1.UpdateCompanyRequest) (*flamev1.Company, error) {
+	var company models.Company
+	if err := findGRPCRecord(ctx, s.h.db, &company, req.GetId(), "Company"); err != nil {
+		return nil, grpcError(err)
+	}
+
+	updatedAt, err := expectedUpdatedAt(req.GetExpectedUpdatedAt(), company.UpdatedAt)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	doc, err := maskDoc(req.GetUpdateMask(), map[string]interface{}{
+		"name":      req.GetName(),
+		"address":   req.GetAddress(),
+		"funnel_id": idPatchValue(req.FunnelId),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	err = s.h.applyPatchDoc(ctx, &company, updatedAt, doc, service.CompanyFields, func(updates map[string]interface{}) error {
+		return prepareCompanyPatch(s.h.db, updates)
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := s.h.db.WithContext(ctx).First(&company, company.ID).Error; err != nil {
+		return nil, grpcError(err)
+	}
+	return companyProto(company), nil
+}
+
+func (s *grpcServer) ListUsers(ctx context.Context, req *flamev1.ListUsersRequest) (*flamev1.ListUsersResponse, error) {
+	rows, meta, err := findList[models.User](ctx, s.h.db, grpcListArgs(req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetFilters()), userQuerySpec)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &flamev1.ListUsersResponse{Total: meta.Total}
+	for _, row := range rows {
+		resp.Users = append(resp.Users, userProto(row))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetUser(ctx context.Context, req *flamev1.GetUserRequest) (*flamev1.User, error) {
+	var user models.User
+	if err := findGRPCRecord(ctx, s.h.db, &user, req.GetId(), "User"); err != nil {
+		return nil, grpcError(err)
+	}
+	return userProto(user), nil
+}
+
+func (s *grpcServer) CreateUser(ctx context.Context, req *flamev1.CreateUserRequest) (*flamev1.User, error) {
+	input := CreateUserInput{
+		Name:      req.GetName(),
+		Email:     req.GetEmail(),
+		Password:  req.GetPassword(),
+		Role:      rolesFromProto[req.GetRole()],
+		CompanyID: optionalUint(req.CompanyId),
+	}
+	if err := validateInput(&input); err != nil {
+		return nil, grpcError(err)
+	}
+
+	user, err := createUser(s.h.db.WithContext(ctx), input)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return userProto(*user), nil
+}
+
+func (s *grpcServer) UpdateUser(ctx context.Context, req *flamev1.UpdateUserRequest) (*flamev1.User, error) {
+	var user models.User
+	if err := findGRPCRecord(ctx, s.h.db, &user, req.GetId(), "User"); err != nil {
+		return nil, grpcError(err)
+	}
+	viewer := viewerFrom(ctx)
+	if viewer.Role != models.RoleAdmin && viewer.ID != user.ID {
+		return nil, grpcError(problem.New(http.StatusForbidden, problem.CodeForbidden, "You can only modify your own account"))
+	}
+
+	updatedAt, err := expectedUpdatedAt(req.GetExpectedUpdatedAt(), user.UpdatedAt)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	doc, err := maskDoc(req.GetUpdateMask(), map[string]interface{}{
+		"name":       req.GetName(),
+		"email":      req.GetEmail(),
+		"password":   req.GetPassword(),
+		"role":       string(rolesFromProto[req.GetRole()]),
+		"company_id": idPatchValue(req.CompanyId),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.h.applyPatchDoc(ctx, &user, updatedAt, doc, service.UserFields, service.PrepareUserUpdates); err != nil {
+		return nil, grpcError(userSaveError(err))
+	}
+
+	if err := s.h.db.WithContext(ctx).First(&user, user.ID).Error; err != nil {
+		return nil, grpcError(err)
+	}
+	return userProto(user), nil
+}
+
+func (h *Handler) customersWithRelations() *gorm.DB {
+	return h.db.Preload("Tags").Preload("Memberships")
+}
+
+func (s *grpcServer) ListCustomers(ctx context.Context, req *flamev1.ListCustomersRequest) (*flamev1.ListCustomersResponse, error) {
+	tx := s.h.customersWithRelations()
+	if pipeline := req.GetPipeline(); pipeline != "" {
+		tx = tx.Where("id IN (?)", s.h.db.Model(&models.CustomerFunnel{}).Select("customer_id").Where("pipeline = ?", pipeline))
+	}
+	rows, meta, err := findList[models.Customer](ctx, tx, grpcListArgs(req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetFilters()), customerQuerySpec)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &flamev1.ListCustomersResponse{Total: meta.Total}
+	for _, row := range rows {
+		resp.Customers = append(resp.Customers, customerProto(row))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetCustomer(ctx context.Context, req *flamev1.GetCustomerRequest) (*flamev1.Customer, error) {
+	var customer models.Customer
+	if err := findGRPCRecord(ctx, s.h.customersWithRelations(), &customer, req.GetId(), "Customer"); err != nil {
+		return nil, grpcError(err)
+	}
+	return customerProto(customer), nil
+}
+
+func (s *grpcServer) CreateCustomer(ctx context.Context, req *flamev1.CreateCustomerRequest) (*flamev1.Customer, error) {
+	customer := models.Customer{
+		Name:        req.GetName(),
+		Email:       req.GetEmail(),
+		Phone:       req.GetPhone(),
+		LeadSource:  req.GetLeadSource(),
+		FunnelStage: req.GetFunnelStage(),
+		CompanyID:   uint(req.GetCompanyId()),
+		FunnelID:    optionalUint(req.FunnelId),
+	}
+	for _, name := range req.GetTags() {
+		customer.Tags = append(customer.Tags, models.Tag{Name: name})
+	}
+	if err := validateInput(&customer); err != nil {
+		return nil, grpcError(err)
+	}
+
+	err := s.h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.h.customers(tx).Create(&customer)
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	s.h.customersWithRelations().WithContext(ctx).First(&customer, customer.ID)
+	return customerProto(customer), nil
+}
+
+func (s *grpcServer) UpdateCustomer(ctx context.Context, req *flamev1.UpdateCustomerRequest) (*flamev1.Customer, error) {
+	var customer models.Customer
+	if err := findGRPCRecord(ctx, s.h.db, &customer, req.GetId(), "Customer"); err != nil {
+		return nil, grpcError(err)
+	}
+
+	updatedAt, err := expectedUpdatedAt(req.GetExpectedUpdatedAt(), customer.UpdatedAt)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := requireMaskedIDs(req.GetUpdateMask(), map[string]uint64{"company_id": req.GetCompanyId()}); err != nil {
+		return nil, grpcError(err)
+	}
+	doc, err := maskDoc(req.GetUpdateMask(), map[string]interface{}{
+		"name":         req.GetName(),
+		"email":        req.GetEmail(),
+		"phone":        req.GetPhone(),
+		"lead_source":  req.GetLeadSource(),
+		"funnel_stage": req.GetFunnelStage(),
+		"company_id":   json.Number(strconv.FormatUint(req.GetCompanyId(), 10)),
+		"funnel_id":    idPatchValue(req.FunnelId),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	err = s.h.applyPatchDoc(ctx, &customer, updatedAt, doc, service.CustomerFields, func(updates map[string]interface{}) error {
+		return s.h.prepareCustomerPatch(s.h.db, &customer, updates)
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := s.h.customersWithRelations().WithContext(ctx).First(&customer, customer.ID).Error; err != nil {
+		return nil, grpcError(err)
+	}
+	return customerProto(customer), nil
+}
+
+func (h *Handler) funnelsWithTransitions() *gorm.DB {
+	return h.db.Preload("NextFunnels").Preload("PreviousFunnels")
+}
+
+func (s *grpcServer) ListFunnels(ctx context.Context, req *flamev1.ListFunnelsRequest) (*flamev1.ListFunnelsResponse, error) {
+	rows, meta, err := findList[models.Funnel](ctx, s.h.funnelsWithTransitions(), grpcListArgs(req.GetLimit(), req.GetOffset(), req.GetSort(), req.GetFilters()), funnelQuerySpec)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	resp := &flamev1.ListFunnelsResponse{Total: meta.Total}
+	for _, row := range rows {
+		resp.Funnels = append(resp.Funnels, funnelProto(row))
+	}
+	return resp, nil
+}
+
+func (s *grpcServer) GetFunnel(ctx context.Context, req *flamev1.GetFunnelRequest) (*flamev1.Funnel, error) {
+	var funnel models.Funnel
+	if err := findGRPCRecord(ctx, s.h.funnelsWithTransitions(), &funnel, req.GetId(), "Funnel"); err != nil {
+		return nil, grpcError(err)
+	}
+	return funnelProto(funnel), nil
+}
+
+func (s *grpcServer) CreateFunnel(ctx context.Context, req *flamev1.CreateFunnelRequest) (*flamev1.Funnel, error) {
+	input := CreateFunnelInput{Name: req.GetName(), WIPMode: req.GetWipMode()}
+	if req.WipLimit != nil {
+		limit := int(req.GetWipLimit())
+		input.WIPLimit = &limit
+	}
+	for _, id := range req.GetNextFunnelIds() {
+		input.NextFunnelIDs = append(input.NextFunnelIDs, uint(id))
+	}
+	for _, id := range req.GetPreviousFunnelIds() {
+		input.PreviousFunnelIDs = append(input.PreviousFunnelIDs, uint(id))
+	}
+	if err := validateInput(&input); err != nil {
+		return nil, grpcError(err)
+	}
+
+	var funnel *models.Funnel
+	err := s.h.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		funnel, err = s.h.createFunnel(tx, input)
+		return err
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	s.h.funnelsWithTransitions().WithContext(ctx).First(funnel, funnel.ID)
+	return funnelProto(*funnel), nil
+}
+
+func (s *grpcServer) UpdateFunnel(ctx context.Context, req *flamev1.UpdateFunnelRequest) (*flamev1.Funnel, error) {
+	var funnel models.Funnel
+	if err := findGRPCRecord(ctx, s.h.db, &funnel, req.GetId(), "Funnel"); err != nil {
+		return nil, grpcError(err)
+	}
+
+	var wipLimit interface{}
+	if req.WipLimit != nil {
+		wipLimit = json.Number(strconv.Itoa(int(req.GetWipLimit())))
+	}
+	updatedAt, err := expectedUpdatedAt(req.GetExpectedUpdatedAt(), funnel.UpdatedAt)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	doc, err := maskDoc(req.GetUpdateMask(), map[string]interface{}{
+		"name":      req.GetName(),
+		"wip_limit": wipLimit,
+		"wip_mode":  req.GetWipMode(),
+	})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := s.h.applyPatchDoc(ctx, &funnel, updatedAt, doc, service.FunnelFields, nil); err != nil {
+		return nil, grpcError(err)
+	}
+
+	if err := s.h.funnelsWithTransitions().WithContext(ctx).First(&funnel, funnel.ID).Error; err != nil {
+		return nil, grpcError(err)
+	}
+	return funnelProto(funnel), nil
+}
@@ -0,0 +1,265 @@
+package handlers
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	flamev1 "github.com/mokan/flame-crm-backend/internal/pb/flame/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func setupGRPC(t *testing.T) *grpc.ClientConn {
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.GRPCUnaryAuth()),
+		grpc.ChainStreamInterceptor(middleware.GRPCStreamAuth()),
+	)
+	testHandler.RegisterGRPC(s)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func grpcContext(t *testing.T, userID uint, role models.Role) context.Context {
+	token, err := auth.GenerateToken(userID, string(role))
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+}
+
+func errorInfo(t *testing.T, err error) (*errdetails.ErrorInfo, *errdetails.BadRequest) {
+	var info *errdetails.ErrorInfo
+	var badRequest *errdetails.BadRequest
+	for _, detail := range status.Convert(err).Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			info = d
+		case *errdetails.BadRequest:
+			badRequest = d
+		}
+	}
+	require.NotNil(t, info, "missing ErrorInfo in %v", err)
+	return info, badRequest
+}
+
+func TestGRPCCustomers(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+
+	conn := setupGRPC(t)
+	ctx := grpcContext(t, user.ID, models.RoleAdmin)
+	customers := flamev1.NewCustomerServiceClient(conn)
+
+	created, err := customers.CreateCustomer(ctx, &flamev1.CreateCustomerRequest{
+		Name:      "Alice",
+		Email:     "alice@example.com",
+		CompanyId: uint64(company.ID),
+		FunnelId:  proto.Uint64(uint64(funnel.ID)),
+		Tags:      []string{"vip", "new"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", created.Name)
+	assert.ElementsMatch(t, []string{"vip", "new"}, created.Tags)
+	assert.Equal(t, uint64(funnel.ID), created.GetFunnelId())
+
+	_, err = customers.CreateCustomer(ctx, &flamev1.CreateCustomerRequest{Name: "Bob", CompanyId: uint64(company.ID)})
+	require.NoError(t, err)
+
+	list, err := customers.ListCustomers(ctx, &flamev1.ListCustomersRequest{
+		Filters: []*flamev1.Filter{{Field: "name", Op: "contains", Value: "ali"}},
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, list.Total)
+	require.Len(t, list.Customers, 1)
+	assert.Equal(t, created.Id, list.Customers[0].Id)
+
+	updated, err := customers.UpdateCustomer(ctx, &flamev1.UpdateCustomerRequest{
+		Id:         created.Id,
+		Phone:      "555",
+		Name:       "ignored because it is not in the mask",
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"phone", "funnel_id"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", updated.Name)
+	assert.Equal(t, "555", updated.Phone)
+	assert.Nil(t, updated.FunnelId)
+
+	got, err := customers.GetCustomer(ctx, &flamev1.GetCustomerRequest{Id: created.Id})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(updated, got))
+
+	_, err = customers.UpdateCustomer(ctx, &flamev1.UpdateCustomerRequest{
+		Id:         created.Id,
+		UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"company_id"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, badRequest := errorInfo(t, err)
+	require.NotNil(t, badRequest)
+	assert.Equal(t, "company_id", badRequest.FieldViolations[0].Field)
+}
+
+func TestGRPCUpdateChecksExpectedUpdatedAt(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+	conn := setupGRPC(t)
+	ctx := grpcContext(t, user.ID, models.RoleAdmin)
+	companies := flamev1.NewCompanyServiceClient(conn)
+
+	current, err := companies.GetCompany(ctx, &flamev1.GetCompanyRequest{Id: uint64(company.ID)})
+	require.NoError(t, err)
+	mask := &fieldmaskpb.FieldMask{Paths: []string{"name"}}
+
+	first, err := companies.UpdateCompany(ctx, &flamev1.UpdateCompanyRequest{Id: current.Id, Name: "First Writer", UpdateMask: mask, ExpectedUpdatedAt: current.UpdatedAt})
+	require.NoError(t, err)
+	assert.Equal(t, "First Writer", first.Name)
+
+	_, err = companies.UpdateCompany(ctx, &flamev1.UpdateCompanyRequest{Id: current.Id, Name: "Second Writer", UpdateMask: mask, ExpectedUpdatedAt: current.UpdatedAt})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	info, _ := errorInfo(t, err)
+	assert.Equal(t, "precondition_failed", info.Reason)
+
+	got, err := companies.GetCompany(ctx, &flamev1.GetCompanyRequest{Id: current.Id})
+	require.NoError(t, err)
+	assert.Equal(t, "First Writer", got.Name)
+
+	funnel := models.Funnel{Name: "Lead"}
+	require.NoError(t, testDB.Create(&funnel).Error)
+	funnels := flamev1.NewFunnelServiceClient(conn)
+	stale := timestamppb.New(funnel.UpdatedAt.Add(-time.Second))
+	_, err = funnels.UpdateFunnel(ctx, &flamev1.UpdateFunnelRequest{Id: uint64(funnel.ID), Name: "Renamed", UpdateMask: mask, ExpectedUpdatedAt: stale})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestGRPCErrors(t *testing.T) {
+	company, admin := createTestCompanyAndUser(t)
+	sales := models.User{Name: "Sales", Email: "sales@example.com", Password: "password123", CompanyID: &company.ID, Role: models.RoleSales}
+	assert.NoError(t, testDB.Create(&sales).Error)
+	funnel := models.Funnel{Name: "Lead"}
+	assert.NoError(t, testDB.Create(&funnel).Error)
+
+	conn := setupGRPC(t)
+	companies := flamev1.NewCompanyServiceClient(conn)
+	users := flamev1.NewUserServiceClient(conn)
+	funnels := flamev1.NewFunnelServiceClient(conn)
+
+	_, err := companies.GetCompany(context.Background(), &flamev1.GetCompanyRequest{Id: uint64(company.ID)})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := grpcContext(t, admin.ID, models.RoleAdmin)
+	_, err = companies.GetCompany(ctx, &flamev1.GetCompanyRequest{Id: 999999})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	info, _ := errorInfo(t, err)
+	assert.Equal(t, "not_found", info.Reason)
+
+	_, err = users.CreateUser(ctx, &flamev1.CreateUserRequest{Name: "New", Email: "not-an-email", Password: "secret123", Role: flamev1.Role_ROLE_SALES})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	info, badRequest := errorInfo(t, err)
+	assert.Equal(t, "validation_failed", info.Reason)
+	require.NotNil(t, badRequest)
+	require.Len(t, badRequest.FieldViolations, 1)
+	assert.Equal(t, "email", badRequest.FieldViolations[0].Field)
+
+	_, err = users.CreateUser(ctx, &flamev1.CreateUserRequest{Name: "Dup", Email: admin.Email, Password: "secret123", Role: flamev1.Role_ROLE_SALES})
+	assert.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = companies.UpdateCompany(ctx, &flamev1.UpdateCompanyRequest{Id: uint64(company.ID), Name: "Renamed"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	salesCtx := grpcContext(t, sales.ID, models.RoleSales)
+	_, err = funnels.UpdateFunnel(salesCtx, &flamev1.UpdateFunnelRequest{Id: uint64(funnel.ID), WipLimit: proto.Int32(3), UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"wip_limit"}}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	info, badRequest = errorInfo(t, err)
+	assert.Equal(t, "forbidden", info.Reason)
+	assert.Equal(t, "wip_limit", badRequest.FieldViolations[0].Field)
+
+	_, err = users.UpdateUser(salesCtx, &flamev1.UpdateUserRequest{Id: uint64(admin.ID), Name: "Hijacked", UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	self, err := users.UpdateUser(salesCtx, &flamev1.UpdateUserRequest{Id: uint64(sales.ID), Name: "Renamed", UpdateMask: &fieldmaskpb.FieldMask{Paths: []string{"name"}}})
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", self.Name)
+	assert.Equal(t, flamev1.Role_ROLE_SALES, self.Role)
+}
+
+func TestGRPCWatchCustomers(t *testing.T) {
+	saved := customerWatchInterval
+	customerWatchInterval = 10 * time.Millisecond
+	defer func() { customerWatchInterval = saved }()
+
+	company, user := createTestCompanyAndUser(t)
+	other := models.Company{Name: "Other"}
+	assert.NoError(t, testDB.Create(&other).Error)
+
+	conn := setupGRPC(t)
+	ctx := grpcContext(t, user.ID, models.RoleAdmin)
+	stream, err := flamev1.NewCustomerServiceClient(conn).WatchCustomers(ctx, &flamev1.WatchCustomersRequest{CompanyId: proto.Uint64(uint64(company.ID))})
+	require.NoError(t, err)
+	// Give the server time to take its starting point, which defaults to now.
+	time.Sleep(20 * time.Millisecond)
+
+	ignored := models.Customer{Name: "Elsewhere", CompanyID: other.ID}
+	assert.NoError(t, testHandler.customers(testDB).Create(&ignored))
+	customer := models.Customer{Name: "Carol", CompanyID: company.ID}
+	assert.NoError(t, testHandler.customers(testDB).Create(&customer))
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, flamev1.CustomerEvent_TYPE_CREATED, event.Type)
+	assert.Equal(t, "Carol", event.Customer.Name)
+	require.NotEmpty(t, event.Cursor)
+	created := event.Cursor
+
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, testDB.Model(&customer).Update("phone", "555").Error)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, flamev1.CustomerEvent_TYPE_UPDATED, event.Type)
+	assert.Equal(t, "555", event.Customer.Phone)
+
+	time.Sleep(5 * time.Millisecond)
+	assert.NoError(t, testDB.Delete(&customer).Error)
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, flamev1.CustomerEvent_TYPE_DELETED, event.Type)
+	assert.Equal(t, uint64(customer.ID), event.Customer.Id)
+
+	// Resuming from a cursor replays the changes logged after it.
+	resumed, err := flamev1.NewCustomerServiceClient(conn).WatchCustomers(ctx, &flamev1.WatchCustomersRequest{Cursor: created})
+	require.NoError(t, err)
+	event, err = resumed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, flamev1.CustomerEvent_TYPE_UPDATED, event.Type)
+	assert.Equal(t, uint64(customer.ID), event.Customer.Id)
+	event, err = resumed.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, flamev1.CustomerEvent_TYPE_DELETED, event.Type)
+
+	invalid, err := flamev1.NewCustomerServiceClient(conn).WatchCustomers(ctx, &flamev1.WatchCustomersRequest{Cursor: "not-a-cursor"})
+	require.NoError(t, err)
+	_, err = invalid.Recv()
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/changefeed"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	flamev1 "github.com/mokan/flame-crm-backend/internal/pb/flame/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+var customerWatchInterval = time.Second
+
+var customerEventTypes = map[string]flamev1.CustomerEvent_Type{
+	changefeed.ActionCreated: flamev1.CustomerEvent_TYPE_CREATED,
+	changefeed.ActionUpdated: flamev1.CustomerEvent_TYPE_UPDATED,
+	changefeed.ActionDeleted: flamev1.CustomerEvent_TYPE_DELETED,
+}
+
+// WatchCustomers polls the change feed for customer changes after the last
+// one it sent. The feed is filled by triggers, so it sees every write path,
+// and its sequence numbers follow commit order, so a slow transaction cannot
+// commit behind the watermark.
+func (s *grpcServer) WatchCustomers(req *flamev1.WatchCustomersRequest, stream flamev1.CustomerService_WatchCustomersServer) error {
+	ctx := stream.Context()
+	w := &customerWatch{h: s.h, companyID: optionalUint(req.CompanyId), funnelID: optionalUint(req.FunnelId)}
+	var err error
+	switch {
+	case req.GetCursor() != "":
+		if w.after, err = changefeed.DecodeCursor(req.GetCursor()); err != nil {
+			return status.Error(codes.InvalidArgument, "Invalid cursor")
+		}
+	case req.GetSince() != nil:
+		w.after, err = lastChangeBefore(s.h.db.WithContext(ctx), req.GetSince().AsTime())
+	default:
+		w.after, err = lastChangeBefore(s.h.db.WithContext(ctx), time.Now())
+	}
+	if err != nil {
+		return grpcError(err)
+	}
+
+	ticker := time.NewTicker(customerWatchInterval)
+	defer ticker.Stop()
+	for {
+		if err := w.poll(ctx, stream.Send); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return grpcError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// lastChangeBefore returns the sequence number of the last change logged
+// before at, or 0 when there is none.
+func lastChangeBefore(tx *gorm.DB, at time.Time) (uint64, error) {
+	var seq uint64
+	err := tx.Table("changes").Where("changed_at < ?", at).Select("COALESCE(MAX(seq), 0)").Scan(&seq).Error
+	return seq, err
+}
+
+type customerWatch struct {
+	h         *Handler
+	after     uint64
+	companyID *uint
+	funnelID  *uint
+}
+
+// poll sends the customer changes logged after w.after and moves w.after
+// past them.
+func (w *customerWatch) poll(ctx context.Context, send func(*flamev1.CustomerEvent) error) error {
+	for {
+		changes, more, err := changefeed.Since(w.h.db.WithContext(ctx), changefeed.Options{
+			After:    w.after,
+			Entities: []string{changefeed.EntityCustomer},
+			Limit:    changefeed.MaxLimit,
+		})
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(changes))
+		for _, change := range changes {
+			ids = append(ids, change.EntityID)
+		}
+		var rows []models.Customer
+		if len(ids) > 0 {
+			if err := w.h.customersWithRelations().Unscoped().WithContext(ctx).Find(&rows, ids).Error; err != nil {
+				return err
+			}
+		}
+		customers := make(map[uint]models.Customer, len(rows))
+		for _, row := range rows {
+			customers[row.ID] = row
+		}
+
+		for _, change := range changes {
+			w.after = change.Seq
+			customer, ok := customers[change.EntityID]
+			if !ok {
+				customer = models.Customer{}
+				customer.ID = change.EntityID
+			}
+			if !w.matches(customer, ok) {
+				continue
+			}
+			event := &flamev1.CustomerEvent{
+				Type:      customerEventTypes[change.Action],
+				Customer:  customerProto(customer),
+				ChangedAt: timestamppb.New(change.ChangedAt),
+				Cursor:    changefeed.EncodeCursor(change.Seq),
+			}
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// matches applies the filters to the customer's current state. A customer
+// that no longer exists only matches a watch without filters.
+func (w *customerWatch) matches(customer models.Customer, exists bool) bool {
+	if w.companyID == nil && w.funnelID == nil {
+		return true
+	}
+	if !exists {
+		return false
+	}
+	if w.companyID != nil && customer.CompanyID != *w.companyID {
+		return false
+	}
+	if w.funnelID != nil && (customer.FunnelID == nil || *customer.FunnelID != *w.funnelID) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
+)
+
+// Handler serves the REST, GraphQL and gRPC APIs from the database it was
+// built with. Business rules live in the service package; handlers build the
+// services on the transaction they write in. Auth, user writes, saved views,
+// import jobs and funnel version publishing go through the repositories; the
+// other endpoints, and every list built with the query and sparse packages,
+// still query the database directly.
+type Handler struct {
+	db      *gorm.DB
+	graphQL *graphql.Schema
+}
+
+func New(database *gorm.DB) *Handler {
+	h := &Handler{db: database}
+	h.graphQL = newGraphQLSchema(h)
+	return h
+}
+
+func (h *Handler) customers(tx *gorm.DB) *service.Customers {
+	return service.NewCustomers(repository.NewGorm(tx))
+}
+
+func (h *Handler) funnels(tx *gorm.DB) *service.Funnels {
+	return service.NewFunnels(repository.NewGorm(tx))
+}
+
+func (h *Handler) users(tx *gorm.DB) *service.Users {
+	return service.NewUsers(repository.NewGorm(tx))
+}
+
+func (h *Handler) funnelVersions(tx *gorm.DB) *service.FunnelVersions {
+	return service.NewFunnelVersions(repository.NewGorm(tx))
+}
+
+func (h *Handler) store() repository.Store {
+	return repository.NewGorm(h.db)
+}
+
+// pathID parses the :id path parameter. Callers answer 404 when it is not an
+// ID, as for an ID that does not exist.
+func pathID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	return uint(id), err == nil
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/importer"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/service"
+)
+
+type ImportResponse struct {
+	ID uint `json:"id"`
+	*importer.Report
+	ErrorReportURL string `json:"error_report_url,omitempty"`
+}
+
+func (h *Handler) ImportCustomers(c *gin.Context) {
+	h.importCSV(c, service.ImportEntityCustomers)
+}
+
+func (h *Handler) ImportCompanies(c *gin.Context) {
+	h.importCSV(c, service.ImportEntityCompanies)
+}
+
+func (h *Handler) importCSV(c *gin.Context, entity string) {
+	header, err := c.FormFile("file")
+	if err != nil {
+		problem.Validation(c, "A CSV file is required", problem.FieldError{Field: "file", Code: "required", Message: "is required"})
+		return
+	}
+
+	opts := service.ImportOptions{Entity: entity, DedupeKey: c.PostForm("dedupe_key"), Role: currentRole(c)}
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &opts.Mapping); err != nil {
+			problem.Validation(c, "Invalid column mapping", problem.FieldError{Field: "mapping", Code: "json", Message: "must be a JSON object of column to field"})
+			return
+		}
+	}
+	if raw := c.PostForm("dry_run"); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			problem.Validation(c, "Invalid dry_run flag", problem.FieldError{Field: "dry_run", Code: "boolean", Message: "must be true or false"})
+			return
+		}
+		opts.DryRun = dryRun
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	defer file.Close()
+
+	report, err := service.NewImports(h.store()).Run(file, opts)
+	var importErr *service.ImportError
+	if errors.As(err, &importErr) {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidImport, importErr.Message)
+		return
+	}
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	job := models.ImportJob{
+		Entity:    entity,
+		DedupeKey: report.DedupeKey,
+		DryRun:    report.DryRun,
+		Total:     report.Total,
+		Created:   report.Created,
+		Updated:   report.Updated,
+		Failed:    report.Failed,
+		UserID:    currentUserID(c),
+	}
+	if report.HasErrors() {
+		var buf bytes.Buffer
+		if err := report.WriteErrors(&buf); err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		job.ErrorReport = buf.String()
+	}
+	if err := h.store().ImportJobs().Create(&job); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+
+	response := ImportResponse{ID: job.ID, Report: report}
+	if report.HasErrors() {
+		response.ErrorReportURL = fmt.Sprintf("/api/imports/%d/errors", job.ID)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *Handler) GetImport(c *gin.Context) {
+	sel, ok := h.parseSelection(c, importSelection)
+	if !ok {
+		return
+	}
+
+	job, ok := h.findVisibleImport(c)
+	if !ok {
+		return
+	}
+	renderSelection(c, sel, job)
+}
+
+func (h *Handler) GetImportErrors(c *gin.Context) {
+	job, ok := h.findVisibleImport(c)
+	if !ok {
+		return
+	}
+	if job.ErrorReport == "" {
+		problem.NotFound(c, "Import has no rejected rows")
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="import-%d-errors.csv"`, job.ID))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", []byte(job.ErrorReport))
+}
+
+// findVisibleImport loads an import job run by the current user, since its
+// error report holds the imported rows. Admins see every job.
+func (h *Handler) findVisibleImport(c *gin.Context) (*models.ImportJob, bool) {
+	var owner *uint
+	if currentRole(c) != models.RoleAdmin {
+		id := currentUserID(c)
+		owner = &id
+	}
+	id, ok := pathID(c)
+	if !ok {
+		problem.NotFound(c, "Import not found")
+		return nil, false
+	}
+	job, err := h.store().ImportJobs().Get(id, owner)
+	if err != nil {
+		problem.NotFound(c, "Import not found")
+		return nil, false
+	}
+	return job, true
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupImportRouter() *gin.Engine {
+	r := gin.Default()
+	r.POST("/customers/import", testHandler.ImportCustomers)
+	r.POST("/companies/import", testHandler.ImportCompanies)
+	r.GET("/imports/:id/errors", testHandler.GetImportErrors)
+	return r
+}
+
+func uploadCSV(r http.Handler, path, content string, fields map[string]string) *httptest.ResponseRecorder {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, _ := writer.CreateFormFile("file", "import.csv")
+	part.Write([]byte(content))
+	for key, value := range fields {
+		writer.WriteField(key, value)
+	}
+	writer.Close()
+
+	req, _ := http.NewRequest("POST", path, &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestImportCustomersDryRunAndCommit(t *testing.T) {
+	r := setupImportRouter()
+	company, _ := createTestCompanyAndUser(t)
+
+	existing := models.Customer{Name: "Old Name", Email: "ada@example.com", CompanyID: company.ID}
+	assert.NoError(t, testDB.Create(&existing).Error)
+
+	csv := "Full Name,Mail,Org,Labels\n" +
+		"Ada Lovelace,ADA@example.com,test company,vip\n" +
+		"Grace Hopper,grace@example.com,Test Company,vip;navy\n" +
+		"Nobody,nobody@example.com,Missing Corp,\n" +
+		",noname@example.com,,\n" +
+		"Grace H.,grace@example.com,,\n"
+	fields := map[string]string{
+		"mapping":    `{"Full Name": "name", "Mail": "email", "Org": "company", "Labels": "tags"}`,
+		"dedupe_key": "email",
+		"dry_run":    "true",
+	}
+
+	w := uploadCSV(r, "/customers/import", csv, fields)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var report ImportResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.True(t, report.DryRun)
+	assert.Equal(t, 5, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 2, report.Updated)
+	assert.Equal(t, 2, report.Failed)
+	assert.Equal(t, "update", report.Rows[0].Action)
+	assert.Equal(t, []string{`company no company named "Missing Corp"`}, report.Rows[2].Errors)
+	assert.Equal(t, []string{"name is required"}, report.Rows[3].Errors)
+	assert.NotEmpty(t, report.ErrorReportURL)
+
+	var count int64
+	testDB.Model(&models.Customer{}).Count(&count)
+	assert.Equal(t, int64(1), count)
+
+	delete(fields, "dry_run")
+	w = uploadCSV(r, "/customers/import", csv, fields)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.False(t, report.DryRun)
+	assert.Equal(t, existing.ID, report.Rows[0].ID)
+
+	var grace models.Customer
+	assert.NoError(t, testDB.Preload("Tags").Where("email = ?", "grace@example.com").First(&grace).Error)
+	assert.Equal(t, "Grace H.", grace.Name)
+	assert.Equal(t, company.ID, grace.CompanyID)
+	assert.Len(t, grace.Tags, 2)
+
+	var ada models.Customer
+	assert.NoError(t, testDB.First(&ada, existing.ID).Error)
+	assert.Equal(t, "Ada Lovelace", ada.Name)
+
+	w = performRequest(r, "GET", report.ErrorReportURL[len("/api"):], nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "line,Full Name,Mail,Org,Labels,errors\n4,Nobody,")
+}
+
+func TestImportCompaniesRejectsBadOptions(t *testing.T) {
+	r := setupImportRouter()
+	clearTable(t)
+
+	w := uploadCSV(r, "/companies/import", "name,address\nAcme,Main St\n", map[string]string{"dedupe_key": "address"})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"code":"invalid_import"`)
+
+	w = uploadCSV(r, "/companies/import", "Title\nAcme\n", nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = uploadCSV(r, "/companies/import", "name,address\nAcme,Main St\nacme,Second St\n", nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var companies []models.Company
+	testDB.Find(&companies)
+	assert.Len(t, companies, 1)
+	assert.Equal(t, "Second St", companies[0].Address)
+}
+
+func TestImportJobsAreVisibleToTheirOwner(t *testing.T) {
+	job := models.ImportJob{Entity: service.ImportEntityCustomers, UserID: 41, ErrorReport: "line,name,errors\n2,Ada,name is required\n"}
+	assert.NoError(t, testDB.Create(&job).Error)
+
+	as := func(userID uint, role models.Role) *gin.Engine {
+		r := gin.New()
+		r.Use(func(c *gin.Context) {
+			c.Set("user_id", userID)
+			c.Set("role", string(role))
+			c.Next()
+		})
+		r.GET("/imports/:id", testHandler.GetImport)
+		r.GET("/imports/:id/errors", testHandler.GetImportErrors)
+		return r
+	}
+
+	path := fmt.Sprintf("/imports/%d", job.ID)
+	for _, suffix := range []string{"", "/errors"} {
+		assert.Equal(t, http.StatusOK, performRequest(as(41, models.RoleSales), "GET", path+suffix, nil).Code)
+		assert.Equal(t, http.StatusNotFound, performRequest(as(42, models.RoleHeadOfSales), "GET", path+suffix, nil).Code)
+		assert.Equal(t, http.StatusOK, performRequest(as(1, models.RoleAdmin), "GET", path+suffix, nil).Code)
+	}
+}
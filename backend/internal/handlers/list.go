@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/sparse"
+)
+
+var (
+	companyQuerySpec = query.Spec{
+		Fields: map[string]query.Field{
+			"id":          {Column: "id", Type: query.Number},
+			"name":        {Column: "name", Type: query.String},
+			"address":     {Column: "address", Type: query.String},
+			"source":      {Column: "source", Type: query.String, Nullable: true},
+			"external_id": {Column: "external_id", Type: query.String, Nullable: true},
+			"funnel_id":   {Column: "funnel_id", Type: query.Number, Nullable: true},
+			"created_at":  {Column: "created_at", Type: query.Time},
+			"updated_at":  {Column: "updated_at", Type: query.Time},
+		},
+		DefaultSort: "id",
+		Params:      []string{"fields", "include"},
+	}
+
+	customerQuerySpec = query.Spec{
+		Fields: map[string]query.Field{
+			"id":           {Column: "id", Type: query.Number},
+			"name":         {Column: "name", Type: query.String},
+			"email":        {Column: "email", Type: query.String},
+			"phone":        {Column: "phone", Type: query.String},
+			"source":       {Column: "source", Type: query.String, Nullable: true},
+			"external_id":  {Column: "external_id", Type: query.String, Nullable: true},
+			"company_id":   {Column: "company_id", Type: query.Number},
+			"funnel_id":    {Column: "funnel_id", Type: query.Number, Nullable: true},
+			"funnel_stage": {Column: "funnel_stage", Type: query.String},
+			"lead_source":  {Column: "lead_source", Type: query.String},
+			"created_at":   {Column: "created_at", Type: query.Time},
+			"updated_at":   {Column: "updated_at", Type: query.Time},
+		},
+		DefaultSort: "id",
+		Params:      []string{"fields", "include", "pipeline"},
+	}
+
+	userQuerySpec = query.Spec{
+		Fields: map[string]query.Field{
+			"id":          {Column: "id", Type: query.Number},
+			"name":        {Column: "name", Type: query.String},
+			"email":       {Column: "email", Type: query.String},
+			"role":        {Column: "role", Type: query.String},
+			"source":      {Column: "source", Type: query.String, Nullable: true},
+			"external_id": {Column: "external_id", Type: query.String, Nullable: true},
+			"company_id":  {Column: "company_id", Type: query.Number, Nullable: true},
+			"created_at":  {Column: "created_at", Type: query.Time},
+			"updated_at":  {Column: "updated_at", Type: query.Time},
+		},
+		DefaultSort: "id",
+		Params:      []string{"fields", "include"},
+	}
+
+	funnelQuerySpec = query.Spec{
+		Fields: map[string]query.Field{
+			"id":         {Column: "id", Type: query.Number},
+			"name":       {Column: "name", Type: query.String},
+			"created_at": {Column: "created_at", Type: query.Time},
+			"updated_at": {Column: "updated_at", Type: query.Time},
+		},
+		DefaultSort: "id",
+		Params:      []string{"fields", "include"},
+	}
+)
+
+var (
+	companySelection = sparse.Spec{
+		Model:    models.Company{},
+		Fields:   []string{"name", "address", "source", "external_id", "funnel_id", "created_at", "updated_at"},
+		Includes: map[string]string{"users": "Users", "customers": "Customers", "funnel": "Funnel"},
+		Default:  []string{"users", "customers", "funnel"},
+	}
+
+	customerSelection = sparse.Spec{
+		Model:    models.Customer{},
+		Fields:   []string{"name", "email", "phone", "source", "external_id", "company_id", "funnel_id", "funnel_stage", "funnel_version_id", "lead_source", "board_position", "created_at", "updated_at"},
+		Includes: map[string]string{"tags": "Tags", "memberships": "Memberships"},
+		Default:  []string{"tags", "memberships"},
+	}
+
+	userSelection = sparse.Spec{
+		Model:    models.User{},
+		Fields:   []string{"name", "email", "role", "source", "external_id", "company_id", "created_at", "updated_at"},
+		Includes: map[string]string{"company": "Company"},
+		Default:  []string{"company"},
+	}
+
+	funnelSelection = sparse.Spec{
+		Model:    models.Funnel{},
+		Fields:   []string{"name", "wip_limit", "wip_mode", "created_at", "updated_at"},
+		Includes: map[string]string{"next_funnels": "NextFunnels", "previous_funnels": "PreviousFunnels"},
+		Default:  []string{"next_funnels", "previous_funnels"},
+	}
+
+	funnelVersionSelection = sparse.Spec{
+		Model:    models.FunnelVersion{},
+		Fields:   []string{"number", "status", "published_at", "created_at", "updated_at"},
+		Includes: map[string]string{"transitions": "Transitions"},
+		Default:  []string{"transitions"},
+	}
+
+	funnelVersionListSelection = sparse.Spec{
+		Model:    funnelVersionSelection.Model,
+		Fields:   funnelVersionSelection.Fields,
+		Includes: funnelVersionSelection.Includes,
+	}
+
+	enrollmentRuleSelection = sparse.Spec{
+		Model:  models.EnrollmentRule{},
+		Fields: []string{"name", "disabled", "priority", "company_id", "match_tag", "match_lead_source", "pipeline", "funnel_id", "funnel_stage", "created_at", "updated_at"},
+	}
+
+	importSelection = sparse.Spec{
+		Model:  models.ImportJob{},
+		Fields: []string{"entity", "dedupe_key", "dry_run", "total", "created", "updated", "failed", "user_id", "created_at", "updated_at"},
+	}
+)
+
+// parseListQuery reads the list parameters. Unless paged is set, the list
+// returns every row when the client asks for no page, as v1 lists did
+// before pagination.
+func parseListQuery(c *gin.Context, spec query.Spec, paged bool) (query.Params, bool) {
+	params, err := query.Parse(c.Request.URL.Query(), spec)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return params, false
+	}
+	if !paged {
+		params.Unbounded()
+	}
+	return params, true
+}
+
+// pagedVersion reports whether the request came through an API version
+// whose lists are paged by default.
+func pagedVersion(c *gin.Context) bool {
+	return c.GetString("api_version") == "v2"
+}
+
+// parseSelection reads ?fields= and ?include=. Relations in spec.Default are
+// loaded when include is absent, except in v2 where they are opt-in.
+func (h *Handler) parseSelection(c *gin.Context, spec sparse.Spec) (sparse.Selection, bool) {
+	if c.GetString("api_version") == "v2" {
+		spec.Default = nil
+	}
+	sel, err := sparse.Parse(h.db, c.Request.URL.Query(), spec)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return sel, false
+	}
+	return sel, true
+}
+
+func renderSelection(c *gin.Context, sel sparse.Selection, v interface{}) {
+	body, err := sel.Render(v)
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, body)
+}
+
+func setListMeta(c *gin.Context, meta query.Meta) {
+	c.Header("X-Total-Count", strconv.FormatInt(meta.Total, 10))
+	if meta.Limit > 0 {
+		c.Header("X-Limit", strconv.Itoa(meta.Limit))
+	}
+	c.Header("X-Offset", strconv.Itoa(meta.Offset))
+	if meta.NextCursor != "" {
+		c.Header("X-Next-Cursor", meta.NextCursor)
+	}
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+)
+
+func currentRole(c *gin.Context) models.Role {
+	role, _ := c.Get("role")
+	name, _ := role.(string)
+	return models.Role(name)
+}
+
+func currentUserID(c *gin.Context) uint {
+	id, _ := c.Get("user_id")
+	userID, _ := id.(uint)
+	return userID
+}
+
+func bindMergePatch(c *gin.Context, allow patch.Allowlist) (map[string]interface{}, bool) {
+	body, err := c.GetRawData()
+	if err != nil {
+		problem.BadRequest(c, "Request body could not be read")
+		return nil, false
+	}
+
+	doc, err := patch.Decode(body)
+	if err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeMalformedJSON, err.Error())
+		return nil, false
+	}
+
+	updates, err := patch.Apply(doc, allow, currentRole(c))
+	if err != nil {
+		problem.Write(c, patchProblem(err))
+		return nil, false
+	}
+	return updates, true
+}
+
+func patchProblem(err error) *problem.Problem {
+	var fieldErr *patch.FieldError
+	if !errors.As(err, &fieldErr) {
+		return problem.New(http.StatusBadRequest, problem.CodeBadRequest, err.Error())
+	}
+	if fieldErr.Forbidden {
+		p := problem.New(http.StatusForbidden, problem.CodeForbidden, err.Error())
+		p.Errors = []problem.FieldError{{Field: fieldErr.Field, Code: "forbidden", Message: fieldErr.Message}}
+		return p
+	}
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Request body failed validation")
+	p.Errors = []problem.FieldError{{Field: fieldErr.Field, Code: "invalid", Message: fieldErr.Message}}
+	return p
+}
+
+// patchDoc is a merge-patch document built from something other than a
+// request body, so GraphQL and gRPC writes go through the same allowlists as
+// PATCH requests.
+type patchDoc map[string]interface{}
+
+// applyPatchDoc writes doc to record. When updatedAt is set, the write only
+// lands if the record still has that updated_at, the way claimWrite guards
+// If-Match requests.
+func (h *Handler) applyPatchDoc(ctx context.Context, record interface{}, updatedAt *time.Time, doc patchDoc, allow patch.Allowlist, prepare func(map[string]interface{}) error) error {
+	updates, err := patch.Apply(doc, allow, viewerFrom(ctx).Role)
+	if err != nil {
+		return patchProblem(err)
+	}
+	if prepare != nil {
+		if err := prepare(updates); err != nil {
+			return err
+		}
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+
+	write := h.db.WithContext(ctx).Model(record)
+	if updatedAt != nil {
+		write = write.Where("updated_at = ?", *updatedAt)
+	}
+	result := write.Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if updatedAt != nil && result.RowsAffected == 0 {
+		return problem.New(http.StatusPreconditionFailed, problem.CodePreconditionFailed, errPreconditionFailed.Error())
+	}
+	return nil
+}
@@ -0,0 +1,548 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mokan/flame-crm-backend/internal/changefeed"
+	"github.com/mokan/flame-crm-backend/internal/export"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/openapi"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/search"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"github.com/mokan/flame-crm-backend/internal/sparse"
+)
+
+// redocBundle is pinned to an exact release so that /docs never runs a script
+// nobody reviewed. Bump it deliberately.
+const redocBundle = "https://cdn.redoc.ly/redoc/v2.1.5/bundles/redoc.standalone.js"
+
+const docsPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>Flame CRM API</title>
+<meta charset="utf-8"/>
+<meta name="viewport" content="width=device-width, initial-scale=1">
+</head>
+<body>
+<redoc spec-url="/openapi.json"></redoc>
+<script src="` + redocBundle + `" crossorigin="anonymous"></script>
+</body>
+</html>`
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Document
+)
+
+func OpenAPIDocument() *openapi.Document {
+	specOnce.Do(func() {
+		spec = buildOpenAPI()
+	})
+	return spec
+}
+
+func OpenAPISpec(c *gin.Context) {
+	c.JSON(http.StatusOK, OpenAPIDocument())
+}
+
+func APIDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsPage))
+}
+
+func buildOpenAPI() *openapi.Document {
+	b := openapi.NewBuilder(openapi.Info{
+		Title:       "Flame CRM API",
+		Version:     "1.0.0",
+		Description: "Companies, customers, users and sales funnels. Errors use application/problem+json (RFC 7807).",
+	})
+
+	b.Define("FieldError", nil, &openapi.Schema{
+		Type:     "object",
+		Required: []string{"field", "code", "message"},
+		Properties: map[string]*openapi.Schema{
+			"field":   {Type: "string"},
+			"code":    {Type: "string"},
+			"message": {Type: "string"},
+		},
+	})
+	b.Define("Problem", problem.Problem{}, &openapi.Schema{
+		Type:                 "object",
+		Required:             []string{"type", "title", "status", "code"},
+		AdditionalProperties: true,
+		Properties: map[string]*openapi.Schema{
+			"type":     {Type: "string"},
+			"title":    {Type: "string"},
+			"status":   {Type: "integer"},
+			"code":     {Type: "string"},
+			"detail":   {Type: "string"},
+			"instance": {Type: "string"},
+			"errors":   {Type: "array", Items: openapi.Ref("FieldError")},
+		},
+	})
+	b.Define("Role", models.Role(""), &openapi.Schema{
+		Type: "string",
+		Enum: []interface{}{string(models.RoleAdmin), string(models.RoleSales), string(models.RoleHeadOfSales)},
+	})
+
+	b.Define("OptionalInteger", optionalInt{}, &openapi.Schema{
+		Type:        []string{"integer", "null"},
+		Minimum:     new(float64),
+		Description: "Omit to keep the current value; null clears it.",
+	})
+
+	for _, route := range versionedRoutes(apiRoutes(), apiV2Changes()) {
+		b.Add(route)
+	}
+	return b.Document()
+}
+
+func apiRoutes() []openapi.Route {
+	errs := func(codes ...int) []int { return codes }
+	id := http.StatusNotFound
+	bad := http.StatusBadRequest
+
+	read := openapi.Route{Headers: etagHeaders(), Extra: map[int]string{http.StatusNotModified: "Not Modified"}}
+	write := openapi.Route{Headers: etagHeaders(), Query: []openapi.Parameter{ifMatchHeader()}}
+
+	return []openapi.Route{
+		{Method: "GET", Path: "/openapi.json", Tag: "meta", Summary: "OpenAPI document", Public: true, Response: map[string]interface{}{}},
+		{Method: "GET", Path: "/docs", Tag: "meta", Summary: "API reference UI", Public: true, Content: map[string]*openapi.Schema{"text/html": {Type: "string"}}},
+
+		{Method: "POST", Path: "/register", Tag: "auth", Summary: "Register a user", Public: true, Body: RegisterInput{}, Response: RegisterResponse{}, Errors: errs(bad, http.StatusConflict)},
+		{Method: "POST", Path: "/login", Tag: "auth", Summary: "Log in and receive a JWT", Public: true, Body: LoginInput{}, Response: LoginResponse{}, Errors: errs(bad, http.StatusUnauthorized)},
+
+		idempotent(openapi.Route{Method: "POST", Path: "/graphql", Tag: "graphql", Summary: "GraphQL queries and mutations over companies, users, customers and funnels", Body: GraphQLRequest{}, Response: graphql.Response{}, Errors: errs(bad)}),
+
+		{Method: "GET", Path: "/api/api-usage", Tag: "meta", Summary: "Requests per API version and route since the server started", Response: []middleware.VersionUsage{}, Errors: errs(http.StatusForbidden)},
+
+		{Method: "GET", Path: "/api/search", Tag: "search", Summary: "Search companies, customers and users", Response: search.Results{}, Errors: errs(bad), Query: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			{Name: "types", In: "query", Description: "Comma-separated subset of companies, customers, users.", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1)}},
+		}},
+
+		{Method: "GET", Path: "/api/changes", Tag: "changes", Summary: "Records created, updated or deleted since a cursor, in commit order", Response: ChangeFeed{}, Errors: errs(bad), Query: []openapi.Parameter{
+			{Name: "cursor", In: "query", Description: "next_cursor from the previous page. Omit to start from the beginning.", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "types", In: "query", Description: "Comma-separated subset of companies, customers, users, funnels.", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(changefeed.MaxLimit)}},
+		}},
+
+		selectable(listRoute("/api/companies", "companies", companyQuerySpec, []models.Company{}), companySelection),
+		exportRoute("/api/companies/export", "companies", companyExport),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/companies/:id", Tag: "companies", Summary: "Get a company", Response: models.Company{}, Errors: errs(id)}), companySelection),
+		idempotent(with(openapi.Route{Headers: etagHeaders()}, openapi.Route{Method: "POST", Path: "/api/companies", Tag: "companies", Summary: "Create a company", Body: models.CompanyInput{}, Response: models.Company{}, Errors: errs(bad, http.StatusConflict)})),
+		with(write, openapi.Route{Method: "PUT", Path: "/api/companies/:id", Tag: "companies", Summary: "Replace a company", Body: models.CompanyInput{}, Response: models.Company{}, Errors: errs(bad, id, http.StatusPreconditionFailed)}),
+		with(write, patchRoute("/api/companies/:id", "companies", "Update company fields", service.CompanyFields, models.Company{})),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/companies/external/:source/:external_id", Tag: "companies", Summary: "Get a company by external ID", Response: models.Company{}, Query: externalKeyParams(), Errors: errs(bad, id)}), companySelection),
+		upsertRoute(openapi.Route{Method: "PUT", Path: "/api/companies/external/:source/:external_id", Tag: "companies", Summary: "Create or update a company by external ID", Body: models.CompanyInput{}, Response: models.Company{}, Errors: errs(bad, http.StatusConflict)}),
+		idempotent(bulkRoute("/api/companies/bulk", "companies", "companies")),
+		idempotent(importRoute("/api/companies/import", "companies", service.ImportEntityCompanies)),
+
+		selectable(listRoute("/api/users", "users", userQuerySpec, []models.User{}), userSelection),
+		exportRoute("/api/users/export", "users", userExport),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/users/:id", Tag: "users", Summary: "Get a user", Response: models.User{}, Errors: errs(id)}), userSelection),
+		idempotent(with(openapi.Route{Headers: etagHeaders()}, openapi.Route{Method: "POST", Path: "/api/users", Tag: "users", Summary: "Create a user", Body: CreateUserInput{}, Response: models.User{}, Errors: errs(bad, http.StatusForbidden, http.StatusConflict)})),
+		with(write, asPut(patchRoute("/api/users/:id", "users", "Update user fields", service.UserFields, models.User{}))),
+		with(write, patchRoute("/api/users/:id", "users", "Update user fields", service.UserFields, models.User{})),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/users/external/:source/:external_id", Tag: "users", Summary: "Get a user by external ID", Response: models.User{}, Query: externalKeyParams(), Errors: errs(bad, id)}), userSelection),
+		upsertRoute(openapi.Route{Method: "PUT", Path: "/api/users/external/:source/:external_id", Tag: "users", Summary: "Create or update a user by external ID", Body: UpsertUserInput{}, Response: models.User{}, Errors: errs(bad, http.StatusForbidden, http.StatusConflict)}),
+
+		selectable(listRoute("/api/customers", "customers", customerQuerySpec, []models.Customer{}, pipelineParam()), customerSelection),
+		exportRoute("/api/customers/export", "customers", customerExport, pipelineParam()),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/customers/:id", Tag: "customers", Summary: "Get a customer", Response: models.Customer{}, Errors: errs(id)}), customerSelection),
+		idempotent(with(openapi.Route{Headers: etagHeaders()}, openapi.Route{Method: "POST", Path: "/api/customers", Tag: "customers", Summary: "Create a customer", Body: models.CreateCustomerInput{}, Response: models.Customer{}, Errors: errs(bad, http.StatusConflict)})),
+		with(write, openapi.Route{Method: "PUT", Path: "/api/customers/:id", Tag: "customers", Summary: "Replace a customer", Body: models.UpdateCustomerInput{}, Response: models.Customer{}, Errors: errs(bad, id, http.StatusPreconditionFailed)}),
+		with(write, patchRoute("/api/customers/:id", "customers", "Update customer fields", service.CustomerFields, models.Customer{})),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/customers/external/:source/:external_id", Tag: "customers", Summary: "Get a customer by external ID", Response: models.Customer{}, Query: externalKeyParams(), Errors: errs(bad, id)}), customerSelection),
+		upsertRoute(openapi.Route{Method: "PUT", Path: "/api/customers/external/:source/:external_id", Tag: "customers", Summary: "Create or update a customer by external ID", Body: models.UpsertCustomerInput{}, Response: models.Customer{}, Errors: errs(bad, http.StatusConflict)}),
+		{Method: "POST", Path: "/api/customers/enroll", Tag: "customers", Summary: "Apply enrollment rules to customers", Response: EnrollResult{}, Query: []openapi.Parameter{
+			{Name: "rule_id", In: "query", Description: "Only apply this rule.", Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "company_defaults", In: "query", Description: "Set to false to skip company default funnels.", Schema: &openapi.Schema{Type: "boolean"}},
+		}},
+		idempotent(bulkRoute("/api/customers/bulk", "customers", "customers")),
+		idempotent(importRoute("/api/customers/import", "customers", service.ImportEntityCustomers)),
+
+		selectable(openapi.Route{Method: "GET", Path: "/api/imports/:id", Tag: "imports", Summary: "Get an import job", Response: models.ImportJob{}, Errors: errs(id)}, importSelection),
+		{Method: "GET", Path: "/api/imports/:id/errors", Tag: "imports", Summary: "Download rejected rows as CSV", Content: map[string]*openapi.Schema{"text/csv": {Type: "string"}}, Errors: errs(id)},
+
+		idempotent(bulkRoute("/api/tags/bulk", "tags", "tags")),
+
+		{Method: "GET", Path: "/api/views", Tag: "views", Summary: "List saved views visible to the caller", Response: []models.SavedView{}, Query: []openapi.Parameter{
+			{Name: "resource", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []interface{}{"companies", "customers", "users", "funnels"}}},
+		}},
+		{Method: "GET", Path: "/api/views/:id", Tag: "views", Summary: "Get a saved view", Response: models.SavedView{}, Errors: errs(id)},
+		{Method: "GET", Path: "/api/views/:id/run", Tag: "views", Summary: "Run a saved view; request parameters override the saved ones", Errors: errs(bad, id), Query: []openapi.Parameter{
+			{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(query.MaxLimit)}},
+			{Name: "offset", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(0)}},
+			{Name: "cursor", In: "query", Schema: &openapi.Schema{Type: "string"}},
+		}, Content: map[string]*openapi.Schema{"application/json": {Description: "The response of the list endpoint for the view's resource."}}, Headers: listHeaders()},
+		idempotent(openapi.Route{Method: "POST", Path: "/api/views", Tag: "views", Summary: "Save a view", Body: models.SavedView{}, Response: models.SavedView{}, Errors: errs(bad)}),
+		{Method: "PUT", Path: "/api/views/:id", Tag: "views", Summary: "Replace a saved view", Body: models.SavedView{}, Response: models.SavedView{}, Errors: errs(bad, http.StatusForbidden, id)},
+		{Method: "DELETE", Path: "/api/views/:id", Tag: "views", Summary: "Delete a saved view", Response: MessageResponse{}, Errors: errs(http.StatusForbidden, id)},
+
+		selectable(openapi.Route{Method: "GET", Path: "/api/enrollment-rules", Tag: "enrollment", Summary: "List enrollment rules", Response: []models.EnrollmentRule{}}, enrollmentRuleSelection),
+		idempotent(openapi.Route{Method: "POST", Path: "/api/enrollment-rules", Tag: "enrollment", Summary: "Create an enrollment rule", Body: models.EnrollmentRule{}, Response: models.EnrollmentRule{}, Errors: errs(bad)}),
+		{Method: "PUT", Path: "/api/enrollment-rules/:id", Tag: "enrollment", Summary: "Replace an enrollment rule", Body: models.EnrollmentRule{}, Response: models.EnrollmentRule{}, Errors: errs(bad, id)},
+		{Method: "DELETE", Path: "/api/enrollment-rules/:id", Tag: "enrollment", Summary: "Delete an enrollment rule", Response: MessageResponse{}, Errors: errs(id)},
+
+		selectable(listRoute("/api/funnels", "funnels", funnelQuerySpec, []models.Funnel{}), funnelSelection),
+		exportRoute("/api/funnels/export", "funnels", funnelExport),
+		selectable(with(read, openapi.Route{Method: "GET", Path: "/api/funnels/:id", Tag: "funnels", Summary: "Get a funnel", Response: models.Funnel{}, Errors: errs(id)}), funnelSelection),
+		idempotent(with(openapi.Route{Headers: etagHeaders()}, openapi.Route{Method: "POST", Path: "/api/funnels", Tag: "funnels", Summary: "Create a funnel", Body: CreateFunnelInput{}, Response: models.Funnel{}, Errors: errs(bad)})),
+		with(write, openapi.Route{Method: "PUT", Path: "/api/funnels/:id", Tag: "funnels", Summary: "Replace a funnel", Body: UpdateFunnelInput{}, Response: models.Funnel{}, Errors: errs(bad, http.StatusForbidden, id, http.StatusPreconditionFailed)}),
+		with(write, patchRoute("/api/funnels/:id", "funnels", "Update funnel fields", service.FunnelFields, models.Funnel{})),
+		{Method: "DELETE", Path: "/api/funnels/:id", Tag: "funnels", Summary: "Delete a funnel, optionally moving its references", Response: DeleteFunnelResult{}, Errors: errs(bad, id, http.StatusConflict), Query: []openapi.Parameter{
+			{Name: "target_funnel_id", In: "query", Description: "Funnel that takes over customers, companies and memberships.", Schema: &openapi.Schema{Type: "integer"}},
+		}},
+		{Method: "GET", Path: "/api/funnels/:id/board", Tag: "board", Summary: "Kanban board for a funnel", Response: FunnelBoard{}, Errors: errs(id), Query: boardParams()},
+		{Method: "GET", Path: "/api/funnels/:id/board/columns/:stage_id", Tag: "board", Summary: "One board column", Response: BoardColumn{}, Errors: errs(id), Query: boardParams()},
+		{Method: "POST", Path: "/api/funnels/:id/board/move", Tag: "board", Summary: "Move a card to another stage", Body: MoveBoardCardInput{}, Response: MoveBoardCardResult{}, Errors: errs(bad, id, http.StatusConflict, http.StatusPreconditionFailed)},
+
+		selectable(openapi.Route{Method: "GET", Path: "/api/funnel-versions", Tag: "funnel-versions", Summary: "List funnel versions", Response: []models.FunnelVersion{}}, funnelVersionListSelection),
+		selectable(openapi.Route{Method: "GET", Path: "/api/funnel-versions/:id", Tag: "funnel-versions", Summary: "Get a funnel version", Response: models.FunnelVersion{}, Errors: errs(id)}, funnelVersionSelection),
+		idempotent(openapi.Route{Method: "POST", Path: "/api/funnel-versions/publish", Tag: "funnel-versions", Summary: "Publish the draft funnel version", Response: models.FunnelVersion{}, Errors: errs(bad, http.StatusForbidden)}),
+		{Method: "POST", Path: "/api/funnel-versions/:id/migrate", Tag: "funnel-versions", Summary: "Migrate customers to the active version", Body: MigrateFunnelVersionInput{}, Response: MigrateFunnelVersionResult{}, Errors: errs(bad, http.StatusForbidden, id)},
+	}
+}
+
+// apiV2Changes documents the routes whose v2 shape differs from v1.
+func apiV2Changes() []openapi.Route {
+	customers := selectable(listRoute("/api/v2/customers", "customers", customerQuerySpec, CustomerPage{}, pipelineParam()), customerSelection)
+	customers.Headers = nil
+	return []openapi.Route{customers}
+}
+
+// versionedRoutes expands the v1 routes, written under /api, into the
+// /api/v1 and /api/v2 namespaces and the deprecated unversioned /api alias.
+func versionedRoutes(v1, v2Changes []openapi.Route) []openapi.Route {
+	changed := map[string]openapi.Route{}
+	for _, route := range v2Changes {
+		changed[route.Method+" "+strings.TrimPrefix(route.Path, "/api/v2")] = route
+	}
+
+	var routes []openapi.Route
+	for _, route := range v1 {
+		rest, versioned := strings.CutPrefix(route.Path, "/api/")
+		if !versioned {
+			routes = append(routes, route)
+			continue
+		}
+		rest = "/" + rest
+
+		routes = append(routes, deprecatedRoute(route, true))
+
+		current := route
+		current.Path = "/api/v1" + rest
+		v2, ok := changed[route.Method+" "+rest]
+		if ok {
+			current = deprecatedRoute(current, false)
+		}
+		routes = append(routes, current)
+
+		if !ok {
+			v2 = route
+			v2.Path = "/api/v2" + rest
+		}
+		routes = append(routes, v2)
+	}
+	return routes
+}
+
+func deprecatedRoute(route openapi.Route, sunset bool) openapi.Route {
+	route.Deprecated = true
+	headers := map[string]*openapi.Header{
+		"Deprecation": {Description: "When this route was deprecated, as @<unix seconds>.", Schema: &openapi.Schema{Type: "string"}},
+		"Link":        {Description: "The successor-version of this route.", Schema: &openapi.Schema{Type: "string"}},
+	}
+	if sunset {
+		headers["Sunset"] = &openapi.Header{Description: "When this route stops being served.", Schema: &openapi.Schema{Type: "string"}}
+	}
+	for name, header := range route.Headers {
+		headers[name] = header
+	}
+	route.Headers = headers
+	return route
+}
+
+// PUT on users is served by the same merge-patch handler as PATCH.
+func asPut(route openapi.Route) openapi.Route {
+	route.Method = "PUT"
+	return route
+}
+
+func with(base, route openapi.Route) openapi.Route {
+	if route.Headers == nil {
+		route.Headers = base.Headers
+	}
+	if route.Extra == nil {
+		route.Extra = base.Extra
+	}
+	route.Query = append(route.Query, base.Query...)
+	return route
+}
+
+// selectable documents the fields and include parameters allowed by spec.
+func selectable(route openapi.Route, spec sparse.Spec) openapi.Route {
+	route.Query = append(route.Query, openapi.Parameter{Name: "fields", In: "query", Description: "Comma-separated fields to return besides ID: " + strings.Join(spec.Fields, ", ") + ".", Schema: &openapi.Schema{Type: "string"}})
+	if len(spec.Includes) > 0 {
+		names := make([]string, 0, len(spec.Includes))
+		for name := range spec.Includes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		description := "Comma-separated relations to load: " + strings.Join(names, ", ") + "."
+		if len(spec.Default) > 0 {
+			description += " Without this parameter v1 loads " + strings.Join(spec.Default, ", ") + " and v2 loads none."
+		}
+		route.Query = append(route.Query, openapi.Parameter{Name: "include", In: "query", Description: description, Schema: &openapi.Schema{Type: "string"}})
+	}
+	if !slices.Contains(route.Errors, http.StatusBadRequest) {
+		route.Errors = append(route.Errors, http.StatusBadRequest)
+	}
+	return route
+}
+
+// idempotent documents the Idempotency-Key header honoured on creating routes.
+func idempotent(route openapi.Route) openapi.Route {
+	route.Query = append(route.Query, openapi.Parameter{Name: middleware.IdempotencyKeyHeader, In: "header", Description: "Retrying with the same key and body replays the first response; reusing it for a different request fails with 422.", Schema: &openapi.Schema{Type: "string", MaxLength: intPtr(255)}})
+	for _, code := range []int{http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity} {
+		if !slices.Contains(route.Errors, code) {
+			route.Errors = append(route.Errors, code)
+		}
+	}
+	headers := map[string]*openapi.Header{
+		middleware.IdempotentReplayedHeader: {Description: "Set to true when the response is a replay of an earlier request.", Schema: &openapi.Schema{Type: "string"}},
+	}
+	for name, header := range route.Headers {
+		headers[name] = header
+	}
+	route.Headers = headers
+	return route
+}
+
+func externalKeyParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		{Name: "source", In: "path", Required: true, Description: "The system the record comes from, such as erp.", Schema: &openapi.Schema{Type: "string", MaxLength: intPtr(maxExternalKeyLength)}},
+		{Name: "external_id", In: "path", Required: true, Description: "The record's ID in that system.", Schema: &openapi.Schema{Type: "string", MaxLength: intPtr(maxExternalKeyLength)}},
+	}
+}
+
+// upsertRoute documents a PUT that answers 201 when it created the record.
+func upsertRoute(route openapi.Route) openapi.Route {
+	route.Query = append(route.Query, externalKeyParams()...)
+	route.Headers = etagHeaders()
+	route.Extra = map[int]string{http.StatusCreated: "Created"}
+	return route
+}
+
+func etagHeaders() map[string]*openapi.Header {
+	return map[string]*openapi.Header{
+		"ETag": {Description: "Version of the resource, for If-Match and If-None-Match.", Schema: &openapi.Schema{Type: "string"}},
+	}
+}
+
+func ifMatchHeader() openapi.Parameter {
+	return openapi.Parameter{Name: "If-Match", In: "header", Description: "Reject the write with 412 unless the resource still has this ETag.", Schema: &openapi.Schema{Type: "string"}}
+}
+
+func pipelineParam() openapi.Parameter {
+	return openapi.Parameter{Name: "pipeline", In: "query", Description: "Only customers with a membership in this pipeline.", Schema: &openapi.Schema{Type: "string"}}
+}
+
+func boardParams() []openapi.Parameter {
+	return []openapi.Parameter{
+		{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(maxBoardColumnLimit)}},
+		{Name: "offset", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(0)}},
+	}
+}
+
+func listRoute(path, tag string, spec query.Spec, response interface{}, extra ...openapi.Parameter) openapi.Route {
+	return openapi.Route{
+		Method:   "GET",
+		Path:     path,
+		Tag:      tag,
+		Summary:  "List " + tag,
+		Query:    append(listParams(spec), extra...),
+		Response: response,
+		Errors:   []int{http.StatusBadRequest},
+		Headers:  listHeaders(),
+	}
+}
+
+func listHeaders() map[string]*openapi.Header {
+	return map[string]*openapi.Header{
+		"X-Total-Count": {Description: "Rows matching the filters.", Schema: &openapi.Schema{Type: "integer"}},
+		"X-Limit":       {Schema: &openapi.Schema{Type: "integer"}},
+		"X-Offset":      {Schema: &openapi.Schema{Type: "integer"}},
+		"X-Next-Cursor": {Description: "Cursor for the next page when cursor pagination is used.", Schema: &openapi.Schema{Type: "string"}},
+	}
+}
+
+func exportRoute(path, tag string, spec exportSpec, extra ...openapi.Parameter) openapi.Route {
+	keys := make([]string, len(spec.Columns))
+	for i, column := range spec.Columns {
+		keys[i] = column.Key
+	}
+	params := append(listParams(spec.Query),
+		openapi.Parameter{Name: "format", In: "query", Schema: &openapi.Schema{Type: "string", Enum: []interface{}{export.FormatCSV, export.FormatXLSX}}},
+		openapi.Parameter{Name: "columns", In: "query", Description: "Comma-separated columns: " + strings.Join(keys, ", ") + ". Defaults to " + strings.Join(spec.Defaults, ", ") + ".", Schema: &openapi.Schema{Type: "string"}},
+	)
+	return openapi.Route{
+		Method:  "GET",
+		Path:    path,
+		Tag:     tag,
+		Summary: "Export " + tag + " as CSV or XLSX",
+		Query:   append(params, extra...),
+		Content: map[string]*openapi.Schema{
+			"text/csv":                            {Type: "string"},
+			export.ContentType(export.FormatXLSX): {Type: "string", Format: "binary"},
+		},
+		Errors: []int{http.StatusBadRequest},
+	}
+}
+
+func listParams(spec query.Spec) []openapi.Parameter {
+	names := make([]string, 0, len(spec.Fields))
+	for name := range spec.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	params := []openapi.Parameter{
+		{Name: "limit", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(1), Maximum: floatPtr(query.MaxLimit)}},
+		{Name: "offset", In: "query", Schema: &openapi.Schema{Type: "integer", Minimum: floatPtr(0)}},
+		{Name: "cursor", In: "query", Description: "Opaque cursor from X-Next-Cursor; pass an empty value for the first page.", Schema: &openapi.Schema{Type: "string"}},
+		{Name: "sort", In: "query", Description: "Comma-separated fields, prefix with - for descending: " + strings.Join(names, ", ") + ".", Schema: &openapi.Schema{Type: "string"}},
+	}
+
+	explode := true
+	for _, name := range names {
+		value := filterSchema(spec.Fields[name].Type)
+		operators := map[string]*openapi.Schema{}
+		for _, op := range []string{"eq", "ne", "gt", "gte", "lt", "lte"} {
+			operators[op] = value
+		}
+		operators["in"] = &openapi.Schema{Type: "string", Description: "Comma-separated values."}
+		if spec.Fields[name].Type == query.String {
+			operators["contains"] = &openapi.Schema{Type: "string"}
+		}
+		params = append(params, openapi.Parameter{
+			Name:        name,
+			In:          "query",
+			Description: "Filter as " + name + "[op]=value; a bare " + name + "=value means eq.",
+			Style:       "deepObject",
+			Explode:     &explode,
+			Schema:      &openapi.Schema{Type: "object", Properties: operators},
+		})
+	}
+	return params
+}
+
+func filterSchema(t query.FieldType) *openapi.Schema {
+	switch t {
+	case query.Number:
+		return &openapi.Schema{Type: "integer"}
+	case query.Bool:
+		return &openapi.Schema{Type: "boolean"}
+	case query.Time:
+		return &openapi.Schema{Type: "string", Format: "date-time"}
+	}
+	return &openapi.Schema{Type: "string"}
+}
+
+func patchRoute(path, tag, summary string, allow patch.Allowlist, response interface{}) openapi.Route {
+	properties := make(map[string]*openapi.Schema, len(allow))
+	for name, field := range allow {
+		var schema *openapi.Schema
+		switch field.Kind {
+		case patch.Uint:
+			schema = &openapi.Schema{Type: "integer", Minimum: floatPtr(0)}
+		case patch.Int:
+			schema = &openapi.Schema{Type: "integer"}
+		case patch.Bool:
+			schema = &openapi.Schema{Type: "boolean"}
+		default:
+			schema = &openapi.Schema{Type: "string"}
+		}
+		schema = openapi.WithRules(schema, strings.TrimPrefix(field.Rules, "omitempty,"))
+		if field.Nullable {
+			schema = openapi.Nullable(schema)
+		}
+		if len(field.Roles) > 0 {
+			roles := make([]string, len(field.Roles))
+			for i, role := range field.Roles {
+				roles[i] = string(role)
+			}
+			schema.Description = "Writable by: " + strings.Join(roles, ", ") + "."
+		}
+		properties[name] = schema
+	}
+
+	return openapi.Route{
+		Method:     "PATCH",
+		Path:       path,
+		Tag:        tag,
+		Summary:    summary,
+		BodyType:   "application/merge-patch+json",
+		BodySchema: &openapi.Schema{Type: "object", Properties: properties, AdditionalProperties: false},
+		Response:   response,
+		Errors:     []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusPreconditionFailed},
+	}
+}
+
+func bulkRoute(path, tag, entity string) openapi.Route {
+	return openapi.Route{
+		Method:   "POST",
+		Path:     path,
+		Tag:      tag,
+		Summary:  "Create, update and delete " + entity + " in one request",
+		Body:     BulkInput{},
+		Response: BulkResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound, http.StatusConflict, http.StatusFailedDependency},
+	}
+}
+
+func importRoute(path, tag, entity string) openapi.Route {
+	keys := make([]interface{}, len(service.ImportDedupeKeys[entity]))
+	for i, key := range service.ImportDedupeKeys[entity] {
+		keys[i] = key
+	}
+
+	return openapi.Route{
+		Method:   "POST",
+		Path:     path,
+		Tag:      tag,
+		Summary:  "Import " + entity + " from CSV",
+		BodyType: "multipart/form-data",
+		BodySchema: &openapi.Schema{
+			Type:     "object",
+			Required: []string{"file"},
+			Properties: map[string]*openapi.Schema{
+				"file":       {Type: "string", Format: "binary"},
+				"mapping":    {Type: "string", Description: "JSON object of CSV column to field. Fields: " + strings.Join(service.ImportFields[entity], ", ") + "."},
+				"dedupe_key": {Type: "string", Enum: keys},
+				"dry_run":    {Type: "boolean"},
+			},
+		},
+		Response: ImportResponse{},
+		Errors:   []int{http.StatusBadRequest},
+	}
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+func intPtr(n int) *int {
+	return &n
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/openapi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupSpecRouter(userID uint) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", string(models.RoleAdmin))
+		c.Next()
+	})
+	r.POST("/login", testHandler.Login)
+	r.POST("/register", testHandler.Register)
+	api := r.Group("/api")
+	api.GET("/search", testHandler.Search)
+	api.GET("/companies", testHandler.GetCompanies)
+	api.GET("/companies/:id", testHandler.GetCompany)
+	api.POST("/companies", testHandler.CreateCompany)
+	api.PATCH("/companies/:id", testHandler.PatchCompany)
+	api.GET("/users", testHandler.GetUsers)
+	api.GET("/users/:id", testHandler.GetUser)
+	api.GET("/customers", testHandler.GetCustomers)
+	api.POST("/customers", testHandler.CreateCustomer)
+	api.PUT("/customers/:id", testHandler.UpdateCustomer)
+	api.POST("/customers/bulk", testHandler.BulkCustomers)
+	api.GET("/funnels", testHandler.GetFunnels)
+	api.GET("/funnels/:id", testHandler.GetFunnel)
+	api.POST("/funnels", testHandler.CreateFunnel)
+	api.DELETE("/funnels/:id", testHandler.DeleteFunnel)
+	api.GET("/funnels/:id/board", testHandler.GetFunnelBoard)
+	api.POST("/customers/enroll", testHandler.EnrollCustomers)
+	api.GET("/enrollment-rules", testHandler.GetEnrollmentRules)
+	api.POST("/funnel-versions/publish", testHandler.PublishFunnelVersion)
+	api.GET("/funnel-versions", testHandler.GetFunnelVersions)
+	api.GET("/api-usage", GetAPIUsage)
+	r.GET("/api/v2/customers", testHandler.GetCustomersV2)
+	return r
+}
+
+func assertMatchesSpec(t *testing.T, method, route string, w *httptest.ResponseRecorder) {
+	t.Helper()
+	doc := OpenAPIDocument()
+
+	op := doc.Paths[openapi.PathFromGin(route)][strings.ToLower(method)]
+	require.NotNil(t, op, "%s %s is not documented", method, route)
+	response := op.Responses[strconv.Itoa(w.Code)]
+	require.NotNil(t, response, "%s %s returned undocumented status %d: %s", method, route, w.Code, w.Body.String())
+
+	contentType, _, _ := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	media := response.Content[contentType]
+	require.NotNil(t, media, "%s %s returned undocumented content type %q", method, route, contentType)
+	assert.NoError(t, doc.ValidateJSON(media.Schema, w.Body.Bytes()), "%s %s: %s", method, route, w.Body.String())
+
+	for name := range w.Header() {
+		if strings.HasPrefix(name, "X-") || name == "Etag" {
+			_, documented := response.Headers[http.CanonicalHeaderKey(name)]
+			if name == "Etag" {
+				_, documented = response.Headers["ETag"]
+			}
+			assert.True(t, documented, "%s %s sets undocumented header %s", method, route, name)
+		}
+	}
+}
+
+func TestResponsesMatchOpenAPISpec(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+	r := setupSpecRouter(user.ID)
+
+	check := func(method, route, path string, body interface{}) *httptest.ResponseRecorder {
+		w := performRequest(r, method, path, body)
+		assertMatchesSpec(t, method, route, w)
+		return w
+	}
+
+	check("POST", "/register", "/register", RegisterInput{Name: "New", Email: "new@example.com", Password: "secret1"})
+	check("POST", "/register", "/register", RegisterInput{Name: "New", Email: "new@example.com", Password: "secret1"})
+	check("POST", "/login", "/login", LoginInput{Email: "new@example.com", Password: "secret1"})
+	check("POST", "/login", "/login", LoginInput{Email: "new@example.com", Password: "wrong"})
+	check("POST", "/login", "/login", map[string]string{"email": "nope"})
+
+	limit := 2
+	lead := models.Funnel{Name: "Lead"}
+	won := models.Funnel{Name: "Won", WIPLimit: &limit, WIPMode: models.WIPModeWarn}
+	assert.NoError(t, testDB.Create(&lead).Error)
+	assert.NoError(t, testDB.Create(&won).Error)
+	assert.NoError(t, testDB.Model(&lead).Association("NextFunnels").Replace([]*models.Funnel{&won}))
+	tag := models.Tag{Name: "vip"}
+	assert.NoError(t, testDB.Create(&tag).Error)
+	customer := models.Customer{Name: "Ada", Email: "ada@example.com", CompanyID: company.ID, FunnelID: &lead.ID, Tags: []models.Tag{tag}}
+	assert.NoError(t, testDB.Create(&customer).Error)
+	assert.NoError(t, testDB.Create(&models.EnrollmentRule{Name: "All", Pipeline: "sales", FunnelID: lead.ID}).Error)
+
+	check("GET", "/api/companies", "/api/companies?sort=-name", nil)
+	check("GET", "/api/companies", "/api/companies?sort=bogus", nil)
+	check("GET", "/api/companies/:id", fmt.Sprintf("/api/companies/%d", company.ID), nil)
+	check("GET", "/api/companies/:id", "/api/companies/999999", nil)
+	check("POST", "/api/companies", "/api/companies", map[string]string{"name": "Acme"})
+	check("POST", "/api/companies", "/api/companies", map[string]string{})
+	check("GET", "/api/users", "/api/users?cursor=&limit=1", nil)
+	check("GET", "/api/users/:id", fmt.Sprintf("/api/users/%d", user.ID), nil)
+	check("GET", "/api/customers", "/api/customers", nil)
+	check("GET", "/api/v2/customers", "/api/v2/customers?limit=1", nil)
+	check("GET", "/api/v2/customers", "/api/v2/customers?sort=bogus", nil)
+	check("GET", "/api/api-usage", "/api/api-usage", nil)
+	check("PUT", "/api/customers/:id", fmt.Sprintf("/api/customers/%d", customer.ID), map[string]interface{}{"name": "Ada L", "funnel_id": won.ID, "tags": []string{"vip", "new"}})
+	check("PUT", "/api/customers/:id", fmt.Sprintf("/api/customers/%d", customer.ID), map[string]interface{}{"name": "Ada L", "funnel_id": 999999})
+	check("POST", "/api/customers", "/api/customers", map[string]interface{}{"name": "Grace", "company_id": company.ID})
+	check("POST", "/api/customers/bulk", "/api/customers/bulk", map[string]interface{}{
+		"mode":       BulkModeBestEffort,
+		"operations": []map[string]interface{}{{"op": "delete", "id": 999999}, {"op": "create", "data": map[string]interface{}{"name": "Bulk", "company_id": company.ID}}},
+	})
+	check("POST", "/api/customers/bulk", "/api/customers/bulk", map[string]interface{}{
+		"operations": []map[string]interface{}{{"op": "delete", "id": 999999}},
+	})
+	check("POST", "/api/customers/enroll", "/api/customers/enroll", nil)
+	check("GET", "/api/enrollment-rules", "/api/enrollment-rules", nil)
+	check("GET", "/api/search", "/api/search?q=ada", nil)
+	check("GET", "/api/search", "/api/search", nil)
+	check("GET", "/api/funnels", "/api/funnels", nil)
+	check("GET", "/api/funnels/:id", fmt.Sprintf("/api/funnels/%d", lead.ID), nil)
+	check("POST", "/api/funnels", "/api/funnels", CreateFunnelInput{Name: "Lost", WIPMode: "bogus"})
+	check("GET", "/api/funnels/:id/board", fmt.Sprintf("/api/funnels/%d/board", lead.ID), nil)
+	check("POST", "/api/funnel-versions/publish", "/api/funnel-versions/publish", nil)
+	check("GET", "/api/funnel-versions", "/api/funnel-versions", nil)
+	check("DELETE", "/api/funnels/:id", fmt.Sprintf("/api/funnels/%d", won.ID), nil)
+}
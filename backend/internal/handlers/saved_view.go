@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/sparse"
+)
+
+type viewResource struct {
+	query     query.Spec
+	selection sparse.Spec
+	list      func(*Handler, *gin.Context)
+}
+
+var viewResources = map[string]viewResource{
+	"companies": {companyQuerySpec, companySelection, (*Handler).GetCompanies},
+	"customers": {customerQuerySpec, customerSelection, (*Handler).GetCustomers},
+	"users":     {userQuerySpec, userSelection, (*Handler).GetUsers},
+	"funnels":   {funnelQuerySpec, funnelSelection, (*Handler).GetFunnels},
+}
+
+// viewValues turns a view into the query string of its list endpoint.
+func viewValues(view models.SavedView) url.Values {
+	values := url.Values{}
+	for key, value := range view.Filters {
+		values.Set(key, value)
+	}
+	if view.Sort != "" {
+		values.Set("sort", view.Sort)
+	}
+	if len(view.Columns) > 0 {
+		values.Set("fields", strings.Join(view.Columns, ","))
+	}
+	return values
+}
+
+// findVisibleView loads a view the current user owns or that is shared with
+// them.
+func (h *Handler) findVisibleView(c *gin.Context) (*models.SavedView, bool) {
+	user, err := h.store().Users().Get(currentUserID(c))
+	if err != nil {
+		problem.Internal(c, err)
+		return nil, false
+	}
+	id, ok := pathID(c)
+	if !ok {
+		problem.NotFound(c, "Saved view not found")
+		return nil, false
+	}
+	view, err := h.store().SavedViews().GetVisible(user, id)
+	if err != nil {
+		problem.NotFound(c, "Saved view not found")
+		return nil, false
+	}
+	return view, true
+}
+
+// findOwnedView loads a view the current user may change: their own, or any
+// view for admins.
+func (h *Handler) findOwnedView(c *gin.Context) (*models.SavedView, bool) {
+	view, ok := h.findVisibleView(c)
+	if !ok {
+		return nil, false
+	}
+	if view.UserID != currentUserID(c) && currentRole(c) != models.RoleAdmin {
+		problem.Forbidden(c, "You can only modify your own saved views")
+		return nil, false
+	}
+	return view, true
+}
+
+func (h *Handler) bindSavedView(c *gin.Context) (models.SavedView, bool) {
+	var input models.SavedView
+	if err := c.ShouldBindJSON(&input); err != nil {
+		problem.Bind(c, err)
+		return input, false
+	}
+	if input.Visibility == "" {
+		input.Visibility = models.ViewPrivate
+	}
+
+	resource := viewResources[input.Resource]
+	values := viewValues(input)
+	if _, err := query.Parse(values, resource.query); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return input, false
+	}
+	if _, err := sparse.Parse(h.db, values, resource.selection); err != nil {
+		problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+		return input, false
+	}
+	return input, true
+}
+
+func (h *Handler) GetSavedViews(c *gin.Context) {
+	user, err := h.store().Users().Get(currentUserID(c))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	views, err := h.store().SavedViews().Visible(user, c.Query("resource"))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+func (h *Handler) GetSavedView(c *gin.Context) {
+	view, ok := h.findVisibleView(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, view)
+}
+
+func (h *Handler) CreateSavedView(c *gin.Context) {
+	input, ok := h.bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	user, err := h.store().Users().Get(currentUserID(c))
+	if err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	input.UserID = user.ID
+	input.CompanyID = user.CompanyID
+
+	if err := h.store().SavedViews().Create(&input); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, input)
+}
+
+func (h *Handler) UpdateSavedView(c *gin.Context) {
+	view, ok := h.findOwnedView(c)
+	if !ok {
+		return
+	}
+	input, ok := h.bindSavedView(c)
+	if !ok {
+		return
+	}
+
+	input.Model = view.Model
+	input.UserID = view.UserID
+	input.CompanyID = view.CompanyID
+	if err := h.store().SavedViews().Save(&input); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, input)
+}
+
+func (h *Handler) DeleteSavedView(c *gin.Context) {
+	view, ok := h.findOwnedView(c)
+	if !ok {
+		return
+	}
+	if err := h.store().SavedViews().Delete(view); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, MessageResponse{Message: "Saved view deleted"})
+}
+
+// RunSavedView answers like the view's list endpoint called with the saved
+// parameters. Parameters on the request, such as limit or cursor, take
+// precedence over the saved ones.
+func (h *Handler) RunSavedView(c *gin.Context) {
+	view, ok := h.findVisibleView(c)
+	if !ok {
+		return
+	}
+
+	values := viewValues(*view)
+	for key, value := range c.Request.URL.Query() {
+		values[key] = value
+	}
+	c.Request.URL.RawQuery = values.Encode()
+
+	list := viewResources[view.Resource].list
+	if view.Resource == "customers" && c.GetString("api_version") == "v2" {
+		list = (*Handler).GetCustomersV2
+	}
+	list(h, c)
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupViewRouter(user models.User) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", user.ID)
+		c.Set("role", string(user.Role))
+		c.Next()
+	})
+	r.GET("/views", testHandler.GetSavedViews)
+	r.GET("/views/:id", testHandler.GetSavedView)
+	r.GET("/views/:id/run", testHandler.RunSavedView)
+	r.POST("/views", testHandler.CreateSavedView)
+	r.PUT("/views/:id", testHandler.UpdateSavedView)
+	r.DELETE("/views/:id", testHandler.DeleteSavedView)
+	return r
+}
+
+func createView(t *testing.T, r http.Handler, body map[string]interface{}) models.SavedView {
+	w := performRequest(r, "POST", "/views", body)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var view models.SavedView
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &view))
+	return view
+}
+
+func TestSavedViewVisibility(t *testing.T) {
+	company, admin := createTestCompanyAndUser(t)
+	other := models.Company{Name: "Other"}
+	assert.NoError(t, testDB.Create(&other).Error)
+	owner := models.User{Name: "Owner", Email: "owner@example.com", Password: "password123", CompanyID: &company.ID, Role: models.RoleSales}
+	teammate := models.User{Name: "Teammate", Email: "teammate@example.com", Password: "password123", CompanyID: &company.ID, Role: models.RoleSales}
+	outsider := models.User{Name: "Outsider", Email: "outsider@example.com", Password: "password123", CompanyID: &other.ID, Role: models.RoleSales}
+	for _, user := range []*models.User{&owner, &teammate, &outsider} {
+		assert.NoError(t, testDB.Create(user).Error)
+	}
+
+	r := setupViewRouter(owner)
+	private := createView(t, r, map[string]interface{}{"name": "Mine", "resource": "customers"})
+	team := createView(t, r, map[string]interface{}{"name": "Team", "resource": "customers", "visibility": "team"})
+	everyone := createView(t, r, map[string]interface{}{"name": "All", "resource": "companies", "visibility": "everyone"})
+	assert.Equal(t, models.ViewPrivate, private.Visibility)
+	assert.Equal(t, owner.ID, team.UserID)
+	assert.Equal(t, company.ID, *team.CompanyID)
+
+	visible := func(user models.User, query string) []string {
+		w := performRequest(setupViewRouter(user), "GET", "/views"+query, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		var views []models.SavedView
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &views))
+		names := []string{}
+		for _, view := range views {
+			names = append(names, view.Name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"All", "Mine", "Team"}, visible(owner, ""))
+	assert.Equal(t, []string{"Mine", "Team"}, visible(owner, "?resource=customers"))
+	assert.Equal(t, []string{"All", "Team"}, visible(teammate, ""))
+	assert.Equal(t, []string{"All"}, visible(outsider, ""))
+
+	w := performRequest(setupViewRouter(outsider), "GET", fmt.Sprintf("/views/%d", team.ID), nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	update := map[string]interface{}{"name": "Renamed", "resource": "customers", "visibility": "team"}
+	w = performRequest(setupViewRouter(teammate), "PUT", fmt.Sprintf("/views/%d", team.ID), update)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequest(setupViewRouter(admin), "PUT", fmt.Sprintf("/views/%d", team.ID), update)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"user_id":`+fmt.Sprint(owner.ID))
+
+	w = performRequest(setupViewRouter(teammate), "DELETE", fmt.Sprintf("/views/%d", everyone.ID), nil)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = performRequest(r, "DELETE", fmt.Sprintf("/views/%d", everyone.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, []string{"Renamed"}, visible(teammate, ""))
+}
+
+func TestSavedViewValidation(t *testing.T) {
+	_, user := createTestCompanyAndUser(t)
+	r := setupViewRouter(user)
+
+	for _, body := range []map[string]interface{}{
+		{"name": "Bad resource", "resource": "tags"},
+		{"name": "Bad filter", "resource": "customers", "filters": map[string]string{"stage": "Negotiation"}},
+		{"name": "Bad operator", "resource": "customers", "filters": map[string]string{"name[near]": "x"}},
+		{"name": "Bad value", "resource": "customers", "filters": map[string]string{"company_id": "abc"}},
+		{"name": "Bad sort", "resource": "customers", "sort": "-stage"},
+		{"name": "Bad column", "resource": "users", "columns": []string{"password"}},
+		{"name": "Bad visibility", "resource": "users", "visibility": "friends"},
+	} {
+		w := performRequest(r, "POST", "/views", body)
+		assert.Equal(t, http.StatusBadRequest, w.Code, body["name"])
+	}
+}
+
+func TestRunSavedView(t *testing.T) {
+	company, user := createTestCompanyAndUser(t)
+	for _, customer := range []models.Customer{
+		{Name: "Ada", Email: "ada@example.com", FunnelStage: "Negotiation", CompanyID: company.ID},
+		{Name: "Bob", Email: "bob@example.com", FunnelStage: "Negotiation", CompanyID: company.ID},
+		{Name: "Cy", Email: "cy@example.com", FunnelStage: "Won", CompanyID: company.ID},
+	} {
+		assert.NoError(t, testDB.Create(&customer).Error)
+	}
+
+	r := setupViewRouter(user)
+	view := createView(t, r, map[string]interface{}{
+		"name":     "Negotiation",
+		"resource": "customers",
+		"filters":  map[string]string{"funnel_stage": "Negotiation"},
+		"sort":     "-name",
+		"columns":  []string{"name", "email"},
+	})
+
+	w := performRequest(r, "GET", fmt.Sprintf("/views/%d/run", view.ID), nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get("X-Total-Count"))
+	var rows []map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Bob", rows[0]["name"])
+	assert.NotContains(t, rows[0], "funnel_stage")
+
+	w = performRequest(r, "GET", fmt.Sprintf("/views/%d/run?limit=1&sort=name", view.ID), nil)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rows))
+	require.Len(t, rows, 1)
+	assert.Equal(t, "Ada", rows[0]["name"])
+
+	w = performRequest(r, "GET", "/views/999999/run", nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/search"
+)
+
+var searchTypes = map[string]string{
+	"companies": search.EntityCompany,
+	"customers": search.EntityCustomer,
+	"users":     search.EntityUser,
+}
+
+func (h *Handler) Search(c *gin.Context) {
+	var opts search.Options
+	if raw := c.Query("types"); raw != "" {
+		for _, name := range strings.Split(raw, ",") {
+			entity, ok := searchTypes[strings.TrimSpace(name)]
+			if !ok {
+				problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "Unknown search type: "+name)
+				return
+			}
+			opts.Entities = append(opts.Entities, entity)
+		}
+	}
+	if raw := c.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, "limit must be a positive integer")
+			return
+		}
+		opts.Limit = limit
+	}
+
+	results, err := search.Search(h.db, c.Query("q"), opts)
+	if err != nil {
+		if errors.Is(err, search.ErrEmptyQuery) {
+			problem.Respond(c, http.StatusBadRequest, problem.CodeInvalidQuery, err.Error())
+			return
+		}
+		problem.Internal(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, results)
+}
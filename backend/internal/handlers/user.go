@@ -1,21 +1,56 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/mokan/flame-crm-backend/internal/db"
 	"github.com/mokan/flame-crm-backend/internal/models"
-	"golang.org/x/crypto/bcrypt"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/query"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"github.com/mokan/flame-crm-backend/internal/service"
+	"gorm.io/gorm"
 )
 
-func GetUsers(c *gin.Context) {
+func (h *Handler) GetUsers(c *gin.Context) {
+	params, ok := parseListQuery(c, userQuerySpec, pagedVersion(c))
+	if !ok {
+		return
+	}
+	sel, ok := h.parseSelection(c, userSelection)
+	if !ok {
+		return
+	}
+
 	var users []models.User
-	if err := db.DB.Preload("Company").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	meta, err := query.Find(sel.Apply(h.db, params.SortColumns()...), params, &users)
+	if err != nil {
+		problem.Internal(c, err)
 		return
 	}
-	c.JSON(http.StatusOK, users)
+	setListMeta(c, meta)
+	renderSelection(c, sel, users)
+}
+
+func (h *Handler) GetUser(c *gin.Context) {
+	sel, ok := h.parseSelection(c, userSelection)
+	if !ok {
+		return
+	}
+
+	id := c.Param("id")
+	var user models.User
+	if err := sel.Apply(h.db, "updated_at").First(&user, id).Error; err != nil {
+		problem.NotFound(c, "User not found")
+		return
+	}
+
+	if notModified(c, user.UpdatedAt) {
+		return
+	}
+	setETag(c, user.UpdatedAt)
+	renderSelection(c, sel, user)
 }
 
 type CreateUserInput struct {
@@ -26,54 +61,102 @@ type CreateUserInput struct {
 	CompanyID *uint       `json:"company_id"`
 }
 
-func CreateUser(c *gin.Context) {
+// UpsertUserInput is the body of a user upsert. Password is only required
+// when the user does not exist yet.
+type UpsertUserInput struct {
+	Name      string      `json:"name" binding:"required"`
+	Email     string      `json:"email" binding:"required,email"`
+	Password  string      `json:"password" binding:"omitempty,min=6"`
+	Role      models.Role `json:"role" binding:"required"`
+	CompanyID *uint       `json:"company_id"`
+}
+
+func (h *Handler) CreateUser(c *gin.Context) {
 	var input CreateUserInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Bind(c, err)
 		return
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	user, err := createUser(h.db, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		problem.Write(c, problemFor(err))
 		return
 	}
 
-	user := models.User{
-		Name:      input.Name,
-		Email:     input.Email,
-		Password:  string(hashedPassword),
-		Role:      input.Role,
-		CompanyID: input.CompanyID,
+	setETag(c, user.UpdatedAt)
+	c.JSON(http.StatusOK, user)
+}
+
+func (h *Handler) UpdateUser(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		problem.NotFound(c, "User not found")
+		return
+	}
+	user, err := h.store().Users().Get(id)
+	if err != nil {
+		problem.NotFound(c, "User not found")
+		return
 	}
 
-	if err := db.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email already exists or invalid data"})
+	if currentRole(c) != models.RoleAdmin && currentUserID(c) != user.ID {
+		problem.Forbidden(c, "You can only modify your own account")
 		return
 	}
 
-	c.JSON(http.StatusOK, user)
-}
+	if ifMatchFailed(c, user.UpdatedAt) {
+		preconditionFailed(c, user.UpdatedAt, user)
+		return
+	}
 
-func UpdateUser(c *gin.Context) {
-	id := c.Param("id")
-	var user models.User
-	if err := db.DB.First(&user, id).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+	updates, ok := bindMergePatch(c, service.UserFields)
+	if !ok {
 		return
 	}
 
-	var input map[string]interface{}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimWrite(c, tx, &models.User{}, user.ID, user.UpdatedAt); err != nil {
+			return err
+		}
+		return h.users(tx).Update(user, updates)
+	})
+	if err != nil {
+		if errors.Is(err, errPreconditionFailed) {
+			if current, err := h.store().Users().Get(user.ID); err == nil {
+				user = current
+			}
+			preconditionFailed(c, user.UpdatedAt, user)
+			return
+		}
+		userSaveFailed(c, err)
 		return
 	}
 
-	if password, ok := input["password"].(string); ok && password != "" {
-		hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-		input["password"] = string(hashedPassword)
+	current, err := h.store().Users().Get(user.ID)
+	if err != nil {
+		problem.Internal(c, err)
+		return
 	}
+	setETag(c, current.UpdatedAt)
+	c.JSON(http.StatusOK, current)
+}
 
-	db.DB.Model(&user).Updates(input)
-	c.JSON(http.StatusOK, user)
+func createUser(tx *gorm.DB, input CreateUserInput) (*models.User, error) {
+	user := models.User{Name: input.Name, Email: input.Email, Role: input.Role, CompanyID: input.CompanyID}
+	if err := service.NewUsers(repository.NewGorm(tx)).Create(&user, input.Password); err != nil {
+		return nil, userSaveError(err)
+	}
+	return &user, nil
+}
+
+func userSaveError(err error) error {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return problem.New(http.StatusConflict, problem.CodeAlreadyExists, "Email already exists")
+	}
+	return err
+}
+
+func userSaveFailed(c *gin.Context, err error) {
+	problem.Write(c, problemFor(userSaveError(err)))
 }
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupUserRouter(userID uint, role models.Role) *gin.Engine {
+	r := gin.Default()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", userID)
+		c.Set("role", string(role))
+		c.Next()
+	})
+	r.PATCH("/users/:id", testHandler.UpdateUser)
+	return r
+}
+
+func patchRequest(r http.Handler, path, body string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("PATCH", path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestUpdateUserMassAssignment(t *testing.T) {
+	_, user := createTestCompanyAndUser(t)
+	user.Role = models.RoleSales
+	assert.NoError(t, testDB.Save(&user).Error)
+	path := fmt.Sprintf("/users/%d", user.ID)
+
+	self := setupUserRouter(user.ID, models.RoleSales)
+
+	w := patchRequest(self, path, `{"role": "admin"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	w = patchRequest(self, path, `{"id": 999}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = patchRequest(self, path, `{"created_at": "2000-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = patchRequest(self, path, `{"name": "Renamed", "password": "newsecret"}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "newsecret")
+
+	var dbUser models.User
+	assert.NoError(t, testDB.First(&dbUser, user.ID).Error)
+	assert.Equal(t, "Renamed", dbUser.Name)
+	assert.Equal(t, models.RoleSales, dbUser.Role)
+	assert.NotEqual(t, "newsecret", dbUser.Password)
+
+	other := setupUserRouter(user.ID+100, models.RoleSales)
+	w = patchRequest(other, path, `{"name": "Hijacked"}`)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	admin := setupUserRouter(user.ID+100, models.RoleAdmin)
+	w = patchRequest(admin, path, `{"role": "head_of_sales", "company_id": null}`)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, testDB.First(&dbUser, user.ID).Error)
+	assert.Equal(t, models.RoleHeadOfSales, dbUser.Role)
+	assert.Nil(t, dbUser.CompanyID)
+
+	w = patchRequest(admin, path, `{"role": "superuser"}`)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
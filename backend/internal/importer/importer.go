@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionError  = "error"
+)
+
+var ErrNoHeader = errors.New("CSV file has no header row")
+
+type Row struct {
+	Line   int
+	Values map[string]string
+	Raw    []string
+}
+
+type File struct {
+	Header  []string
+	Mapping map[string]string
+	Rows    []Row
+}
+
+type Outcome struct {
+	Line   int      `json:"line"`
+	Action string   `json:"action"`
+	ID     uint     `json:"id,omitempty"`
+	Key    string   `json:"key,omitempty"`
+	Errors []string `json:"errors,omitempty"`
+}
+
+type Report struct {
+	Entity    string    `json:"entity"`
+	DryRun    bool      `json:"dry_run"`
+	DedupeKey string    `json:"dedupe_key"`
+	Total     int       `json:"total"`
+	Created   int       `json:"created"`
+	Updated   int       `json:"updated"`
+	Failed    int       `json:"failed"`
+	Rows      []Outcome `json:"rows"`
+
+	header   []string
+	rejected [][]string
+}
+
+func normalize(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
+
+func ParseMapping(spec string) (map[string]string, error) {
+	mapping := map[string]string{}
+	if strings.TrimSpace(spec) == "" {
+		return mapping, nil
+	}
+	for _, pair := range strings.Split(spec, ",") {
+		column, field, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(column) == "" || strings.TrimSpace(field) == "" {
+			return nil, fmt.Errorf("invalid mapping %q, expected column=field", pair)
+		}
+		mapping[strings.TrimSpace(column)] = strings.TrimSpace(field)
+	}
+	return mapping, nil
+}
+
+func Parse(r io.Reader, fields []string, mapping map[string]string) (*File, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, ErrNoHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+	header[0] = strings.TrimPrefix(header[0], "\ufeff")
+
+	allowed := map[string]bool{}
+	for _, field := range fields {
+		allowed[field] = true
+	}
+
+	columns := make([]string, len(header))
+	resolved := map[string]string{}
+	if len(mapping) == 0 {
+		for i, column := range header {
+			if field := normalize(column); allowed[field] {
+				columns[i] = field
+				resolved[column] = field
+			}
+		}
+	} else {
+		positions := map[string]int{}
+		for i, column := range header {
+			positions[normalize(column)] = i
+		}
+		for column, field := range mapping {
+			if !allowed[field] {
+				return nil, fmt.Errorf("unknown field %q, expected one of: %s", field, strings.Join(fields, ", "))
+			}
+			i, ok := positions[normalize(column)]
+			if !ok {
+				return nil, fmt.Errorf("column %q not found in CSV header", column)
+			}
+			columns[i] = field
+			resolved[header[i]] = field
+		}
+	}
+	if len(resolved) == 0 {
+		return nil, fmt.Errorf("no CSV columns map to a field, expected one of: %s", strings.Join(fields, ", "))
+	}
+
+	file := &File{Header: header, Mapping: resolved}
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+
+		values := map[string]string{}
+		empty := true
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value != "" {
+				empty = false
+			}
+			if i < len(columns) && columns[i] != "" {
+				values[columns[i]] = value
+			}
+		}
+		if empty {
+			continue
+		}
+		file.Rows = append(file.Rows, Row{Line: line, Values: values, Raw: record})
+	}
+	return file, nil
+}
+
+func NewReport(entity, dedupeKey string, dryRun bool, file *File) *Report {
+	return &Report{Entity: entity, DedupeKey: dedupeKey, DryRun: dryRun, Rows: []Outcome{}, header: file.Header}
+}
+
+func (r *Report) Add(row Row, outcome Outcome) {
+	outcome.Line = row.Line
+	r.Total++
+	switch outcome.Action {
+	case ActionCreate:
+		r.Created++
+	case ActionUpdate:
+		r.Updated++
+	default:
+		outcome.Action = ActionError
+		sort.Strings(outcome.Errors)
+		r.Failed++
+		rejected := append([]string{fmt.Sprint(row.Line)}, row.Raw...)
+		r.rejected = append(r.rejected, append(rejected, strings.Join(outcome.Errors, "; ")))
+	}
+	r.Rows = append(r.Rows, outcome)
+}
+
+func (r *Report) HasErrors() bool {
+	return len(r.rejected) > 0
+}
+
+func (r *Report) WriteErrors(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := append(append([]string{"line"}, r.header...), "errors")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(r.rejected); err != nil {
+		return err
+	}
+	return writer.Error()
+}
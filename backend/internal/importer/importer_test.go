@@ -0,0 +1,62 @@
+package importer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var fields = []string{"name", "email", "company"}
+
+func TestParseAutoMapsHeaders(t *testing.T) {
+	csv := "\ufeffName,E-mail,Email,Notes\nAda, ada@example.com ,ada@example.com,x\n,,,\nBob,,bob@example.com,\n"
+	file, err := Parse(strings.NewReader(csv), fields, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Name": "name", "Email": "email"}, file.Mapping)
+	assert.Len(t, file.Rows, 2)
+	assert.Equal(t, 2, file.Rows[0].Line)
+	assert.Equal(t, "Ada", file.Rows[0].Values["name"])
+	assert.Equal(t, 4, file.Rows[1].Line)
+	assert.Equal(t, "bob@example.com", file.Rows[1].Values["email"])
+}
+
+func TestParseExplicitMapping(t *testing.T) {
+	mapping, err := ParseMapping("Full Name=name, Org=company")
+	assert.NoError(t, err)
+
+	file, err := Parse(strings.NewReader("Full Name,Org,Email\nAda,Acme,ada@example.com\n"), fields, mapping)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"name": "Ada", "company": "Acme"}, file.Rows[0].Values)
+
+	_, err = Parse(strings.NewReader("Full Name\nAda\n"), fields, map[string]string{"Full Name": "nickname"})
+	assert.ErrorContains(t, err, `unknown field "nickname"`)
+
+	_, err = Parse(strings.NewReader("Full Name\nAda\n"), fields, map[string]string{"Org": "company"})
+	assert.ErrorContains(t, err, `column "Org" not found`)
+
+	_, err = Parse(strings.NewReader(""), fields, nil)
+	assert.ErrorIs(t, err, ErrNoHeader)
+
+	_, err = ParseMapping("Full Name")
+	assert.Error(t, err)
+}
+
+func TestReportWritesRejectedRows(t *testing.T) {
+	file, err := Parse(strings.NewReader("name,email\nAda,ada@example.com\n,broken\n"), fields, nil)
+	assert.NoError(t, err)
+
+	report := NewReport("customers", "email", false, file)
+	report.Add(file.Rows[0], Outcome{Action: ActionCreate, ID: 1})
+	report.Add(file.Rows[1], Outcome{Errors: []string{"name is required"}})
+
+	assert.Equal(t, 2, report.Total)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, ActionError, report.Rows[1].Action)
+
+	var buf bytes.Buffer
+	assert.NoError(t, report.WriteErrors(&buf))
+	assert.Equal(t, "line,name,email,errors\n3,,broken,name is required\n", buf.String())
+}
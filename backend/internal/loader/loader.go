@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"context"
+	"sync"
+)
+
+// Fetch loads the values for a batch of keys. Keys missing from the result
+// resolve to the zero value.
+type Fetch[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Loader batches lookups by key. Resolvers for a list prime the loader with
+// the keys of every parent row, so the first Load fetches all of them in a
+// single call instead of one query per row.
+type Loader[K comparable, V any] struct {
+	fetch Fetch[K, V]
+
+	// fetching serialises fetches; mu guards the fields below and is never
+	// held during a fetch, so a Fetch may prime the loader it belongs to.
+	fetching sync.Mutex
+	mu       sync.Mutex
+	pending  map[K]struct{}
+	cache    map[K]V
+	batches  int
+}
+
+func New[K comparable, V any](fetch Fetch[K, V]) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, pending: map[K]struct{}{}, cache: map[K]V{}}
+}
+
+// Prime queues keys for the next batch without fetching them.
+func (l *Loader[K, V]) Prime(keys ...K) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, key := range keys {
+		if _, ok := l.cache[key]; !ok {
+			l.pending[key] = struct{}{}
+		}
+	}
+}
+
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	if value, ok := l.cached(key); ok {
+		return value, nil
+	}
+
+	l.fetching.Lock()
+	defer l.fetching.Unlock()
+
+	l.mu.Lock()
+	if value, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return value, nil
+	}
+	l.pending[key] = struct{}{}
+	keys := make([]K, 0, len(l.pending))
+	for k := range l.pending {
+		keys = append(keys, k)
+	}
+	l.pending = map[K]struct{}{}
+	l.mu.Unlock()
+
+	values, err := l.fetch(ctx, keys)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err != nil {
+		for _, k := range keys {
+			l.pending[k] = struct{}{}
+		}
+		var zero V
+		return zero, err
+	}
+	l.batches++
+	for _, k := range keys {
+		l.cache[k] = values[k]
+		delete(l.pending, k)
+	}
+	return l.cache[key], nil
+}
+
+func (l *Loader[K, V]) cached(key K) (V, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	value, ok := l.cache[key]
+	return value, ok
+}
+
+// Batches reports how many fetches the loader has made.
+func (l *Loader[K, V]) Batches() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.batches
+}
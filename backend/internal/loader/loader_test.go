@@ -0,0 +1,90 @@
+package loader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderBatchesPrimedKeys(t *testing.T) {
+	var calls [][]int
+	l := New(func(ctx context.Context, keys []int) (map[int]string, error) {
+		calls = append(calls, keys)
+		out := map[int]string{}
+		for _, k := range keys {
+			if k != 3 {
+				out[k] = string(rune('a' + k))
+			}
+		}
+		return out, nil
+	})
+
+	l.Prime(1, 2, 3)
+	var wg sync.WaitGroup
+	for _, key := range []int{1, 2, 3} {
+		wg.Add(1)
+		go func(key int) {
+			defer wg.Done()
+			_, err := l.Load(context.Background(), key)
+			assert.NoError(t, err)
+		}(key)
+	}
+	wg.Wait()
+
+	assert.Len(t, calls, 1)
+	assert.ElementsMatch(t, []int{1, 2, 3}, calls[0])
+	value, _ := l.Load(context.Background(), 2)
+	assert.Equal(t, "c", value)
+	missing, _ := l.Load(context.Background(), 3)
+	assert.Equal(t, "", missing)
+	assert.Equal(t, 1, l.Batches())
+
+	value, _ = l.Load(context.Background(), 4)
+	assert.Equal(t, "e", value)
+	assert.Equal(t, 2, l.Batches())
+}
+
+func TestLoaderDoesNotCacheErrors(t *testing.T) {
+	fail := true
+	l := New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		if fail {
+			return nil, errors.New("boom")
+		}
+		return map[int]int{1: 10}, nil
+	})
+
+	_, err := l.Load(context.Background(), 1)
+	assert.Error(t, err)
+	fail = false
+	value, err := l.Load(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, value)
+}
+
+func TestLoaderFetchCanPrimeItself(t *testing.T) {
+	var l *Loader[int, int]
+	var calls [][]int
+	l = New(func(ctx context.Context, keys []int) (map[int]int, error) {
+		calls = append(calls, keys)
+		out := map[int]int{}
+		for _, k := range keys {
+			out[k] = k * 10
+			l.Prime(k + 1)
+		}
+		return out, nil
+	})
+
+	value, err := l.Load(context.Background(), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 10, value)
+
+	l.Prime(5)
+	value, err = l.Load(context.Background(), 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 20, value)
+	assert.Equal(t, [][]int{{1}}, calls[:1])
+	assert.ElementsMatch(t, []int{2, 5}, calls[1])
+}
@@ -6,28 +6,26 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/problem"
 )
 
 func AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
-			c.Abort()
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Authorization header is required")
 			return
 		}
 
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token format"})
-			c.Abort()
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid token format")
 			return
 		}
 
 		claims, err := auth.ValidateToken(bearerToken[1])
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
+			problem.Respond(c, http.StatusUnauthorized, problem.CodeUnauthorized, "Invalid or expired token")
 			return
 		}
 
@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 	"net/http/httptest"
+	"os"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -10,6 +11,14 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
+const testJWTSecret = "test-secret"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	auth.Configure(testJWTSecret, "")
+	os.Exit(m.Run())
+}
+
 func TestAuthMiddleware(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -0,0 +1,189 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"gorm.io/gorm"
+)
+
+// GRPCUnaryAuth and GRPCStreamAuth accept the same bearer tokens as
+// AuthMiddleware in "authorization" metadata, or a service key in "x-api-key".
+func GRPCUnaryAuth() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticateGRPC(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func GRPCStreamAuth() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticateGRPC(ss.Context())
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+func authenticateGRPC(ctx context.Context) (context.Context, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		claims, err := auth.ValidateAPIKey(keys[0])
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "Invalid API key")
+		}
+		return auth.NewContext(ctx, claims), nil
+	}
+
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "Authorization metadata is required")
+	}
+
+	bearerToken := strings.Split(values[0], " ")
+	if len(bearerToken) != 2 {
+		return nil, status.Error(codes.Unauthenticated, "Invalid token format")
+	}
+
+	claims, err := auth.ValidateToken(bearerToken[1])
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "Invalid or expired token")
+	}
+	return auth.NewContext(ctx, claims), nil
+}
+
+// GRPCUnaryIdempotency gives the Create* methods the Idempotency-Key handling
+// of Idempotency, with the key in "idempotency-key" metadata. It must run
+// after GRPCUnaryAuth. Only successful responses are stored, so a call that
+// failed can be retried with the same key.
+func GRPCUnaryIdempotency(database *gorm.DB, ttl time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		keys := md.Get("idempotency-key")
+		message, ok := req.(proto.Message)
+		if !ok || len(keys) == 0 || keys[0] == "" || !strings.HasPrefix(path.Base(info.FullMethod), "Create") {
+			return handler(ctx, req)
+		}
+		if len(keys[0]) > maxIdempotencyKeyLength {
+			return nil, status.Error(codes.InvalidArgument, "idempotency-key must be at most 255 characters")
+		}
+		caller := grpcCaller(ctx, md)
+		if caller == "" {
+			return handler(ctx, req)
+		}
+
+		body, err := proto.MarshalOptions{Deterministic: true}.Marshal(message)
+		if err != nil {
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+		fingerprint := sha256.Sum256(append([]byte(info.FullMethod+"\n"), body...))
+		record := models.IdempotencyKey{
+			Caller:      caller,
+			Key:         keys[0],
+			Fingerprint: hex.EncodeToString(fingerprint[:]),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		existing, err := claimIdempotencyKey(database, &record)
+		if err != nil {
+			log.Printf("failed to claim idempotency key: %v", err)
+			return nil, status.Error(codes.Internal, "Internal server error")
+		}
+		if existing != nil {
+			return replayGRPC(ctx, existing, record.Fingerprint)
+		}
+
+		stored := false
+		defer func() {
+			if !stored {
+				database.Delete(&record)
+			}
+		}()
+
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		result, ok := resp.(proto.Message)
+		if !ok {
+			return resp, nil
+		}
+		encoded, err := anypb.New(result)
+		if err == nil {
+			body, err = proto.Marshal(encoded)
+		}
+		if err == nil {
+			err = database.Model(&record).Updates(map[string]interface{}{"status_code": http.StatusOK, "body": body}).Error
+		}
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+			return resp, nil
+		}
+		stored = true
+		return resp, nil
+	}
+}
+
+// grpcCaller scopes keys to the signed-in user, or to the service key for
+// calls made with one.
+func grpcCaller(ctx context.Context, md metadata.MD) string {
+	claims, ok := auth.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+	if claims.UserID != 0 {
+		return userCaller(claims.UserID)
+	}
+	if keys := md.Get("x-api-key"); len(keys) > 0 {
+		digest := sha256.Sum256([]byte(keys[0]))
+		return "key:" + hex.EncodeToString(digest[:16])
+	}
+	return ""
+}
+
+func replayGRPC(ctx context.Context, existing *models.IdempotencyKey, fingerprint string) (interface{}, error) {
+	if existing.Fingerprint != fingerprint {
+		return nil, status.Error(codes.FailedPrecondition, "idempotency-key was already used for a different request")
+	}
+	if existing.StatusCode == 0 {
+		return nil, status.Error(codes.Aborted, "A request with this idempotency-key is still being processed")
+	}
+
+	var encoded anypb.Any
+	if err := proto.Unmarshal(existing.Body, &encoded); err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	resp, err := encoded.UnmarshalNew()
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Internal server error")
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(strings.ToLower(IdempotentReplayedHeader), "true"))
+	return resp, nil
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/auth"
+	flamev1 "github.com/mokan/flame-crm-backend/internal/pb/flame/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGRPCUnaryAuth(t *testing.T) {
+	auth.Configure(testJWTSecret, "billing-key=head_of_sales")
+	t.Cleanup(func() { auth.Configure(testJWTSecret, "") })
+	token, _ := auth.GenerateToken(7, "sales")
+
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		wantCode codes.Code
+		wantMsg  string
+		wantUser uint
+		wantRole string
+	}{
+		{name: "No Metadata", md: metadata.MD{}, wantCode: codes.Unauthenticated, wantMsg: "Authorization metadata is required"},
+		{name: "Invalid Token Format", md: metadata.Pairs("authorization", "Bearer"), wantCode: codes.Unauthenticated, wantMsg: "Invalid token format"},
+		{name: "Invalid Token", md: metadata.Pairs("authorization", "Bearer invalidtoken123"), wantCode: codes.Unauthenticated, wantMsg: "Invalid or expired token"},
+		{name: "Invalid API Key", md: metadata.Pairs("x-api-key", "nope"), wantCode: codes.Unauthenticated, wantMsg: "Invalid API key"},
+		{name: "Valid API Key", md: metadata.Pairs("x-api-key", "billing-key"), wantCode: codes.OK, wantRole: "head_of_sales"},
+		{name: "Valid Token", md: metadata.Pairs("authorization", "Bearer "+token), wantCode: codes.OK, wantUser: 7, wantRole: "sales"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			var got *auth.Claims
+			_, err := GRPCUnaryAuth()(ctx, nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+				got, _ = auth.FromContext(ctx)
+				return nil, nil
+			})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantMsg, status.Convert(err).Message())
+				assert.Nil(t, got)
+				return
+			}
+			assert.Equal(t, tt.wantUser, got.UserID)
+			assert.Equal(t, tt.wantRole, got.Role)
+		})
+	}
+}
+
+func TestGRPCUnaryIdempotency(t *testing.T) {
+	auth.Configure(testJWTSecret, "billing-key=head_of_sales")
+	t.Cleanup(func() { auth.Configure(testJWTSecret, "") })
+	token, _ := auth.GenerateToken(7, "sales")
+	database := setupIdempotencyDB(t)
+
+	var calls uint64
+	create := func(md metadata.MD, method string, req *flamev1.CreateCompanyRequest) (interface{}, error) {
+		ctx := metadata.NewIncomingContext(context.Background(), md)
+		info := &grpc.UnaryServerInfo{FullMethod: "/flame.v1.CompanyService/" + method}
+		handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+			calls++
+			return &flamev1.Company{Id: calls, Name: req.(*flamev1.CreateCompanyRequest).Name}, nil
+		}
+		return GRPCUnaryAuth()(ctx, req, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return GRPCUnaryIdempotency(database, time.Hour)(ctx, req, info, handler)
+		})
+	}
+	user := metadata.Pairs("authorization", "Bearer "+token, "idempotency-key", "abc")
+	service := metadata.Pairs("x-api-key", "billing-key", "idempotency-key", "abc")
+
+	first, err := create(user, "CreateCompany", &flamev1.CreateCompanyRequest{Name: "Acme"})
+	require.NoError(t, err)
+	retry, err := create(user, "CreateCompany", &flamev1.CreateCompanyRequest{Name: "Acme"})
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(first.(proto.Message), retry.(proto.Message)))
+	assert.EqualValues(t, 1, calls)
+
+	_, err = create(user, "CreateCompany", &flamev1.CreateCompanyRequest{Name: "Other"})
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	resp, err := create(service, "CreateCompany", &flamev1.CreateCompanyRequest{Name: "Acme"})
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, resp.(*flamev1.Company).Id)
+
+	create(user, "UpdateCompany", &flamev1.CreateCompanyRequest{Name: "Acme"})
+	create(user, "UpdateCompany", &flamev1.CreateCompanyRequest{Name: "Acme"})
+	assert.EqualValues(t, 4, calls)
+}
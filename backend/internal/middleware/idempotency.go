@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"gorm.io/gorm"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// maxIdempotentBodySize bounds the body read into memory to fingerprint
+	// a request. It matches gin's default multipart memory, so imports that
+	// fit in memory for the handler also fit here.
+	maxIdempotentBodySize = 32 << 20
+)
+
+// Idempotency makes a request carrying an Idempotency-Key safe to retry. The
+// first request with a key runs normally and its response is stored; a retry
+// with the same key and body gets that response replayed, while reusing the
+// key for a different request is rejected with 422. Keys are scoped to the
+// authenticated user, so anonymous requests pass straight through. Server
+// errors are not stored, so they can be retried. Keys are kept in database.
+func Idempotency(database *gorm.DB, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		userID := c.GetUint("user_id")
+		if key == "" || userID == 0 {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			problem.BadRequest(c, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Respond(c, http.StatusRequestEntityTooLarge, problem.CodeRequestTooLarge, fmt.Sprintf("Request body must be at most %d bytes", tooLarge.Limit))
+			return
+		}
+		if err != nil {
+			problem.BadRequest(c, "Failed to read request body")
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record := models.IdempotencyKey{
+			Caller:      userCaller(userID),
+			Key:         key,
+			Fingerprint: requestFingerprint(c.Request, body),
+			ExpiresAt:   time.Now().Add(ttl),
+		}
+		existing, err := claimIdempotencyKey(database, &record)
+		if err != nil {
+			problem.Internal(c, err)
+			return
+		}
+		if existing != nil {
+			replayIdempotent(c, existing, record.Fingerprint)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		stored := false
+		defer func() {
+			if !stored {
+				database.Delete(&record)
+			}
+		}()
+
+		c.Next()
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+		header := recorder.Header().Clone()
+		header.Del("Date")
+		header.Del("Set-Cookie")
+		encoded, _ := json.Marshal(header)
+		err = database.Model(&record).Updates(map[string]interface{}{
+			"status_code": recorder.Status(),
+			"header":      string(encoded),
+			"body":        recorder.body.Bytes(),
+		}).Error
+		if err != nil {
+			log.Printf("failed to store idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// claimIdempotencyKey inserts record as in flight. If the key is already
+// taken it returns the existing record instead.
+func claimIdempotencyKey(tx *gorm.DB, record *models.IdempotencyKey) (*models.IdempotencyKey, error) {
+	if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, err
+	}
+
+	err := tx.Create(record).Error
+	if err == nil {
+		return nil, nil
+	}
+	if !errors.Is(err, gorm.ErrDuplicatedKey) {
+		return nil, err
+	}
+
+	var existing models.IdempotencyKey
+	if err := tx.Where(map[string]interface{}{"caller": record.Caller, "key": record.Key}).First(&existing).Error; err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+func userCaller(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func replayIdempotent(c *gin.Context, existing *models.IdempotencyKey, fingerprint string) {
+	if existing.Fingerprint != fingerprint {
+		problem.Respond(c, http.StatusUnprocessableEntity, problem.CodeIdempotencyKeyReused, "Idempotency-Key was already used for a different request")
+		return
+	}
+	if existing.StatusCode == 0 {
+		problem.Respond(c, http.StatusConflict, problem.CodeIdempotencyKeyInUse, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	var header http.Header
+	if err := json.Unmarshal([]byte(existing.Header), &header); err != nil {
+		problem.Internal(c, err)
+		return
+	}
+	for name, values := range header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Writer.WriteHeader(existing.StatusCode)
+	c.Writer.Write(existing.Body)
+	c.Abort()
+}
+
+// requestFingerprint identifies a request by method, URL, media type and body.
+// Multipart boundaries are random per attempt, so they are left out.
+func requestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+
+	mediaType, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	io.WriteString(h, mediaType+"\n")
+	if boundary := params["boundary"]; boundary != "" {
+		body = bytes.ReplaceAll(body, []byte(boundary), nil)
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// setupIdempotencyDB opens a database of the test's own, so the tests can run
+// in parallel.
+func setupIdempotencyDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{TranslateError: true})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.IdempotencyKey{}))
+	t.Cleanup(func() {
+		if sqlDB, err := database.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return database
+}
+
+func idempotentRouter(database *gorm.DB, ttl time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		c.Set("user_id", uint(1))
+		c.Next()
+	})
+	r.POST("/things", Idempotency(database, ttl), handler)
+	return r
+}
+
+func postIdempotent(r http.Handler, key, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyReplaysResponse(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	var calls atomic.Int64
+	r := idempotentRouter(database, time.Hour, func(c *gin.Context) {
+		n := calls.Add(1)
+		c.Header("Location", "/things/1")
+		c.JSON(http.StatusCreated, gin.H{"call": n})
+	})
+
+	first := postIdempotent(r, "abc", "application/json", []byte(`{"name":"a"}`))
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	retry := postIdempotent(r, "abc", "application/json", []byte(`{"name":"a"}`))
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, "/things/1", retry.Header().Get("Location"))
+	assert.JSONEq(t, first.Body.String(), retry.Body.String())
+	assert.EqualValues(t, 1, calls.Load())
+
+	reused := postIdempotent(r, "abc", "application/json", []byte(`{"name":"b"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, reused.Code)
+	assert.Contains(t, reused.Body.String(), "idempotency_key_reused")
+
+	postIdempotent(r, "", "application/json", []byte(`{"name":"a"}`))
+	postIdempotent(r, "", "application/json", []byte(`{"name":"a"}`))
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r := idempotentRouter(database, time.Hour, func(c *gin.Context) {
+		close(started)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(r, "abc", "application/json", []byte(`{}`)) }()
+	<-started
+
+	w := postIdempotent(r, "abc", "application/json", []byte(`{}`))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_in_use")
+
+	close(release)
+	assert.Equal(t, http.StatusCreated, (<-done).Code)
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	var calls atomic.Int64
+	r := idempotentRouter(database, time.Hour, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postIdempotent(r, "abc", "application/json", []byte(`{}`)).Code)
+	w := postIdempotent(r, "abc", "application/json", []byte(`{}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Empty(t, w.Header().Get(IdempotentReplayedHeader))
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	var calls atomic.Int64
+	r := idempotentRouter(database, time.Millisecond, func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	postIdempotent(r, "abc", "application/json", []byte(`{}`))
+	time.Sleep(5 * time.Millisecond)
+	w := postIdempotent(r, "abc", "application/json", []byte(`{"other":true}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestIdempotencyIgnoresMultipartBoundary(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	var calls atomic.Int64
+	r := idempotentRouter(database, time.Hour, func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusAccepted, gin.H{})
+	})
+
+	upload := func() *httptest.ResponseRecorder {
+		var body bytes.Buffer
+		writer := multipart.NewWriter(&body)
+		part, _ := writer.CreateFormFile("file", "customers.csv")
+		part.Write([]byte("name\nAlice\n"))
+		writer.Close()
+		return postIdempotent(r, "upload", writer.FormDataContentType(), body.Bytes())
+	}
+
+	upload()
+	w := upload()
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, "true", w.Header().Get(IdempotentReplayedHeader))
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestIdempotencyRejectsOversizedBodies(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	r := idempotentRouter(database, time.Hour, func(c *gin.Context) {
+		t.Error("handler must not run")
+	})
+
+	w := postIdempotent(r, "big", "text/csv", bytes.Repeat([]byte("a"), maxIdempotentBodySize+1))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+	assert.Contains(t, w.Body.String(), "request_too_large")
+}
+
+func TestIdempotencySkipsAnonymousRequests(t *testing.T) {
+	t.Parallel()
+	database := setupIdempotencyDB(t)
+	var calls atomic.Int64
+	r := gin.New()
+	r.POST("/things", Idempotency(database, time.Hour), func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	postIdempotent(r, "abc", "application/json", []byte(`{"name":"a"}`))
+	w := postIdempotent(r, "abc", "application/json", []byte(`{"name":"b"}`))
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.EqualValues(t, 2, calls.Load())
+
+	var count int64
+	database.Model(&models.IdempotencyKey{}).Count(&count)
+	assert.Zero(t, count)
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecation describes a version or route that has a successor. Prefix is
+// swapped for SuccessorPrefix in the request path to build the Link header.
+type Deprecation struct {
+	Since           time.Time
+	Sunset          time.Time
+	Prefix          string
+	SuccessorPrefix string
+}
+
+// Deprecate announces d on every response with the Deprecation (RFC 9745),
+// Sunset (RFC 8594) and successor-version Link headers.
+func Deprecate(d Deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			c.Header("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.SuccessorPrefix != "" {
+			successor := d.SuccessorPrefix + strings.TrimPrefix(c.Request.URL.Path, d.Prefix)
+			c.Header("Link", "<"+successor+`>; rel="successor-version"`)
+		}
+		c.Next()
+	}
+}
+
+type VersionUsage struct {
+	Version  string    `json:"version"`
+	Method   string    `json:"method"`
+	Route    string    `json:"route"`
+	Requests int64     `json:"requests"`
+	Users    int       `json:"users"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+type versionUsageKey struct {
+	version, method, route string
+}
+
+type versionUsageCount struct {
+	requests int64
+	users    map[uint]bool
+	lastSeen time.Time
+}
+
+var versionUsage = struct {
+	sync.Mutex
+	counts map[versionUsageKey]*versionUsageCount
+}{counts: map[versionUsageKey]*versionUsageCount{}}
+
+// TrackVersion counts requests per API version and route, so we can see
+// who still calls a version before removing it. Counts live in memory and
+// restart with the process.
+func TrackVersion(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("api_version", version)
+		c.Next()
+
+		key := versionUsageKey{version: version, method: c.Request.Method, route: c.FullPath()}
+		versionUsage.Lock()
+		defer versionUsage.Unlock()
+		count := versionUsage.counts[key]
+		if count == nil {
+			count = &versionUsageCount{users: map[uint]bool{}}
+			versionUsage.counts[key] = count
+		}
+		count.requests++
+		if userID := c.GetUint("user_id"); userID != 0 {
+			count.users[userID] = true
+		}
+		count.lastSeen = time.Now()
+	}
+}
+
+// VersionUsageStats returns the counts recorded by TrackVersion, ordered by
+// version and route.
+func VersionUsageStats() []VersionUsage {
+	versionUsage.Lock()
+	defer versionUsage.Unlock()
+
+	stats := make([]VersionUsage, 0, len(versionUsage.counts))
+	for key, count := range versionUsage.counts {
+		stats = append(stats, VersionUsage{
+			Version:  key.version,
+			Method:   key.method,
+			Route:    key.route,
+			Requests: count.requests,
+			Users:    len(count.users),
+			LastSeen: count.lastSeen,
+		})
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		a, b := stats[i], stats[j]
+		if a.Version != b.Version {
+			return a.Version < b.Version
+		}
+		if a.Route != b.Route {
+			return a.Route < b.Route
+		}
+		return a.Method < b.Method
+	})
+	return stats
+}
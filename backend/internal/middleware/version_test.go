@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecate(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	old := r.Group("/api", Deprecate(Deprecation{
+		Since:           time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+		Sunset:          time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+		Prefix:          "/api",
+		SuccessorPrefix: "/api/v1",
+	}))
+	old.GET("/companies/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/companies/7", nil))
+	assert.Equal(t, "@1792368000", w.Header().Get("Deprecation"))
+	assert.Equal(t, "Mon, 19 Apr 2027 00:00:00 GMT", w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v1/companies/7>; rel="successor-version"`, w.Header().Get("Link"))
+}
+
+func TestTrackVersion(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	v9 := r.Group("/api/v9", TrackVersion("v9"))
+	v9.GET("/things/:id", func(c *gin.Context) {
+		c.Set("user_id", uint(c.GetHeader("X-User")[0]-'0'))
+		c.Status(http.StatusOK)
+	})
+
+	for _, user := range []string{"1", "1", "2"} {
+		req := httptest.NewRequest("GET", "/api/v9/things/3", nil)
+		req.Header.Set("X-User", user)
+		r.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var found *VersionUsage
+	for _, stat := range VersionUsageStats() {
+		if stat.Version == "v9" {
+			found = &stat
+		}
+	}
+	if assert.NotNil(t, found) {
+		assert.Equal(t, "/api/v9/things/:id", found.Route)
+		assert.Equal(t, "GET", found.Method)
+		assert.EqualValues(t, 3, found.Requests)
+		assert.Equal(t, 2, found.Users)
+		assert.WithinDuration(t, time.Now(), found.LastSeen, time.Minute)
+	}
+}
@@ -0,0 +1,355 @@
+// Package migrate applies the versioned SQL migrations embedded in the
+// binary and records each applied version in the schema_migrations table.
+//
+// Migrations live in migrations/ as NNNN_name.up.sql and NNNN_name.down.sql
+// pairs and run in version order, each in its own transaction. A pair named
+// NNNN_name.postgres.up.sql or NNNN_name.sqlite.up.sql only runs on that
+// database and replaces the shared pair there. An up file may come with a
+// NNNN_name[.dialect].fallback.up.sql that runs instead when the up file
+// fails, for features a database build may lack such as SQLite's FTS5.
+// Released migrations must not be edited; add a new version instead.
+package migrate
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed migrations/*.sql
+var embedded embed.FS
+
+// lockKey is the PostgreSQL advisory lock held while a migration runs, so
+// that two instances starting together do not apply the same version.
+const lockKey = 7_305_118_442
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)(?:\.(postgres|sqlite))?(\.fallback)?\.(up|down)\.sql$`)
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	// Fallback runs instead of Up when Up fails.
+	Fallback string
+}
+
+// Status is a known migration, or a version recorded in schema_migrations
+// that this binary has no file for (Unknown).
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+	Unknown   bool
+}
+
+func (s Status) Applied() bool { return s.AppliedAt != nil }
+
+type appliedVersion struct {
+	Version   int64 `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedVersion) TableName() string { return "schema_migrations" }
+
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for the migrations embedded in the binary.
+func New(db *gorm.DB) (*Migrator, error) {
+	files, err := fs.Sub(embedded, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	return NewFromFS(db, files)
+}
+
+// NewFromFS returns a Migrator for the migrations at the root of files.
+func NewFromFS(db *gorm.DB, files fs.FS) (*Migrator, error) {
+	migrations, err := Load(files, db.Dialector.Name())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Load reads the migrations at the root of files for the dialect, ordered by
+// version. Every version needs an up and a down file, either shared or for
+// the dialect.
+func Load(files fs.FS, dialect string) ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int64]*Migration{}
+	specific := map[string]bool{}
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: name must be NNNN_name.up.sql or NNNN_name.down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s: version must be a positive number", entry.Name())
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names: %s and %s", version, m.Name, match[2])
+		}
+		fileDialect := match[3]
+		if fileDialect != "" && fileDialect != dialect {
+			continue
+		}
+		body, err := fs.ReadFile(files, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		kind := match[4] + match[5]
+		if kind == ".fallbackdown" {
+			return nil, fmt.Errorf("migration %s: only up files can have a fallback", entry.Name())
+		}
+		key := fmt.Sprintf("%d.%s", version, kind)
+		if fileDialect == "" && specific[key] {
+			continue
+		}
+		if fileDialect != "" {
+			specific[key] = true
+		}
+		switch kind {
+		case "up":
+			m.Up = string(body)
+		case "down":
+			m.Down = string(body)
+		default:
+			m.Fallback = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int { return cmp.Compare(a.Version, b.Version) })
+	return migrations, nil
+}
+
+// Latest is the highest known version, or 0 when there are no migrations.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists the known migrations followed by any unknown applied
+// versions, each in version order.
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if a, ok := applied[migration.Version]; ok {
+			status.AppliedAt = &a.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	unknown := make([]Status, 0, len(applied))
+	for _, a := range applied {
+		unknown = append(unknown, Status{Version: a.Version, Name: a.Name, AppliedAt: &a.AppliedAt, Unknown: true})
+	}
+	slices.SortFunc(unknown, func(a, b Status) int { return cmp.Compare(a.Version, b.Version) })
+	return append(statuses, unknown...), nil
+}
+
+// Pending lists the known migrations that are not applied yet.
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok {
+			pending = append(pending, migration)
+		}
+	}
+	return pending, nil
+}
+
+// Up applies every pending migration and returns the ones it ran.
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.To(m.Latest())
+}
+
+// Down rolls back the most recently applied known migration. It returns
+// nil when nothing is applied.
+func (m *Migrator) Down() (*Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; ok {
+			return &migration, m.rollback(migration)
+		}
+	}
+	return nil, nil
+}
+
+// To applies the pending migrations up to and including version, then rolls
+// back the applied ones above it, newest first. Version 0 rolls back
+// everything. It returns the migrations it ran.
+func (m *Migrator) To(version int64) ([]Migration, error) {
+	if version != 0 && !slices.ContainsFunc(m.migrations, func(mg Migration) bool { return mg.Version == version }) {
+		return nil, fmt.Errorf("unknown migration version %d", version)
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+	for v := range applied {
+		if v > version && !slices.ContainsFunc(m.migrations, func(mg Migration) bool { return mg.Version == v }) {
+			return nil, fmt.Errorf("cannot roll back version %d: it was applied by a newer build", v)
+		}
+	}
+
+	var ran []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok || migration.Version > version {
+			continue
+		}
+		if err := m.apply(migration); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok || migration.Version <= version {
+			continue
+		}
+		if err := m.rollback(migration); err != nil {
+			return ran, err
+		}
+		ran = append(ran, migration)
+	}
+	return ran, nil
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	return m.step(migration, true, func(tx *gorm.DB) error {
+		return tx.Create(&appliedVersion{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+	})
+}
+
+func (m *Migrator) rollback(migration Migration) error {
+	return m.step(migration, false, func(tx *gorm.DB) error {
+		return tx.Delete(&appliedVersion{}, "version = ?", migration.Version).Error
+	})
+}
+
+// step runs one direction of migration and records it in the same
+// transaction. It does nothing when another instance got there first.
+func (m *Migrator) step(migration Migration, up bool, record func(tx *gorm.DB) error) error {
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", lockKey).Error; err != nil {
+				return err
+			}
+		}
+		var count int64
+		if err := tx.Model(&appliedVersion{}).Where("version = ?", migration.Version).Count(&count).Error; err != nil {
+			return err
+		}
+		if (count > 0) == up {
+			return nil
+		}
+
+		if !up {
+			if err := tx.Exec(migration.Down).Error; err != nil {
+				return err
+			}
+			return record(tx)
+		}
+
+		if migration.Fallback != "" {
+			if err := tx.SavePoint("migration_up").Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Exec(migration.Up).Error; err != nil {
+			if migration.Fallback == "" {
+				return err
+			}
+			if err := tx.RollbackTo("migration_up").Error; err != nil {
+				return err
+			}
+			if fallbackErr := tx.Exec(migration.Fallback).Error; fallbackErr != nil {
+				return fmt.Errorf("%w; fallback: %v", err, fallbackErr)
+			}
+		}
+		return record(tx)
+	})
+	if err != nil {
+		direction := "down"
+		if up {
+			direction = "up"
+		}
+		return fmt.Errorf("migration %d_%s %s: %w", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+// applied creates schema_migrations if needed and returns its rows by
+// version.
+func (m *Migrator) applied() (map[int64]appliedVersion, error) {
+	err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMP NOT NULL
+)`).Error
+	if err != nil {
+		return nil, fmt.Errorf("creating schema_migrations: %w", err)
+	}
+
+	var rows []appliedVersion
+	if err := m.db.Order("version").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	applied := make(map[int64]appliedVersion, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row
+	}
+	return applied, nil
+}
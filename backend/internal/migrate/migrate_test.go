@@ -0,0 +1,356 @@
+package migrate
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupMigrateDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := database.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	return database
+}
+
+func testFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"0001_notes.up.sql":        {Data: []byte("CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT);")},
+		"0001_notes.down.sql":      {Data: []byte("DROP TABLE notes;")},
+		"0002_note_title.up.sql":   {Data: []byte("ALTER TABLE notes ADD COLUMN title TEXT;\nCREATE INDEX idx_notes_title ON notes (title);")},
+		"0002_note_title.down.sql": {Data: []byte("DROP INDEX idx_notes_title;\nALTER TABLE notes DROP COLUMN title;")},
+		"README.md":                {Data: []byte("ignored")},
+	}
+}
+
+func versions(migrations []Migration) []int64 {
+	var out []int64
+	for _, m := range migrations {
+		out = append(out, m.Version)
+	}
+	return out
+}
+
+func TestUpDownAndTo(t *testing.T) {
+	database := setupMigrateDB(t)
+	m, err := NewFromFS(database, testFiles())
+	require.NoError(t, err)
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions(pending))
+
+	ran, err := m.Up()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, versions(ran))
+	assert.NoError(t, database.Exec("INSERT INTO notes (body, title) VALUES ('hi', 'greeting')").Error)
+
+	ran, err = m.Up()
+	require.NoError(t, err)
+	assert.Empty(t, ran)
+
+	rolledBack, err := m.Down()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), rolledBack.Version)
+	assert.False(t, database.Migrator().HasColumn("notes", "title"))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[0].Applied())
+	assert.False(t, statuses[1].Applied())
+
+	ran, err = m.To(2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2}, versions(ran))
+
+	ran, err = m.To(0)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{2, 1}, versions(ran))
+	assert.False(t, database.Migrator().HasTable("notes"))
+
+	rolledBack, err = m.Down()
+	require.NoError(t, err)
+	assert.Nil(t, rolledBack)
+
+	_, err = m.To(3)
+	assert.ErrorContains(t, err, "unknown migration version 3")
+}
+
+func TestFailedMigrationIsNotRecorded(t *testing.T) {
+	database := setupMigrateDB(t)
+	files := testFiles()
+	files["0003_broken.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE broken (id INTEGER);\nNOT SQL;")}
+	files["0003_broken.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE broken;")}
+	m, err := NewFromFS(database, files)
+	require.NoError(t, err)
+
+	ran, err := m.Up()
+	assert.ErrorContains(t, err, "migration 3_broken up")
+	assert.Equal(t, []int64{1, 2}, versions(ran))
+	assert.False(t, database.Migrator().HasTable("broken"))
+
+	pending, err := m.Pending()
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3}, versions(pending))
+}
+
+func TestUnknownAppliedVersions(t *testing.T) {
+	database := setupMigrateDB(t)
+	files := testFiles()
+	newer, err := NewFromFS(database, files)
+	require.NoError(t, err)
+	_, err = newer.Up()
+	require.NoError(t, err)
+
+	delete(files, "0002_note_title.up.sql")
+	delete(files, "0002_note_title.down.sql")
+	older, err := NewFromFS(database, files)
+	require.NoError(t, err)
+
+	statuses, err := older.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 2)
+	assert.True(t, statuses[1].Unknown)
+	assert.Equal(t, "note_title", statuses[1].Name)
+
+	pending, err := older.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	_, err = older.To(0)
+	assert.ErrorContains(t, err, "applied by a newer build")
+}
+
+func TestLoadRejectsBadFiles(t *testing.T) {
+	cases := map[string]fstest.MapFS{
+		"needs both an up and a down file": {"0001_a.up.sql": {Data: []byte("SELECT 1;")}},
+		"has two names": {
+			"0001_a.up.sql":   {Data: []byte("SELECT 1;")},
+			"0001_b.down.sql": {Data: []byte("SELECT 1;")},
+		},
+		"name must be": {"create_users.sql": {Data: []byte("SELECT 1;")}},
+		"positive":     {"0000_zero.up.sql": {Data: []byte("SELECT 1;")}},
+	}
+	for want, files := range cases {
+		_, err := Load(files, "sqlite")
+		assert.ErrorContains(t, err, want)
+	}
+}
+
+func TestFallbackRunsWhenUpFails(t *testing.T) {
+	database := setupMigrateDB(t)
+	files := testFiles()
+	files["0003_index.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE attempted (id INTEGER);\nCREATE VIRTUAL TABLE idx USING no_such_module(body);")}
+	files["0003_index.sqlite.fallback.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE idx (body TEXT);")}
+	files["0003_index.down.sql"] = &fstest.MapFile{Data: []byte("DROP TABLE idx;")}
+	m, err := NewFromFS(database, files)
+	require.NoError(t, err)
+
+	_, err = m.Up()
+	require.NoError(t, err)
+	assert.True(t, database.Migrator().HasTable("idx"))
+	assert.False(t, database.Migrator().HasTable("attempted"))
+
+	files["0003_index.sqlite.fallback.up.sql"] = &fstest.MapFile{Data: []byte("NOT SQL;")}
+	m, err = NewFromFS(setupMigrateDB(t), files)
+	require.NoError(t, err)
+	_, err = m.Up()
+	assert.ErrorContains(t, err, "no such module")
+	assert.ErrorContains(t, err, "fallback")
+
+	files["0003_index.fallback.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	_, err = Load(files, "sqlite")
+	assert.ErrorContains(t, err, "only up files can have a fallback")
+}
+
+func TestLoadPicksDialectFiles(t *testing.T) {
+	files := testFiles()
+	files["0003_feed.up.sql"] = &fstest.MapFile{Data: []byte("shared up")}
+	files["0003_feed.down.sql"] = &fstest.MapFile{Data: []byte("shared down")}
+	files["0003_feed.sqlite.up.sql"] = &fstest.MapFile{Data: []byte("sqlite up")}
+	files["0004_trgm.postgres.up.sql"] = &fstest.MapFile{Data: []byte("postgres up")}
+	files["0004_trgm.postgres.down.sql"] = &fstest.MapFile{Data: []byte("postgres down")}
+
+	_, err := Load(files, "sqlite")
+	assert.ErrorContains(t, err, "migration 4_trgm needs both an up and a down file")
+
+	migrations, err := Load(files, "postgres")
+	require.NoError(t, err)
+	require.Equal(t, []int64{1, 2, 3, 4}, versions(migrations))
+	assert.Equal(t, "shared up", migrations[2].Up)
+	assert.Equal(t, "postgres down", migrations[3].Down)
+
+	files["0004_trgm.sqlite.up.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	files["0004_trgm.sqlite.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;")}
+	migrations, err = Load(files, "sqlite")
+	require.NoError(t, err)
+	assert.Equal(t, "sqlite up", migrations[2].Up)
+	assert.Equal(t, "shared down", migrations[2].Down)
+}
+
+func TestEmbeddedMigrationsApplyAndRollBack(t *testing.T) {
+	database := setupMigrateDB(t)
+	m, err := New(database)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, m.Latest(), int64(1))
+
+	_, err = m.Up()
+	require.NoError(t, err)
+	for _, table := range []string{"companies", "customers", "funnels", "customer_tags", "funnel_transitions", "saved_views", "changes", "search_documents"} {
+		assert.True(t, database.Migrator().HasTable(table), table)
+	}
+
+	_, err = m.To(0)
+	require.NoError(t, err)
+	var left []string
+	require.NoError(t, database.Raw("SELECT name FROM sqlite_master WHERE type IN ('table', 'trigger') AND name NOT IN ('schema_migrations', 'sqlite_sequence')").Scan(&left).Error)
+	assert.Empty(t, left)
+}
+
+// TestMigratedDatabaseTakesWrites runs the migrations on an empty database,
+// without AutoMigrate, and writes through the models.
+func TestMigratedDatabaseTakesWrites(t *testing.T) {
+	database := setupMigrateDB(t)
+	m, err := New(database)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	funnel := models.Funnel{Name: "Lead"}
+	require.NoError(t, database.Create(&funnel).Error)
+	company := models.Company{Name: "Acme", FunnelID: &funnel.ID}
+	require.NoError(t, database.Create(&company).Error)
+	customer := models.Customer{Name: "Ada", CompanyID: company.ID, Tags: []models.Tag{{Name: "vip"}}}
+	require.NoError(t, database.Create(&customer).Error)
+	user := models.User{Name: "Bob", Email: "bob@example.com", Role: models.RoleSales}
+	require.NoError(t, database.Create(&user).Error)
+	assert.NotZero(t, funnel.ID)
+	assert.NotZero(t, company.ID)
+	assert.NotZero(t, customer.ID)
+	assert.NotZero(t, user.ID)
+
+	var logged []uint
+	require.NoError(t, database.Table("changes").Where("entity = ?", "customer").Distinct().Pluck("entity_id", &logged).Error)
+	assert.Equal(t, []uint{customer.ID}, logged)
+}
+
+// baselineCompany, baselineUser, baselineCustomer and baselineFunnel are the
+// models as they were before versioned migrations.
+type baselineCompany struct {
+	gorm.Model
+	Name     string
+	Address  string
+	FunnelID *uint
+	Funnel   *baselineFunnel
+}
+
+func (baselineCompany) TableName() string { return "companies" }
+
+type baselineUser struct {
+	gorm.Model
+	Name      string
+	Email     string `gorm:"uniqueIndex"`
+	Password  string
+	Role      string
+	CompanyID *uint
+	Company   baselineCompany
+}
+
+func (baselineUser) TableName() string { return "users" }
+
+type baselineCustomer struct {
+	gorm.Model
+	Name        string
+	Email       string
+	Phone       string
+	CompanyID   uint
+	Company     baselineCompany
+	FunnelID    *uint
+	FunnelStage string
+}
+
+func (baselineCustomer) TableName() string { return "customers" }
+
+type baselineFunnel struct {
+	gorm.Model
+	Name        string
+	NextFunnels []*baselineFunnel `gorm:"many2many:funnel_transitions;joinForeignKey:from_funnel_id;joinReferences:to_funnel_id"`
+}
+
+func (baselineFunnel) TableName() string { return "funnels" }
+
+// TestMigratingAutoMigratedBaseline upgrades a database AutoMigrate created
+// before versioned migrations, keeping its rows.
+func TestMigratingAutoMigratedBaseline(t *testing.T) {
+	database := setupMigrateDB(t)
+	require.NoError(t, database.AutoMigrate(&baselineFunnel{}, &baselineCompany{}, &baselineUser{}, &baselineCustomer{}))
+	require.NoError(t, database.Create(&baselineCompany{Name: "Acme"}).Error)
+
+	m, err := New(database)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	var company models.Company
+	require.NoError(t, database.First(&company).Error)
+	assert.Equal(t, "Acme", company.Name)
+	customer := models.Customer{Name: "Ada", CompanyID: company.ID, BoardPosition: 1}
+	require.NoError(t, database.Create(&customer).Error)
+	assert.NotZero(t, customer.ID)
+
+	var hits int64
+	require.NoError(t, database.Raw("SELECT count(*) FROM search_documents WHERE search_documents MATCH ?", "acme").Scan(&hits).Error)
+	assert.Equal(t, int64(2), hits)
+}
+
+// TestMigratedSchemaMatchesModels guards against a model change that ships
+// without a migration: the migrated schema must have the tables, columns and
+// indexes AutoMigrate would create.
+func TestMigratedSchemaMatchesModels(t *testing.T) {
+	migrated := setupMigrateDB(t)
+	m, err := New(migrated)
+	require.NoError(t, err)
+	_, err = m.Up()
+	require.NoError(t, err)
+
+	fromModels := setupMigrateDB(t)
+	require.NoError(t, fromModels.AutoMigrate(&models.Company{}, &models.User{}, &models.Customer{}, &models.Funnel{}, &models.FunnelMove{}, &models.CustomerFunnel{}, &models.Tag{}, &models.EnrollmentRule{}, &models.FunnelVersion{}, &models.FunnelVersionTransition{}, &models.ImportJob{}, &models.IdempotencyKey{}, &models.SavedView{}))
+
+	assert.ElementsMatch(t, modelTables(t, fromModels), modelTables(t, migrated))
+	for _, table := range modelTables(t, fromModels) {
+		assert.ElementsMatch(t, columnNames(t, fromModels, table), columnNames(t, migrated, table), table)
+		assert.ElementsMatch(t, indexNames(t, fromModels, table), indexNames(t, migrated, table), table)
+	}
+}
+
+// modelTables lists the tables except the ones the migrations keep for
+// themselves, the change log and the search index.
+func modelTables(t *testing.T, database *gorm.DB) []string {
+	var tables []string
+	require.NoError(t, database.Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence', 'changes') AND name NOT LIKE 'search_documents%'").Scan(&tables).Error)
+	return tables
+}
+
+func columnNames(t *testing.T, database *gorm.DB, table string) []string {
+	columns, err := database.Migrator().ColumnTypes(table)
+	require.NoError(t, err)
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name()
+	}
+	return names
+}
+
+func indexNames(t *testing.T, database *gorm.DB, table string) []string {
+	var names []string
+	require.NoError(t, database.Raw("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = ? AND name NOT LIKE 'sqlite_autoindex%'", table).Scan(&names).Error)
+	return names
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS funnel_transitions;
+DROP TABLE IF EXISTS customers;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS companies;
+DROP TABLE IF EXISTS funnels;
@@ -0,0 +1,67 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations.
+-- Every statement is guarded, so it also applies cleanly to databases that
+-- were created by AutoMigrate at the time. Later columns and tables come in
+-- their own migrations.
+
+CREATE TABLE IF NOT EXISTS funnels (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_funnels_deleted_at ON funnels (deleted_at);
+
+CREATE TABLE IF NOT EXISTS companies (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    address text,
+    funnel_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_companies_funnel FOREIGN KEY (funnel_id) REFERENCES funnels(id)
+);
+CREATE INDEX IF NOT EXISTS idx_companies_deleted_at ON companies (deleted_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    email text,
+    password text,
+    role text,
+    company_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_companies_users FOREIGN KEY (company_id) REFERENCES companies(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    email text,
+    phone text,
+    company_id bigint,
+    funnel_id bigint,
+    funnel_stage text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_companies_customers FOREIGN KEY (company_id) REFERENCES companies(id)
+);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS funnel_transitions (
+    to_funnel_id bigint,
+    from_funnel_id bigint,
+    PRIMARY KEY (to_funnel_id,from_funnel_id),
+    CONSTRAINT fk_funnel_transitions_funnel FOREIGN KEY (to_funnel_id) REFERENCES funnels(id),
+    CONSTRAINT fk_funnel_transitions_previous_funnels FOREIGN KEY (from_funnel_id) REFERENCES funnels(id)
+);
@@ -0,0 +1,63 @@
+-- Baseline: the schema AutoMigrate created before versioned migrations.
+-- Every statement is guarded, so it also applies cleanly to databases that
+-- were created by AutoMigrate at the time. Later columns and tables come in
+-- their own migrations.
+
+CREATE TABLE IF NOT EXISTS funnels (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text
+);
+CREATE INDEX IF NOT EXISTS idx_funnels_deleted_at ON funnels (deleted_at);
+
+CREATE TABLE IF NOT EXISTS companies (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    address text,
+    funnel_id integer,
+    CONSTRAINT fk_companies_funnel FOREIGN KEY (funnel_id) REFERENCES funnels(id)
+);
+CREATE INDEX IF NOT EXISTS idx_companies_deleted_at ON companies (deleted_at);
+
+CREATE TABLE IF NOT EXISTS users (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    email text,
+    password text,
+    role text,
+    company_id integer,
+    CONSTRAINT fk_companies_users FOREIGN KEY (company_id) REFERENCES companies(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email ON users (email);
+CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users (deleted_at);
+
+CREATE TABLE IF NOT EXISTS customers (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    email text,
+    phone text,
+    company_id integer,
+    funnel_id integer,
+    funnel_stage text,
+    CONSTRAINT fk_companies_customers FOREIGN KEY (company_id) REFERENCES companies(id)
+);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers (deleted_at);
+
+CREATE TABLE IF NOT EXISTS funnel_transitions (
+    to_funnel_id integer,
+    from_funnel_id integer,
+    PRIMARY KEY (to_funnel_id,from_funnel_id),
+    CONSTRAINT fk_funnel_transitions_funnel FOREIGN KEY (to_funnel_id) REFERENCES funnels(id),
+    CONSTRAINT fk_funnel_transitions_previous_funnels FOREIGN KEY (from_funnel_id) REFERENCES funnels(id)
+);
@@ -0,0 +1,16 @@
+-- History of records moved between funnel stages.
+
+CREATE TABLE IF NOT EXISTS funnel_moves (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    entity_type text,
+    entity_id bigint,
+    from_funnel_id bigint,
+    to_funnel_id bigint,
+    reason text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_moves_entity ON funnel_moves (entity_type,entity_id);
+CREATE INDEX IF NOT EXISTS idx_funnel_moves_deleted_at ON funnel_moves (deleted_at);
@@ -0,0 +1,15 @@
+-- History of records moved between funnel stages.
+
+CREATE TABLE IF NOT EXISTS funnel_moves (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    entity_type text,
+    entity_id integer,
+    from_funnel_id integer,
+    to_funnel_id integer,
+    reason text
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_moves_entity ON funnel_moves (entity_type,entity_id);
+CREATE INDEX IF NOT EXISTS idx_funnel_moves_deleted_at ON funnel_moves (deleted_at);
@@ -0,0 +1,18 @@
+-- A customer's stage in each pipeline they belong to.
+
+CREATE TABLE IF NOT EXISTS customer_funnels (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    customer_id bigint,
+    pipeline text,
+    funnel_id bigint,
+    funnel_stage text,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_customer_funnels_funnel FOREIGN KEY (funnel_id) REFERENCES funnels(id),
+    CONSTRAINT fk_customers_memberships FOREIGN KEY (customer_id) REFERENCES customers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_customer_funnels_funnel_id ON customer_funnels (funnel_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_pipeline ON customer_funnels (customer_id,pipeline);
+CREATE INDEX IF NOT EXISTS idx_customer_funnels_deleted_at ON customer_funnels (deleted_at);
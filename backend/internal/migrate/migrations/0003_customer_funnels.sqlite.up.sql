@@ -0,0 +1,17 @@
+-- A customer's stage in each pipeline they belong to.
+
+CREATE TABLE IF NOT EXISTS customer_funnels (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    customer_id integer,
+    pipeline text,
+    funnel_id integer,
+    funnel_stage text,
+    CONSTRAINT fk_customer_funnels_funnel FOREIGN KEY (funnel_id) REFERENCES funnels(id),
+    CONSTRAINT fk_customers_memberships FOREIGN KEY (customer_id) REFERENCES customers(id)
+);
+CREATE INDEX IF NOT EXISTS idx_customer_funnels_funnel_id ON customer_funnels (funnel_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_pipeline ON customer_funnels (customer_id,pipeline);
+CREATE INDEX IF NOT EXISTS idx_customer_funnels_deleted_at ON customer_funnels (deleted_at);
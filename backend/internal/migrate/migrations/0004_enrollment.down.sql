@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS enrollment_rules;
+DROP TABLE IF EXISTS customer_tags;
+DROP TABLE IF EXISTS tags;
+ALTER TABLE customers DROP COLUMN lead_source;
@@ -0,0 +1,40 @@
+-- Lead sources, tags and the rules that enroll new customers in pipelines.
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS lead_source text;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    PRIMARY KEY (id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags (deleted_at);
+
+CREATE TABLE IF NOT EXISTS customer_tags (
+    customer_id bigint,
+    tag_id bigint,
+    PRIMARY KEY (customer_id,tag_id),
+    CONSTRAINT fk_customer_tags_customer FOREIGN KEY (customer_id) REFERENCES customers(id),
+    CONSTRAINT fk_customer_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE TABLE IF NOT EXISTS enrollment_rules (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    disabled boolean,
+    priority bigint,
+    company_id bigint,
+    match_tag text,
+    match_lead_source text,
+    pipeline text,
+    funnel_id bigint,
+    funnel_stage text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_enrollment_rules_deleted_at ON enrollment_rules (deleted_at);
@@ -0,0 +1,38 @@
+-- Lead sources, tags and the rules that enroll new customers in pipelines.
+
+ALTER TABLE customers ADD COLUMN lead_source text;
+
+CREATE TABLE IF NOT EXISTS tags (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_name ON tags (name);
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags (deleted_at);
+
+CREATE TABLE IF NOT EXISTS customer_tags (
+    customer_id integer,
+    tag_id integer,
+    PRIMARY KEY (customer_id,tag_id),
+    CONSTRAINT fk_customer_tags_customer FOREIGN KEY (customer_id) REFERENCES customers(id),
+    CONSTRAINT fk_customer_tags_tag FOREIGN KEY (tag_id) REFERENCES tags(id)
+);
+
+CREATE TABLE IF NOT EXISTS enrollment_rules (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    disabled numeric,
+    priority integer,
+    company_id integer,
+    match_tag text,
+    match_lead_source text,
+    pipeline text,
+    funnel_id integer,
+    funnel_stage text
+);
+CREATE INDEX IF NOT EXISTS idx_enrollment_rules_deleted_at ON enrollment_rules (deleted_at);
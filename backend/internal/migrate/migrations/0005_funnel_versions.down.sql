@@ -0,0 +1,4 @@
+ALTER TABLE customer_funnels DROP COLUMN funnel_version_id;
+ALTER TABLE customers DROP COLUMN funnel_version_id;
+DROP TABLE IF EXISTS funnel_version_transitions;
+DROP TABLE IF EXISTS funnel_versions;
@@ -0,0 +1,29 @@
+-- Published snapshots of the funnel graph; customers and memberships are
+-- pinned to the version they were created under.
+
+CREATE TABLE IF NOT EXISTS funnel_versions (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    number bigint,
+    status text,
+    published_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_versions_status ON funnel_versions (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_funnel_versions_number ON funnel_versions (number);
+CREATE INDEX IF NOT EXISTS idx_funnel_versions_deleted_at ON funnel_versions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS funnel_version_transitions (
+    id bigserial,
+    funnel_version_id bigint,
+    from_funnel_id bigint,
+    to_funnel_id bigint,
+    PRIMARY KEY (id),
+    CONSTRAINT fk_funnel_versions_transitions FOREIGN KEY (funnel_version_id) REFERENCES funnel_versions(id)
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_version_transitions_funnel_version_id ON funnel_version_transitions (funnel_version_id);
+
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS funnel_version_id bigint;
+ALTER TABLE customer_funnels ADD COLUMN IF NOT EXISTS funnel_version_id bigint;
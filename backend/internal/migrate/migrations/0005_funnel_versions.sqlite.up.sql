@@ -0,0 +1,27 @@
+-- Published snapshots of the funnel graph; customers and memberships are
+-- pinned to the version they were created under.
+
+CREATE TABLE IF NOT EXISTS funnel_versions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    number integer,
+    status text,
+    published_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_versions_status ON funnel_versions (status);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_funnel_versions_number ON funnel_versions (number);
+CREATE INDEX IF NOT EXISTS idx_funnel_versions_deleted_at ON funnel_versions (deleted_at);
+
+CREATE TABLE IF NOT EXISTS funnel_version_transitions (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    funnel_version_id integer,
+    from_funnel_id integer,
+    to_funnel_id integer,
+    CONSTRAINT fk_funnel_versions_transitions FOREIGN KEY (funnel_version_id) REFERENCES funnel_versions(id)
+);
+CREATE INDEX IF NOT EXISTS idx_funnel_version_transitions_funnel_version_id ON funnel_version_transitions (funnel_version_id);
+
+ALTER TABLE customers ADD COLUMN funnel_version_id integer;
+ALTER TABLE customer_funnels ADD COLUMN funnel_version_id integer;
@@ -0,0 +1,3 @@
+ALTER TABLE customers DROP COLUMN board_position;
+ALTER TABLE funnels DROP COLUMN wip_mode;
+ALTER TABLE funnels DROP COLUMN wip_limit;
@@ -0,0 +1,5 @@
+-- WIP limits per funnel and the manual order of cards on the board.
+
+ALTER TABLE funnels ADD COLUMN IF NOT EXISTS wip_limit bigint;
+ALTER TABLE funnels ADD COLUMN IF NOT EXISTS wip_mode text;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS board_position bigint;
@@ -0,0 +1,5 @@
+-- WIP limits per funnel and the manual order of cards on the board.
+
+ALTER TABLE funnels ADD COLUMN wip_limit integer;
+ALTER TABLE funnels ADD COLUMN wip_mode text;
+ALTER TABLE customers ADD COLUMN board_position integer;
@@ -0,0 +1,19 @@
+-- Results of CSV imports, with the rejected rows of each.
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    entity text,
+    dedupe_key text,
+    dry_run boolean,
+    total bigint,
+    created bigint,
+    updated bigint,
+    failed bigint,
+    user_id bigint,
+    error_report text,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_deleted_at ON import_jobs (deleted_at);
@@ -0,0 +1,18 @@
+-- Results of CSV imports, with the rejected rows of each.
+
+CREATE TABLE IF NOT EXISTS import_jobs (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    entity text,
+    dedupe_key text,
+    dry_run numeric,
+    total integer,
+    created integer,
+    updated integer,
+    failed integer,
+    user_id integer,
+    error_report text
+);
+CREATE INDEX IF NOT EXISTS idx_import_jobs_deleted_at ON import_jobs (deleted_at);
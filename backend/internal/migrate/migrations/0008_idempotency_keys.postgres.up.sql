@@ -0,0 +1,16 @@
+-- Stored responses to requests sent with an Idempotency-Key header.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id bigserial,
+    created_at timestamptz,
+    user_id bigint,
+    key varchar(255),
+    fingerprint text,
+    status_code bigint,
+    header text,
+    body bytea,
+    expires_at timestamptz,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_user_key ON idempotency_keys (user_id,key);
@@ -0,0 +1,15 @@
+-- Stored responses to requests sent with an Idempotency-Key header.
+
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    user_id integer,
+    key text,
+    fingerprint text,
+    status_code integer,
+    header text,
+    body blob,
+    expires_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_user_key ON idempotency_keys (user_id,key);
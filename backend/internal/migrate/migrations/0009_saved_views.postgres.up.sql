@@ -0,0 +1,20 @@
+-- Named list parameters users save and share.
+
+CREATE TABLE IF NOT EXISTS saved_views (
+    id bigserial,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz,
+    name text,
+    resource text,
+    filters text,
+    sort text,
+    columns text,
+    visibility text,
+    user_id bigint,
+    company_id bigint,
+    PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_saved_views_user_id ON saved_views (user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_views_resource ON saved_views (resource);
+CREATE INDEX IF NOT EXISTS idx_saved_views_deleted_at ON saved_views (deleted_at);
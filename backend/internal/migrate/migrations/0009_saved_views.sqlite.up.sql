@@ -0,0 +1,19 @@
+-- Named list parameters users save and share.
+
+CREATE TABLE IF NOT EXISTS saved_views (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    updated_at datetime,
+    deleted_at datetime,
+    name text,
+    resource text,
+    filters text,
+    sort text,
+    columns text,
+    visibility text,
+    user_id integer,
+    company_id integer
+);
+CREATE INDEX IF NOT EXISTS idx_saved_views_user_id ON saved_views (user_id);
+CREATE INDEX IF NOT EXISTS idx_saved_views_resource ON saved_views (resource);
+CREATE INDEX IF NOT EXISTS idx_saved_views_deleted_at ON saved_views (deleted_at);
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_customers_external;
+ALTER TABLE customers DROP COLUMN external_id;
+ALTER TABLE customers DROP COLUMN source;
+DROP INDEX IF EXISTS idx_users_external;
+ALTER TABLE users DROP COLUMN external_id;
+ALTER TABLE users DROP COLUMN source;
+DROP INDEX IF EXISTS idx_companies_external;
+ALTER TABLE companies DROP COLUMN external_id;
+ALTER TABLE companies DROP COLUMN source;
@@ -0,0 +1,13 @@
+-- IDs of records in external systems, unique per source.
+
+ALTER TABLE companies ADD COLUMN source text;
+ALTER TABLE companies ADD COLUMN external_id text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_companies_external ON companies (source,external_id);
+
+ALTER TABLE users ADD COLUMN source text;
+ALTER TABLE users ADD COLUMN external_id text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_external ON users (source,external_id);
+
+ALTER TABLE customers ADD COLUMN source text;
+ALTER TABLE customers ADD COLUMN external_id text;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customers_external ON customers (source,external_id);
@@ -0,0 +1,17 @@
+-- Full-text search over companies, customers and users. search_documents
+-- holds one row per live record and is kept in sync by triggers.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE TABLE IF NOT EXISTS search_documents (
+    entity text NOT NULL,
+    entity_id bigint NOT NULL,
+    title text NOT NULL DEFAULT '',
+    body text NOT NULL DEFAULT '',
+    document tsvector GENERATED ALWAYS AS (
+        setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', body), 'B')
+    ) STORED,
+    PRIMARY KEY (entity, entity_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_documents_document ON search_documents USING GIN (document);
+CREATE INDEX IF NOT EXISTS idx_search_documents_trgm ON search_documents USING GIN ((title || ' ' || body) gin_trgm_ops);
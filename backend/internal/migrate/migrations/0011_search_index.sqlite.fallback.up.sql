@@ -0,0 +1,2 @@
+-- FTS4 is in every SQLite build; search ranks its matches in Go.
+CREATE VIRTUAL TABLE IF NOT EXISTS search_documents USING fts4(entity, entity_id, title, body, notindexed=entity, notindexed=entity_id, tokenize=unicode61);
@@ -0,0 +1,3 @@
+-- Full-text index over companies, customers and users, ranked with bm25.
+-- Builds without the FTS5 module run the fallback file instead.
+CREATE VIRTUAL TABLE IF NOT EXISTS search_documents USING fts5(entity UNINDEXED, entity_id UNINDEXED, title, body, tokenize = 'unicode61', prefix = '2 3');
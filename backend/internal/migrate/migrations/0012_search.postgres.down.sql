@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS search_users ON users;
+DROP TRIGGER IF EXISTS search_customers ON customers;
+DROP TRIGGER IF EXISTS search_companies ON companies;
+DROP FUNCTION IF EXISTS search_users_trigger();
+DROP FUNCTION IF EXISTS search_customers_trigger();
+DROP FUNCTION IF EXISTS search_companies_trigger();
+DROP FUNCTION IF EXISTS search_index_user(bigint);
+DROP FUNCTION IF EXISTS search_index_customer(bigint);
+DROP FUNCTION IF EXISTS search_index_company(bigint);
@@ -0,0 +1,64 @@
+-- Keeps search_documents in sync with companies, customers and users.
+
+CREATE OR REPLACE FUNCTION search_index_company(target bigint) RETURNS void AS $$
+BEGIN
+    DELETE FROM search_documents WHERE entity = 'company' AND entity_id = target;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'company', c.id, coalesce(c.name, ''), coalesce(c.address, '')
+    FROM companies c WHERE c.id = target AND c.deleted_at IS NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_index_customer(target bigint) RETURNS void AS $$
+BEGIN
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id = target;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'customer', c.id, coalesce(c.name, ''),
+        concat_ws(' ', c.email, c.phone, regexp_replace(coalesce(c.phone, ''), '\D', '', 'g'), co.name)
+    FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+    WHERE c.id = target AND c.deleted_at IS NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_index_user(target bigint) RETURNS void AS $$
+BEGIN
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id = target;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'user', u.id, coalesce(u.name, ''), concat_ws(' ', u.email, co.name)
+    FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+    WHERE u.id = target AND u.deleted_at IS NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_companies_trigger() RETURNS trigger AS $$
+DECLARE
+    target bigint := CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END;
+BEGIN
+    PERFORM search_index_company(target);
+    PERFORM search_index_customer(id) FROM customers WHERE company_id = target;
+    PERFORM search_index_user(id) FROM users WHERE company_id = target;
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_customers_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM search_index_customer(CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END);
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION search_users_trigger() RETURNS trigger AS $$
+BEGIN
+    PERFORM search_index_user(CASE WHEN TG_OP = 'DELETE' THEN OLD.id ELSE NEW.id END);
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS search_companies ON companies;
+CREATE TRIGGER search_companies AFTER INSERT OR UPDATE OR DELETE ON companies FOR EACH ROW EXECUTE FUNCTION search_companies_trigger();
+
+DROP TRIGGER IF EXISTS search_customers ON customers;
+CREATE TRIGGER search_customers AFTER INSERT OR UPDATE OR DELETE ON customers FOR EACH ROW EXECUTE FUNCTION search_customers_trigger();
+
+DROP TRIGGER IF EXISTS search_users ON users;
+CREATE TRIGGER search_users AFTER INSERT OR UPDATE OR DELETE ON users FOR EACH ROW EXECUTE FUNCTION search_users_trigger();
+
+DELETE FROM search_documents;
+SELECT search_index_company(id) FROM companies WHERE deleted_at IS NULL;
+SELECT search_index_customer(id) FROM customers WHERE deleted_at IS NULL;
+SELECT search_index_user(id) FROM users WHERE deleted_at IS NULL;
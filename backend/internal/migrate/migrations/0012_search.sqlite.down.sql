@@ -0,0 +1,9 @@
+DROP TRIGGER IF EXISTS search_customers_insert;
+DROP TRIGGER IF EXISTS search_users_insert;
+DROP TRIGGER IF EXISTS search_companies_insert;
+DROP TRIGGER IF EXISTS search_customers_update;
+DROP TRIGGER IF EXISTS search_users_update;
+DROP TRIGGER IF EXISTS search_companies_update;
+DROP TRIGGER IF EXISTS search_customers_delete;
+DROP TRIGGER IF EXISTS search_users_delete;
+DROP TRIGGER IF EXISTS search_companies_delete;
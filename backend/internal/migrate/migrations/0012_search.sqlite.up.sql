@@ -0,0 +1,117 @@
+-- Keeps search_documents in sync with companies, customers and users.
+
+CREATE TRIGGER IF NOT EXISTS search_customers_insert AFTER INSERT ON customers BEGIN
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.id = NEW.id AND c.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_users_insert AFTER INSERT ON users BEGIN
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.id = NEW.id AND u.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_companies_insert AFTER INSERT ON companies BEGIN
+    DELETE FROM search_documents WHERE entity = 'company' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'company', cp.id, cp.name, coalesce(cp.address, '')
+        FROM companies cp
+        WHERE cp.id = NEW.id AND cp.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id IN (SELECT id FROM customers WHERE company_id = NEW.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.company_id = NEW.id AND c.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id IN (SELECT id FROM users WHERE company_id = NEW.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.company_id = NEW.id AND u.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_customers_update AFTER UPDATE ON customers BEGIN
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.id = NEW.id AND c.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_users_update AFTER UPDATE ON users BEGIN
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.id = NEW.id AND u.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_companies_update AFTER UPDATE ON companies BEGIN
+    DELETE FROM search_documents WHERE entity = 'company' AND entity_id = NEW.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'company', cp.id, cp.name, coalesce(cp.address, '')
+        FROM companies cp
+        WHERE cp.id = NEW.id AND cp.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id IN (SELECT id FROM customers WHERE company_id = NEW.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.company_id = NEW.id AND c.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id IN (SELECT id FROM users WHERE company_id = NEW.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.company_id = NEW.id AND u.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_customers_delete AFTER DELETE ON customers BEGIN
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id = OLD.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.id = OLD.id AND c.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_users_delete AFTER DELETE ON users BEGIN
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id = OLD.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.id = OLD.id AND u.deleted_at IS NULL;
+END;
+
+CREATE TRIGGER IF NOT EXISTS search_companies_delete AFTER DELETE ON companies BEGIN
+    DELETE FROM search_documents WHERE entity = 'company' AND entity_id = OLD.id;
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'company', cp.id, cp.name, coalesce(cp.address, '')
+        FROM companies cp
+        WHERE cp.id = OLD.id AND cp.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'customer' AND entity_id IN (SELECT id FROM customers WHERE company_id = OLD.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+        FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+        WHERE c.company_id = OLD.id AND c.deleted_at IS NULL;
+    DELETE FROM search_documents WHERE entity = 'user' AND entity_id IN (SELECT id FROM users WHERE company_id = OLD.id);
+    INSERT INTO search_documents (entity, entity_id, title, body)
+        SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+        FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+        WHERE u.company_id = OLD.id AND u.deleted_at IS NULL;
+END;
+
+DELETE FROM search_documents;
+INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'company', cp.id, cp.name, coalesce(cp.address, '')
+    FROM companies cp
+    WHERE cp.deleted_at IS NULL;
+INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'customer', c.id, c.name, trim(coalesce(c.email, '') || ' ' || coalesce(c.phone, '') || ' ' || replace(replace(replace(replace(replace(replace(coalesce(c.phone, ''), ' ', ''), '-', ''), '(', ''), ')', ''), '+', ''), '.', '') || ' ' || coalesce(co.name, ''))
+    FROM customers c LEFT JOIN companies co ON co.id = c.company_id AND co.deleted_at IS NULL
+    WHERE c.deleted_at IS NULL;
+INSERT INTO search_documents (entity, entity_id, title, body)
+    SELECT 'user', u.id, u.name, trim(coalesce(u.email, '') || ' ' || coalesce(co.name, ''))
+    FROM users u LEFT JOIN companies co ON co.id = u.company_id AND co.deleted_at IS NULL
+    WHERE u.deleted_at IS NULL;
@@ -0,0 +1,11 @@
+DROP TRIGGER IF EXISTS changes_customer_funnels ON customer_funnels;
+DROP TRIGGER IF EXISTS changes_customer_tags ON customer_tags;
+DROP TRIGGER IF EXISTS changes_funnels ON funnels;
+DROP TRIGGER IF EXISTS changes_users ON users;
+DROP TRIGGER IF EXISTS changes_customers ON customers;
+DROP TRIGGER IF EXISTS changes_companies ON companies;
+DROP FUNCTION IF EXISTS changes_parent_trigger();
+DROP FUNCTION IF EXISTS changes_trigger();
+DROP FUNCTION IF EXISTS changes_record(text, bigint, text);
+DROP TABLE IF EXISTS changes;
+DROP TABLE IF EXISTS change_sequence;
@@ -0,0 +1,100 @@
+-- Append-only log of writes to the synced tables, filled by triggers so it
+-- sees every code path.
+--
+-- A sequence would hand out numbers in start order, and a transaction that
+-- started first can commit last, so a client could page past a change that
+-- was not yet visible. Taking the next number from a single counter row
+-- holds that row's lock until commit, which keeps numbers in commit order at
+-- the cost of serialising writes to the watched tables.
+CREATE TABLE IF NOT EXISTS change_sequence (
+    id integer PRIMARY KEY CHECK (id = 1),
+    value bigint NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS changes (
+    seq bigint PRIMARY KEY,
+    entity text NOT NULL,
+    entity_id bigint NOT NULL,
+    action text NOT NULL,
+    changed_at timestamptz NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_changes_entity ON changes (entity, seq);
+
+CREATE OR REPLACE FUNCTION changes_record(target_entity text, target_id bigint, target_action text) RETURNS void AS $$
+DECLARE
+    next_seq bigint;
+BEGIN
+    UPDATE change_sequence SET value = value + 1 WHERE id = 1 RETURNING value INTO next_seq;
+    INSERT INTO changes (seq, entity, entity_id, action, changed_at)
+    VALUES (next_seq, target_entity, target_id, target_action, clock_timestamp());
+END $$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION changes_trigger() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM changes_record(TG_ARGV[0], NEW.id, 'created');
+    ELSIF TG_OP = 'UPDATE' THEN
+        PERFORM changes_record(TG_ARGV[0], NEW.id, CASE WHEN NEW.deleted_at IS NOT NULL THEN 'deleted' WHEN OLD.deleted_at IS NOT NULL THEN 'created' ELSE 'updated' END);
+    ELSE
+        PERFORM changes_record(TG_ARGV[0], OLD.id, 'deleted');
+    END IF;
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+-- changes_parent_trigger(entity, parent table, column) logs an update of
+-- the live parent the row points to, and of the old parent when the row
+-- moves to another one.
+CREATE OR REPLACE FUNCTION changes_parent_trigger() RETURNS trigger AS $$
+DECLARE
+    parent_id bigint;
+    old_parent_id bigint;
+    target bigint;
+    live boolean;
+BEGIN
+    IF TG_OP <> 'DELETE' THEN
+        parent_id := (to_jsonb(NEW) ->> TG_ARGV[2])::bigint;
+    END IF;
+    IF TG_OP <> 'INSERT' THEN
+        old_parent_id := (to_jsonb(OLD) ->> TG_ARGV[2])::bigint;
+    END IF;
+    FOREACH target IN ARRAY ARRAY[parent_id, NULLIF(old_parent_id, parent_id)] LOOP
+        CONTINUE WHEN target IS NULL;
+        EXECUTE format('SELECT EXISTS (SELECT 1 FROM %I WHERE id = $1 AND deleted_at IS NULL)', TG_ARGV[1])
+            INTO live USING target;
+        IF live THEN
+            PERFORM changes_record(TG_ARGV[0], target, 'updated');
+        END IF;
+    END LOOP;
+    RETURN NULL;
+END $$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS changes_companies ON companies;
+CREATE TRIGGER changes_companies AFTER INSERT OR UPDATE OR DELETE ON companies FOR EACH ROW EXECUTE FUNCTION changes_trigger('company');
+DROP TRIGGER IF EXISTS changes_customers ON customers;
+CREATE TRIGGER changes_customers AFTER INSERT OR UPDATE OR DELETE ON customers FOR EACH ROW EXECUTE FUNCTION changes_trigger('customer');
+DROP TRIGGER IF EXISTS changes_users ON users;
+CREATE TRIGGER changes_users AFTER INSERT OR UPDATE OR DELETE ON users FOR EACH ROW EXECUTE FUNCTION changes_trigger('user');
+DROP TRIGGER IF EXISTS changes_funnels ON funnels;
+CREATE TRIGGER changes_funnels AFTER INSERT OR UPDATE OR DELETE ON funnels FOR EACH ROW EXECUTE FUNCTION changes_trigger('funnel');
+
+DROP TRIGGER IF EXISTS changes_customer_tags ON customer_tags;
+CREATE TRIGGER changes_customer_tags AFTER INSERT OR UPDATE OR DELETE ON customer_tags FOR EACH ROW EXECUTE FUNCTION changes_parent_trigger('customer', 'customers', 'customer_id');
+DROP TRIGGER IF EXISTS changes_customer_funnels ON customer_funnels;
+CREATE TRIGGER changes_customer_funnels AFTER INSERT OR UPDATE OR DELETE ON customer_funnels FOR EACH ROW EXECUTE FUNCTION changes_parent_trigger('customer', 'customers', 'customer_id');
+
+-- Every live row starts as created, so a client without a cursor gets a
+-- full copy. Databases that already have a log keep it.
+INSERT INTO changes (seq, entity, entity_id, action, changed_at)
+SELECT row_number() OVER (ORDER BY changed_at, entity, id), entity, id, 'created', changed_at FROM (
+    SELECT 'company' AS entity, id, coalesce(updated_at, now()) AS changed_at FROM companies WHERE deleted_at IS NULL
+    UNION ALL
+    SELECT 'customer' AS entity, id, coalesce(updated_at, now()) AS changed_at FROM customers WHERE deleted_at IS NULL
+    UNION ALL
+    SELECT 'user' AS entity, id, coalesce(updated_at, now()) AS changed_at FROM users WHERE deleted_at IS NULL
+    UNION ALL
+    SELECT 'funnel' AS entity, id, coalesce(updated_at, now()) AS changed_at FROM funnels WHERE deleted_at IS NULL
+) existing
+WHERE NOT EXISTS (SELECT 1 FROM changes);
+INSERT INTO change_sequence (id, value) VALUES (1, 0) ON CONFLICT DO NOTHING;
+UPDATE change_sequence SET value = (SELECT coalesce(max(seq), 0) FROM changes) WHERE id = 1;
@@ -0,0 +1,19 @@
+DROP TRIGGER IF EXISTS changes_companies_insert;
+DROP TRIGGER IF EXISTS changes_companies_update;
+DROP TRIGGER IF EXISTS changes_companies_delete;
+DROP TRIGGER IF EXISTS changes_customers_insert;
+DROP TRIGGER IF EXISTS changes_customers_update;
+DROP TRIGGER IF EXISTS changes_customers_delete;
+DROP TRIGGER IF EXISTS changes_users_insert;
+DROP TRIGGER IF EXISTS changes_users_update;
+DROP TRIGGER IF EXISTS changes_users_delete;
+DROP TRIGGER IF EXISTS changes_funnels_insert;
+DROP TRIGGER IF EXISTS changes_funnels_update;
+DROP TRIGGER IF EXISTS changes_funnels_delete;
+DROP TRIGGER IF EXISTS changes_customer_tags_insert;
+DROP TRIGGER IF EXISTS changes_customer_tags_update;
+DROP TRIGGER IF EXISTS changes_customer_tags_delete;
+DROP TRIGGER IF EXISTS changes_customer_funnels_insert;
+DROP TRIGGER IF EXISTS changes_customer_funnels_update;
+DROP TRIGGER IF EXISTS changes_customer_funnels_delete;
+DROP TABLE IF EXISTS changes;
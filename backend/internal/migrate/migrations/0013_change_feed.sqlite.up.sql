@@ -0,0 +1,101 @@
+-- Append-only log of writes to the synced tables, filled by triggers so it
+-- sees every code path. SQLite runs one writer at a time, so the
+-- autoincrement key is already in commit order.
+CREATE TABLE IF NOT EXISTS changes (
+    seq integer PRIMARY KEY AUTOINCREMENT,
+    entity text NOT NULL,
+    entity_id integer NOT NULL,
+    action text NOT NULL,
+    changed_at datetime NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_changes_entity ON changes (entity, seq);
+
+-- Every live row starts as created, so a client without a cursor gets a
+-- full copy.
+INSERT INTO changes (entity, entity_id, action, changed_at)
+    SELECT 'company', id, 'created', coalesce(updated_at, CURRENT_TIMESTAMP) FROM companies WHERE deleted_at IS NULL ORDER BY id;
+INSERT INTO changes (entity, entity_id, action, changed_at)
+    SELECT 'customer', id, 'created', coalesce(updated_at, CURRENT_TIMESTAMP) FROM customers WHERE deleted_at IS NULL ORDER BY id;
+INSERT INTO changes (entity, entity_id, action, changed_at)
+    SELECT 'user', id, 'created', coalesce(updated_at, CURRENT_TIMESTAMP) FROM users WHERE deleted_at IS NULL ORDER BY id;
+INSERT INTO changes (entity, entity_id, action, changed_at)
+    SELECT 'funnel', id, 'created', coalesce(updated_at, CURRENT_TIMESTAMP) FROM funnels WHERE deleted_at IS NULL ORDER BY id;
+
+CREATE TRIGGER IF NOT EXISTS changes_companies_insert AFTER INSERT ON companies BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('company', NEW.id, 'created', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_companies_update AFTER UPDATE ON companies BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('company', NEW.id, CASE WHEN NEW.deleted_at IS NOT NULL THEN 'deleted' WHEN OLD.deleted_at IS NOT NULL THEN 'created' ELSE 'updated' END, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_companies_delete AFTER DELETE ON companies BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('company', OLD.id, 'deleted', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_customers_insert AFTER INSERT ON customers BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('customer', NEW.id, 'created', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customers_update AFTER UPDATE ON customers BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('customer', NEW.id, CASE WHEN NEW.deleted_at IS NOT NULL THEN 'deleted' WHEN OLD.deleted_at IS NOT NULL THEN 'created' ELSE 'updated' END, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customers_delete AFTER DELETE ON customers BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('customer', OLD.id, 'deleted', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_users_insert AFTER INSERT ON users BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('user', NEW.id, 'created', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_users_update AFTER UPDATE ON users BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('user', NEW.id, CASE WHEN NEW.deleted_at IS NOT NULL THEN 'deleted' WHEN OLD.deleted_at IS NOT NULL THEN 'created' ELSE 'updated' END, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_users_delete AFTER DELETE ON users BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('user', OLD.id, 'deleted', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_funnels_insert AFTER INSERT ON funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('funnel', NEW.id, 'created', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_funnels_update AFTER UPDATE ON funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('funnel', NEW.id, CASE WHEN NEW.deleted_at IS NOT NULL THEN 'deleted' WHEN OLD.deleted_at IS NOT NULL THEN 'created' ELSE 'updated' END, strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+CREATE TRIGGER IF NOT EXISTS changes_funnels_delete AFTER DELETE ON funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at) VALUES ('funnel', OLD.id, 'deleted', strftime('%Y-%m-%d %H:%M:%f', 'now'));
+END;
+
+-- A write to a tag link or a membership is an update of the live customer.
+CREATE TRIGGER IF NOT EXISTS changes_customer_tags_insert AFTER INSERT ON customer_tags BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', NEW.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = NEW.customer_id AND deleted_at IS NULL);
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customer_tags_update AFTER UPDATE ON customer_tags BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', NEW.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = NEW.customer_id AND deleted_at IS NULL);
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', OLD.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = OLD.customer_id AND deleted_at IS NULL) AND OLD.customer_id IS NOT NEW.customer_id;
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customer_tags_delete AFTER DELETE ON customer_tags BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', OLD.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = OLD.customer_id AND deleted_at IS NULL);
+END;
+
+CREATE TRIGGER IF NOT EXISTS changes_customer_funnels_insert AFTER INSERT ON customer_funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', NEW.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = NEW.customer_id AND deleted_at IS NULL);
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customer_funnels_update AFTER UPDATE ON customer_funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', NEW.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = NEW.customer_id AND deleted_at IS NULL);
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', OLD.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = OLD.customer_id AND deleted_at IS NULL) AND OLD.customer_id IS NOT NEW.customer_id;
+END;
+CREATE TRIGGER IF NOT EXISTS changes_customer_funnels_delete AFTER DELETE ON customer_funnels BEGIN
+    INSERT INTO changes (entity, entity_id, action, changed_at)
+        SELECT 'customer', OLD.customer_id, 'updated', strftime('%Y-%m-%d %H:%M:%f', 'now')
+        WHERE EXISTS (SELECT 1 FROM customers WHERE id = OLD.customer_id AND deleted_at IS NULL);
+END;
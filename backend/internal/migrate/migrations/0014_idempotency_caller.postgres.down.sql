@@ -0,0 +1,6 @@
+ALTER TABLE idempotency_keys ADD COLUMN user_id bigint;
+DELETE FROM idempotency_keys WHERE caller NOT LIKE 'user:%';
+UPDATE idempotency_keys SET user_id = CAST(substr(caller, 6) AS bigint);
+DROP INDEX IF EXISTS idx_idempotency_caller_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_user_key ON idempotency_keys (user_id,key);
+ALTER TABLE idempotency_keys DROP COLUMN caller;
@@ -0,0 +1,7 @@
+-- Idempotency keys are scoped by caller instead of user id, so that service
+-- keys, which have no user, get a namespace of their own.
+ALTER TABLE idempotency_keys ADD COLUMN caller varchar(255);
+UPDATE idempotency_keys SET caller = 'user:' || user_id;
+DROP INDEX IF EXISTS idx_idempotency_user_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_caller_key ON idempotency_keys (caller,key);
+ALTER TABLE idempotency_keys DROP COLUMN user_id;
@@ -0,0 +1,14 @@
+DROP TABLE IF EXISTS idempotency_keys;
+CREATE TABLE idempotency_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    user_id integer,
+    key text,
+    fingerprint text,
+    status_code integer,
+    header text,
+    body blob,
+    expires_at datetime
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX idx_idempotency_user_key ON idempotency_keys (user_id,key);
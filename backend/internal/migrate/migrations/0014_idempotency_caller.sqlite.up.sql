@@ -0,0 +1,18 @@
+-- Idempotency keys are scoped by caller instead of user id, so that service
+-- keys, which have no user, get a namespace of their own. Keys only live for
+-- the idempotency TTL, so SQLite databases, which are only used in
+-- development, start over instead of copying them.
+DROP TABLE IF EXISTS idempotency_keys;
+CREATE TABLE idempotency_keys (
+    id integer PRIMARY KEY AUTOINCREMENT,
+    created_at datetime,
+    caller text,
+    key text,
+    fingerprint text,
+    status_code integer,
+    header text,
+    body blob,
+    expires_at datetime
+);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
+CREATE UNIQUE INDEX idx_idempotency_caller_key ON idempotency_keys (caller,key);
@@ -4,10 +4,12 @@ import "gorm.io/gorm"
 
 type Company struct {
 	gorm.Model
-	Name      string     `json:"name" binding:"required"`
-	Address   string     `json:"address"`
-	Users     []User     `json:"users,omitempty"`
-	Customers []Customer `json:"customers,omitempty"`
-	FunnelID  *uint      `json:"funnel_id"`
-	Funnel    *Funnel    `json:"funnel,omitempty"`
+	Name       string     `json:"name" binding:"required"`
+	Address    string     `json:"address"`
+	Source     *string    `json:"source" gorm:"uniqueIndex:idx_companies_external"`
+	ExternalID *string    `json:"external_id" gorm:"uniqueIndex:idx_companies_external"`
+	Users      []User     `json:"users,omitempty"`
+	Customers  []Customer `json:"customers,omitempty"`
+	FunnelID   *uint      `json:"funnel_id"`
+	Funnel     *Funnel    `json:"funnel,omitempty"`
 }
@@ -0,0 +1,9 @@
+package models
+
+// CompanyInput is the body of a company create or full update. Source and
+// ExternalID are left out; they are only written by the external-ID upsert.
+type CompanyInput struct {
+	Name     string `json:"name" binding:"required"`
+	Address  string `json:"address"`
+	FunnelID *uint  `json:"funnel_id"`
+}
@@ -4,11 +4,18 @@ import "gorm.io/gorm"
 
 type Customer struct {
 	gorm.Model
-	Name        string  `json:"name" binding:"required"`
-	Email       string  `json:"email"`
-	Phone       string  `json:"phone"`
-	CompanyID   uint    `json:"company_id"`
-	Company     Company `json:"-"`
-	FunnelID    *uint   `json:"funnel_id"`
-	FunnelStage string  `json:"funnel_stage"`
+	Name            string           `json:"name" binding:"required"`
+	Email           string           `json:"email"`
+	Phone           string           `json:"phone"`
+	Source          *string          `json:"source" gorm:"uniqueIndex:idx_customers_external"`
+	ExternalID      *string          `json:"external_id" gorm:"uniqueIndex:idx_customers_external"`
+	CompanyID       uint             `json:"company_id"`
+	Company         Company          `json:"-" binding:"-"`
+	FunnelID        *uint            `json:"funnel_id"`
+	FunnelStage     string           `json:"funnel_stage"`
+	FunnelVersionID *uint            `json:"funnel_version_id"`
+	LeadSource      string           `json:"lead_source"`
+	BoardPosition   int              `json:"board_position"`
+	Tags            []Tag            `json:"tags,omitempty" gorm:"many2many:customer_tags"`
+	Memberships     []CustomerFunnel `json:"memberships,omitempty"`
 }
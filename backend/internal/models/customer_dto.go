@@ -1,9 +1,59 @@
 package models
 
+// CreateCustomerInput is the body of a customer create. Source and
+// ExternalID are left out; they are only written by the external-ID upsert.
+type CreateCustomerInput struct {
+	Name        string `json:"name" binding:"required"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	CompanyID   uint   `json:"company_id"`
+	FunnelID    *uint  `json:"funnel_id"`
+	FunnelStage string `json:"funnel_stage"`
+	LeadSource  string `json:"lead_source"`
+	Tags        []Tag  `json:"tags"`
+}
+
+func (in CreateCustomerInput) Customer() Customer {
+	return Customer{
+		Name:        in.Name,
+		Email:       in.Email,
+		Phone:       in.Phone,
+		CompanyID:   in.CompanyID,
+		FunnelID:    in.FunnelID,
+		FunnelStage: in.FunnelStage,
+		LeadSource:  in.LeadSource,
+		Tags:        in.Tags,
+	}
+}
+
 type UpdateCustomerInput struct {
-	Name        string  `json:"name"`
-	Email       string  `json:"email"`
-	Phone       string  `json:"phone"`
-	FunnelID    *uint   `json:"funnel_id"`
-	FunnelStage string  `json:"funnel_stage"`
+	Name        string                `json:"name"`
+	Email       string                `json:"email"`
+	Phone       string                `json:"phone"`
+	FunnelID    *uint                 `json:"funnel_id"`
+	FunnelStage string                `json:"funnel_stage"`
+	LeadSource  string                `json:"lead_source"`
+	Tags        []string              `json:"tags"`
+	Memberships []CustomerFunnelInput `json:"memberships" binding:"dive"`
+}
+
+// UpsertCustomerInput is the body of a customer upsert. CompanyID only
+// applies when the customer is created, as with PUT /customers/:id.
+type UpsertCustomerInput struct {
+	Name        string                `json:"name" binding:"required"`
+	Email       string                `json:"email"`
+	Phone       string                `json:"phone"`
+	CompanyID   uint                  `json:"company_id"`
+	FunnelID    *uint                 `json:"funnel_id"`
+	FunnelStage string                `json:"funnel_stage"`
+	LeadSource  string                `json:"lead_source"`
+	Tags        []string              `json:"tags"`
+	Memberships []CustomerFunnelInput `json:"memberships" binding:"dive"`
+}
+
+type CustomerFunnelInput struct {
+	Pipeline    string `json:"pipeline" binding:"required"`
+	FunnelID    *uint  `json:"funnel_id"`
+	FunnelStage string `json:"funnel_stage"`
+	Remove      bool   `json:"remove"`
 }
@@ -0,0 +1,13 @@
+package models
+
+import "gorm.io/gorm"
+
+type CustomerFunnel struct {
+	gorm.Model
+	CustomerID      uint    `json:"customer_id" gorm:"uniqueIndex:idx_customer_pipeline"`
+	Pipeline        string  `json:"pipeline" gorm:"uniqueIndex:idx_customer_pipeline"`
+	FunnelID        uint    `json:"funnel_id" gorm:"index"`
+	Funnel          *Funnel `json:"funnel,omitempty"`
+	FunnelStage     string  `json:"funnel_stage"`
+	FunnelVersionID *uint   `json:"funnel_version_id"`
+}
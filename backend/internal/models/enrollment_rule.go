@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+type EnrollmentRule struct {
+	gorm.Model
+	Name            string `json:"name" binding:"required"`
+	Disabled        bool   `json:"disabled"`
+	Priority        int    `json:"priority"`
+	CompanyID       *uint  `json:"company_id"`
+	MatchTag        string `json:"match_tag"`
+	MatchLeadSource string `json:"match_lead_source"`
+	Pipeline        string `json:"pipeline" binding:"required"`
+	FunnelID        uint   `json:"funnel_id" binding:"required"`
+	FunnelStage     string `json:"funnel_stage"`
+}
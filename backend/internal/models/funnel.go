@@ -2,9 +2,16 @@ package models
 
 import "gorm.io/gorm"
 
+const (
+	WIPModeBlock = "block"
+	WIPModeWarn  = "warn"
+)
+
 type Funnel struct {
 	gorm.Model
 	Name            string    `json:"name" binding:"required"`
+	WIPLimit        *int      `gorm:"column:wip_limit" json:"wip_limit"`
+	WIPMode         string    `gorm:"column:wip_mode" json:"wip_mode"`
 	NextFunnels     []*Funnel `gorm:"many2many:funnel_transitions;joinForeignKey:from_funnel_id;joinReferences:to_funnel_id" json:"next_funnels"`
 	PreviousFunnels []*Funnel `gorm:"many2many:funnel_transitions;joinForeignKey:to_funnel_id;joinReferences:from_funnel_id" json:"previous_funnels"`
 }
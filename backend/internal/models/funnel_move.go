@@ -0,0 +1,18 @@
+package models
+
+import "gorm.io/gorm"
+
+const (
+	FunnelMoveEntityCustomer = "customer"
+	FunnelMoveEntityCompany  = "company"
+	FunnelMoveEntityMember   = "customer_funnel"
+)
+
+type FunnelMove struct {
+	gorm.Model
+	EntityType   string `json:"entity_type" gorm:"index:idx_funnel_moves_entity"`
+	EntityID     uint   `json:"entity_id" gorm:"index:idx_funnel_moves_entity"`
+	FromFunnelID uint   `json:"from_funnel_id"`
+	ToFunnelID   uint   `json:"to_funnel_id"`
+	Reason       string `json:"reason"`
+}
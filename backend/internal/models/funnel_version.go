@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FunnelVersionStatus string
+
+const (
+	FunnelVersionDraft    FunnelVersionStatus = "draft"
+	FunnelVersionActive   FunnelVersionStatus = "active"
+	FunnelVersionArchived FunnelVersionStatus = "archived"
+)
+
+type FunnelVersion struct {
+	gorm.Model
+	Number      int                       `json:"number" gorm:"uniqueIndex"`
+	Status      FunnelVersionStatus       `json:"status" gorm:"index"`
+	PublishedAt *time.Time                `json:"published_at"`
+	Transitions []FunnelVersionTransition `json:"transitions,omitempty"`
+}
+
+type FunnelVersionTransition struct {
+	ID              uint `json:"id" gorm:"primarykey"`
+	FunnelVersionID uint `json:"funnel_version_id" gorm:"index"`
+	FromFunnelID    uint `json:"from_funnel_id"`
+	ToFunnelID      uint `json:"to_funnel_id"`
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// IdempotencyKey records the response to a request sent with an
+// Idempotency-Key header. It is hard-deleted when it expires, so it does not
+// embed gorm.Model.
+type IdempotencyKey struct {
+	ID          uint `gorm:"primarykey"`
+	CreatedAt   time.Time
+	Caller      string `gorm:"uniqueIndex:idx_idempotency_caller_key;size:255"` // "user:<id>" or "key:<digest>"
+	Key         string `gorm:"uniqueIndex:idx_idempotency_caller_key;size:255"`
+	Fingerprint string
+	StatusCode  int    // zero while the first request is still running
+	Header      string `gorm:"type:text"`
+	Body        []byte
+	ExpiresAt   time.Time `gorm:"index"`
+}
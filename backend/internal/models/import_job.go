@@ -0,0 +1,16 @@
+package models
+
+import "gorm.io/gorm"
+
+type ImportJob struct {
+	gorm.Model
+	Entity      string `json:"entity"`
+	DedupeKey   string `json:"dedupe_key"`
+	DryRun      bool   `json:"dry_run"`
+	Total       int    `json:"total"`
+	Created     int    `json:"created"`
+	Updated     int    `json:"updated"`
+	Failed      int    `json:"failed"`
+	UserID      uint   `json:"user_id"`
+	ErrorReport string `json:"-" gorm:"type:text"`
+}
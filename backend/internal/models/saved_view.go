@@ -0,0 +1,26 @@
+package models
+
+import "gorm.io/gorm"
+
+type ViewVisibility string
+
+const (
+	ViewPrivate  ViewVisibility = "private"
+	ViewTeam     ViewVisibility = "team"
+	ViewEveryone ViewVisibility = "everyone"
+)
+
+// SavedView stores list parameters for one resource. Filters use the same
+// keys as the list endpoint's query string, e.g. "funnel_stage" or
+// "name[contains]". A team view is visible to users of the owner's company.
+type SavedView struct {
+	gorm.Model
+	Name       string            `json:"name" binding:"required"`
+	Resource   string            `json:"resource" gorm:"index" binding:"required,oneof=companies customers users funnels"`
+	Filters    map[string]string `json:"filters" gorm:"serializer:json"`
+	Sort       string            `json:"sort"`
+	Columns    []string          `json:"columns" gorm:"serializer:json"`
+	Visibility ViewVisibility    `json:"visibility" binding:"omitempty,oneof=private team everyone"`
+	UserID     uint              `json:"user_id" gorm:"index" binding:"-"`
+	CompanyID  *uint             `json:"company_id" binding:"-"`
+}
@@ -12,10 +12,12 @@ const (
 
 type User struct {
 	gorm.Model
-	Name      string  `json:"name" binding:"required"`
-	Email     string  `json:"email" gorm:"uniqueIndex" binding:"required,email"`
-	Password  string  `json:"-"`
-	Role      Role    `json:"role" binding:"required"`
-	CompanyID *uint   `json:"company_id"`
-	Company   Company `json:"company,omitempty"`
+	Name       string  `json:"name" binding:"required"`
+	Email      string  `json:"email" gorm:"uniqueIndex" binding:"required,email"`
+	Password   string  `json:"-"`
+	Role       Role    `json:"role" binding:"required"`
+	CompanyID  *uint   `json:"company_id"`
+	Company    Company `json:"company,omitempty"`
+	Source     *string `json:"source" gorm:"uniqueIndex:idx_users_external"`
+	ExternalID *string `json:"external_id" gorm:"uniqueIndex:idx_users_external"`
 }
@@ -0,0 +1,479 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Style       string  `json:"style,omitempty"`
+	Explode     *bool   `json:"explode,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 interface{}        `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties interface{}        `json:"additionalProperties,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+}
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func Nullable(s *Schema) *Schema {
+	if s.Ref != "" || s.Type == nil {
+		return &Schema{AnyOf: []*Schema{s, {Type: "null"}}}
+	}
+	out := *s
+	switch t := s.Type.(type) {
+	case string:
+		out.Type = []string{t, "null"}
+	case []string:
+		out.Type = append(append([]string{}, t...), "null")
+	}
+	return &out
+}
+
+type Route struct {
+	Method     string
+	Path       string
+	Summary    string
+	Tag        string
+	Public     bool
+	Deprecated bool
+	Query      []Parameter
+	Body       interface{}
+	BodyType   string
+	BodySchema *Schema
+	Status     int
+	Response   interface{}
+	Content    map[string]*Schema
+	Headers    map[string]*Header
+	Errors     []int
+	Extra      map[int]string
+}
+
+type Builder struct {
+	doc       *Document
+	names     map[reflect.Type]string
+	overrides map[reflect.Type]*Schema
+}
+
+func NewBuilder(info Info) *Builder {
+	return &Builder{
+		doc: &Document{
+			OpenAPI: Version,
+			Info:    info,
+			Paths:   map[string]map[string]*Operation{},
+			Components: Components{
+				Schemas: map[string]*Schema{},
+				SecuritySchemes: map[string]SecurityScheme{
+					"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				},
+			},
+			Security: []map[string][]string{{"bearerAuth": {}}},
+		},
+		names:     map[reflect.Type]string{},
+		overrides: map[reflect.Type]*Schema{},
+	}
+}
+
+func (b *Builder) Define(name string, v interface{}, schema *Schema) {
+	b.doc.Components.Schemas[name] = schema
+	if v != nil {
+		b.overrides[reflect.TypeOf(v)] = Ref(name)
+	}
+}
+
+var pathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+func PathFromGin(path string) string {
+	return pathParam.ReplaceAllString(path, "{$1}")
+}
+
+func (b *Builder) Add(r Route) {
+	path := PathFromGin(r.Path)
+	op := &Operation{
+		OperationID: operationID(r.Method, path),
+		Summary:     r.Summary,
+		Responses:   map[string]*Response{},
+		Deprecated:  r.Deprecated,
+	}
+	if r.Tag != "" {
+		op.Tags = []string{r.Tag}
+	}
+	if r.Public {
+		op.Security = []map[string][]string{}
+	}
+
+	// Path parameters are IDs unless the route declares them in Query.
+	declared := map[string]bool{}
+	for _, param := range r.Query {
+		if param.In == "path" {
+			declared[param.Name] = true
+		}
+	}
+	for _, match := range pathParam.FindAllStringSubmatch(r.Path, -1) {
+		if declared[match[1]] {
+			continue
+		}
+		op.Parameters = append(op.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: &Schema{Type: "integer", Minimum: float(1)}})
+	}
+	op.Parameters = append(op.Parameters, r.Query...)
+
+	if r.Body != nil || r.BodySchema != nil {
+		schema := r.BodySchema
+		if schema == nil {
+			schema = b.Schema(r.Body)
+		}
+		contentType := r.BodyType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{contentType: {Schema: schema}}}
+	}
+
+	status := r.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status), Headers: r.Headers}
+	if r.Response != nil {
+		success.Content = map[string]*MediaType{"application/json": {Schema: b.Schema(r.Response)}}
+	}
+	for contentType, schema := range r.Content {
+		if success.Content == nil {
+			success.Content = map[string]*MediaType{}
+		}
+		success.Content[contentType] = &MediaType{Schema: schema}
+	}
+	op.Responses[strconv.Itoa(status)] = success
+
+	for code, description := range r.Extra {
+		op.Responses[strconv.Itoa(code)] = &Response{Description: description}
+	}
+
+	errors := r.Errors
+	if !r.Public {
+		errors = append([]int{http.StatusUnauthorized}, errors...)
+	}
+	for _, code := range errors {
+		op.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{"application/problem+json": {Schema: Ref("Problem")}},
+		}
+	}
+
+	if b.doc.Paths[path] == nil {
+		b.doc.Paths[path] = map[string]*Operation{}
+	}
+	b.doc.Paths[path][strings.ToLower(r.Method)] = op
+}
+
+func (b *Builder) Document() *Document {
+	return b.doc
+}
+
+func operationID(method, path string) string {
+	var out strings.Builder
+	out.WriteString(strings.ToLower(method))
+	upper := true
+	for _, r := range path {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+func (b *Builder) Schema(v interface{}) *Schema {
+	return b.schemaFor(reflect.TypeOf(v))
+}
+
+func (b *Builder) schemaFor(t reflect.Type) *Schema {
+	if s, ok := b.overrides[t]; ok {
+		return s
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+	if t.Kind() == reflect.Ptr {
+		if s, ok := b.overrides[t.Elem()]; ok {
+			return Nullable(s)
+		}
+		return Nullable(b.schemaFor(t.Elem()))
+	}
+	if t.Implements(marshalerType) && t.Kind() == reflect.Struct {
+		// gorm.DeletedAt and similar wrappers marshal as a nullable timestamp.
+		if t.Name() == "DeletedAt" {
+			return &Schema{Type: []string{"string", "null"}, Format: "date-time"}
+		}
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Minimum: float(0)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: []string{"array", "null"}, Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := b.componentName(t)
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		return Ref(name)
+	}
+	return &Schema{}
+}
+
+func (b *Builder) componentName(t reflect.Type) string {
+	if name, ok := b.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for other, taken := range b.names {
+		if taken == name && other != t {
+			pkg := t.PkgPath()
+			name = strings.Title(pkg[strings.LastIndex(pkg, "/")+1:]) + name
+			break
+		}
+	}
+	b.names[t] = name
+	return name
+}
+
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(s, t)
+	sort.Strings(s.Required)
+	return s
+}
+
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if _, ok := b.overrides[embedded]; !ok {
+					b.addFields(s, embedded)
+					continue
+				}
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := b.schemaFor(field.Type)
+		required := applyRules(&prop, field.Tag.Get("binding"))
+		s.Properties[name] = prop
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
+
+// WithRules returns a copy of s constrained by validator rules such as
+// "required,min=1,oneof=a b".
+func WithRules(s *Schema, rules string) *Schema {
+	applyRules(&s, rules)
+	return s
+}
+
+func applyRules(prop **Schema, binding string) bool {
+	if binding == "" || binding == "-" {
+		return false
+	}
+	required, optional := false, false
+	target := *prop
+	if target.Ref == "" {
+		copied := *target
+		target = &copied
+	}
+	for _, rule := range strings.Split(binding, ",") {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "required":
+			required = true
+		case "omitempty":
+			optional = true
+		case "email":
+			target.Format = "email"
+		case "oneof":
+			// omitempty lets the zero value through the validator.
+			if optional && strings.Contains(fmt.Sprint(target.Type), "string") {
+				target.Enum = append(target.Enum, "")
+			}
+			for _, v := range strings.Fields(param) {
+				target.Enum = append(target.Enum, v)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			setBound(target, key == "min", n)
+		}
+	}
+	*prop = target
+	return required
+}
+
+func setBound(s *Schema, min bool, n int) {
+	types := fmt.Sprint(s.Type)
+	switch {
+	case strings.Contains(types, "string"):
+		if min {
+			s.MinLength = &n
+		} else {
+			s.MaxLength = &n
+		}
+	case strings.Contains(types, "array"):
+		if min {
+			s.MinItems = &n
+		} else {
+			s.MaxItems = &n
+		}
+	case strings.Contains(types, "integer"), strings.Contains(types, "number"):
+		if min {
+			s.Minimum = float(n)
+		} else {
+			s.Maximum = float(n)
+		}
+	}
+}
+
+func float(n int) *float64 {
+	f := float64(n)
+	return &f
+}
@@ -0,0 +1,69 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+type testOwner struct {
+	Name string `json:"name"`
+}
+
+type testRecord struct {
+	gorm.Model
+	Title    string            `json:"title" binding:"required,min=2"`
+	Email    string            `json:"email" binding:"omitempty,email"`
+	Status   string            `json:"status" binding:"oneof=open closed"`
+	Limit    *int              `json:"limit" binding:"omitempty,min=0"`
+	Owner    *testOwner        `json:"owner,omitempty"`
+	Labels   []string          `json:"labels"`
+	Meta     map[string]string `json:"meta"`
+	Seen     time.Time         `json:"seen"`
+	Internal string            `json:"-"`
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	ref := b.Schema(testRecord{})
+	assert.Equal(t, "#/components/schemas/testRecord", ref.Ref)
+
+	doc := b.Document()
+	s := doc.Components.Schemas["testRecord"]
+	require.NotNil(t, s)
+	assert.Equal(t, []string{"title"}, s.Required)
+	assert.NotContains(t, s.Properties, "Internal")
+	assert.Contains(t, s.Properties, "ID")
+	assert.Equal(t, []string{"string", "null"}, s.Properties["DeletedAt"].Type)
+	assert.Equal(t, 2, *s.Properties["title"].MinLength)
+	assert.Equal(t, "email", s.Properties["email"].Format)
+	assert.Equal(t, []interface{}{"open", "closed"}, s.Properties["status"].Enum)
+	assert.Equal(t, []string{"integer", "null"}, s.Properties["limit"].Type)
+	assert.Equal(t, float64(0), *s.Properties["limit"].Minimum)
+	assert.Equal(t, "#/components/schemas/testOwner", s.Properties["owner"].AnyOf[0].Ref)
+	assert.Equal(t, "date-time", s.Properties["seen"].Format)
+	assert.Equal(t, "object", s.Properties["meta"].Type)
+}
+
+func TestValidate(t *testing.T) {
+	b := NewBuilder(Info{Title: "test", Version: "1"})
+	schema := b.Schema([]testRecord{})
+	doc := b.Document()
+
+	valid := `[{"ID":1,"CreatedAt":"2024-01-01T00:00:00Z","UpdatedAt":"2024-01-01T00:00:00Z","DeletedAt":null,
+		"title":"ok","email":"","status":"open","limit":null,"owner":{"name":"x"},"labels":null,"meta":{},"seen":"2024-01-01T00:00:00Z"}]`
+	assert.NoError(t, doc.ValidateJSON(schema, []byte(valid)))
+
+	assert.ErrorContains(t, doc.ValidateJSON(schema, []byte(`[{"ID":1}]`)), "missing required property \"title\"")
+	assert.ErrorContains(t, doc.ValidateJSON(schema, []byte(`[{"title":"ok","status":"pending"}]`)), "is not one of")
+	assert.ErrorContains(t, doc.ValidateJSON(schema, []byte(`[{"title":5}]`)), "expected string")
+	assert.ErrorContains(t, doc.ValidateJSON(schema, []byte(`[{"title":"ok","extra":true}]`)), "not described")
+}
+
+func TestPathFromGin(t *testing.T) {
+	assert.Equal(t, "/api/funnels/{id}/board/columns/{stage_id}", PathFromGin("/api/funnels/:id/board/columns/:stage_id"))
+	assert.Equal(t, "getApiFunnelsIdBoard", operationID("GET", "/api/funnels/{id}/board"))
+}
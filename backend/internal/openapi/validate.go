@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Validate checks a decoded JSON value against a schema, following $refs into
+// the document's components. It covers the subset of JSON Schema the
+// generator emits.
+func (d *Document) Validate(schema *Schema, value interface{}) error {
+	return d.validate(schema, value, "$")
+}
+
+func (d *Document) ValidateJSON(schema *Schema, body []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return err
+	}
+	return d.Validate(schema, value)
+}
+
+func (d *Document) validate(s *Schema, value interface{}, path string) error {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		target, ok := d.Components.Schemas[name]
+		if !ok {
+			return fmt.Errorf("%s: unresolved reference %s", path, s.Ref)
+		}
+		return d.validate(target, value, path)
+	}
+
+	if len(s.AnyOf) > 0 {
+		var errs []string
+		for _, option := range s.AnyOf {
+			err := d.validate(option, value, path)
+			if err == nil {
+				return nil
+			}
+			errs = append(errs, err.Error())
+		}
+		return fmt.Errorf("%s: no anyOf option matched (%s)", path, strings.Join(errs, "; "))
+	}
+
+	if types := typeList(s.Type); len(types) > 0 {
+		actual := jsonType(value)
+		matched := false
+		for _, t := range types {
+			if t == actual || (t == "number" && actual == "integer") {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: expected %s, got %s", path, strings.Join(types, " or "), actual)
+		}
+	}
+
+	if len(s.Enum) > 0 && value != nil {
+		found := false
+		for _, option := range s.Enum {
+			if fmt.Sprint(option) == fmt.Sprint(value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", path, value, s.Enum)
+		}
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				return fmt.Errorf("%s: missing required property %q", path, name)
+			}
+		}
+		for name, prop := range v {
+			if schema, ok := s.Properties[name]; ok {
+				if err := d.validate(schema, prop, path+"."+name); err != nil {
+					return err
+				}
+				continue
+			}
+			switch extra := s.AdditionalProperties.(type) {
+			case *Schema:
+				if err := d.validate(extra, prop, path+"."+name); err != nil {
+					return err
+				}
+			case bool:
+				if !extra {
+					return fmt.Errorf("%s: unexpected property %q", path, name)
+				}
+			default:
+				if s.Properties != nil {
+					return fmt.Errorf("%s: property %q is not described by the schema", path, name)
+				}
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := d.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func typeList(t interface{}) []string {
+	switch v := t.(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	return nil
+}
+
+func jsonType(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
@@ -0,0 +1,162 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"github.com/mokan/flame-crm-backend/internal/models"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Uint
+	Int
+	Bool
+)
+
+type Field struct {
+	Column   string
+	Kind     Kind
+	Nullable bool
+	Required bool
+	Rules    string
+	Roles    []models.Role
+}
+
+type Allowlist map[string]Field
+
+type FieldError struct {
+	Field     string
+	Message   string
+	Forbidden bool
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+var ErrNotObject = errors.New("merge patch document must be a JSON object")
+
+func Decode(body []byte) (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var doc map[string]interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, ErrNotObject
+	}
+	if doc == nil {
+		return nil, ErrNotObject
+	}
+	return doc, nil
+}
+
+func Apply(doc map[string]interface{}, allow Allowlist, role models.Role) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(doc))
+	for key := range doc {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	updates := make(map[string]interface{}, len(doc))
+	for _, key := range keys {
+		field, ok := allow[key]
+		if !ok {
+			return nil, &FieldError{Field: key, Message: "field is not writable"}
+		}
+		if !roleAllowed(field.Roles, role) {
+			return nil, &FieldError{Field: key, Message: "your role cannot modify this field", Forbidden: true}
+		}
+
+		value, err := field.convert(doc[key])
+		if err != nil {
+			return nil, &FieldError{Field: key, Message: err.Error()}
+		}
+		updates[field.Column] = value
+	}
+	return updates, nil
+}
+
+func (f Field) convert(raw interface{}) (interface{}, error) {
+	if raw == nil {
+		switch {
+		case f.Required:
+			return nil, errors.New("field cannot be cleared")
+		case f.Nullable:
+			return nil, nil
+		}
+		return f.zero(), nil
+	}
+
+	var value interface{}
+	switch f.Kind {
+	case String:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, errors.New("must be a string")
+		}
+		value = s
+	case Uint, Int:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return nil, errors.New("must be a number")
+		}
+		i, err := strconv.ParseInt(n.String(), 10, 64)
+		if err != nil || (f.Kind == Uint && i < 0) {
+			return nil, errors.New("must be a whole number")
+		}
+		if f.Kind == Uint {
+			value = uint(i)
+		} else {
+			value = int(i)
+		}
+	case Bool:
+		b, ok := raw.(bool)
+		if !ok {
+			return nil, errors.New("must be a boolean")
+		}
+		value = b
+	}
+
+	if f.Rules != "" {
+		if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+			if err := v.Var(value, f.Rules); err != nil {
+				return nil, fmt.Errorf("failed validation %q", f.Rules)
+			}
+		}
+	}
+	return value, nil
+}
+
+func (f Field) zero() interface{} {
+	switch f.Kind {
+	case Uint:
+		return uint(0)
+	case Int:
+		return 0
+	case Bool:
+		return false
+	default:
+		return ""
+	}
+}
+
+func roleAllowed(roles []models.Role, role models.Role) bool {
+	if len(roles) == 0 {
+		return true
+	}
+	for _, allowed := range roles {
+		if allowed == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package patch
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFields = Allowlist{
+	"name":       {Column: "name", Kind: String, Required: true, Rules: "min=1"},
+	"email":      {Column: "email", Kind: String, Rules: "email"},
+	"company_id": {Column: "company_id", Kind: Uint, Nullable: true, Roles: []models.Role{models.RoleAdmin}},
+	"limit":      {Column: "wip_limit", Kind: Int, Nullable: true},
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	doc, err := Decode([]byte(`{"email": null, "company_id": 7, "limit": null, "name": "Jan"}`))
+	assert.NoError(t, err)
+
+	updates, err := Apply(doc, testFields, models.RoleAdmin)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"name":       "Jan",
+		"email":      "",
+		"company_id": uint(7),
+		"wip_limit":  nil,
+	}, updates)
+}
+
+func TestApplyRejectsInvalidPatches(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		role      models.Role
+		forbidden bool
+	}{
+		{name: "unknown field", body: `{"id": 3}`, role: models.RoleAdmin},
+		{name: "required cleared", body: `{"name": null}`, role: models.RoleAdmin},
+		{name: "wrong type", body: `{"name": 5}`, role: models.RoleAdmin},
+		{name: "negative uint", body: `{"company_id": -1}`, role: models.RoleAdmin},
+		{name: "rule violation", body: `{"email": "nope"}`, role: models.RoleAdmin},
+		{name: "role not allowed", body: `{"company_id": 1}`, role: models.RoleSales, forbidden: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Decode([]byte(tt.body))
+			assert.NoError(t, err)
+
+			_, err = Apply(doc, testFields, tt.role)
+			var fieldErr *FieldError
+			assert.True(t, errors.As(err, &fieldErr))
+			assert.Equal(t, tt.forbidden, fieldErr.Forbidden)
+		})
+	}
+}
+
+func TestDecodeRequiresObject(t *testing.T) {
+	for _, body := range []string{`[]`, `null`, `"x"`, `{`} {
+		_, err := Decode([]byte(body))
+		assert.ErrorIs(t, err, ErrNotObject, body)
+	}
+}
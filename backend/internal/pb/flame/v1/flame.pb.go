@@ -0,0 +1,2579 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: flame/v1/flame.proto
+
+package flamev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Role int32
+
+const (
+	Role_ROLE_UNSPECIFIED   Role = 0
+	Role_ROLE_ADMIN         Role = 1
+	Role_ROLE_SALES         Role = 2
+	Role_ROLE_HEAD_OF_SALES Role = 3
+)
+
+// Enum value maps for Role.
+var (
+	Role_name = map[int32]string{
+		0: "ROLE_UNSPECIFIED",
+		1: "ROLE_ADMIN",
+		2: "ROLE_SALES",
+		3: "ROLE_HEAD_OF_SALES",
+	}
+	Role_value = map[string]int32{
+		"ROLE_UNSPECIFIED":   0,
+		"ROLE_ADMIN":         1,
+		"ROLE_SALES":         2,
+		"ROLE_HEAD_OF_SALES": 3,
+	}
+)
+
+func (x Role) Enum() *Role {
+	p := new(Role)
+	*p = x
+	return p
+}
+
+func (x Role) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Role) Descriptor() protoreflect.EnumDescriptor {
+	return file_flame_v1_flame_proto_enumTypes[0].Descriptor()
+}
+
+func (Role) Type() protoreflect.EnumType {
+	return &file_flame_v1_flame_proto_enumTypes[0]
+}
+
+func (x Role) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Role.Descriptor instead.
+func (Role) EnumDescriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{0}
+}
+
+type CustomerEvent_Type int32
+
+const (
+	CustomerEvent_TYPE_UNSPECIFIED CustomerEvent_Type = 0
+	CustomerEvent_TYPE_CREATED     CustomerEvent_Type = 1
+	CustomerEvent_TYPE_UPDATED     CustomerEvent_Type = 2
+	CustomerEvent_TYPE_DELETED     CustomerEvent_Type = 3
+)
+
+// Enum value maps for CustomerEvent_Type.
+var (
+	CustomerEvent_Type_name = map[int32]string{
+		0: "TYPE_UNSPECIFIED",
+		1: "TYPE_CREATED",
+		2: "TYPE_UPDATED",
+		3: "TYPE_DELETED",
+	}
+	CustomerEvent_Type_value = map[string]int32{
+		"TYPE_UNSPECIFIED": 0,
+		"TYPE_CREATED":     1,
+		"TYPE_UPDATED":     2,
+		"TYPE_DELETED":     3,
+	}
+)
+
+func (x CustomerEvent_Type) Enum() *CustomerEvent_Type {
+	p := new(CustomerEvent_Type)
+	*p = x
+	return p
+}
+
+func (x CustomerEvent_Type) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CustomerEvent_Type) Descriptor() protoreflect.EnumDescriptor {
+	return file_flame_v1_flame_proto_enumTypes[1].Descriptor()
+}
+
+func (CustomerEvent_Type) Type() protoreflect.EnumType {
+	return &file_flame_v1_flame_proto_enumTypes[1]
+}
+
+func (x CustomerEvent_Type) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CustomerEvent_Type.Descriptor instead.
+func (CustomerEvent_Type) EnumDescriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{17, 0}
+}
+
+type Company struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Address       string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	FunnelId      *uint64                `protobuf:"varint,4,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Company) Reset() {
+	*x = Company{}
+	mi := &file_flame_v1_flame_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Company) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Company) ProtoMessage() {}
+
+func (x *Company) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Company.ProtoReflect.Descriptor instead.
+func (*Company) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Company) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Company) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Company) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *Company) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *Company) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Company) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Role          Role                   `protobuf:"varint,4,opt,name=role,proto3,enum=flame.v1.Role" json:"role,omitempty"`
+	CompanyId     *uint64                `protobuf:"varint,5,opt,name=company_id,json=companyId,proto3,oneof" json:"company_id,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_flame_v1_flame_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *User) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+func (x *User) GetCompanyId() uint64 {
+	if x != nil && x.CompanyId != nil {
+		return *x.CompanyId
+	}
+	return 0
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Customer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Phone         string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	LeadSource    string                 `protobuf:"bytes,5,opt,name=lead_source,json=leadSource,proto3" json:"lead_source,omitempty"`
+	FunnelStage   string                 `protobuf:"bytes,6,opt,name=funnel_stage,json=funnelStage,proto3" json:"funnel_stage,omitempty"`
+	BoardPosition int32                  `protobuf:"varint,7,opt,name=board_position,json=boardPosition,proto3" json:"board_position,omitempty"`
+	CompanyId     uint64                 `protobuf:"varint,8,opt,name=company_id,json=companyId,proto3" json:"company_id,omitempty"`
+	FunnelId      *uint64                `protobuf:"varint,9,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	Tags          []string               `protobuf:"bytes,10,rep,name=tags,proto3" json:"tags,omitempty"`
+	Memberships   []*Membership          `protobuf:"bytes,11,rep,name=memberships,proto3" json:"memberships,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Customer) Reset() {
+	*x = Customer{}
+	mi := &file_flame_v1_flame_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Customer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Customer) ProtoMessage() {}
+
+func (x *Customer) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Customer.ProtoReflect.Descriptor instead.
+func (*Customer) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Customer) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Customer) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Customer) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *Customer) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *Customer) GetLeadSource() string {
+	if x != nil {
+		return x.LeadSource
+	}
+	return ""
+}
+
+func (x *Customer) GetFunnelStage() string {
+	if x != nil {
+		return x.FunnelStage
+	}
+	return ""
+}
+
+func (x *Customer) GetBoardPosition() int32 {
+	if x != nil {
+		return x.BoardPosition
+	}
+	return 0
+}
+
+func (x *Customer) GetCompanyId() uint64 {
+	if x != nil {
+		return x.CompanyId
+	}
+	return 0
+}
+
+func (x *Customer) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *Customer) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Customer) GetMemberships() []*Membership {
+	if x != nil {
+		return x.Memberships
+	}
+	return nil
+}
+
+func (x *Customer) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Customer) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type Membership struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pipeline      string                 `protobuf:"bytes,1,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
+	FunnelId      uint64                 `protobuf:"varint,2,opt,name=funnel_id,json=funnelId,proto3" json:"funnel_id,omitempty"`
+	FunnelStage   string                 `protobuf:"bytes,3,opt,name=funnel_stage,json=funnelStage,proto3" json:"funnel_stage,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Membership) Reset() {
+	*x = Membership{}
+	mi := &file_flame_v1_flame_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Membership) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Membership) ProtoMessage() {}
+
+func (x *Membership) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Membership.ProtoReflect.Descriptor instead.
+func (*Membership) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Membership) GetPipeline() string {
+	if x != nil {
+		return x.Pipeline
+	}
+	return ""
+}
+
+func (x *Membership) GetFunnelId() uint64 {
+	if x != nil {
+		return x.FunnelId
+	}
+	return 0
+}
+
+func (x *Membership) GetFunnelStage() string {
+	if x != nil {
+		return x.FunnelStage
+	}
+	return ""
+}
+
+type Funnel struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	WipLimit          *int32                 `protobuf:"varint,3,opt,name=wip_limit,json=wipLimit,proto3,oneof" json:"wip_limit,omitempty"`
+	WipMode           string                 `protobuf:"bytes,4,opt,name=wip_mode,json=wipMode,proto3" json:"wip_mode,omitempty"`
+	NextFunnelIds     []uint64               `protobuf:"varint,5,rep,packed,name=next_funnel_ids,json=nextFunnelIds,proto3" json:"next_funnel_ids,omitempty"`
+	PreviousFunnelIds []uint64               `protobuf:"varint,6,rep,packed,name=previous_funnel_ids,json=previousFunnelIds,proto3" json:"previous_funnel_ids,omitempty"`
+	CreatedAt         *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt         *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *Funnel) Reset() {
+	*x = Funnel{}
+	mi := &file_flame_v1_flame_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Funnel) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Funnel) ProtoMessage() {}
+
+func (x *Funnel) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Funnel.ProtoReflect.Descriptor instead.
+func (*Funnel) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Funnel) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Funnel) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Funnel) GetWipLimit() int32 {
+	if x != nil && x.WipLimit != nil {
+		return *x.WipLimit
+	}
+	return 0
+}
+
+func (x *Funnel) GetWipMode() string {
+	if x != nil {
+		return x.WipMode
+	}
+	return ""
+}
+
+func (x *Funnel) GetNextFunnelIds() []uint64 {
+	if x != nil {
+		return x.NextFunnelIds
+	}
+	return nil
+}
+
+func (x *Funnel) GetPreviousFunnelIds() []uint64 {
+	if x != nil {
+		return x.PreviousFunnelIds
+	}
+	return nil
+}
+
+func (x *Funnel) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Funnel) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// Filter uses the same fields and operators as the REST list filters, e.g.
+// {field: "name", op: "contains", value: "acme"}. An empty op means "eq".
+type Filter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Op            string                 `protobuf:"bytes,2,opt,name=op,proto3" json:"op,omitempty"`
+	Value         string                 `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Filter) Reset() {
+	*x = Filter{}
+	mi := &file_flame_v1_flame_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Filter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Filter) ProtoMessage() {}
+
+func (x *Filter) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Filter.ProtoReflect.Descriptor instead.
+func (*Filter) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Filter) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *Filter) GetOp() string {
+	if x != nil {
+		return x.Op
+	}
+	return ""
+}
+
+func (x *Filter) GetValue() string {
+	if x != nil {
+		return x.Value
+	}
+	return ""
+}
+
+type ListCompaniesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	Filters       []*Filter              `protobuf:"bytes,4,rep,name=filters,proto3" json:"filters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCompaniesRequest) Reset() {
+	*x = ListCompaniesRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCompaniesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCompaniesRequest) ProtoMessage() {}
+
+func (x *ListCompaniesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCompaniesRequest.ProtoReflect.Descriptor instead.
+func (*ListCompaniesRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListCompaniesRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListCompaniesRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListCompaniesRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListCompaniesRequest) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+type ListCompaniesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Companies     []*Company             `protobuf:"bytes,1,rep,name=companies,proto3" json:"companies,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCompaniesResponse) Reset() {
+	*x = ListCompaniesResponse{}
+	mi := &file_flame_v1_flame_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCompaniesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCompaniesResponse) ProtoMessage() {}
+
+func (x *ListCompaniesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCompaniesResponse.ProtoReflect.Descriptor instead.
+func (*ListCompaniesResponse) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListCompaniesResponse) GetCompanies() []*Company {
+	if x != nil {
+		return x.Companies
+	}
+	return nil
+}
+
+func (x *ListCompaniesResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetCompanyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCompanyRequest) Reset() {
+	*x = GetCompanyRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCompanyRequest) ProtoMessage() {}
+
+func (x *GetCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCompanyRequest.ProtoReflect.Descriptor instead.
+func (*GetCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *GetCompanyRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateCompanyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	FunnelId      *uint64                `protobuf:"varint,3,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCompanyRequest) Reset() {
+	*x = CreateCompanyRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCompanyRequest) ProtoMessage() {}
+
+func (x *CreateCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCompanyRequest.ProtoReflect.Descriptor instead.
+func (*CreateCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *CreateCompanyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCompanyRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *CreateCompanyRequest) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+// Update requests apply only the fields named in update_mask, using the REST
+// PATCH field names. A masked optional field that is unset is cleared. When
+// expected_updated_at is set, the update fails with FAILED_PRECONDITION
+// unless it still matches the record's updated_at, like If-Match over REST.
+type UpdateCompanyRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Address           string                 `protobuf:"bytes,3,opt,name=address,proto3" json:"address,omitempty"`
+	FunnelId          *uint64                `protobuf:"varint,4,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	UpdateMask        *fieldmaskpb.FieldMask `protobuf:"bytes,5,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedUpdatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expected_updated_at,json=expectedUpdatedAt,proto3" json:"expected_updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateCompanyRequest) Reset() {
+	*x = UpdateCompanyRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCompanyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCompanyRequest) ProtoMessage() {}
+
+func (x *UpdateCompanyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCompanyRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCompanyRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *UpdateCompanyRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCompanyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCompanyRequest) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *UpdateCompanyRequest) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *UpdateCompanyRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateCompanyRequest) GetExpectedUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpectedUpdatedAt
+	}
+	return nil
+}
+
+type ListCustomersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	Filters       []*Filter              `protobuf:"bytes,4,rep,name=filters,proto3" json:"filters,omitempty"`
+	Pipeline      string                 `protobuf:"bytes,5,opt,name=pipeline,proto3" json:"pipeline,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersRequest) Reset() {
+	*x = ListCustomersRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersRequest) ProtoMessage() {}
+
+func (x *ListCustomersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersRequest.ProtoReflect.Descriptor instead.
+func (*ListCustomersRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ListCustomersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListCustomersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListCustomersRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListCustomersRequest) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+func (x *ListCustomersRequest) GetPipeline() string {
+	if x != nil {
+		return x.Pipeline
+	}
+	return ""
+}
+
+type ListCustomersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Customers     []*Customer            `protobuf:"bytes,1,rep,name=customers,proto3" json:"customers,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCustomersResponse) Reset() {
+	*x = ListCustomersResponse{}
+	mi := &file_flame_v1_flame_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCustomersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCustomersResponse) ProtoMessage() {}
+
+func (x *ListCustomersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCustomersResponse.ProtoReflect.Descriptor instead.
+func (*ListCustomersResponse) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ListCustomersResponse) GetCustomers() []*Customer {
+	if x != nil {
+		return x.Customers
+	}
+	return nil
+}
+
+func (x *ListCustomersResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCustomerRequest) Reset() {
+	*x = GetCustomerRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCustomerRequest) ProtoMessage() {}
+
+func (x *GetCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCustomerRequest.ProtoReflect.Descriptor instead.
+func (*GetCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *GetCustomerRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateCustomerRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Phone         string                 `protobuf:"bytes,3,opt,name=phone,proto3" json:"phone,omitempty"`
+	LeadSource    string                 `protobuf:"bytes,4,opt,name=lead_source,json=leadSource,proto3" json:"lead_source,omitempty"`
+	FunnelStage   string                 `protobuf:"bytes,5,opt,name=funnel_stage,json=funnelStage,proto3" json:"funnel_stage,omitempty"`
+	CompanyId     uint64                 `protobuf:"varint,6,opt,name=company_id,json=companyId,proto3" json:"company_id,omitempty"`
+	FunnelId      *uint64                `protobuf:"varint,7,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	Tags          []string               `protobuf:"bytes,8,rep,name=tags,proto3" json:"tags,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCustomerRequest) Reset() {
+	*x = CreateCustomerRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCustomerRequest) ProtoMessage() {}
+
+func (x *CreateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*CreateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *CreateCustomerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetLeadSource() string {
+	if x != nil {
+		return x.LeadSource
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetFunnelStage() string {
+	if x != nil {
+		return x.FunnelStage
+	}
+	return ""
+}
+
+func (x *CreateCustomerRequest) GetCompanyId() uint64 {
+	if x != nil {
+		return x.CompanyId
+	}
+	return 0
+}
+
+func (x *CreateCustomerRequest) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *CreateCustomerRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type UpdateCustomerRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email             string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Phone             string                 `protobuf:"bytes,4,opt,name=phone,proto3" json:"phone,omitempty"`
+	LeadSource        string                 `protobuf:"bytes,5,opt,name=lead_source,json=leadSource,proto3" json:"lead_source,omitempty"`
+	FunnelStage       string                 `protobuf:"bytes,6,opt,name=funnel_stage,json=funnelStage,proto3" json:"funnel_stage,omitempty"`
+	CompanyId         uint64                 `protobuf:"varint,7,opt,name=company_id,json=companyId,proto3" json:"company_id,omitempty"`
+	FunnelId          *uint64                `protobuf:"varint,8,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	UpdateMask        *fieldmaskpb.FieldMask `protobuf:"bytes,9,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedUpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=expected_updated_at,json=expectedUpdatedAt,proto3" json:"expected_updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateCustomerRequest) Reset() {
+	*x = UpdateCustomerRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCustomerRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCustomerRequest) ProtoMessage() {}
+
+func (x *UpdateCustomerRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCustomerRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCustomerRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *UpdateCustomerRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCustomerRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetPhone() string {
+	if x != nil {
+		return x.Phone
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetLeadSource() string {
+	if x != nil {
+		return x.LeadSource
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetFunnelStage() string {
+	if x != nil {
+		return x.FunnelStage
+	}
+	return ""
+}
+
+func (x *UpdateCustomerRequest) GetCompanyId() uint64 {
+	if x != nil {
+		return x.CompanyId
+	}
+	return 0
+}
+
+func (x *UpdateCustomerRequest) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *UpdateCustomerRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateCustomerRequest) GetExpectedUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpectedUpdatedAt
+	}
+	return nil
+}
+
+type WatchCustomersRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only changes logged after this time are sent. Defaults to the time of
+	// the call. Ignored when cursor is set.
+	Since     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	CompanyId *uint64                `protobuf:"varint,2,opt,name=company_id,json=companyId,proto3,oneof" json:"company_id,omitempty"`
+	FunnelId  *uint64                `protobuf:"varint,3,opt,name=funnel_id,json=funnelId,proto3,oneof" json:"funnel_id,omitempty"`
+	// Resume after the event that carried this cursor. Unlike since, it cannot
+	// skip a change that committed late.
+	Cursor        string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchCustomersRequest) Reset() {
+	*x = WatchCustomersRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchCustomersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchCustomersRequest) ProtoMessage() {}
+
+func (x *WatchCustomersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchCustomersRequest.ProtoReflect.Descriptor instead.
+func (*WatchCustomersRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *WatchCustomersRequest) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *WatchCustomersRequest) GetCompanyId() uint64 {
+	if x != nil && x.CompanyId != nil {
+		return *x.CompanyId
+	}
+	return 0
+}
+
+func (x *WatchCustomersRequest) GetFunnelId() uint64 {
+	if x != nil && x.FunnelId != nil {
+		return *x.FunnelId
+	}
+	return 0
+}
+
+func (x *WatchCustomersRequest) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type CustomerEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Type      CustomerEvent_Type     `protobuf:"varint,1,opt,name=type,proto3,enum=flame.v1.CustomerEvent_Type" json:"type,omitempty"`
+	Customer  *Customer              `protobuf:"bytes,2,opt,name=customer,proto3" json:"customer,omitempty"`
+	ChangedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=changed_at,json=changedAt,proto3" json:"changed_at,omitempty"`
+	// Pass as cursor to resume after a reconnect.
+	Cursor        string `protobuf:"bytes,4,opt,name=cursor,proto3" json:"cursor,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CustomerEvent) Reset() {
+	*x = CustomerEvent{}
+	mi := &file_flame_v1_flame_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CustomerEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CustomerEvent) ProtoMessage() {}
+
+func (x *CustomerEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CustomerEvent.ProtoReflect.Descriptor instead.
+func (*CustomerEvent) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CustomerEvent) GetType() CustomerEvent_Type {
+	if x != nil {
+		return x.Type
+	}
+	return CustomerEvent_TYPE_UNSPECIFIED
+}
+
+func (x *CustomerEvent) GetCustomer() *Customer {
+	if x != nil {
+		return x.Customer
+	}
+	return nil
+}
+
+func (x *CustomerEvent) GetChangedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ChangedAt
+	}
+	return nil
+}
+
+func (x *CustomerEvent) GetCursor() string {
+	if x != nil {
+		return x.Cursor
+	}
+	return ""
+}
+
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	Filters       []*Filter              `protobuf:"bytes,4,rep,name=filters,proto3" json:"filters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListUsersRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListUsersRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListUsersRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListUsersRequest) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_flame_v1_flame_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GetUserRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Email         string                 `protobuf:"bytes,2,opt,name=email,proto3" json:"email,omitempty"`
+	Password      string                 `protobuf:"bytes,3,opt,name=password,proto3" json:"password,omitempty"`
+	Role          Role                   `protobuf:"varint,4,opt,name=role,proto3,enum=flame.v1.Role" json:"role,omitempty"`
+	CompanyId     *uint64                `protobuf:"varint,5,opt,name=company_id,json=companyId,proto3,oneof" json:"company_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateUserRequest) Reset() {
+	*x = CreateUserRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateUserRequest) ProtoMessage() {}
+
+func (x *CreateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateUserRequest.ProtoReflect.Descriptor instead.
+func (*CreateUserRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *CreateUserRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *CreateUserRequest) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+func (x *CreateUserRequest) GetCompanyId() uint64 {
+	if x != nil && x.CompanyId != nil {
+		return *x.CompanyId
+	}
+	return 0
+}
+
+type UpdateUserRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Email             string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	Password          string                 `protobuf:"bytes,4,opt,name=password,proto3" json:"password,omitempty"`
+	Role              Role                   `protobuf:"varint,5,opt,name=role,proto3,enum=flame.v1.Role" json:"role,omitempty"`
+	CompanyId         *uint64                `protobuf:"varint,6,opt,name=company_id,json=companyId,proto3,oneof" json:"company_id,omitempty"`
+	UpdateMask        *fieldmaskpb.FieldMask `protobuf:"bytes,7,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedUpdatedAt *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=expected_updated_at,json=expectedUpdatedAt,proto3" json:"expected_updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateUserRequest) Reset() {
+	*x = UpdateUserRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateUserRequest) ProtoMessage() {}
+
+func (x *UpdateUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateUserRequest.ProtoReflect.Descriptor instead.
+func (*UpdateUserRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *UpdateUserRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateUserRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+func (x *UpdateUserRequest) GetRole() Role {
+	if x != nil {
+		return x.Role
+	}
+	return Role_ROLE_UNSPECIFIED
+}
+
+func (x *UpdateUserRequest) GetCompanyId() uint64 {
+	if x != nil && x.CompanyId != nil {
+		return *x.CompanyId
+	}
+	return 0
+}
+
+func (x *UpdateUserRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateUserRequest) GetExpectedUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpectedUpdatedAt
+	}
+	return nil
+}
+
+type ListFunnelsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	Filters       []*Filter              `protobuf:"bytes,4,rep,name=filters,proto3" json:"filters,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFunnelsRequest) Reset() {
+	*x = ListFunnelsRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFunnelsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFunnelsRequest) ProtoMessage() {}
+
+func (x *ListFunnelsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFunnelsRequest.ProtoReflect.Descriptor instead.
+func (*ListFunnelsRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListFunnelsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListFunnelsRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *ListFunnelsRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListFunnelsRequest) GetFilters() []*Filter {
+	if x != nil {
+		return x.Filters
+	}
+	return nil
+}
+
+type ListFunnelsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Funnels       []*Funnel              `protobuf:"bytes,1,rep,name=funnels,proto3" json:"funnels,omitempty"`
+	Total         int64                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListFunnelsResponse) Reset() {
+	*x = ListFunnelsResponse{}
+	mi := &file_flame_v1_flame_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListFunnelsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListFunnelsResponse) ProtoMessage() {}
+
+func (x *ListFunnelsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListFunnelsResponse.ProtoReflect.Descriptor instead.
+func (*ListFunnelsResponse) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListFunnelsResponse) GetFunnels() []*Funnel {
+	if x != nil {
+		return x.Funnels
+	}
+	return nil
+}
+
+func (x *ListFunnelsResponse) GetTotal() int64 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+type GetFunnelRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetFunnelRequest) Reset() {
+	*x = GetFunnelRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetFunnelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetFunnelRequest) ProtoMessage() {}
+
+func (x *GetFunnelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetFunnelRequest.ProtoReflect.Descriptor instead.
+func (*GetFunnelRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GetFunnelRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateFunnelRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Name              string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	NextFunnelIds     []uint64               `protobuf:"varint,2,rep,packed,name=next_funnel_ids,json=nextFunnelIds,proto3" json:"next_funnel_ids,omitempty"`
+	PreviousFunnelIds []uint64               `protobuf:"varint,3,rep,packed,name=previous_funnel_ids,json=previousFunnelIds,proto3" json:"previous_funnel_ids,omitempty"`
+	WipLimit          *int32                 `protobuf:"varint,4,opt,name=wip_limit,json=wipLimit,proto3,oneof" json:"wip_limit,omitempty"`
+	WipMode           string                 `protobuf:"bytes,5,opt,name=wip_mode,json=wipMode,proto3" json:"wip_mode,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *CreateFunnelRequest) Reset() {
+	*x = CreateFunnelRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateFunnelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateFunnelRequest) ProtoMessage() {}
+
+func (x *CreateFunnelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateFunnelRequest.ProtoReflect.Descriptor instead.
+func (*CreateFunnelRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CreateFunnelRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateFunnelRequest) GetNextFunnelIds() []uint64 {
+	if x != nil {
+		return x.NextFunnelIds
+	}
+	return nil
+}
+
+func (x *CreateFunnelRequest) GetPreviousFunnelIds() []uint64 {
+	if x != nil {
+		return x.PreviousFunnelIds
+	}
+	return nil
+}
+
+func (x *CreateFunnelRequest) GetWipLimit() int32 {
+	if x != nil && x.WipLimit != nil {
+		return *x.WipLimit
+	}
+	return 0
+}
+
+func (x *CreateFunnelRequest) GetWipMode() string {
+	if x != nil {
+		return x.WipMode
+	}
+	return ""
+}
+
+type UpdateFunnelRequest struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Id                uint64                 `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	WipLimit          *int32                 `protobuf:"varint,3,opt,name=wip_limit,json=wipLimit,proto3,oneof" json:"wip_limit,omitempty"`
+	WipMode           string                 `protobuf:"bytes,4,opt,name=wip_mode,json=wipMode,proto3" json:"wip_mode,omitempty"`
+	UpdateMask        *fieldmaskpb.FieldMask `protobuf:"bytes,5,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	ExpectedUpdatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=expected_updated_at,json=expectedUpdatedAt,proto3" json:"expected_updated_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *UpdateFunnelRequest) Reset() {
+	*x = UpdateFunnelRequest{}
+	mi := &file_flame_v1_flame_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateFunnelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateFunnelRequest) ProtoMessage() {}
+
+func (x *UpdateFunnelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_flame_v1_flame_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateFunnelRequest.ProtoReflect.Descriptor instead.
+func (*UpdateFunnelRequest) Descriptor() ([]byte, []int) {
+	return file_flame_v1_flame_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UpdateFunnelRequest) GetId() uint64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateFunnelRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateFunnelRequest) GetWipLimit() int32 {
+	if x != nil && x.WipLimit != nil {
+		return *x.WipLimit
+	}
+	return 0
+}
+
+func (x *UpdateFunnelRequest) GetWipMode() string {
+	if x != nil {
+		return x.WipMode
+	}
+	return ""
+}
+
+func (x *UpdateFunnelRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+func (x *UpdateFunnelRequest) GetExpectedUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpectedUpdatedAt
+	}
+	return nil
+}
+
+var File_flame_v1_flame_proto protoreflect.FileDescriptor
+
+const file_flame_v1_flame_proto_rawDesc = "" +
+	"\n" +
+	"\x14flame/v1/flame.proto\x12\bflame.v1\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xed\x01\n" +
+	"\aCompany\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12 \n" +
+	"\tfunnel_id\x18\x04 \x01(\x04H\x00R\bfunnelId\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\f\n" +
+	"\n" +
+	"_funnel_id\"\x8d\x02\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\"\n" +
+	"\x04role\x18\x04 \x01(\x0e2\x0e.flame.v1.RoleR\x04role\x12\"\n" +
+	"\n" +
+	"company_id\x18\x05 \x01(\x04H\x00R\tcompanyId\x88\x01\x01\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\r\n" +
+	"\v_company_id\"\xd6\x03\n" +
+	"\bCustomer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x1f\n" +
+	"\vlead_source\x18\x05 \x01(\tR\n" +
+	"leadSource\x12!\n" +
+	"\ffunnel_stage\x18\x06 \x01(\tR\vfunnelStage\x12%\n" +
+	"\x0eboard_position\x18\a \x01(\x05R\rboardPosition\x12\x1d\n" +
+	"\n" +
+	"company_id\x18\b \x01(\x04R\tcompanyId\x12 \n" +
+	"\tfunnel_id\x18\t \x01(\x04H\x00R\bfunnelId\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\n" +
+	" \x03(\tR\x04tags\x126\n" +
+	"\vmemberships\x18\v \x03(\v2\x14.flame.v1.MembershipR\vmemberships\x129\n" +
+	"\n" +
+	"created_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\r \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\f\n" +
+	"\n" +
+	"_funnel_id\"h\n" +
+	"\n" +
+	"Membership\x12\x1a\n" +
+	"\bpipeline\x18\x01 \x01(\tR\bpipeline\x12\x1b\n" +
+	"\tfunnel_id\x18\x02 \x01(\x04R\bfunnelId\x12!\n" +
+	"\ffunnel_stage\x18\x03 \x01(\tR\vfunnelStage\"\xc5\x02\n" +
+	"\x06Funnel\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\twip_limit\x18\x03 \x01(\x05H\x00R\bwipLimit\x88\x01\x01\x12\x19\n" +
+	"\bwip_mode\x18\x04 \x01(\tR\awipMode\x12&\n" +
+	"\x0fnext_funnel_ids\x18\x05 \x03(\x04R\rnextFunnelIds\x12.\n" +
+	"\x13previous_funnel_ids\x18\x06 \x03(\x04R\x11previousFunnelIds\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAtB\f\n" +
+	"\n" +
+	"_wip_limit\"D\n" +
+	"\x06Filter\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x0e\n" +
+	"\x02op\x18\x02 \x01(\tR\x02op\x12\x14\n" +
+	"\x05value\x18\x03 \x01(\tR\x05value\"\x84\x01\n" +
+	"\x14ListCompaniesRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12*\n" +
+	"\afilters\x18\x04 \x03(\v2\x10.flame.v1.FilterR\afilters\"^\n" +
+	"\x15ListCompaniesResponse\x12/\n" +
+	"\tcompanies\x18\x01 \x03(\v2\x11.flame.v1.CompanyR\tcompanies\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"#\n" +
+	"\x11GetCompanyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"t\n" +
+	"\x14CreateCompanyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12 \n" +
+	"\tfunnel_id\x18\x03 \x01(\x04H\x00R\bfunnelId\x88\x01\x01B\f\n" +
+	"\n" +
+	"_funnel_id\"\x8d\x02\n" +
+	"\x14UpdateCompanyRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
+	"\aaddress\x18\x03 \x01(\tR\aaddress\x12 \n" +
+	"\tfunnel_id\x18\x04 \x01(\x04H\x00R\bfunnelId\x88\x01\x01\x12;\n" +
+	"\vupdate_mask\x18\x05 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12J\n" +
+	"\x13expected_updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x11expectedUpdatedAtB\f\n" +
+	"\n" +
+	"_funnel_id\"\xa0\x01\n" +
+	"\x14ListCustomersRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12*\n" +
+	"\afilters\x18\x04 \x03(\v2\x10.flame.v1.FilterR\afilters\x12\x1a\n" +
+	"\bpipeline\x18\x05 \x01(\tR\bpipeline\"_\n" +
+	"\x15ListCustomersResponse\x120\n" +
+	"\tcustomers\x18\x01 \x03(\v2\x12.flame.v1.CustomerR\tcustomers\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"$\n" +
+	"\x12GetCustomerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xfe\x01\n" +
+	"\x15CreateCustomerRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x03 \x01(\tR\x05phone\x12\x1f\n" +
+	"\vlead_source\x18\x04 \x01(\tR\n" +
+	"leadSource\x12!\n" +
+	"\ffunnel_stage\x18\x05 \x01(\tR\vfunnelStage\x12\x1d\n" +
+	"\n" +
+	"company_id\x18\x06 \x01(\x04R\tcompanyId\x12 \n" +
+	"\tfunnel_id\x18\a \x01(\x04H\x00R\bfunnelId\x88\x01\x01\x12\x12\n" +
+	"\x04tags\x18\b \x03(\tR\x04tagsB\f\n" +
+	"\n" +
+	"_funnel_id\"\x83\x03\n" +
+	"\x15UpdateCustomerRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x14\n" +
+	"\x05phone\x18\x04 \x01(\tR\x05phone\x12\x1f\n" +
+	"\vlead_source\x18\x05 \x01(\tR\n" +
+	"leadSource\x12!\n" +
+	"\ffunnel_stage\x18\x06 \x01(\tR\vfunnelStage\x12\x1d\n" +
+	"\n" +
+	"company_id\x18\a \x01(\x04R\tcompanyId\x12 \n" +
+	"\tfunnel_id\x18\b \x01(\x04H\x00R\bfunnelId\x88\x01\x01\x12;\n" +
+	"\vupdate_mask\x18\t \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12J\n" +
+	"\x13expected_updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\x11expectedUpdatedAtB\f\n" +
+	"\n" +
+	"_funnel_id\"\xc4\x01\n" +
+	"\x15WatchCustomersRequest\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x12\"\n" +
+	"\n" +
+	"company_id\x18\x02 \x01(\x04H\x00R\tcompanyId\x88\x01\x01\x12 \n" +
+	"\tfunnel_id\x18\x03 \x01(\x04H\x01R\bfunnelId\x88\x01\x01\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursorB\r\n" +
+	"\v_company_idB\f\n" +
+	"\n" +
+	"_funnel_id\"\x98\x02\n" +
+	"\rCustomerEvent\x120\n" +
+	"\x04type\x18\x01 \x01(\x0e2\x1c.flame.v1.CustomerEvent.TypeR\x04type\x12.\n" +
+	"\bcustomer\x18\x02 \x01(\v2\x12.flame.v1.CustomerR\bcustomer\x129\n" +
+	"\n" +
+	"changed_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tchangedAt\x12\x16\n" +
+	"\x06cursor\x18\x04 \x01(\tR\x06cursor\"R\n" +
+	"\x04Type\x12\x14\n" +
+	"\x10TYPE_UNSPECIFIED\x10\x00\x12\x10\n" +
+	"\fTYPE_CREATED\x10\x01\x12\x10\n" +
+	"\fTYPE_UPDATED\x10\x02\x12\x10\n" +
+	"\fTYPE_DELETED\x10\x03\"\x80\x01\n" +
+	"\x10ListUsersRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12*\n" +
+	"\afilters\x18\x04 \x03(\v2\x10.flame.v1.FilterR\afilters\"O\n" +
+	"\x11ListUsersResponse\x12$\n" +
+	"\x05users\x18\x01 \x03(\v2\x0e.flame.v1.UserR\x05users\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\" \n" +
+	"\x0eGetUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xb0\x01\n" +
+	"\x11CreateUserRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x02 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x03 \x01(\tR\bpassword\x12\"\n" +
+	"\x04role\x18\x04 \x01(\x0e2\x0e.flame.v1.RoleR\x04role\x12\"\n" +
+	"\n" +
+	"company_id\x18\x05 \x01(\x04H\x00R\tcompanyId\x88\x01\x01B\r\n" +
+	"\v_company_id\"\xc9\x02\n" +
+	"\x11UpdateUserRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12\x1a\n" +
+	"\bpassword\x18\x04 \x01(\tR\bpassword\x12\"\n" +
+	"\x04role\x18\x05 \x01(\x0e2\x0e.flame.v1.RoleR\x04role\x12\"\n" +
+	"\n" +
+	"company_id\x18\x06 \x01(\x04H\x00R\tcompanyId\x88\x01\x01\x12;\n" +
+	"\vupdate_mask\x18\a \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12J\n" +
+	"\x13expected_updated_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\x11expectedUpdatedAtB\r\n" +
+	"\v_company_id\"\x82\x01\n" +
+	"\x12ListFunnelsRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12*\n" +
+	"\afilters\x18\x04 \x03(\v2\x10.flame.v1.FilterR\afilters\"W\n" +
+	"\x13ListFunnelsResponse\x12*\n" +
+	"\afunnels\x18\x01 \x03(\v2\x10.flame.v1.FunnelR\afunnels\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x03R\x05total\"\"\n" +
+	"\x10GetFunnelRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\"\xcc\x01\n" +
+	"\x13CreateFunnelRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12&\n" +
+	"\x0fnext_funnel_ids\x18\x02 \x03(\x04R\rnextFunnelIds\x12.\n" +
+	"\x13previous_funnel_ids\x18\x03 \x03(\x04R\x11previousFunnelIds\x12 \n" +
+	"\twip_limit\x18\x04 \x01(\x05H\x00R\bwipLimit\x88\x01\x01\x12\x19\n" +
+	"\bwip_mode\x18\x05 \x01(\tR\awipModeB\f\n" +
+	"\n" +
+	"_wip_limit\"\x8d\x02\n" +
+	"\x13UpdateFunnelRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x04R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\twip_limit\x18\x03 \x01(\x05H\x00R\bwipLimit\x88\x01\x01\x12\x19\n" +
+	"\bwip_mode\x18\x04 \x01(\tR\awipMode\x12;\n" +
+	"\vupdate_mask\x18\x05 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\x12J\n" +
+	"\x13expected_updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x11expectedUpdatedAtB\f\n" +
+	"\n" +
+	"_wip_limit*T\n" +
+	"\x04Role\x12\x14\n" +
+	"\x10ROLE_UNSPECIFIED\x10\x00\x12\x0e\n" +
+	"\n" +
+	"ROLE_ADMIN\x10\x01\x12\x0e\n" +
+	"\n" +
+	"ROLE_SALES\x10\x02\x12\x16\n" +
+	"\x12ROLE_HEAD_OF_SALES\x10\x032\xa8\x02\n" +
+	"\x0eCompanyService\x12P\n" +
+	"\rListCompanies\x12\x1e.flame.v1.ListCompaniesRequest\x1a\x1f.flame.v1.ListCompaniesResponse\x12<\n" +
+	"\n" +
+	"GetCompany\x12\x1b.flame.v1.GetCompanyRequest\x1a\x11.flame.v1.Company\x12B\n" +
+	"\rCreateCompany\x12\x1e.flame.v1.CreateCompanyRequest\x1a\x11.flame.v1.Company\x12B\n" +
+	"\rUpdateCompany\x12\x1e.flame.v1.UpdateCompanyRequest\x1a\x11.flame.v1.Company2\x80\x03\n" +
+	"\x0fCustomerService\x12P\n" +
+	"\rListCustomers\x12\x1e.flame.v1.ListCustomersRequest\x1a\x1f.flame.v1.ListCustomersResponse\x12?\n" +
+	"\vGetCustomer\x12\x1c.flame.v1.GetCustomerRequest\x1a\x12.flame.v1.Customer\x12E\n" +
+	"\x0eCreateCustomer\x12\x1f.flame.v1.CreateCustomerRequest\x1a\x12.flame.v1.Customer\x12E\n" +
+	"\x0eUpdateCustomer\x12\x1f.flame.v1.UpdateCustomerRequest\x1a\x12.flame.v1.Customer\x12L\n" +
+	"\x0eWatchCustomers\x12\x1f.flame.v1.WatchCustomersRequest\x1a\x17.flame.v1.CustomerEvent0\x012\xfe\x01\n" +
+	"\vUserService\x12D\n" +
+	"\tListUsers\x12\x1a.flame.v1.ListUsersRequest\x1a\x1b.flame.v1.ListUsersResponse\x123\n" +
+	"\aGetUser\x12\x18.flame.v1.GetUserRequest\x1a\x0e.flame.v1.User\x129\n" +
+	"\n" +
+	"CreateUser\x12\x1b.flame.v1.CreateUserRequest\x1a\x0e.flame.v1.User\x129\n" +
+	"\n" +
+	"UpdateUser\x12\x1b.flame.v1.UpdateUserRequest\x1a\x0e.flame.v1.User2\x98\x02\n" +
+	"\rFunnelService\x12J\n" +
+	"\vListFunnels\x12\x1c.flame.v1.ListFunnelsRequest\x1a\x1d.flame.v1.ListFunnelsResponse\x129\n" +
+	"\tGetFunnel\x12\x1a.flame.v1.GetFunnelRequest\x1a\x10.flame.v1.Funnel\x12?\n" +
+	"\fCreateFunnel\x12\x1d.flame.v1.CreateFunnelRequest\x1a\x10.flame.v1.Funnel\x12?\n" +
+	"\fUpdateFunnel\x12\x1d.flame.v1.UpdateFunnelRequest\x1a\x10.flame.v1.FunnelBAZ?github.com/mokan/flame-crm-backend/internal/pb/flame/v1;flamev1b\x06proto3"
+
+var (
+	file_flame_v1_flame_proto_rawDescOnce sync.Once
+	file_flame_v1_flame_proto_rawDescData []byte
+)
+
+func file_flame_v1_flame_proto_rawDescGZIP() []byte {
+	file_flame_v1_flame_proto_rawDescOnce.Do(func() {
+		file_flame_v1_flame_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_flame_v1_flame_proto_rawDesc), len(file_flame_v1_flame_proto_rawDesc)))
+	})
+	return file_flame_v1_flame_proto_rawDescData
+}
+
+var file_flame_v1_flame_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_flame_v1_flame_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_flame_v1_flame_proto_goTypes = []any{
+	(Role)(0),                     // 0: flame.v1.Role
+	(CustomerEvent_Type)(0),       // 1: flame.v1.CustomerEvent.Type
+	(*Company)(nil),               // 2: flame.v1.Company
+	(*User)(nil),                  // 3: flame.v1.User
+	(*Customer)(nil),              // 4: flame.v1.Customer
+	(*Membership)(nil),            // 5: flame.v1.Membership
+	(*Funnel)(nil),                // 6: flame.v1.Funnel
+	(*Filter)(nil),                // 7: flame.v1.Filter
+	(*ListCompaniesRequest)(nil),  // 8: flame.v1.ListCompaniesRequest
+	(*ListCompaniesResponse)(nil), // 9: flame.v1.ListCompaniesResponse
+	(*GetCompanyRequest)(nil),     // 10: flame.v1.GetCompanyRequest
+	(*CreateCompanyRequest)(nil),  // 11: flame.v1.CreateCompanyRequest
+	(*UpdateCompanyRequest)(nil),  // 12: flame.v1.UpdateCompanyRequest
+	(*ListCustomersRequest)(nil),  // 13: flame.v1.ListCustomersRequest
+	(*ListCustomersResponse)(nil), // 14: flame.v1.ListCustomersResponse
+	(*GetCustomerRequest)(nil),    // 15: flame.v1.GetCustomerRequest
+	(*CreateCustomerRequest)(nil), // 16: flame.v1.CreateCustomerRequest
+	(*UpdateCustomerRequest)(nil), // 17: flame.v1.UpdateCustomerRequest
+	(*WatchCustomersRequest)(nil), // 18: flame.v1.WatchCustomersRequest
+	(*CustomerEvent)(nil),         // 19: flame.v1.CustomerEvent
+	(*ListUsersRequest)(nil),      // 20: flame.v1.ListUsersRequest
+	(*ListUsersResponse)(nil),     // 21: flame.v1.ListUsersResponse
+	(*GetUserRequest)(nil),        // 22: flame.v1.GetUserRequest
+	(*CreateUserRequest)(nil),     // 23: flame.v1.CreateUserRequest
+	(*UpdateUserRequest)(nil),     // 24: flame.v1.UpdateUserRequest
+	(*ListFunnelsRequest)(nil),    // 25: flame.v1.ListFunnelsRequest
+	(*ListFunnelsResponse)(nil),   // 26: flame.v1.ListFunnelsResponse
+	(*GetFunnelRequest)(nil),      // 27: flame.v1.GetFunnelRequest
+	(*CreateFunnelRequest)(nil),   // 28: flame.v1.CreateFunnelRequest
+	(*UpdateFunnelRequest)(nil),   // 29: flame.v1.UpdateFunnelRequest
+	(*timestamppb.Timestamp)(nil), // 30: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil), // 31: google.protobuf.FieldMask
+}
+var file_flame_v1_flame_proto_depIdxs = []int32{
+	30, // 0: flame.v1.Company.created_at:type_name -> google.protobuf.Timestamp
+	30, // 1: flame.v1.Company.updated_at:type_name -> google.protobuf.Timestamp
+	0,  // 2: flame.v1.User.role:type_name -> flame.v1.Role
+	30, // 3: flame.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	30, // 4: flame.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	5,  // 5: flame.v1.Customer.memberships:type_name -> flame.v1.Membership
+	30, // 6: flame.v1.Customer.created_at:type_name -> google.protobuf.Timestamp
+	30, // 7: flame.v1.Customer.updated_at:type_name -> google.protobuf.Timestamp
+	30, // 8: flame.v1.Funnel.created_at:type_name -> google.protobuf.Timestamp
+	30, // 9: flame.v1.Funnel.updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 10: flame.v1.ListCompaniesRequest.filters:type_name -> flame.v1.Filter
+	2,  // 11: flame.v1.ListCompaniesResponse.companies:type_name -> flame.v1.Company
+	31, // 12: flame.v1.UpdateCompanyRequest.update_mask:type_name -> google.protobuf.FieldMask
+	30, // 13: flame.v1.UpdateCompanyRequest.expected_updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 14: flame.v1.ListCustomersRequest.filters:type_name -> flame.v1.Filter
+	4,  // 15: flame.v1.ListCustomersResponse.customers:type_name -> flame.v1.Customer
+	31, // 16: flame.v1.UpdateCustomerRequest.update_mask:type_name -> google.protobuf.FieldMask
+	30, // 17: flame.v1.UpdateCustomerRequest.expected_updated_at:type_name -> google.protobuf.Timestamp
+	30, // 18: flame.v1.WatchCustomersRequest.since:type_name -> google.protobuf.Timestamp
+	1,  // 19: flame.v1.CustomerEvent.type:type_name -> flame.v1.CustomerEvent.Type
+	4,  // 20: flame.v1.CustomerEvent.customer:type_name -> flame.v1.Customer
+	30, // 21: flame.v1.CustomerEvent.changed_at:type_name -> google.protobuf.Timestamp
+	7,  // 22: flame.v1.ListUsersRequest.filters:type_name -> flame.v1.Filter
+	3,  // 23: flame.v1.ListUsersResponse.users:type_name -> flame.v1.User
+	0,  // 24: flame.v1.CreateUserRequest.role:type_name -> flame.v1.Role
+	0,  // 25: flame.v1.UpdateUserRequest.role:type_name -> flame.v1.Role
+	31, // 26: flame.v1.UpdateUserRequest.update_mask:type_name -> google.protobuf.FieldMask
+	30, // 27: flame.v1.UpdateUserRequest.expected_updated_at:type_name -> google.protobuf.Timestamp
+	7,  // 28: flame.v1.ListFunnelsRequest.filters:type_name -> flame.v1.Filter
+	6,  // 29: flame.v1.ListFunnelsResponse.funnels:type_name -> flame.v1.Funnel
+	31, // 30: flame.v1.UpdateFunnelRequest.update_mask:type_name -> google.protobuf.FieldMask
+	30, // 31: flame.v1.UpdateFunnelRequest.expected_updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 32: flame.v1.CompanyService.ListCompanies:input_type -> flame.v1.ListCompaniesRequest
+	10, // 33: flame.v1.CompanyService.GetCompany:input_type -> flame.v1.GetCompanyRequest
+	11, // 34: flame.v1.CompanyService.CreateCompany:input_type -> flame.v1.CreateCompanyRequest
+	12, // 35: flame.v1.CompanyService.UpdateCompany:input_type -> flame.v1.UpdateCompanyRequest
+	13, // 36: flame.v1.CustomerService.ListCustomers:input_type -> flame.v1.ListCustomersRequest
+	15, // 37: flame.v1.CustomerService.GetCustomer:input_type -> flame.v1.GetCustomerRequest
+	16, // 38: flame.v1.CustomerService.CreateCustomer:input_type -> flame.v1.CreateCustomerRequest
+	17, // 39: flame.v1.CustomerService.UpdateCustomer:input_type -> flame.v1.UpdateCustomerRequest
+	18, // 40: flame.v1.CustomerService.WatchCustomers:input_type -> flame.v1.WatchCustomersRequest
+	20, // 41: flame.v1.UserService.ListUsers:input_type -> flame.v1.ListUsersRequest
+	22, // 42: flame.v1.UserService.GetUser:input_type -> flame.v1.GetUserRequest
+	23, // 43: flame.v1.UserService.CreateUser:input_type -> flame.v1.CreateUserRequest
+	24, // 44: flame.v1.UserService.UpdateUser:input_type -> flame.v1.UpdateUserRequest
+	25, // 45: flame.v1.FunnelService.ListFunnels:input_type -> flame.v1.ListFunnelsRequest
+	27, // 46: flame.v1.FunnelService.GetFunnel:input_type -> flame.v1.GetFunnelRequest
+	28, // 47: flame.v1.FunnelService.CreateFunnel:input_type -> flame.v1.CreateFunnelRequest
+	29, // 48: flame.v1.FunnelService.UpdateFunnel:input_type -> flame.v1.UpdateFunnelRequest
+	9,  // 49: flame.v1.CompanyService.ListCompanies:output_type -> flame.v1.ListCompaniesResponse
+	2,  // 50: flame.v1.CompanyService.GetCompany:output_type -> flame.v1.Company
+	2,  // 51: flame.v1.CompanyService.CreateCompany:output_type -> flame.v1.Company
+	2,  // 52: flame.v1.CompanyService.UpdateCompany:output_type -> flame.v1.Company
+	14, // 53: flame.v1.CustomerService.ListCustomers:output_type -> flame.v1.ListCustomersResponse
+	4,  // 54: flame.v1.CustomerService.GetCustomer:output_type -> flame.v1.Customer
+	4,  // 55: flame.v1.CustomerService.CreateCustomer:output_type -> flame.v1.Customer
+	4,  // 56: flame.v1.CustomerService.UpdateCustomer:output_type -> flame.v1.Customer
+	19, // 57: flame.v1.CustomerService.WatchCustomers:output_type -> flame.v1.CustomerEvent
+	21, // 58: flame.v1.UserService.ListUsers:output_type -> flame.v1.ListUsersResponse
+	3,  // 59: flame.v1.UserService.GetUser:output_type -> flame.v1.User
+	3,  // 60: flame.v1.UserService.CreateUser:output_type -> flame.v1.User
+	3,  // 61: flame.v1.UserService.UpdateUser:output_type -> flame.v1.User
+	26, // 62: flame.v1.FunnelService.ListFunnels:output_type -> flame.v1.ListFunnelsResponse
+	6,  // 63: flame.v1.FunnelService.GetFunnel:output_type -> flame.v1.Funnel
+	6,  // 64: flame.v1.FunnelService.CreateFunnel:output_type -> flame.v1.Funnel
+	6,  // 65: flame.v1.FunnelService.UpdateFunnel:output_type -> flame.v1.Funnel
+	49, // [49:66] is the sub-list for method output_type
+	32, // [32:49] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
+}
+
+func init() { file_flame_v1_flame_proto_init() }
+func file_flame_v1_flame_proto_init() {
+	if File_flame_v1_flame_proto != nil {
+		return
+	}
+	file_flame_v1_flame_proto_msgTypes[0].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[1].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[2].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[4].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[9].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[10].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[14].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[15].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[16].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[21].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[22].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[26].OneofWrappers = []any{}
+	file_flame_v1_flame_proto_msgTypes[27].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_flame_v1_flame_proto_rawDesc), len(file_flame_v1_flame_proto_rawDesc)),
+			NumEnums:      2,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_flame_v1_flame_proto_goTypes,
+		DependencyIndexes: file_flame_v1_flame_proto_depIdxs,
+		EnumInfos:         file_flame_v1_flame_proto_enumTypes,
+		MessageInfos:      file_flame_v1_flame_proto_msgTypes,
+	}.Build()
+	File_flame_v1_flame_proto = out.File
+	file_flame_v1_flame_proto_goTypes = nil
+	file_flame_v1_flame_proto_depIdxs = nil
+}
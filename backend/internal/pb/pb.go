@@ -0,0 +1,5 @@
+// Package pb holds the Go code generated from the protobuf definitions in
+// /proto. Regenerate it after editing a .proto file.
+package pb
+
+//go:generate protoc -I ../../proto --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative flame/v1/flame.proto
@@ -0,0 +1,237 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+)
+
+const ContentType = "application/problem+json"
+
+const (
+	CodeBadRequest              = "bad_request"
+	CodeMalformedJSON           = "malformed_json"
+	CodeValidationFailed        = "validation_failed"
+	CodeInvalidQuery            = "invalid_query"
+	CodeUnauthorized            = "unauthorized"
+	CodeInvalidCredentials      = "invalid_credentials"
+	CodeForbidden               = "forbidden"
+	CodeNotFound                = "not_found"
+	CodeConflict                = "conflict"
+	CodeAlreadyExists           = "already_exists"
+	CodePreconditionFailed      = "precondition_failed"
+	CodeInvalidFunnelTransition = "invalid_funnel_transition"
+	CodeFunnelStateInvalid      = "funnel_state_invalid"
+	CodeFunnelNotFound          = "funnel_not_found"
+	CodeNothingToPublish        = "nothing_to_publish"
+	CodeWIPLimitReached         = "wip_limit_reached"
+	CodeBulkFailed              = "bulk_failed"
+	CodeInvalidImport           = "invalid_import"
+	CodeRolledBack              = "rolled_back"
+	CodeNotAttempted            = "not_attempted"
+	CodeIdempotencyKeyReused    = "idempotency_key_reused"
+	CodeIdempotencyKeyInUse     = "idempotency_key_in_use"
+	CodeRequestTooLarge         = "request_too_large"
+	CodeInternal                = "internal_error"
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Code       string
+	Detail     string
+	Instance   string
+	Errors     []FieldError
+	Extensions map[string]interface{}
+}
+
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+func New(status int, code, detail string) *Problem {
+	return &Problem{
+		Type:   "/problems/" + strings.ReplaceAll(code, "_", "-"),
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   code,
+		Detail: detail,
+	}
+}
+
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = map[string]interface{}{}
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]interface{}, len(p.Extensions)+7)
+	for key, value := range p.Extensions {
+		body[key] = value
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	body["code"] = p.Code
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	if len(p.Errors) > 0 {
+		body["errors"] = p.Errors
+	}
+	return json.Marshal(body)
+}
+
+func (p *Problem) Error() string {
+	return p.Detail
+}
+
+func Write(c *gin.Context, p *Problem) {
+	if c.Request != nil && p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, p)
+}
+
+func Respond(c *gin.Context, status int, code, detail string) {
+	Write(c, New(status, code, detail))
+}
+
+func BadRequest(c *gin.Context, detail string) {
+	Respond(c, http.StatusBadRequest, CodeBadRequest, detail)
+}
+
+func NotFound(c *gin.Context, detail string) {
+	Respond(c, http.StatusNotFound, CodeNotFound, detail)
+}
+
+func Forbidden(c *gin.Context, detail string) {
+	Respond(c, http.StatusForbidden, CodeForbidden, detail)
+}
+
+func Internal(c *gin.Context, err error) {
+	if c.Request != nil {
+		log.Printf("internal error on %s %s: %v", c.Request.Method, c.Request.URL.Path, err)
+	} else {
+		log.Printf("internal error: %v", err)
+	}
+	Respond(c, http.StatusInternalServerError, CodeInternal, "An unexpected error occurred")
+}
+
+func Database(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		NotFound(c, "Resource not found")
+	case errors.Is(err, gorm.ErrDuplicatedKey):
+		Respond(c, http.StatusConflict, CodeAlreadyExists, "A record with the same unique value already exists")
+	case errors.Is(err, gorm.ErrForeignKeyViolated):
+		Respond(c, http.StatusConflict, CodeConflict, "The record references or is referenced by another record")
+	default:
+		Internal(c, err)
+	}
+}
+
+func Bind(c *gin.Context, err error) {
+	Write(c, FromBindError(err))
+}
+
+func FromBindError(err error) *Problem {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		p := New(http.StatusBadRequest, CodeValidationFailed, "Request body failed validation")
+		for _, fe := range validationErrs {
+			p.Errors = append(p.Errors, FieldError{
+				Field:   fieldPath(fe),
+				Code:    fe.Tag(),
+				Message: fieldMessage(fe),
+			})
+		}
+		return p
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr):
+		p := New(http.StatusBadRequest, CodeValidationFailed, "Request body failed validation")
+		p.Errors = []FieldError{{Field: typeErr.Field, Code: "type", Message: "must be a " + typeErr.Type.String()}}
+		return p
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF), errors.Is(err, io.EOF):
+		return New(http.StatusBadRequest, CodeMalformedJSON, "Request body is not valid JSON")
+	}
+	return New(http.StatusBadRequest, CodeBadRequest, "Request body could not be processed")
+}
+
+func Validation(c *gin.Context, detail string, fields ...FieldError) {
+	p := New(http.StatusBadRequest, CodeValidationFailed, detail)
+	p.Errors = fields
+	Write(c, p)
+}
+
+func fieldPath(fe validator.FieldError) string {
+	namespace := fe.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return fe.Field()
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	default:
+		return "failed the " + fe.Tag() + " check"
+	}
+}
+
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,105 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type bindInput struct {
+	Name  string `json:"name" binding:"required"`
+	Email string `json:"email" binding:"omitempty,email"`
+	Items []struct {
+		Pipeline string `json:"pipeline" binding:"required"`
+	} `json:"items" binding:"dive"`
+}
+
+func bind(body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+
+	var input bindInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		Bind(c, err)
+	}
+	return w
+}
+
+func decode(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	var body map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid problem body: %v", err)
+	}
+	return body
+}
+
+func TestBindValidationErrors(t *testing.T) {
+	w := bind(`{"email": "nope", "items": [{}]}`)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, ContentType, w.Header().Get("Content-Type"))
+
+	body := decode(t, w)
+	assert.Equal(t, CodeValidationFailed, body["code"])
+	assert.Equal(t, "/problems/validation-failed", body["type"])
+	assert.Equal(t, "/things", body["instance"])
+	assert.EqualValues(t, 400, body["status"])
+
+	var fields []string
+	for _, e := range body["errors"].([]interface{}) {
+		fields = append(fields, e.(map[string]interface{})["field"].(string))
+	}
+	assert.ElementsMatch(t, []string{"name", "email", "items[0].pipeline"}, fields)
+}
+
+func TestBindMalformedJSON(t *testing.T) {
+	w := bind(`{"name": `)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, CodeMalformedJSON, decode(t, w)["code"])
+}
+
+func TestBindTypeMismatch(t *testing.T) {
+	w := bind(`{"name": 42}`)
+
+	body := decode(t, w)
+	assert.Equal(t, CodeValidationFailed, body["code"])
+	assert.Contains(t, w.Body.String(), `"field":"name"`)
+}
+
+func TestInternalHidesError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/things", nil)
+
+	Internal(c, errors.New("pq: relation \"secrets\" does not exist"))
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.NotContains(t, w.Body.String(), "secrets")
+	assert.Equal(t, CodeInternal, decode(t, w)["code"])
+}
+
+func TestExtensions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodPut, "/things/1", nil)
+
+	Write(c, New(http.StatusConflict, CodeConflict, "Still referenced").With("customers", 3))
+
+	body := decode(t, w)
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.EqualValues(t, 3, body["customers"])
+	assert.Equal(t, "Still referenced", body["detail"])
+	assert.Equal(t, "Conflict", body["title"])
+}
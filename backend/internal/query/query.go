@@ -0,0 +1,383 @@
+package query
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+type FieldType int
+
+const (
+	String FieldType = iota
+	Number
+	Time
+	Bool
+)
+
+type Field struct {
+	Column   string
+	Type     FieldType
+	Nullable bool
+}
+
+type Spec struct {
+	Fields      map[string]Field
+	DefaultSort string
+	// Params are the other query parameters the endpoint reads itself.
+	// Parse leaves them alone and rejects any other unknown key.
+	Params []string
+}
+
+type Filter struct {
+	Field  string
+	Op     string
+	Values []interface{}
+}
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+type Params struct {
+	Limit   int
+	Offset  int
+	Cursor  []interface{}
+	Filters []Filter
+	Sort    []SortField
+
+	spec    Spec
+	cursor  bool
+	limited bool
+}
+
+type Meta struct {
+	Total      int64  `json:"total"`
+	Limit      int    `json:"limit"`
+	Offset     int    `json:"offset"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+var reserved = map[string]bool{"limit": true, "offset": true, "cursor": true, "sort": true}
+
+var operators = map[string]string{
+	"eq":       "=",
+	"ne":       "<>",
+	"gt":       ">",
+	"gte":      ">=",
+	"lt":       "<",
+	"lte":      "<=",
+	"in":       "IN",
+	"contains": "LIKE",
+}
+
+func Parse(values url.Values, spec Spec) (Params, error) {
+	p := Params{Limit: DefaultLimit, spec: spec}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			return p, errors.New("limit must be a positive integer")
+		}
+		if limit > MaxLimit {
+			limit = MaxLimit
+		}
+		p.Limit = limit
+		p.limited = true
+	}
+
+	if raw := values.Get("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return p, errors.New("offset must be a non-negative integer")
+		}
+		p.Offset = offset
+	}
+
+	sort := values.Get("sort")
+	if sort == "" {
+		sort = spec.DefaultSort
+	}
+	if err := p.parseSort(sort); err != nil {
+		return p, err
+	}
+
+	if raw, ok := values["cursor"]; ok {
+		if p.Offset > 0 {
+			return p, errors.New("cursor and offset cannot be combined")
+		}
+		p.cursor = true
+		for _, s := range p.Sort {
+			if spec.Fields[s.Field].Nullable {
+				return p, fmt.Errorf("cursor pagination cannot sort by nullable field %q", s.Field)
+			}
+		}
+		if raw[0] != "" {
+			cursor, err := p.decodeCursor(raw[0])
+			if err != nil {
+				return p, err
+			}
+			p.Cursor = cursor
+		}
+	}
+
+	for key, vals := range values {
+		if reserved[key] {
+			continue
+		}
+		name, op := key, "eq"
+		if i := strings.Index(key, "["); i > 0 && strings.HasSuffix(key, "]") {
+			name, op = key[:i], key[i+1:len(key)-1]
+		}
+		field, ok := spec.Fields[name]
+		if !ok {
+			if name == key && slices.Contains(spec.Params, key) {
+				continue
+			}
+			return p, fmt.Errorf("unknown filter field %q", name)
+		}
+		if _, ok := operators[op]; !ok {
+			return p, fmt.Errorf("unknown filter operator %q", op)
+		}
+		if op == "contains" && field.Type != String {
+			return p, fmt.Errorf("operator contains is only supported on text fields")
+		}
+
+		for _, raw := range vals {
+			filter := Filter{Field: name, Op: op}
+			parts := []string{raw}
+			if op == "in" {
+				parts = strings.Split(raw, ",")
+			}
+			for _, part := range parts {
+				value, err := parseValue(field, strings.TrimSpace(part))
+				if err != nil {
+					return p, fmt.Errorf("invalid value for %s: %w", name, err)
+				}
+				filter.Values = append(filter.Values, value)
+			}
+			p.Filters = append(p.Filters, filter)
+		}
+	}
+
+	return p, nil
+}
+
+// Unbounded drops the default limit when the request asked for neither a
+// limit nor a cursor, so the page holds every matching row.
+func (p *Params) Unbounded() {
+	if !p.limited && !p.cursor {
+		p.Limit = 0
+	}
+}
+
+func (p *Params) parseSort(raw string) error {
+	hasID := false
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		sf := SortField{Field: strings.TrimPrefix(part, "-"), Desc: strings.HasPrefix(part, "-")}
+		if _, ok := p.spec.Fields[sf.Field]; !ok {
+			return fmt.Errorf("unknown sort field %q", sf.Field)
+		}
+		if sf.Field == "id" {
+			hasID = true
+		}
+		p.Sort = append(p.Sort, sf)
+	}
+	if !hasID {
+		if _, ok := p.spec.Fields["id"]; ok {
+			p.Sort = append(p.Sort, SortField{Field: "id"})
+		}
+	}
+	return nil
+}
+
+// SortColumns returns the columns the page is ordered by, which a narrowed
+// select must keep for cursors to work.
+func (p Params) SortColumns() []string {
+	columns := make([]string, len(p.Sort))
+	for i, s := range p.Sort {
+		columns[i] = p.spec.Fields[s.Field].Column
+	}
+	return columns
+}
+
+func (p Params) Where(tx *gorm.DB) *gorm.DB {
+	for _, f := range p.Filters {
+		column := p.spec.Fields[f.Field].Column
+		switch f.Op {
+		case "in":
+			tx = tx.Where(column+" IN ?", f.Values)
+		case "contains":
+			tx = tx.Where("LOWER("+column+") LIKE ? ESCAPE '\\'", "%"+escapeLike(strings.ToLower(f.Values[0].(string)))+"%")
+		default:
+			tx = tx.Where(column+" "+operators[f.Op]+" ?", f.Values[0])
+		}
+	}
+	return tx
+}
+
+func (p Params) Apply(tx *gorm.DB) *gorm.DB {
+	return p.order(p.Where(tx))
+}
+
+func (p Params) order(tx *gorm.DB) *gorm.DB {
+	for _, s := range p.Sort {
+		column := p.spec.Fields[s.Field].Column
+		if s.Desc {
+			column += " DESC"
+		}
+		tx = tx.Order(column)
+	}
+	return tx
+}
+
+func (p Params) after(tx *gorm.DB) *gorm.DB {
+	if len(p.Cursor) != len(p.Sort) {
+		return tx
+	}
+
+	var clauses []string
+	var args []interface{}
+	for i, s := range p.Sort {
+		var parts []string
+		for j := 0; j < i; j++ {
+			parts = append(parts, p.spec.Fields[p.Sort[j].Field].Column+" = ?")
+			args = append(args, p.Cursor[j])
+		}
+		op := ">"
+		if s.Desc {
+			op = "<"
+		}
+		parts = append(parts, p.spec.Fields[s.Field].Column+" "+op+" ?")
+		args = append(args, p.Cursor[i])
+		clauses = append(clauses, "("+strings.Join(parts, " AND ")+")")
+	}
+	return tx.Where(strings.Join(clauses, " OR "), args...)
+}
+
+func Find[T any](tx *gorm.DB, p Params, dest *[]T) (Meta, error) {
+	meta := Meta{Limit: p.Limit, Offset: p.Offset}
+
+	var model T
+	filtered := p.Where(tx.Session(&gorm.Session{}))
+	if err := filtered.Session(&gorm.Session{}).Model(&model).Count(&meta.Total).Error; err != nil {
+		return meta, err
+	}
+
+	page := p.order(filtered)
+	if p.cursor {
+		page = p.after(page).Limit(p.Limit + 1)
+	} else if p.Limit > 0 {
+		page = page.Limit(p.Limit).Offset(p.Offset)
+	} else {
+		page = page.Offset(p.Offset)
+	}
+	if err := page.Find(dest).Error; err != nil {
+		return meta, err
+	}
+
+	if p.cursor && len(*dest) > p.Limit {
+		*dest = (*dest)[:p.Limit]
+		cursor, err := p.encodeCursor(tx, &(*dest)[p.Limit-1])
+		if err != nil {
+			return meta, err
+		}
+		meta.NextCursor = cursor
+	}
+	return meta, nil
+}
+
+func (p Params) encodeCursor(tx *gorm.DB, row interface{}) (string, error) {
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(row); err != nil {
+		return "", err
+	}
+
+	values := make([]string, 0, len(p.Sort))
+	for _, s := range p.Sort {
+		field := stmt.Schema.LookUpField(p.spec.Fields[s.Field].Column)
+		if field == nil {
+			return "", fmt.Errorf("cannot build cursor for field %q", s.Field)
+		}
+		value, _ := field.ValueOf(context.Background(), reflect.ValueOf(row).Elem())
+		values = append(values, formatValue(value))
+	}
+
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+func (p Params) decodeCursor(cursor string) ([]interface{}, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var values []string
+	if err := json.Unmarshal(raw, &values); err != nil || len(values) != len(p.Sort) {
+		return nil, errors.New("invalid cursor")
+	}
+
+	decoded := make([]interface{}, 0, len(values))
+	for i, s := range p.Sort {
+		value, err := parseValue(p.spec.Fields[s.Field], values[i])
+		if err != nil {
+			return nil, errors.New("invalid cursor")
+		}
+		decoded = append(decoded, value)
+	}
+	return decoded, nil
+}
+
+func parseValue(field Field, raw string) (interface{}, error) {
+	switch field.Type {
+	case Number:
+		return strconv.ParseInt(raw, 10, 64)
+	case Bool:
+		return strconv.ParseBool(raw)
+	case Time:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a date", raw)
+	default:
+		return raw, nil
+	}
+}
+
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,170 @@
+package query
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type widget struct {
+	ID        uint `gorm:"primarykey"`
+	Name      string
+	Size      int
+	OwnerID   *uint
+	Parts     []part
+	CreatedAt time.Time
+}
+
+type part struct {
+	ID       uint `gorm:"primarykey"`
+	WidgetID uint
+}
+
+var widgetSpec = Spec{
+	Fields: map[string]Field{
+		"id":         {Column: "id", Type: Number},
+		"name":       {Column: "name", Type: String},
+		"size":       {Column: "size", Type: Number},
+		"owner_id":   {Column: "owner_id", Type: Number, Nullable: true},
+		"created_at": {Column: "created_at", Type: Time},
+	},
+	DefaultSort: "id",
+}
+
+func setupWidgets(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file:query_test?mode=memory&cache=shared"), &gorm.Config{})
+	assert.NoError(t, err)
+	assert.NoError(t, database.Migrator().DropTable(&widget{}, &part{}))
+	assert.NoError(t, database.AutoMigrate(&widget{}, &part{}))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	names := []string{"Alpha", "beta", "Gamma 100%", "delta", "Alphabet"}
+	for i, name := range names {
+		w := widget{Name: name, Size: i % 3, CreatedAt: base.AddDate(0, 0, i)}
+		assert.NoError(t, database.Create(&w).Error)
+	}
+	return database
+}
+
+func parse(t *testing.T, raw string) Params {
+	values, err := url.ParseQuery(raw)
+	assert.NoError(t, err)
+	p, err := Parse(values, widgetSpec)
+	assert.NoError(t, err)
+	return p
+}
+
+func names(widgets []widget) []string {
+	out := make([]string, 0, len(widgets))
+	for _, w := range widgets {
+		out = append(out, w.Name)
+	}
+	return out
+}
+
+func TestFindFiltersAndSorts(t *testing.T) {
+	database := setupWidgets(t)
+
+	var widgets []widget
+	meta, err := Find(database, parse(t, "name[contains]=ALPHA&sort=-id"), &widgets)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), meta.Total)
+	assert.Equal(t, []string{"Alphabet", "Alpha"}, names(widgets))
+
+	widgets = nil
+	_, err = Find(database, parse(t, "name[contains]=100%25"), &widgets)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Gamma 100%"}, names(widgets))
+
+	widgets = nil
+	_, err = Find(database, parse(t, "size[in]=0,2&sort=-size,name"), &widgets)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Gamma 100%", "Alpha", "delta"}, names(widgets))
+
+	widgets = nil
+	meta, err = Find(database, parse(t, "created_at[gte]=2024-01-02&created_at[lte]=2024-01-03"), &widgets)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), meta.Total)
+	assert.Equal(t, []string{"beta", "Gamma 100%"}, names(widgets))
+}
+
+func TestFindOffsetPagination(t *testing.T) {
+	database := setupWidgets(t)
+
+	var widgets []widget
+	meta, err := Find(database.Preload("Parts"), parse(t, "limit=2&offset=2"), &widgets)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), meta.Total)
+	assert.Equal(t, []string{"Gamma 100%", "delta"}, names(widgets))
+}
+
+func TestFindUnbounded(t *testing.T) {
+	database := setupWidgets(t)
+
+	p := parse(t, "offset=1")
+	p.Unbounded()
+	var widgets []widget
+	meta, err := Find(database, p, &widgets)
+	assert.NoError(t, err)
+	assert.Zero(t, meta.Limit)
+	assert.Len(t, widgets, 4)
+
+	p = parse(t, "limit=2")
+	p.Unbounded()
+	assert.Equal(t, 2, p.Limit)
+}
+
+func TestParseAcceptsDeclaredParams(t *testing.T) {
+	spec := widgetSpec
+	spec.Params = []string{"fields"}
+	values, _ := url.ParseQuery("fields=name&name=Alpha")
+	p, err := Parse(values, spec)
+	assert.NoError(t, err)
+	assert.Len(t, p.Filters, 1)
+
+	values, _ = url.ParseQuery("fields[eq]=name")
+	_, err = Parse(values, spec)
+	assert.Error(t, err)
+}
+
+func TestFindCursorPagination(t *testing.T) {
+	database := setupWidgets(t)
+
+	var seen []string
+	cursor := ""
+	for page := 0; page < 5; page++ {
+		var widgets []widget
+		meta, err := Find(database, parse(t, "limit=2&sort=-size&cursor="+cursor), &widgets)
+		assert.NoError(t, err)
+		seen = append(seen, names(widgets)...)
+		if meta.NextCursor == "" {
+			break
+		}
+		cursor = meta.NextCursor
+	}
+	assert.Equal(t, []string{"Gamma 100%", "beta", "Alphabet", "Alpha", "delta"}, seen)
+}
+
+func TestParseRejectsInvalidInput(t *testing.T) {
+	for _, raw := range []string{
+		"limit=0",
+		"offset=-1",
+		"sort=password",
+		"password[eq]=x",
+		"name[regex]=x",
+		"size[contains]=1",
+		"size=abc",
+		"created_at[gte]=yesterday",
+		"cursor=&sort=owner_id",
+		"cursor=garbage",
+		"nmae=Alpha",
+	} {
+		values, _ := url.ParseQuery(raw)
+		_, err := Parse(values, widgetSpec)
+		assert.Error(t, err, raw)
+	}
+}
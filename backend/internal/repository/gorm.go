@@ -0,0 +1,338 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"gorm.io/gorm"
+)
+
+type gormStore struct {
+	db *gorm.DB
+}
+
+// NewGorm returns a Store backed by tx. When tx is a transaction, every
+// repository of the store writes inside it.
+func NewGorm(tx *gorm.DB) Store {
+	return gormStore{db: tx}
+}
+
+func (s gormStore) Companies() CompanyRepository              { return gormCompanies(s) }
+func (s gormStore) Customers() CustomerRepository             { return gormCustomers(s) }
+func (s gormStore) Funnels() FunnelRepository                 { return gormFunnels(s) }
+func (s gormStore) Tags() TagRepository                       { return gormTags(s) }
+func (s gormStore) EnrollmentRules() EnrollmentRuleRepository { return gormEnrollmentRules(s) }
+func (s gormStore) Users() UserRepository                     { return gormUsers(s) }
+func (s gormStore) SavedViews() SavedViewRepository           { return gormSavedViews(s) }
+func (s gormStore) ImportJobs() ImportJobRepository           { return gormImportJobs(s) }
+func (s gormStore) FunnelVersions() FunnelVersionRepository   { return gormFunnelVersions(s) }
+
+func (s gormStore) Transaction(fn func(tx Store) error) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		return fn(NewGorm(tx))
+	})
+}
+
+func first[T any](tx *gorm.DB, id uint) (*T, error) {
+	return findOne[T](tx.Where("id = ?", id))
+}
+
+func findOne[T any](tx *gorm.DB) (*T, error) {
+	var record T
+	if err := tx.First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return &record, nil
+}
+
+type gormCompanies gormStore
+
+func (r gormCompanies) Get(id uint) (*models.Company, error) {
+	return first[models.Company](r.db, id)
+}
+
+func (r gormCompanies) FindByName(name string) (*models.Company, error) {
+	return findOne[models.Company](r.db.Where("LOWER(name) = ?", strings.ToLower(name)))
+}
+
+func (r gormCompanies) Create(company *models.Company) error {
+	return r.db.Create(company).Error
+}
+
+func (r gormCompanies) Update(company *models.Company, updates map[string]interface{}) error {
+	return r.db.Model(company).Updates(updates).Error
+}
+
+type gormCustomers gormStore
+
+func (r gormCustomers) Get(id uint) (*models.Customer, error) {
+	return first[models.Customer](r.db, id)
+}
+
+func (r gormCustomers) FindByKey(key, value string) (*models.Customer, error) {
+	switch key {
+	case "email", "name":
+		return findOne[models.Customer](r.db.Where("LOWER("+key+") = ?", strings.ToLower(value)))
+	case "phone":
+		return findOne[models.Customer](r.db.Where("phone = ?", value))
+	}
+	return nil, fmt.Errorf("customers cannot be looked up by %s", key)
+}
+
+func (r gormCustomers) Create(customer *models.Customer) error {
+	return r.db.Create(customer).Error
+}
+
+func (r gormCustomers) Save(customer *models.Customer) error {
+	return r.db.Save(customer).Error
+}
+
+func (r gormCustomers) Update(customer *models.Customer, updates map[string]interface{}) error {
+	return r.db.Model(customer).Updates(updates).Error
+}
+
+func (r gormCustomers) SetFunnel(customer *models.Customer, funnelID uint) error {
+	return r.db.Model(customer).Update("funnel_id", funnelID).Error
+}
+
+func (r gormCustomers) ReplaceTags(customer *models.Customer, tags []models.Tag) error {
+	return r.db.Model(customer).Association("Tags").Replace(tags)
+}
+
+func (r gormCustomers) Membership(customerID uint, pipeline string) (*models.CustomerFunnel, error) {
+	var membership models.CustomerFunnel
+	err := r.db.Where("customer_id = ? AND pipeline = ?", customerID, pipeline).First(&membership).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r gormCustomers) SaveMembership(membership *models.CustomerFunnel) error {
+	return r.db.Save(membership).Error
+}
+
+func (r gormCustomers) DeleteMembership(membership *models.CustomerFunnel) error {
+	return r.db.Unscoped().Delete(membership).Error
+}
+
+func (r gormCustomers) UpdateMembership(membership *models.CustomerFunnel, updates map[string]interface{}) error {
+	return r.db.Model(membership).Updates(updates).Error
+}
+
+type gormFunnels gormStore
+
+func (r gormFunnels) Get(id uint) (*models.Funnel, error) {
+	return first[models.Funnel](r.db, id)
+}
+
+func (r gormFunnels) NextFunnelIDs(id uint) ([]uint, error) {
+	var funnel models.Funnel
+	if err := r.db.Preload("NextFunnels").First(&funnel, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	ids := make([]uint, 0, len(funnel.NextFunnels))
+	for _, next := range funnel.NextFunnels {
+		ids = append(ids, next.ID)
+	}
+	return ids, nil
+}
+
+func (r gormFunnels) VersionAllows(versionID, fromID, toID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.FunnelVersionTransition{}).
+		Where("funnel_version_id = ? AND from_funnel_id = ? AND to_funnel_id = ?", versionID, fromID, toID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r gormFunnels) ActiveVersion() (*models.FunnelVersion, error) {
+	return gormFunnelVersions(r).Latest(models.FunnelVersionActive)
+}
+
+func (r gormFunnels) RecordMove(move *models.FunnelMove) error {
+	return r.db.Create(move).Error
+}
+
+type gormTags gormStore
+
+func (r gormTags) FindOrCreate(name string) (models.Tag, error) {
+	var tag models.Tag
+	err := r.db.Where("LOWER(name) = ?", strings.ToLower(name)).Attrs(models.Tag{Name: name}).FirstOrCreate(&tag).Error
+	return tag, err
+}
+
+type gormEnrollmentRules gormStore
+
+func (r gormEnrollmentRules) Active() ([]models.EnrollmentRule, error) {
+	var rules []models.EnrollmentRule
+	err := r.db.Where("disabled = ?", false).Order("priority desc, id").Find(&rules).Error
+	return rules, err
+}
+
+type gormUsers gormStore
+
+func (r gormUsers) Get(id uint) (*models.User, error) {
+	return first[models.User](r.db, id)
+}
+
+func (r gormUsers) FindByEmail(email string) (*models.User, error) {
+	return findOne[models.User](r.db.Where("email = ?", email))
+}
+
+func (r gormUsers) Count() (int64, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Count(&count).Error
+	return count, err
+}
+
+func (r gormUsers) Create(user *models.User) error {
+	return r.db.Create(user).Error
+}
+
+func (r gormUsers) Update(user *models.User, updates map[string]interface{}) error {
+	return r.db.Model(user).Updates(updates).Error
+}
+
+type gormSavedViews gormStore
+
+func (r gormSavedViews) visible(user *models.User) *gorm.DB {
+	shared := r.db.Where("user_id = ?", user.ID).Or("visibility = ?", models.ViewEveryone)
+	if user.CompanyID != nil {
+		shared = shared.Or("visibility = ? AND company_id = ?", models.ViewTeam, *user.CompanyID)
+	}
+	return r.db.Where(shared)
+}
+
+func (r gormSavedViews) Visible(user *models.User, resource string) ([]models.SavedView, error) {
+	tx := r.visible(user)
+	if resource != "" {
+		tx = tx.Where("resource = ?", resource)
+	}
+	var views []models.SavedView
+	err := tx.Order("name, id").Find(&views).Error
+	return views, err
+}
+
+func (r gormSavedViews) GetVisible(user *models.User, id uint) (*models.SavedView, error) {
+	return first[models.SavedView](r.visible(user), id)
+}
+
+func (r gormSavedViews) Create(view *models.SavedView) error {
+	return r.db.Create(view).Error
+}
+
+func (r gormSavedViews) Save(view *models.SavedView) error {
+	return r.db.Save(view).Error
+}
+
+func (r gormSavedViews) Delete(view *models.SavedView) error {
+	return r.db.Delete(view).Error
+}
+
+type gormImportJobs gormStore
+
+func (r gormImportJobs) Create(job *models.ImportJob) error {
+	return r.db.Create(job).Error
+}
+
+func (r gormImportJobs) Get(id uint, ownerID *uint) (*models.ImportJob, error) {
+	tx := r.db
+	if ownerID != nil {
+		tx = tx.Where("user_id = ?", *ownerID)
+	}
+	return first[models.ImportJob](tx, id)
+}
+
+type gormFunnelVersions gormStore
+
+func (r gormFunnelVersions) Get(id uint) (*models.FunnelVersion, error) {
+	return first[models.FunnelVersion](r.db, id)
+}
+
+func (r gormFunnelVersions) Latest(status models.FunnelVersionStatus) (*models.FunnelVersion, error) {
+	version, err := findOne[models.FunnelVersion](r.db.Where("status = ?", status).Order("number desc"))
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	}
+	return version, err
+}
+
+func (r gormFunnelVersions) MaxNumber() (int, error) {
+	var latest int
+	err := r.db.Model(&models.FunnelVersion{}).Select("COALESCE(MAX(number), 0)").Scan(&latest).Error
+	return latest, err
+}
+
+func (r gormFunnelVersions) Create(version *models.FunnelVersion) error {
+	return r.db.Create(version).Error
+}
+
+func (r gormFunnelVersions) Save(version *models.FunnelVersion) error {
+	return r.db.Save(version).Error
+}
+
+func (r gormFunnelVersions) ArchiveActive() error {
+	return r.db.Model(&models.FunnelVersion{}).
+		Where("status = ?", models.FunnelVersionActive).
+		Update("status", models.FunnelVersionArchived).Error
+}
+
+func (r gormFunnelVersions) LiveTransitions() ([]models.FunnelVersionTransition, error) {
+	var transitions []models.FunnelVersionTransition
+	err := r.db.Table("funnel_transitions").Select("from_funnel_id, to_funnel_id").Scan(&transitions).Error
+	return transitions, err
+}
+
+func (r gormFunnelVersions) CreateTransitions(transitions []models.FunnelVersionTransition) error {
+	if len(transitions) == 0 {
+		return nil
+	}
+	return r.db.Create(&transitions).Error
+}
+
+func (r gormFunnelVersions) Stages(versionID *uint) ([]uint, error) {
+	var ids []uint
+	if versionID == nil {
+		err := r.db.Model(&models.Funnel{}).Pluck("id", &ids).Error
+		return ids, err
+	}
+	var transitions []models.FunnelVersionTransition
+	if err := r.db.Where("funnel_version_id = ?", *versionID).Find(&transitions).Error; err != nil {
+		return nil, err
+	}
+	for _, transition := range transitions {
+		ids = append(ids, transition.FromFunnelID, transition.ToFunnelID)
+	}
+	return ids, nil
+}
+
+func (r gormFunnelVersions) pinnedTo(versionID *uint) *gorm.DB {
+	if versionID == nil {
+		return r.db.Where("funnel_version_id IS NULL")
+	}
+	return r.db.Where("funnel_version_id = ?", *versionID)
+}
+
+func (r gormFunnelVersions) PinnedCustomers(versionID *uint) ([]models.Customer, error) {
+	var customers []models.Customer
+	err := r.pinnedTo(versionID).Find(&customers).Error
+	return customers, err
+}
+
+func (r gormFunnelVersions) PinnedMemberships(versionID *uint) ([]models.CustomerFunnel, error) {
+	var memberships []models.CustomerFunnel
+	err := r.pinnedTo(versionID).Find(&memberships).Error
+	return memberships, err
+}
@@ -0,0 +1,130 @@
+// Package repository defines the storage each aggregate needs, so business
+// rules can be written and tested without a particular database.
+package repository
+
+import (
+	"errors"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+)
+
+// ErrNotFound is returned when a looked-up record does not exist.
+var ErrNotFound = errors.New("record not found")
+
+// Store gives access to every repository. Repositories returned by the store
+// passed to a Transaction callback share that transaction.
+type Store interface {
+	Companies() CompanyRepository
+	Customers() CustomerRepository
+	Funnels() FunnelRepository
+	Tags() TagRepository
+	EnrollmentRules() EnrollmentRuleRepository
+	Users() UserRepository
+	SavedViews() SavedViewRepository
+	ImportJobs() ImportJobRepository
+	FunnelVersions() FunnelVersionRepository
+	Transaction(fn func(tx Store) error) error
+}
+
+type CompanyRepository interface {
+	Get(id uint) (*models.Company, error)
+	// FindByName returns the company whose name matches case-insensitively.
+	FindByName(name string) (*models.Company, error)
+	Create(company *models.Company) error
+	// Update writes the given columns only.
+	Update(company *models.Company, updates map[string]interface{}) error
+}
+
+type CustomerRepository interface {
+	Get(id uint) (*models.Customer, error)
+	// FindByKey returns the customer whose email or name matches value
+	// case-insensitively, or whose phone matches it exactly.
+	FindByKey(key, value string) (*models.Customer, error)
+	Create(customer *models.Customer) error
+	Save(customer *models.Customer) error
+	// Update writes the given columns only.
+	Update(customer *models.Customer, updates map[string]interface{}) error
+	// SetFunnel moves the customer to funnelID without touching other fields.
+	SetFunnel(customer *models.Customer, funnelID uint) error
+	ReplaceTags(customer *models.Customer, tags []models.Tag) error
+
+	Membership(customerID uint, pipeline string) (*models.CustomerFunnel, error)
+	SaveMembership(membership *models.CustomerFunnel) error
+	DeleteMembership(membership *models.CustomerFunnel) error
+	UpdateMembership(membership *models.CustomerFunnel, updates map[string]interface{}) error
+}
+
+type FunnelRepository interface {
+	Get(id uint) (*models.Funnel, error)
+	// NextFunnelIDs lists the stages reachable from id in the live funnel
+	// graph, which applies to records not pinned to a published version.
+	NextFunnelIDs(id uint) ([]uint, error)
+	// VersionAllows reports whether the published version allows moving from
+	// one stage to the other.
+	VersionAllows(versionID, fromID, toID uint) (bool, error)
+	// ActiveVersion returns the published version, or nil before the first
+	// publish.
+	ActiveVersion() (*models.FunnelVersion, error)
+	// RecordMove logs a stage change in the funnel move history.
+	RecordMove(move *models.FunnelMove) error
+}
+
+type TagRepository interface {
+	// FindOrCreate returns the tag whose name matches case-insensitively,
+	// creating it when there is none.
+	FindOrCreate(name string) (models.Tag, error)
+}
+
+type EnrollmentRuleRepository interface {
+	// Active lists enabled rules, highest priority first.
+	Active() ([]models.EnrollmentRule, error)
+}
+
+type UserRepository interface {
+	Get(id uint) (*models.User, error)
+	FindByEmail(email string) (*models.User, error)
+	Count() (int64, error)
+	Create(user *models.User) error
+	// Update writes the given columns only.
+	Update(user *models.User, updates map[string]interface{}) error
+}
+
+type SavedViewRepository interface {
+	// Visible lists the views user owns or that are shared with them, for
+	// resource or for every resource when it is empty.
+	Visible(user *models.User, resource string) ([]models.SavedView, error)
+	// GetVisible returns view id when user may see it.
+	GetVisible(user *models.User, id uint) (*models.SavedView, error)
+	Create(view *models.SavedView) error
+	Save(view *models.SavedView) error
+	Delete(view *models.SavedView) error
+}
+
+type ImportJobRepository interface {
+	Create(job *models.ImportJob) error
+	// Get returns job id, limited to jobs run by ownerID unless it is nil.
+	Get(id uint, ownerID *uint) (*models.ImportJob, error)
+}
+
+type FunnelVersionRepository interface {
+	Get(id uint) (*models.FunnelVersion, error)
+	// Latest returns the highest numbered version with status, or nil when
+	// there is none.
+	Latest(status models.FunnelVersionStatus) (*models.FunnelVersion, error)
+	MaxNumber() (int, error)
+	Create(version *models.FunnelVersion) error
+	Save(version *models.FunnelVersion) error
+	// ArchiveActive moves the published version to archived.
+	ArchiveActive() error
+	// LiveTransitions lists the transitions of the live funnel graph, without
+	// a version.
+	LiveTransitions() ([]models.FunnelVersionTransition, error)
+	CreateTransitions(transitions []models.FunnelVersionTransition) error
+	// Stages lists the funnels a version's transitions connect. A nil version
+	// stands for the live funnel graph and lists every funnel.
+	Stages(versionID *uint) ([]uint, error)
+	// PinnedCustomers and PinnedMemberships list the records pinned to a
+	// version, or to none when versionID is nil.
+	PinnedCustomers(versionID *uint) ([]models.Customer, error)
+	PinnedMemberships(versionID *uint) ([]models.CustomerFunnel, error)
+}
@@ -0,0 +1,20 @@
+package router
+
+import (
+	"github.com/mokan/flame-crm-backend/internal/config"
+	"github.com/mokan/flame-crm-backend/internal/handlers"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+	"gorm.io/gorm"
+)
+
+func NewGRPC(cfg config.Config, database *gorm.DB) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(middleware.GRPCUnaryAuth(), middleware.GRPCUnaryIdempotency(database, cfg.IdempotencyTTL)),
+		grpc.ChainStreamInterceptor(middleware.GRPCStreamAuth()),
+	)
+	handlers.New(database).RegisterGRPC(s)
+	reflection.Register(s)
+	return s
+}
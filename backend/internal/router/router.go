@@ -0,0 +1,183 @@
+package router
+
+import (
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/config"
+	"github.com/mokan/flame-crm-backend/internal/handlers"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"gorm.io/gorm"
+)
+
+// The unversioned /api prefix predates versioning. It serves the v1 routes
+// until its sunset so existing clients keep working.
+var unversionedDeprecation = middleware.Deprecation{
+	Since:           time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Sunset:          time.Date(2027, time.April, 19, 0, 0, 0, 0, time.UTC),
+	Prefix:          "/api",
+	SuccessorPrefix: "/api/v1",
+}
+
+// v1Deprecation is announced on v1 routes that v2 replaces.
+var v1Deprecation = middleware.Deprecation{
+	Since:           time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC),
+	Prefix:          "/api/v1",
+	SuccessorPrefix: "/api/v2",
+}
+
+// v2Changes lists the routes whose v2 handlers differ from v1. Every other
+// v1 route is served unchanged under /api/v2.
+func v2Changes(h *handlers.Handler) []route {
+	return []route{
+		{"GET", "/customers", []gin.HandlerFunc{h.GetCustomersV2}},
+	}
+}
+
+func New(cfg config.Config, database *gorm.DB) *gin.Engine {
+	h := handlers.New(database)
+	r := gin.Default()
+
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowAllOrigins = true
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Length", "Content-Type", "Authorization", "If-Match", "If-None-Match", middleware.IdempotencyKeyHeader}
+	corsConfig.ExposeHeaders = []string{"ETag", "X-Total-Count", "X-Limit", "X-Offset", "X-Next-Cursor", "Content-Disposition", middleware.IdempotentReplayedHeader, "Deprecation", "Sunset", "Link"}
+	r.Use(cors.New(corsConfig))
+
+	r.GET("/openapi.json", handlers.OpenAPISpec)
+	r.GET("/docs", handlers.APIDocs)
+
+	// Idempotency-Key is honoured on every authenticated endpoint that
+	// creates records. /register has no caller to scope keys to.
+	idempotent := middleware.Idempotency(database, cfg.IdempotencyTTL)
+
+	r.POST("/register", h.Register)
+	r.POST("/login", h.Login)
+
+	r.POST("/graphql", middleware.AuthMiddleware(), idempotent, h.GraphQL)
+
+	auth := middleware.AuthMiddleware()
+	v1 := v1Routes(h, idempotent)
+	v2, replaced := override(v1, v2Changes(h))
+
+	mount(r.Group("/api", middleware.TrackVersion("unversioned"), middleware.Deprecate(unversionedDeprecation), auth), v1)
+	mount(r.Group("/api/v1", middleware.TrackVersion("v1"), auth), deprecate(v1, replaced, middleware.Deprecate(v1Deprecation)))
+	mount(r.Group("/api/v2", middleware.TrackVersion("v2"), auth), v2)
+
+	return r
+}
+
+type route struct {
+	method   string
+	path     string
+	handlers []gin.HandlerFunc
+}
+
+func v1Routes(h *handlers.Handler, idempotent gin.HandlerFunc) []route {
+	return []route{
+		{"GET", "/api-usage", []gin.HandlerFunc{handlers.GetAPIUsage}},
+
+		{"GET", "/search", []gin.HandlerFunc{h.Search}},
+
+		{"GET", "/changes", []gin.HandlerFunc{h.GetChanges}},
+
+		{"GET", "/companies", []gin.HandlerFunc{h.GetCompanies}},
+		{"GET", "/companies/export", []gin.HandlerFunc{h.ExportCompanies}},
+		{"GET", "/companies/:id", []gin.HandlerFunc{h.GetCompany}},
+		{"GET", "/companies/external/:source/:external_id", []gin.HandlerFunc{h.GetCompanyByExternalID}},
+		{"PUT", "/companies/external/:source/:external_id", []gin.HandlerFunc{h.UpsertCompany}},
+		{"POST", "/companies", []gin.HandlerFunc{idempotent, h.CreateCompany}},
+		{"PUT", "/companies/:id", []gin.HandlerFunc{h.UpdateCompany}},
+		{"PATCH", "/companies/:id", []gin.HandlerFunc{h.PatchCompany}},
+		{"POST", "/companies/bulk", []gin.HandlerFunc{idempotent, h.BulkCompanies}},
+		{"POST", "/companies/import", []gin.HandlerFunc{idempotent, h.ImportCompanies}},
+
+		{"GET", "/users", []gin.HandlerFunc{h.GetUsers}},
+		{"GET", "/users/export", []gin.HandlerFunc{h.ExportUsers}},
+		{"GET", "/users/:id", []gin.HandlerFunc{h.GetUser}},
+		{"GET", "/users/external/:source/:external_id", []gin.HandlerFunc{h.GetUserByExternalID}},
+		{"PUT", "/users/external/:source/:external_id", []gin.HandlerFunc{h.UpsertUser}},
+		{"POST", "/users", []gin.HandlerFunc{idempotent, h.CreateUser}},
+		{"PUT", "/users/:id", []gin.HandlerFunc{h.UpdateUser}},
+		{"PATCH", "/users/:id", []gin.HandlerFunc{h.UpdateUser}},
+
+		{"GET", "/customers", []gin.HandlerFunc{h.GetCustomers}},
+		{"GET", "/customers/export", []gin.HandlerFunc{h.ExportCustomers}},
+		{"GET", "/customers/:id", []gin.HandlerFunc{h.GetCustomer}},
+		{"GET", "/customers/external/:source/:external_id", []gin.HandlerFunc{h.GetCustomerByExternalID}},
+		{"PUT", "/customers/external/:source/:external_id", []gin.HandlerFunc{h.UpsertCustomer}},
+		{"POST", "/customers", []gin.HandlerFunc{idempotent, h.CreateCustomer}},
+		{"PUT", "/customers/:id", []gin.HandlerFunc{h.UpdateCustomer}},
+		{"PATCH", "/customers/:id", []gin.HandlerFunc{h.PatchCustomer}},
+		{"POST", "/customers/enroll", []gin.HandlerFunc{h.EnrollCustomers}},
+		{"POST", "/customers/bulk", []gin.HandlerFunc{idempotent, h.BulkCustomers}},
+		{"POST", "/customers/import", []gin.HandlerFunc{idempotent, h.ImportCustomers}},
+
+		{"GET", "/imports/:id", []gin.HandlerFunc{h.GetImport}},
+		{"GET", "/imports/:id/errors", []gin.HandlerFunc{h.GetImportErrors}},
+
+		{"POST", "/tags/bulk", []gin.HandlerFunc{idempotent, h.BulkTags}},
+
+		{"GET", "/views", []gin.HandlerFunc{h.GetSavedViews}},
+		{"GET", "/views/:id", []gin.HandlerFunc{h.GetSavedView}},
+		{"GET", "/views/:id/run", []gin.HandlerFunc{h.RunSavedView}},
+		{"POST", "/views", []gin.HandlerFunc{idempotent, h.CreateSavedView}},
+		{"PUT", "/views/:id", []gin.HandlerFunc{h.UpdateSavedView}},
+		{"DELETE", "/views/:id", []gin.HandlerFunc{h.DeleteSavedView}},
+
+		{"GET", "/enrollment-rules", []gin.HandlerFunc{h.GetEnrollmentRules}},
+		{"POST", "/enrollment-rules", []gin.HandlerFunc{idempotent, h.CreateEnrollmentRule}},
+		{"PUT", "/enrollment-rules/:id", []gin.HandlerFunc{h.UpdateEnrollmentRule}},
+		{"DELETE", "/enrollment-rules/:id", []gin.HandlerFunc{h.DeleteEnrollmentRule}},
+
+		{"GET", "/funnels", []gin.HandlerFunc{h.GetFunnels}},
+		{"GET", "/funnels/export", []gin.HandlerFunc{h.ExportFunnels}},
+		{"GET", "/funnels/:id", []gin.HandlerFunc{h.GetFunnel}},
+		{"POST", "/funnels", []gin.HandlerFunc{idempotent, h.CreateFunnel}},
+		{"PUT", "/funnels/:id", []gin.HandlerFunc{h.UpdateFunnel}},
+		{"PATCH", "/funnels/:id", []gin.HandlerFunc{h.PatchFunnel}},
+		{"DELETE", "/funnels/:id", []gin.HandlerFunc{h.DeleteFunnel}},
+		{"GET", "/funnels/:id/board", []gin.HandlerFunc{h.GetFunnelBoard}},
+		{"GET", "/funnels/:id/board/columns/:stage_id", []gin.HandlerFunc{h.GetFunnelBoardColumn}},
+		{"POST", "/funnels/:id/board/move", []gin.HandlerFunc{h.MoveBoardCard}},
+
+		{"GET", "/funnel-versions", []gin.HandlerFunc{h.GetFunnelVersions}},
+		{"GET", "/funnel-versions/:id", []gin.HandlerFunc{h.GetFunnelVersion}},
+		{"POST", "/funnel-versions/publish", []gin.HandlerFunc{idempotent, h.PublishFunnelVersion}},
+		{"POST", "/funnel-versions/:id/migrate", []gin.HandlerFunc{h.MigrateFunnelVersion}},
+	}
+}
+
+// override replaces routes in base with changes, returning the result and the
+// method and path of each replaced route.
+func override(base, changes []route) ([]route, map[string]bool) {
+	replaced := map[string]bool{}
+	routes := append([]route(nil), base...)
+	for _, change := range changes {
+		for i, existing := range routes {
+			if existing.method == change.method && existing.path == change.path {
+				routes[i] = change
+				replaced[change.method+" "+change.path] = true
+			}
+		}
+	}
+	return routes, replaced
+}
+
+func deprecate(routes []route, replaced map[string]bool, deprecation gin.HandlerFunc) []route {
+	result := make([]route, len(routes))
+	for i, rt := range routes {
+		result[i] = rt
+		if replaced[rt.method+" "+rt.path] {
+			result[i].handlers = append([]gin.HandlerFunc{deprecation}, rt.handlers...)
+		}
+	}
+	return result
+}
+
+func mount(g *gin.RouterGroup, routes []route) {
+	for _, rt := range routes {
+		g.Handle(rt.method, rt.path, rt.handlers...)
+	}
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mokan/flame-crm-backend/internal/config"
+	"github.com/mokan/flame-crm-backend/internal/handlers"
+	"github.com/mokan/flame-crm-backend/internal/middleware"
+	"github.com/mokan/flame-crm-backend/internal/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEveryRouteIsDocumented(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := handlers.OpenAPIDocument()
+
+	registered := map[string]bool{}
+	for _, route := range New(config.Default(), nil).Routes() {
+		path := openapi.PathFromGin(route.Path)
+		key := route.Method + " " + path
+		registered[key] = true
+
+		op := doc.Paths[path][strings.ToLower(route.Method)]
+		if assert.NotNil(t, op, "%s is registered but missing from the OpenAPI document", key) {
+			for _, param := range strings.Split(path, "/") {
+				if !strings.HasPrefix(param, "{") {
+					continue
+				}
+				name := strings.Trim(param, "{}")
+				found := false
+				for _, p := range op.Parameters {
+					if p.In == "path" && p.Name == name {
+						found = true
+					}
+				}
+				assert.True(t, found, "%s does not declare path parameter %s", key, name)
+			}
+		}
+	}
+
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			key := strings.ToUpper(method) + " " + path
+			assert.True(t, registered[key], "%s is documented but not registered", key)
+		}
+	}
+}
+
+func TestServesSpecAndDocs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New(config.Default(), nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"openapi":"3.1.0"`)
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+	assert.NotContains(t, w.Body.String(), "/latest/")
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/companies", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestAPIVersions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := New(config.Default(), nil)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/api/companies", nil))
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEmpty(t, w.Header().Get("Deprecation"))
+	assert.NotEmpty(t, w.Header().Get("Sunset"))
+	assert.Equal(t, `</api/v1/companies>; rel="successor-version"`, w.Header().Get("Link"))
+
+	for _, path := range []string{"/api/v1/companies", "/api/v2/companies", "/api/v2/customers"} {
+		w = httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		assert.Empty(t, w.Header().Get("Deprecation"), path)
+	}
+
+	requests := map[string]int64{}
+	for _, stat := range middleware.VersionUsageStats() {
+		requests[stat.Version+" "+stat.Route] += stat.Requests
+	}
+	assert.Positive(t, requests["unversioned /api/companies"])
+	assert.Positive(t, requests["v1 /api/v1/companies"])
+	assert.Positive(t, requests["v2 /api/v2/customers"])
+}
+
+func TestV2ReplacesOnlyChangedRoutes(t *testing.T) {
+	h := handlers.New(nil)
+	v1 := v1Routes(h, func(*gin.Context) {})
+	v2, replaced := override(v1, v2Changes(h))
+	assert.Len(t, v2, len(v1))
+	assert.Equal(t, map[string]bool{"GET /customers": true}, replaced)
+
+	for i, rt := range deprecate(v1, replaced, func(*gin.Context) {}) {
+		extra := len(rt.handlers) - len(v1[i].handlers)
+		if rt.method == "GET" && rt.path == "/customers" {
+			assert.Equal(t, 1, extra)
+		} else {
+			assert.Zero(t, extra, rt.path)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type postgresBackend struct{}
+
+func (postgresBackend) search(tx *gorm.DB, terms []string, entity string, limit int) ([]Hit, error) {
+	prefixes := make([]string, 0, len(terms))
+	for _, term := range terms {
+		prefixes = append(prefixes, term+":*")
+	}
+	tsquery := strings.Join(prefixes, " & ")
+	raw := strings.Join(terms, " ")
+
+	var hits []Hit
+	err := tx.Raw(`
+		SELECT entity AS type, entity_id AS id, title, body AS subtitle,
+			ts_rank(document, to_tsquery('simple', ?)) + similarity(title || ' ' || body, ?) AS score
+		FROM search_documents
+		WHERE entity = ?
+			AND (document @@ to_tsquery('simple', ?) OR (title || ' ' || body) ILIKE ?)
+		ORDER BY score DESC, entity_id
+		LIMIT ?`,
+		tsquery, raw, entity, tsquery, "%"+raw+"%", limit,
+	).Scan(&hits).Error
+	if hits == nil {
+		hits = []Hit{}
+	}
+	return hits, err
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	EntityCompany  = "company"
+	EntityCustomer = "customer"
+	EntityUser     = "user"
+
+	DefaultLimit = 10
+	MaxLimit     = 50
+)
+
+var ErrEmptyQuery = errors.New("search query must contain at least one letter or digit")
+
+type Hit struct {
+	Type     string  `json:"type"`
+	ID       uint    `json:"id"`
+	Title    string  `json:"title"`
+	Subtitle string  `json:"subtitle"`
+	Score    float64 `json:"score"`
+}
+
+type Results struct {
+	Query     string `json:"query"`
+	Companies []Hit  `json:"companies"`
+	Customers []Hit  `json:"customers"`
+	Users     []Hit  `json:"users"`
+}
+
+type Options struct {
+	Entities []string
+	Limit    int
+}
+
+type backend interface {
+	search(tx *gorm.DB, terms []string, entity string, limit int) ([]Hit, error)
+}
+
+func backendFor(tx *gorm.DB) (backend, error) {
+	switch tx.Dialector.Name() {
+	case "postgres":
+		return postgresBackend{}, nil
+	case "sqlite":
+		return sqliteBackend{}, nil
+	default:
+		return nil, errors.New("search is not supported on " + tx.Dialector.Name())
+	}
+}
+
+func Search(tx *gorm.DB, q string, opts Options) (Results, error) {
+	results := Results{Query: q, Companies: []Hit{}, Customers: []Hit{}, Users: []Hit{}}
+
+	terms := Terms(q)
+	if len(terms) == 0 {
+		return results, ErrEmptyQuery
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+
+	entities := opts.Entities
+	if len(entities) == 0 {
+		entities = []string{EntityCompany, EntityCustomer, EntityUser}
+	}
+
+	b, err := backendFor(tx)
+	if err != nil {
+		return results, err
+	}
+
+	for _, entity := range entities {
+		hits, err := b.search(tx, terms, entity, limit)
+		if err != nil {
+			return results, err
+		}
+		switch entity {
+		case EntityCompany:
+			results.Companies = hits
+		case EntityCustomer:
+			results.Customers = hits
+		case EntityUser:
+			results.Users = hits
+		default:
+			return results, errors.New("unknown search entity " + entity)
+		}
+	}
+	return results, nil
+}
+
+func Terms(q string) []string {
+	var terms []string
+	for _, term := range strings.FieldsFunc(strings.ToLower(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+func rankByTerms(hits []Hit, terms []string) {
+	for i := range hits {
+		title := Terms(hits[i].Title)
+		body := Terms(hits[i].Subtitle)
+		for _, term := range terms {
+			if hasPrefix(title, term) {
+				hits[i].Score += 2
+			} else if hasPrefix(body, term) {
+				hits[i].Score++
+			}
+		}
+	}
+	sort.SliceStable(hits, func(a, b int) bool {
+		return hits[a].Score > hits[b].Score
+	})
+}
+
+func hasPrefix(words []string, term string) bool {
+	for _, word := range words {
+		if strings.HasPrefix(word, term) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,137 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/migrate"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupSearchDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+	sqlDB, err := database.DB()
+	assert.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	// Stop before the search index, so rows created now are already there
+	// when migrateSearchDB builds it.
+	m, err := migrate.New(database)
+	assert.NoError(t, err)
+	_, err = m.To(10)
+	assert.NoError(t, err)
+	return database
+}
+
+// migrateSearchDB applies the migrations, which build the index from the
+// rows already there.
+func migrateSearchDB(t *testing.T, database *gorm.DB) {
+	m, err := migrate.New(database)
+	assert.NoError(t, err)
+	_, err = m.Up()
+	assert.NoError(t, err)
+}
+
+func TestSearchRanksAndGroupsHits(t *testing.T) {
+	database := setupSearchDB(t)
+
+	acme := models.Company{Name: "Acme Corp", Address: "1 Main Street"}
+	assert.NoError(t, database.Create(&acme).Error)
+	assert.NoError(t, database.Create(&models.Company{Name: "Janssen Logistics"}).Error)
+	assert.NoError(t, database.Create(&models.Customer{Name: "Jan Kowalski", Email: "jan@acme.test", Phone: "+48 123-456-789", CompanyID: acme.ID}).Error)
+	assert.NoError(t, database.Create(&models.Customer{Name: "Janet Doe", Email: "janet@other.test"}).Error)
+	assert.NoError(t, database.Create(&models.User{Name: "Sales Rep", Email: "rep@acme.test", Role: models.RoleSales, CompanyID: &acme.ID}).Error)
+
+	migrateSearchDB(t, database)
+
+	results, err := Search(database, "acme jan", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 1)
+	assert.Equal(t, "Jan Kowalski", results.Customers[0].Title)
+	assert.Equal(t, EntityCustomer, results.Customers[0].Type)
+	assert.Empty(t, results.Companies)
+
+	results, err = Search(database, "jan", Options{Entities: []string{EntityCustomer, EntityCompany}})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 2)
+	assert.Equal(t, "Jan Kowalski", results.Customers[0].Title)
+	assert.Len(t, results.Companies, 1)
+	assert.Empty(t, results.Users)
+
+	results, err = Search(database, "48123456789", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 1)
+
+	results, err = Search(database, "123-456", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 1)
+
+	results, err = Search(database, "acme", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Companies, 1)
+	assert.Len(t, results.Users, 1)
+}
+
+func TestSearchIndexFollowsWrites(t *testing.T) {
+	database := setupSearchDB(t)
+	migrateSearchDB(t, database)
+
+	company := models.Company{Name: "Initech"}
+	assert.NoError(t, database.Create(&company).Error)
+	customer := models.Customer{Name: "Peter", CompanyID: company.ID}
+	assert.NoError(t, database.Create(&customer).Error)
+
+	results, err := Search(database, "initech peter", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 1)
+
+	assert.NoError(t, database.Model(&company).Update("name", "Initrode").Error)
+	results, err = Search(database, "initrode peter", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 1)
+
+	assert.NoError(t, database.Delete(&customer).Error)
+	results, err = Search(database, "peter", Options{})
+	assert.NoError(t, err)
+	assert.Empty(t, results.Customers)
+
+	_, err = Search(database, " %% ", Options{})
+	assert.ErrorIs(t, err, ErrEmptyQuery)
+}
+
+func TestSearchRanksTitleMatchesWithBM25(t *testing.T) {
+	database := setupSearchDB(t)
+	migrateSearchDB(t, database)
+	if !strings.Contains(sqliteIndexSQL(database), "fts5") {
+		t.Skip("SQLite was built without FTS5; run with -tags sqlite_fts5")
+	}
+
+	assert.NoError(t, database.Create(&models.Customer{Name: "Mary Major", Email: "hank@major.test"}).Error)
+	assert.NoError(t, database.Create(&models.Customer{Name: "Hank Hill", Email: "propane@arlen.test"}).Error)
+
+	results, err := Search(database, "hank", Options{})
+	assert.NoError(t, err)
+	assert.Len(t, results.Customers, 2)
+	assert.Equal(t, "Hank Hill", results.Customers[0].Title)
+	assert.Greater(t, results.Customers[0].Score, results.Customers[1].Score)
+}
+
+func TestSearchRanksBeforeLimiting(t *testing.T) {
+	database := setupSearchDB(t)
+	migrateSearchDB(t, database)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, database.Create(&models.Customer{Name: fmt.Sprintf("Contact %d", i), Email: fmt.Sprintf("hank%d@major.test", i)}).Error)
+	}
+	assert.NoError(t, database.Create(&models.Customer{Name: "Hank Hill", Email: "propane@arlen.test"}).Error)
+
+	results, err := Search(database, "hank", Options{Limit: 1})
+	assert.NoError(t, err)
+	if assert.Len(t, results.Customers, 1) {
+		assert.Equal(t, "Hank Hill", results.Customers[0].Title)
+	}
+}
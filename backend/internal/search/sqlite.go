@@ -0,0 +1,65 @@
+package search
+
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type sqliteBackend struct{}
+
+func sqliteIndexSQL(tx *gorm.DB) string {
+	var sql string
+	tx.Raw("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'search_documents'").Scan(&sql)
+	return strings.ToLower(sql)
+}
+
+func (b sqliteBackend) search(tx *gorm.DB, terms []string, entity string, limit int) ([]Hit, error) {
+	fts5 := strings.Contains(sqliteIndexSQL(tx), "fts5")
+
+	match := make([]string, 0, len(terms))
+	join := " "
+	for _, term := range terms {
+		if fts5 {
+			// The exact token scores on top of the prefix, so "jan" ranks
+			// Jan above Janet.
+			match = append(match, "("+term+" OR "+term+"*)")
+			join = " AND "
+		} else {
+			match = append(match, term+"*")
+		}
+	}
+
+	score := "0"
+	order := "rowid"
+	if fts5 {
+		score = "-bm25(search_documents, 0, 0, 10.0, 1.0)"
+		order = "bm25(search_documents, 0, 0, 10.0, 1.0)"
+	}
+
+	// FTS4 cannot score in SQL, so it fetches every match and ranks them
+	// before cutting to the limit.
+	sql := "SELECT entity AS type, entity_id AS id, title, body AS subtitle, " + score + " AS score " +
+		"FROM search_documents WHERE search_documents MATCH ? AND entity = ? ORDER BY " + order
+	args := []interface{}{strings.Join(match, join), entity}
+	if fts5 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+
+	var hits []Hit
+	if err := tx.Raw(sql, args...).Scan(&hits).Error; err != nil {
+		return nil, err
+	}
+
+	if !fts5 {
+		rankByTerms(hits, terms)
+		if len(hits) > limit {
+			hits = hits[:limit]
+		}
+	}
+	if hits == nil {
+		hits = []Hit{}
+	}
+	return hits, nil
+}
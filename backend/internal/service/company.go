@@ -0,0 +1,70 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+// ErrCompanyFunnelNotFound is returned when a company's default funnel does
+// not exist.
+var ErrCompanyFunnelNotFound = errors.New("Funnel not found")
+
+// CompanyFields lists the company fields clients may write and the roles
+// allowed to write them.
+var CompanyFields = patch.Allowlist{
+	"name":      {Column: "name", Kind: patch.String, Required: true, Rules: "min=1"},
+	"address":   {Column: "address", Kind: patch.String},
+	"funnel_id": {Column: "funnel_id", Kind: patch.Uint, Nullable: true, Roles: Managers},
+}
+
+type Companies struct {
+	store repository.Store
+}
+
+func NewCompanies(store repository.Store) *Companies {
+	return &Companies{store: store}
+}
+
+// Updates checks a full company body against CompanyFields, so PUT, bulk and
+// import writes follow the same field and role rules as PATCH. Empty fields
+// are left unchanged.
+func (s *Companies) Updates(input models.CompanyInput, role models.Role) (map[string]interface{}, error) {
+	doc := map[string]interface{}{}
+	if input.Name != "" {
+		doc["name"] = input.Name
+	}
+	if input.Address != "" {
+		doc["address"] = input.Address
+	}
+	if input.FunnelID != nil {
+		doc["funnel_id"] = json.Number(strconv.FormatUint(uint64(*input.FunnelID), 10))
+	}
+	updates, err := patch.Apply(doc, CompanyFields, role)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.CheckUpdates(updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// CheckUpdates checks the references in updates that the allowlist cannot.
+func (s *Companies) CheckUpdates(updates map[string]interface{}) error {
+	funnelID, ok := updates["funnel_id"].(uint)
+	if !ok {
+		return nil
+	}
+	if _, err := s.store.Funnels().Get(funnelID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrCompanyFunnelNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+// CustomerFields lists the customer fields clients may write and the roles
+// allowed to write them.
+var CustomerFields = patch.Allowlist{
+	"name":         {Column: "name", Kind: patch.String, Required: true, Rules: "min=1"},
+	"email":        {Column: "email", Kind: patch.String, Rules: "omitempty,email"},
+	"phone":        {Column: "phone", Kind: patch.String},
+	"lead_source":  {Column: "lead_source", Kind: patch.String},
+	"funnel_stage": {Column: "funnel_stage", Kind: patch.String},
+	"funnel_id":    {Column: "funnel_id", Kind: patch.Uint, Nullable: true},
+	"company_id":   {Column: "company_id", Kind: patch.Uint, Required: true, Roles: Managers},
+}
+
+// MembershipError wraps a failure to apply a pipeline membership with the
+// pipeline it was for.
+type MembershipError struct {
+	Pipeline string
+	Err      error
+}
+
+func (e *MembershipError) Error() string { return e.Err.Error() }
+func (e *MembershipError) Unwrap() error { return e.Err }
+
+type Customers struct {
+	store   repository.Store
+	funnels *Funnels
+}
+
+func NewCustomers(store repository.Store) *Customers {
+	return &Customers{store: store, funnels: NewFunnels(store)}
+}
+
+// Create stores a new customer with its tags, pins it to the active funnel
+// version and enrolls it by its company's default funnel and the active
+// enrollment rules. Memberships in customer are ignored.
+func (s *Customers) Create(customer *models.Customer) error {
+	customer.Memberships = nil
+
+	tagNames := make([]string, 0, len(customer.Tags))
+	for _, tag := range customer.Tags {
+		tagNames = append(tagNames, tag.Name)
+	}
+	tags, err := s.ResolveTags(tagNames)
+	if err != nil {
+		return err
+	}
+	customer.Tags = tags
+
+	version, err := s.funnels.ActiveVersionID()
+	if err != nil {
+		return err
+	}
+	customer.FunnelVersionID = version
+
+	if err := s.store.Customers().Create(customer); err != nil {
+		return err
+	}
+
+	var company *models.Company
+	if customer.CompanyID != 0 {
+		company, _ = s.store.Companies().Get(customer.CompanyID)
+	}
+	rules, err := s.store.EnrollmentRules().Active()
+	if err != nil {
+		return err
+	}
+	_, err = s.Enroll(customer, company, rules, version)
+	return err
+}
+
+// Update applies input to customer. Empty strings leave fields unchanged,
+// while FunnelID is always taken and checked against the customer's funnel
+// version.
+func (s *Customers) Update(customer *models.Customer, input models.UpdateCustomerInput) error {
+	if input.Name != "" {
+		customer.Name = input.Name
+	}
+	if input.Email != "" {
+		customer.Email = input.Email
+	}
+	if input.Phone != "" {
+		customer.Phone = input.Phone
+	}
+	if input.LeadSource != "" {
+		customer.LeadSource = input.LeadSource
+	}
+
+	version, err := s.funnels.PinnedVersion(customer.FunnelVersionID)
+	if err != nil {
+		return err
+	}
+	if input.FunnelID != nil && customer.FunnelID != nil {
+		if err := s.funnels.CheckTransition(version, *customer.FunnelID, *input.FunnelID); err != nil {
+			return err
+		}
+	}
+
+	customer.FunnelID = input.FunnelID
+	customer.FunnelVersionID = version
+
+	if input.FunnelStage != "" {
+		customer.FunnelStage = input.FunnelStage
+	}
+
+	if input.Tags != nil {
+		tags, err := s.ResolveTags(input.Tags)
+		if err != nil {
+			return err
+		}
+		if err := s.store.Customers().ReplaceTags(customer, tags); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ApplyMemberships(customer.ID, input.Memberships); err != nil {
+		return err
+	}
+	return s.store.Customers().Save(customer)
+}
+
+// ApplyMemberships applies each input in order, stopping at the first
+// failure, which is returned as a *MembershipError.
+func (s *Customers) ApplyMemberships(customerID uint, inputs []models.CustomerFunnelInput) error {
+	for _, m := range inputs {
+		if err := s.ApplyMembership(customerID, m); err != nil {
+			return &MembershipError{Pipeline: m.Pipeline, Err: err}
+		}
+	}
+	return nil
+}
+
+// ApplyMembership adds, moves or removes the customer's membership in one
+// pipeline. Without a FunnelID an existing membership keeps its funnel and
+// only the stage changes. Moves are checked against the membership's funnel
+// version.
+func (s *Customers) ApplyMembership(customerID uint, input models.CustomerFunnelInput) error {
+	customers := s.store.Customers()
+	membership, err := customers.Membership(customerID, input.Pipeline)
+	exists := err == nil
+	if err != nil && !errors.Is(err, repository.ErrNotFound) {
+		return err
+	}
+
+	if input.Remove {
+		if !exists {
+			return nil
+		}
+		return customers.DeleteMembership(membership)
+	}
+
+	if input.FunnelID == nil {
+		if !exists {
+			return ErrMembershipFunnelMissing
+		}
+		input.FunnelID = &membership.FunnelID
+	}
+
+	if !exists {
+		if _, err := s.store.Funnels().Get(*input.FunnelID); err != nil {
+			return ErrFunnelNotFound
+		}
+		membership = &models.CustomerFunnel{CustomerID: customerID, Pipeline: input.Pipeline}
+	}
+
+	if membership.FunnelVersionID == nil {
+		active, err := s.funnels.ActiveVersionID()
+		if err != nil {
+			return err
+		}
+		membership.FunnelVersionID = active
+	}
+
+	if exists {
+		if err := s.funnels.CheckTransition(membership.FunnelVersionID, membership.FunnelID, *input.FunnelID); err != nil {
+			return err
+		}
+	}
+
+	membership.FunnelID = *input.FunnelID
+	if input.FunnelStage != "" {
+		membership.FunnelStage = input.FunnelStage
+	}
+	return customers.SaveMembership(membership)
+}
+
+// ResolveTags finds or creates a tag for each name, skipping blank names and
+// case-insensitive duplicates.
+func (s *Customers) ResolveTags(names []string) ([]models.Tag, error) {
+	tags := make([]models.Tag, 0, len(names))
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[strings.ToLower(name)] {
+			continue
+		}
+		seen[strings.ToLower(name)] = true
+
+		tag, err := s.store.Tags().FindOrCreate(name)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
@@ -0,0 +1,85 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCustomerEnrolls(t *testing.T) {
+	store := newMemoryStore()
+	lead := store.addFunnel("Lead")
+	onboarding := store.addFunnel("Onboarding")
+	version := store.publish()
+	company := &models.Company{Name: "Acme", FunnelID: &lead}
+	company.ID = store.id()
+	store.companies[company.ID] = company
+	store.rules = []models.EnrollmentRule{
+		{Pipeline: "onboarding", FunnelID: onboarding, MatchTag: "vip"},
+		{Pipeline: "newsletter", FunnelID: onboarding, MatchLeadSource: "web"},
+	}
+
+	customer := models.Customer{Name: "Ada", CompanyID: company.ID, Tags: []models.Tag{{Name: "VIP"}, {Name: "vip"}, {Name: " "}}}
+	assert.NoError(t, NewCustomers(store).Create(&customer))
+
+	assert.Equal(t, &version, customer.FunnelVersionID)
+	assert.Equal(t, &lead, customer.FunnelID)
+	if assert.Len(t, customer.Tags, 1) {
+		assert.Equal(t, "VIP", customer.Tags[0].Name)
+	}
+	if assert.Len(t, store.memberships, 1) {
+		assert.Equal(t, "onboarding", store.memberships[0].Pipeline)
+		assert.Equal(t, &version, store.memberships[0].FunnelVersionID)
+	}
+}
+
+func TestUpdateCustomerRejectsInvalidTransition(t *testing.T) {
+	store := newMemoryStore()
+	won := store.addFunnel("Won")
+	lead := store.addFunnel("Lead", won)
+	lost := store.addFunnel("Lost")
+	customers := NewCustomers(store)
+
+	customer := models.Customer{Name: "Ada", FunnelID: &lead}
+	assert.NoError(t, customers.Create(&customer))
+
+	err := customers.Update(&customer, models.UpdateCustomerInput{FunnelID: &lost})
+	assert.ErrorIs(t, err, ErrInvalidFunnelTransition)
+
+	assert.NoError(t, customers.Update(&customer, models.UpdateCustomerInput{FunnelID: &won, Name: "Ada L."}))
+	assert.Equal(t, "Ada L.", store.customers[customer.ID].Name)
+	assert.Equal(t, &won, store.customers[customer.ID].FunnelID)
+}
+
+func TestApplyMemberships(t *testing.T) {
+	store := newMemoryStore()
+	won := store.addFunnel("Won")
+	lead := store.addFunnel("Lead", won)
+	customers := NewCustomers(store)
+
+	assert.NoError(t, customers.ApplyMemberships(1, []models.CustomerFunnelInput{{Pipeline: "sales", FunnelID: &lead}}))
+	membership, err := store.Customers().Membership(1, "sales")
+	assert.NoError(t, err)
+	assert.Equal(t, lead, membership.FunnelID)
+
+	err = customers.ApplyMemberships(1, []models.CustomerFunnelInput{{Pipeline: "sales", FunnelID: &lead}, {Pipeline: "upsell", FunnelID: new(uint)}})
+	var membershipErr *MembershipError
+	if assert.ErrorAs(t, err, &membershipErr) {
+		assert.Equal(t, "upsell", membershipErr.Pipeline)
+		assert.ErrorIs(t, err, ErrFunnelNotFound)
+	}
+
+	assert.NoError(t, customers.ApplyMembership(1, models.CustomerFunnelInput{Pipeline: "sales", FunnelID: &won}))
+	assert.NoError(t, customers.ApplyMembership(1, models.CustomerFunnelInput{Pipeline: "sales", FunnelStage: "Negotiation"}))
+	membership, err = store.Customers().Membership(1, "sales")
+	assert.NoError(t, err)
+	assert.Equal(t, won, membership.FunnelID)
+	assert.Equal(t, "Negotiation", membership.FunnelStage)
+
+	err = customers.ApplyMembership(1, models.CustomerFunnelInput{Pipeline: "onboarding", FunnelStage: "Kickoff"})
+	assert.ErrorIs(t, err, ErrMembershipFunnelMissing)
+
+	assert.NoError(t, customers.ApplyMembership(1, models.CustomerFunnelInput{Pipeline: "sales", Remove: true}))
+	assert.Empty(t, store.memberships)
+}
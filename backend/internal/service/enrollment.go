@@ -0,0 +1,78 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+)
+
+type EnrollmentResult struct {
+	FunnelAssigned     bool
+	MembershipsCreated int
+}
+
+// Enroll gives a customer without a funnel its company's default funnel and
+// adds a membership for each matching rule whose pipeline the customer is
+// not in yet. rules must be ordered by priority, highest first.
+func (s *Customers) Enroll(customer *models.Customer, company *models.Company, rules []models.EnrollmentRule, versionID *uint) (EnrollmentResult, error) {
+	var result EnrollmentResult
+	customers := s.store.Customers()
+
+	if customer.FunnelID == nil && company != nil && company.FunnelID != nil {
+		customer.FunnelID = company.FunnelID
+		if err := customers.SetFunnel(customer, *company.FunnelID); err != nil {
+			return result, err
+		}
+		result.FunnelAssigned = true
+	}
+
+	enrolled := map[string]bool{}
+	for _, m := range customer.Memberships {
+		enrolled[m.Pipeline] = true
+	}
+
+	for _, rule := range rules {
+		if enrolled[rule.Pipeline] || !ruleMatches(rule, customer) {
+			continue
+		}
+
+		membership := models.CustomerFunnel{
+			CustomerID:      customer.ID,
+			Pipeline:        rule.Pipeline,
+			FunnelID:        rule.FunnelID,
+			FunnelStage:     rule.FunnelStage,
+			FunnelVersionID: versionID,
+		}
+		if err := customers.SaveMembership(&membership); err != nil {
+			return result, err
+		}
+		customer.Memberships = append(customer.Memberships, membership)
+		enrolled[rule.Pipeline] = true
+		result.MembershipsCreated++
+	}
+
+	return result, nil
+}
+
+// ruleMatches reports whether every condition set on rule holds for customer.
+func ruleMatches(rule models.EnrollmentRule, customer *models.Customer) bool {
+	if rule.CompanyID != nil && *rule.CompanyID != customer.CompanyID {
+		return false
+	}
+	if rule.MatchLeadSource != "" && !strings.EqualFold(rule.MatchLeadSource, customer.LeadSource) {
+		return false
+	}
+	if rule.MatchTag != "" {
+		found := false
+		for _, tag := range customer.Tags {
+			if strings.EqualFold(tag.Name, rule.MatchTag) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
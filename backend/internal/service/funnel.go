@@ -0,0 +1,88 @@
+// Package service holds the business rules shared by the REST, GraphQL and
+// gRPC handlers. Services only talk to storage through the repository
+// interfaces and write through the store they were built with, so callers
+// build them on a transaction store when the writes must be atomic.
+package service
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+var (
+	ErrFunnelStateInvalid      = errors.New("Current funnel state invalid")
+	ErrInvalidFunnelTransition = errors.New("Invalid funnel transition")
+	ErrFunnelNotFound          = errors.New("Funnel not found")
+	ErrMembershipFunnelMissing = errors.New("funnel_id is required to join a pipeline")
+)
+
+// FunnelFields lists the funnel fields clients may write and the roles
+// allowed to write them.
+var FunnelFields = patch.Allowlist{
+	"name":      {Column: "name", Kind: patch.String, Required: true, Rules: "min=1", Roles: Managers},
+	"wip_limit": {Column: "wip_limit", Kind: patch.Int, Nullable: true, Rules: "min=0", Roles: Managers},
+	"wip_mode":  {Column: "wip_mode", Kind: patch.String, Rules: "omitempty,oneof=block warn", Roles: Managers},
+}
+
+type Funnels struct {
+	store repository.Store
+}
+
+func NewFunnels(store repository.Store) *Funnels {
+	return &Funnels{store: store}
+}
+
+// ActiveVersionID returns the ID of the published funnel version, or nil
+// before the first publish.
+func (s *Funnels) ActiveVersionID() (*uint, error) {
+	active, err := s.store.Funnels().ActiveVersion()
+	if err != nil || active == nil {
+		return nil, err
+	}
+	return &active.ID, nil
+}
+
+// PinnedVersion returns versionID, or the active version for records that are
+// not pinned to one yet.
+func (s *Funnels) PinnedVersion(versionID *uint) (*uint, error) {
+	if versionID != nil {
+		return versionID, nil
+	}
+	return s.ActiveVersionID()
+}
+
+// CheckTransition reports whether a record may move between two stages.
+// Records pinned to a version follow that version's transitions; the rest
+// follow the live funnel graph.
+func (s *Funnels) CheckTransition(versionID *uint, fromID, toID uint) error {
+	if fromID == toID {
+		return nil
+	}
+	funnels := s.store.Funnels()
+
+	if versionID != nil {
+		if _, err := funnels.Get(fromID); err != nil {
+			return ErrFunnelStateInvalid
+		}
+		allowed, err := funnels.VersionAllows(*versionID, fromID, toID)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrInvalidFunnelTransition
+		}
+		return nil
+	}
+
+	next, err := funnels.NextFunnelIDs(fromID)
+	if err != nil {
+		return ErrFunnelStateInvalid
+	}
+	if !slices.Contains(next, toID) {
+		return ErrInvalidFunnelTransition
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransitionFollowsLiveGraph(t *testing.T) {
+	store := newMemoryStore()
+	won := store.addFunnel("Won")
+	lead := store.addFunnel("Lead", won)
+	lost := store.addFunnel("Lost")
+	funnels := NewFunnels(store)
+
+	assert.NoError(t, funnels.CheckTransition(nil, lead, won))
+	assert.NoError(t, funnels.CheckTransition(nil, lost, lost))
+	assert.ErrorIs(t, funnels.CheckTransition(nil, lead, lost), ErrInvalidFunnelTransition)
+	assert.ErrorIs(t, funnels.CheckTransition(nil, 999, won), ErrFunnelStateInvalid)
+}
+
+func TestCheckTransitionFollowsPinnedVersion(t *testing.T) {
+	store := newMemoryStore()
+	won := store.addFunnel("Won")
+	lead := store.addFunnel("Lead", won)
+	lost := store.addFunnel("Lost")
+	version := store.publish([2]uint{lead, lost})
+	funnels := NewFunnels(store)
+
+	assert.NoError(t, funnels.CheckTransition(&version, lead, lost))
+	assert.ErrorIs(t, funnels.CheckTransition(&version, lead, won), ErrInvalidFunnelTransition)
+	assert.ErrorIs(t, funnels.CheckTransition(&version, 999, won), ErrFunnelStateInvalid)
+}
+
+func TestPinnedVersion(t *testing.T) {
+	store := newMemoryStore()
+	funnels := NewFunnels(store)
+
+	version, err := funnels.PinnedVersion(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, version)
+
+	active := store.publish()
+	version, err = funnels.PinnedVersion(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, &active, version)
+
+	pinned := uint(42)
+	version, err = funnels.PinnedVersion(&pinned)
+	assert.NoError(t, err)
+	assert.Equal(t, &pinned, version)
+}
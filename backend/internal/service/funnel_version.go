@@ -0,0 +1,236 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+var (
+	ErrNothingToPublish    = errors.New("No draft version to publish")
+	ErrTargetVersionActive = errors.New("Customers can only be migrated to the active version")
+	ErrSameVersion         = errors.New("Source and target versions must differ")
+)
+
+// MigrationError reports a migration request field that does not fit the
+// versions involved.
+type MigrationError struct {
+	Field   string
+	Code    string
+	Message string
+}
+
+func (e *MigrationError) Error() string { return e.Field + " " + e.Message }
+
+type VersionMigration struct {
+	CustomersMigrated   int
+	MembershipsMigrated int
+}
+
+type FunnelVersions struct {
+	store repository.Store
+}
+
+func NewFunnelVersions(store repository.Store) *FunnelVersions {
+	return &FunnelVersions{store: store}
+}
+
+// EnsureDraft returns the draft version, creating one numbered after the
+// latest version when there is none. Edits to the funnel graph call it so
+// they are picked up by the next publish.
+func (s *FunnelVersions) EnsureDraft() (*models.FunnelVersion, error) {
+	versions := s.store.FunnelVersions()
+	draft, err := versions.Latest(models.FunnelVersionDraft)
+	if err != nil || draft != nil {
+		return draft, err
+	}
+
+	latest, err := versions.MaxNumber()
+	if err != nil {
+		return nil, err
+	}
+	draft = &models.FunnelVersion{Number: latest + 1, Status: models.FunnelVersionDraft}
+	if err := versions.Create(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// Publish snapshots the live funnel graph into the draft version and makes it
+// the active one, archiving the previous active version. The first publish
+// needs no draft.
+func (s *FunnelVersions) Publish() (*models.FunnelVersion, error) {
+	versions := s.store.FunnelVersions()
+	draft, err := versions.Latest(models.FunnelVersionDraft)
+	if err != nil {
+		return nil, err
+	}
+	if draft == nil {
+		active, err := versions.Latest(models.FunnelVersionActive)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			return nil, ErrNothingToPublish
+		}
+		if draft, err = s.EnsureDraft(); err != nil {
+			return nil, err
+		}
+	}
+
+	transitions, err := versions.LiveTransitions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range transitions {
+		transitions[i].FunnelVersionID = draft.ID
+	}
+	if err := versions.CreateTransitions(transitions); err != nil {
+		return nil, err
+	}
+	if err := versions.ArchiveActive(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	draft.Status = models.FunnelVersionActive
+	draft.PublishedAt = &now
+	if err := versions.Save(draft); err != nil {
+		return nil, err
+	}
+	draft.Transitions = transitions
+	return draft, nil
+}
+
+// Migrate pins the records pinned to fromVersionID, or to no version when it
+// is nil, to target, moving them along stageMap on the way. Every stage that
+// holds records must be mapped or be part of the target version too, so no
+// record is left on a stage the target does not have. Every move is recorded
+// in the funnel move history.
+func (s *FunnelVersions) Migrate(target *models.FunnelVersion, fromVersionID *uint, stageMap map[uint]uint) (VersionMigration, error) {
+	var result VersionMigration
+	targetStages, err := s.checkMigration(target, fromVersionID, stageMap)
+	if err != nil {
+		return result, err
+	}
+
+	err = s.store.Transaction(func(tx repository.Store) error {
+		customers, err := tx.FunnelVersions().PinnedCustomers(fromVersionID)
+		if err != nil {
+			return err
+		}
+		memberships, err := tx.FunnelVersions().PinnedMemberships(fromVersionID)
+		if err != nil {
+			return err
+		}
+		occupied := make([]uint, 0, len(customers)+len(memberships))
+		for _, customer := range customers {
+			if customer.FunnelID != nil {
+				occupied = append(occupied, *customer.FunnelID)
+			}
+		}
+		for _, membership := range memberships {
+			occupied = append(occupied, membership.FunnelID)
+		}
+		for _, stage := range occupied {
+			if _, mapped := stageMap[stage]; !mapped && !targetStages[stage] {
+				return &MigrationError{Field: "stage_map", Code: "unmapped_stage", Message: fmt.Sprintf("%d holds records but is neither mapped nor a stage of the target version", stage)}
+			}
+		}
+
+		for _, customer := range customers {
+			updates := map[string]interface{}{"funnel_version_id": target.ID}
+			if customer.FunnelID != nil {
+				if to, ok := stageMap[*customer.FunnelID]; ok && to != *customer.FunnelID {
+					updates["funnel_id"] = to
+					if err := recordMove(tx, models.FunnelMoveEntityCustomer, customer.ID, *customer.FunnelID, to); err != nil {
+						return err
+					}
+				}
+			}
+			if err := tx.Customers().Update(&customer, updates); err != nil {
+				return err
+			}
+			result.CustomersMigrated++
+		}
+
+		for _, membership := range memberships {
+			updates := map[string]interface{}{"funnel_version_id": target.ID}
+			if to, ok := stageMap[membership.FunnelID]; ok && to != membership.FunnelID {
+				updates["funnel_id"] = to
+				if err := recordMove(tx, models.FunnelMoveEntityMember, membership.ID, membership.FunnelID, to); err != nil {
+					return err
+				}
+			}
+			if err := tx.Customers().UpdateMembership(&membership, updates); err != nil {
+				return err
+			}
+			result.MembershipsMigrated++
+		}
+		return nil
+	})
+	return result, err
+}
+
+// checkMigration checks the request against the versions involved and
+// returns the stages of the target version.
+func (s *FunnelVersions) checkMigration(target *models.FunnelVersion, fromVersionID *uint, stageMap map[uint]uint) (map[uint]bool, error) {
+	if target.Status != models.FunnelVersionActive {
+		return nil, ErrTargetVersionActive
+	}
+	if fromVersionID != nil && *fromVersionID == target.ID {
+		return nil, ErrSameVersion
+	}
+
+	versions := s.store.FunnelVersions()
+	if fromVersionID != nil {
+		if _, err := versions.Get(*fromVersionID); err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return nil, &MigrationError{Field: "from_version_id", Code: "exists", Message: "must be an existing funnel version"}
+			}
+			return nil, err
+		}
+	}
+	sourceStages, err := s.stages(fromVersionID)
+	if err != nil {
+		return nil, err
+	}
+	targetStages, err := s.stages(&target.ID)
+	if err != nil {
+		return nil, err
+	}
+	for from, to := range stageMap {
+		if !sourceStages[from] {
+			return nil, &MigrationError{Field: "stage_map", Code: "source_stage", Message: fmt.Sprintf("%d is not a stage of the source version", from)}
+		}
+		if !targetStages[to] {
+			return nil, &MigrationError{Field: "stage_map", Code: "target_stage", Message: fmt.Sprintf("%d is not a stage of the target version", to)}
+		}
+	}
+	return targetStages, nil
+}
+
+func (s *FunnelVersions) stages(versionID *uint) (map[uint]bool, error) {
+	ids, err := s.store.FunnelVersions().Stages(versionID)
+	if err != nil {
+		return nil, err
+	}
+	stages := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		stages[id] = true
+	}
+	return stages, nil
+}
+
+func recordMove(tx repository.Store, entityType string, entityID, fromID, toID uint) error {
+	return tx.Funnels().RecordMove(&models.FunnelMove{
+		EntityType:   entityType,
+		EntityID:     entityID,
+		FromFunnelID: fromID,
+		ToFunnelID:   toID,
+		Reason:       "version_migration",
+	})
+}
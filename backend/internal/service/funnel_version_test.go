@@ -0,0 +1,75 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPublishSnapshotsLiveGraph(t *testing.T) {
+	store := newMemoryStore()
+	won := store.addFunnel("Won")
+	lead := store.addFunnel("Lead", won)
+	versions := NewFunnelVersions(store)
+
+	first, err := versions.Publish()
+	require.NoError(t, err)
+	assert.Equal(t, 1, first.Number)
+	assert.Equal(t, models.FunnelVersionActive, first.Status)
+	assert.Equal(t, [][2]uint{{lead, won}}, store.transitions[first.ID])
+
+	_, err = versions.Publish()
+	assert.ErrorIs(t, err, ErrNothingToPublish)
+
+	draft, err := versions.EnsureDraft()
+	require.NoError(t, err)
+	assert.Equal(t, 2, draft.Number)
+	second, err := versions.Publish()
+	require.NoError(t, err)
+	assert.Equal(t, draft.ID, second.ID)
+
+	archived, err := store.FunnelVersions().Get(first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, models.FunnelVersionArchived, archived.Status)
+}
+
+func TestMigrateMovesPinnedRecords(t *testing.T) {
+	store := newMemoryStore()
+	trial := store.addFunnel("Trial")
+	lead := store.addFunnel("Lead", trial)
+	from := store.publish([2]uint{lead, lead})
+	store.versions[0].Status = models.FunnelVersionArchived
+	target := store.publish([2]uint{trial, trial})
+	versions := NewFunnelVersions(store)
+
+	customer := &models.Customer{Name: "Ada", FunnelID: &lead, FunnelVersionID: &from}
+	require.NoError(t, store.Customers().Create(customer))
+	require.NoError(t, store.Customers().SaveMembership(&models.CustomerFunnel{CustomerID: customer.ID, FunnelID: lead, FunnelVersionID: &from}))
+
+	active, err := store.FunnelVersions().Get(target)
+	require.NoError(t, err)
+	_, err = versions.Migrate(active, &from, nil)
+	var migrationErr *MigrationError
+	require.ErrorAs(t, err, &migrationErr)
+	assert.Equal(t, "unmapped_stage", migrationErr.Code)
+	assert.Equal(t, lead, *customer.FunnelID)
+
+	result, err := versions.Migrate(active, &from, map[uint]uint{lead: trial})
+	require.NoError(t, err)
+	assert.Equal(t, VersionMigration{CustomersMigrated: 1, MembershipsMigrated: 1}, result)
+	assert.Equal(t, trial, *customer.FunnelID)
+	assert.Equal(t, target, *customer.FunnelVersionID)
+	assert.Equal(t, trial, store.memberships[0].FunnelID)
+	assert.Len(t, store.moves, 2)
+
+	_, err = versions.Migrate(active, &from, map[uint]uint{trial: lead})
+	require.ErrorAs(t, err, &migrationErr)
+	assert.Equal(t, "stage_map", migrationErr.Field)
+
+	archived, err := store.FunnelVersions().Get(from)
+	require.NoError(t, err)
+	_, err = versions.Migrate(archived, nil, nil)
+	assert.ErrorIs(t, err, ErrTargetVersionActive)
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/mokan/flame-crm-backend/internal/importer"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/problem"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+const (
+	ImportEntityCustomers = "customers"
+	ImportEntityCompanies = "companies"
+)
+
+var (
+	// ImportFields lists the fields a CSV column can map to, per entity.
+	ImportFields = map[string][]string{
+		ImportEntityCustomers: {"name", "email", "phone", "lead_source", "funnel_stage", "company", "tags"},
+		ImportEntityCompanies: {"name", "address"},
+	}
+
+	// ImportDedupeKeys lists the fields that identify an existing record,
+	// the default first.
+	ImportDedupeKeys = map[string][]string{
+		ImportEntityCustomers: {"email", "phone", "name"},
+		ImportEntityCompanies: {"name"},
+	}
+)
+
+var errImportDryRun = errors.New("Import dry run")
+
+// ImportError rejects an import as a whole, before any row is read.
+type ImportError struct {
+	Message string
+}
+
+func (e *ImportError) Error() string { return e.Message }
+
+type ImportOptions struct {
+	Entity    string
+	Mapping   map[string]string
+	DedupeKey string
+	DryRun    bool
+	// Role is the importing user's role, checked against the same field
+	// rules as the REST endpoints.
+	Role models.Role
+}
+
+type Imports struct {
+	store repository.Store
+}
+
+func NewImports(store repository.Store) *Imports {
+	return &Imports{store: store}
+}
+
+// Run creates or updates a record for each CSV row, each row in a
+// transaction of its own, and reports the outcome of every row. A dry run
+// rolls everything back.
+func (s *Imports) Run(r io.Reader, opts ImportOptions) (*importer.Report, error) {
+	fields, ok := ImportFields[opts.Entity]
+	if !ok {
+		return nil, &ImportError{Message: "Unknown import entity: " + opts.Entity}
+	}
+	keys := ImportDedupeKeys[opts.Entity]
+	if opts.DedupeKey == "" {
+		opts.DedupeKey = keys[0]
+	}
+	if !slices.Contains(keys, opts.DedupeKey) {
+		return nil, &ImportError{Message: fmt.Sprintf("dedupe_key must be one of: %s", strings.Join(keys, ", "))}
+	}
+
+	file, err := importer.Parse(r, fields, opts.Mapping)
+	if err != nil {
+		return nil, &ImportError{Message: err.Error()}
+	}
+	report := importer.NewReport(opts.Entity, opts.DedupeKey, opts.DryRun, file)
+
+	companies := map[string]uint{}
+	err = s.store.Transaction(func(tx repository.Store) error {
+		for _, row := range file.Rows {
+			var outcome importer.Outcome
+			err := tx.Transaction(func(tx repository.Store) error {
+				var err error
+				if opts.Entity == ImportEntityCustomers {
+					outcome, err = importCustomerRow(tx, row, opts.DedupeKey, companies)
+				} else {
+					outcome, err = importCompanyRow(tx, row, opts.DedupeKey, opts.Role)
+				}
+				return err
+			})
+			if err != nil {
+				outcome = importer.Outcome{Key: row.Values[opts.DedupeKey], Errors: importErrors(err)}
+			}
+			if opts.DryRun {
+				outcome.ID = 0
+			}
+			report.Add(row, outcome)
+		}
+		if opts.DryRun {
+			return errImportDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errImportDryRun) {
+		return nil, err
+	}
+	return report, nil
+}
+
+// importErrors describes why a row was rejected, one message per field when
+// the error names fields.
+func importErrors(err error) []string {
+	var p *problem.Problem
+	var fieldErr *patch.FieldError
+	switch {
+	case errors.As(err, &p) && len(p.Errors) > 0:
+		messages := make([]string, 0, len(p.Errors))
+		for _, fieldErr := range p.Errors {
+			messages = append(messages, fieldErr.Field+" "+fieldErr.Message)
+		}
+		return messages
+	case errors.As(err, &fieldErr):
+		return []string{fieldErr.Field + " " + fieldErr.Message}
+	case errors.As(err, &p):
+		return []string{p.Detail}
+	}
+	for _, known := range []error{ErrFunnelStateInvalid, ErrInvalidFunnelTransition, ErrFunnelNotFound, ErrMembershipFunnelMissing, ErrCompanyFunnelNotFound} {
+		if errors.Is(err, known) {
+			return []string{known.Error()}
+		}
+	}
+	log.Printf("internal error importing a row: %v", err)
+	return []string{"An unexpected error occurred"}
+}
+
+func validateImport(obj interface{}) error {
+	if err := binding.Validator.ValidateStruct(obj); err != nil {
+		return problem.FromBindError(err)
+	}
+	return nil
+}
+
+func importFieldError(field, code, message string) error {
+	p := problem.New(http.StatusBadRequest, problem.CodeValidationFailed, "Row failed validation")
+	p.Errors = []problem.FieldError{{Field: field, Code: code, Message: message}}
+	return p
+}
+
+// found turns repository.ErrNotFound into a false result.
+func found[T any](record *T, err error) (*T, bool, error) {
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, false, nil
+	}
+	return record, err == nil, err
+}
+
+func importCustomerRow(tx repository.Store, row importer.Row, dedupeKey string, companies map[string]uint) (importer.Outcome, error) {
+	values := row.Values
+	outcome := importer.Outcome{Key: values[dedupeKey]}
+
+	var companyID uint
+	if name := values["company"]; name != "" {
+		id, ok := companies[strings.ToLower(name)]
+		if !ok {
+			company, exists, err := found(tx.Companies().FindByName(name))
+			if err != nil {
+				return outcome, err
+			}
+			if !exists {
+				return outcome, importFieldError("company", "exists", fmt.Sprintf("no company named %q", name))
+			}
+			id = company.ID
+			companies[strings.ToLower(name)] = id
+		}
+		companyID = id
+	}
+
+	tagNames := strings.FieldsFunc(values["tags"], func(r rune) bool { return r == ';' || r == ',' })
+
+	var customer *models.Customer
+	if outcome.Key != "" {
+		existing, _, err := found(tx.Customers().FindByKey(dedupeKey, outcome.Key))
+		if err != nil {
+			return outcome, err
+		}
+		customer = existing
+	}
+
+	if customer == nil {
+		customer = &models.Customer{
+			Name:        values["name"],
+			Email:       values["email"],
+			Phone:       values["phone"],
+			LeadSource:  values["lead_source"],
+			FunnelStage: values["funnel_stage"],
+			CompanyID:   companyID,
+		}
+		for _, name := range tagNames {
+			customer.Tags = append(customer.Tags, models.Tag{Name: name})
+		}
+		if err := validateImport(customer); err != nil {
+			return outcome, err
+		}
+		if err := NewCustomers(tx).Create(customer); err != nil {
+			return outcome, err
+		}
+		outcome.Action = importer.ActionCreate
+		outcome.ID = customer.ID
+		return outcome, nil
+	}
+
+	if companyID != 0 {
+		customer.CompanyID = companyID
+	}
+	input := models.UpdateCustomerInput{
+		Name:        values["name"],
+		Email:       values["email"],
+		Phone:       values["phone"],
+		LeadSource:  values["lead_source"],
+		FunnelStage: values["funnel_stage"],
+		FunnelID:    customer.FunnelID,
+	}
+	if len(tagNames) > 0 {
+		input.Tags = tagNames
+	}
+	if err := NewCustomers(tx).Update(customer, input); err != nil {
+		return outcome, err
+	}
+	outcome.Action = importer.ActionUpdate
+	outcome.ID = customer.ID
+	return outcome, nil
+}
+
+func importCompanyRow(tx repository.Store, row importer.Row, dedupeKey string, role models.Role) (importer.Outcome, error) {
+	values := row.Values
+	outcome := importer.Outcome{Key: values[dedupeKey]}
+
+	var company *models.Company
+	if outcome.Key != "" {
+		existing, _, err := found(tx.Companies().FindByName(outcome.Key))
+		if err != nil {
+			return outcome, err
+		}
+		company = existing
+	}
+
+	input := models.CompanyInput{Name: values["name"], Address: values["address"]}
+	if company == nil {
+		if err := validateImport(&input); err != nil {
+			return outcome, err
+		}
+	}
+	updates, err := NewCompanies(tx).Updates(input, role)
+	if err != nil {
+		return outcome, err
+	}
+
+	if company == nil {
+		company = &models.Company{Name: input.Name, Address: input.Address}
+		if err := tx.Companies().Create(company); err != nil {
+			return outcome, err
+		}
+		outcome.Action = importer.ActionCreate
+		outcome.ID = company.ID
+		return outcome, nil
+	}
+
+	if err := tx.Companies().Update(company, updates); err != nil {
+		return outcome, err
+	}
+	outcome.Action = importer.ActionUpdate
+	outcome.ID = company.ID
+	return outcome, nil
+}
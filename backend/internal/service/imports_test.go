@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/importer"
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportCompaniesDedupesByName(t *testing.T) {
+	store := newMemoryStore()
+	imports := NewImports(store)
+
+	report, err := imports.Run(strings.NewReader("Name,Address\nAcme,Main St\nacme,Second St\n,No Name\n"), ImportOptions{Entity: ImportEntityCompanies, Role: models.RoleSales})
+	require.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, 1, report.Updated)
+	assert.Equal(t, []string{"name is required"}, report.Rows[2].Errors)
+	assert.Equal(t, importer.ActionError, report.Rows[2].Action)
+
+	require.Len(t, store.companies, 1)
+	for _, company := range store.companies {
+		assert.Equal(t, "Second St", company.Address)
+	}
+}
+
+func TestImportRejectsBadOptions(t *testing.T) {
+	imports := NewImports(newMemoryStore())
+
+	_, err := imports.Run(strings.NewReader("name\nAcme\n"), ImportOptions{Entity: "deals"})
+	var importErr *ImportError
+	assert.ErrorAs(t, err, &importErr)
+
+	_, err = imports.Run(strings.NewReader("name\nAcme\n"), ImportOptions{Entity: ImportEntityCompanies, DedupeKey: "address"})
+	assert.ErrorContains(t, err, "dedupe_key must be one of: name")
+}
@@ -0,0 +1,465 @@
+package service
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+)
+
+// memoryStore is an in-memory repository.Store for testing the services
+// without a database. Transactions are not isolated.
+type memoryStore struct {
+	companies   map[uint]*models.Company
+	customers   map[uint]*models.Customer
+	funnels     map[uint]*models.Funnel
+	next        map[uint][]uint
+	versions    []models.FunnelVersion
+	transitions map[uint][][2]uint
+	memberships []*models.CustomerFunnel
+	tags        []models.Tag
+	rules       []models.EnrollmentRule
+	users       []*models.User
+	moves       []models.FunnelMove
+	views       []*models.SavedView
+	jobs        []*models.ImportJob
+	lastID      uint
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{
+		companies:   map[uint]*models.Company{},
+		customers:   map[uint]*models.Customer{},
+		funnels:     map[uint]*models.Funnel{},
+		next:        map[uint][]uint{},
+		transitions: map[uint][][2]uint{},
+	}
+}
+
+func (s *memoryStore) id() uint {
+	s.lastID++
+	return s.lastID
+}
+
+func (s *memoryStore) addFunnel(name string, next ...uint) uint {
+	id := s.id()
+	s.funnels[id] = &models.Funnel{Name: name}
+	s.funnels[id].ID = id
+	s.next[id] = next
+	return id
+}
+
+func (s *memoryStore) publish(transitions ...[2]uint) uint {
+	id := s.id()
+	version := models.FunnelVersion{Number: len(s.versions) + 1, Status: models.FunnelVersionActive}
+	version.ID = id
+	s.versions = append(s.versions, version)
+	s.transitions[id] = transitions
+	return id
+}
+
+func (s *memoryStore) Companies() repository.CompanyRepository              { return memoryCompanies{s} }
+func (s *memoryStore) Customers() repository.CustomerRepository             { return memoryCustomers{s} }
+func (s *memoryStore) Funnels() repository.FunnelRepository                 { return memoryFunnels{s} }
+func (s *memoryStore) Tags() repository.TagRepository                       { return memoryTags{s} }
+func (s *memoryStore) EnrollmentRules() repository.EnrollmentRuleRepository { return memoryRules{s} }
+func (s *memoryStore) Users() repository.UserRepository                     { return memoryUsers{s} }
+func (s *memoryStore) SavedViews() repository.SavedViewRepository           { return memoryViews{s} }
+func (s *memoryStore) ImportJobs() repository.ImportJobRepository           { return memoryJobs{s} }
+func (s *memoryStore) FunnelVersions() repository.FunnelVersionRepository   { return memoryVersions{s} }
+
+func (s *memoryStore) Transaction(fn func(tx repository.Store) error) error {
+	return fn(s)
+}
+
+type memoryCompanies struct{ *memoryStore }
+
+func (r memoryCompanies) Get(id uint) (*models.Company, error) {
+	if company, ok := r.companies[id]; ok {
+		return company, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryCompanies) FindByName(name string) (*models.Company, error) {
+	for _, company := range r.companies {
+		if strings.EqualFold(company.Name, name) {
+			return company, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryCompanies) Create(company *models.Company) error {
+	company.ID = r.id()
+	r.companies[company.ID] = company
+	return nil
+}
+
+func (r memoryCompanies) Update(company *models.Company, updates map[string]interface{}) error {
+	if name, ok := updates["name"].(string); ok {
+		company.Name = name
+	}
+	if address, ok := updates["address"].(string); ok {
+		company.Address = address
+	}
+	return nil
+}
+
+type memoryCustomers struct{ *memoryStore }
+
+func (r memoryCustomers) Get(id uint) (*models.Customer, error) {
+	if customer, ok := r.customers[id]; ok {
+		return customer, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryCustomers) FindByKey(key, value string) (*models.Customer, error) {
+	for _, customer := range r.customers {
+		if key == "email" && strings.EqualFold(customer.Email, value) || key == "name" && strings.EqualFold(customer.Name, value) || key == "phone" && customer.Phone == value {
+			return customer, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryCustomers) Create(customer *models.Customer) error {
+	customer.ID = r.id()
+	r.customers[customer.ID] = customer
+	return nil
+}
+
+func (r memoryCustomers) Save(customer *models.Customer) error {
+	r.customers[customer.ID] = customer
+	return nil
+}
+
+func (r memoryCustomers) Update(customer *models.Customer, updates map[string]interface{}) error {
+	stored := r.customers[customer.ID]
+	if funnelID, ok := updates["funnel_id"].(uint); ok {
+		stored.FunnelID = &funnelID
+	}
+	if versionID, ok := updates["funnel_version_id"].(uint); ok {
+		stored.FunnelVersionID = &versionID
+	}
+	return nil
+}
+
+func (r memoryCustomers) SetFunnel(customer *models.Customer, funnelID uint) error {
+	customer.FunnelID = &funnelID
+	return nil
+}
+
+func (r memoryCustomers) ReplaceTags(customer *models.Customer, tags []models.Tag) error {
+	customer.Tags = tags
+	return nil
+}
+
+func (r memoryCustomers) Membership(customerID uint, pipeline string) (*models.CustomerFunnel, error) {
+	for _, m := range r.memberships {
+		if m.CustomerID == customerID && m.Pipeline == pipeline {
+			copied := *m
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryCustomers) SaveMembership(membership *models.CustomerFunnel) error {
+	if membership.ID == 0 {
+		membership.ID = r.id()
+		copied := *membership
+		r.memberships = append(r.memberships, &copied)
+		return nil
+	}
+	for i, m := range r.memberships {
+		if m.ID == membership.ID {
+			copied := *membership
+			r.memberships[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r memoryCustomers) DeleteMembership(membership *models.CustomerFunnel) error {
+	r.memberships = slices.DeleteFunc(r.memberships, func(m *models.CustomerFunnel) bool { return m.ID == membership.ID })
+	return nil
+}
+
+func (r memoryCustomers) UpdateMembership(membership *models.CustomerFunnel, updates map[string]interface{}) error {
+	for _, m := range r.memberships {
+		if m.ID != membership.ID {
+			continue
+		}
+		if funnelID, ok := updates["funnel_id"].(uint); ok {
+			m.FunnelID = funnelID
+		}
+		if versionID, ok := updates["funnel_version_id"].(uint); ok {
+			m.FunnelVersionID = &versionID
+		}
+	}
+	return nil
+}
+
+type memoryFunnels struct{ *memoryStore }
+
+func (r memoryFunnels) Get(id uint) (*models.Funnel, error) {
+	if funnel, ok := r.funnels[id]; ok {
+		return funnel, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryFunnels) NextFunnelIDs(id uint) ([]uint, error) {
+	if _, ok := r.funnels[id]; !ok {
+		return nil, repository.ErrNotFound
+	}
+	return r.next[id], nil
+}
+
+func (r memoryFunnels) VersionAllows(versionID, fromID, toID uint) (bool, error) {
+	return slices.Contains(r.transitions[versionID], [2]uint{fromID, toID}), nil
+}
+
+func (r memoryFunnels) ActiveVersion() (*models.FunnelVersion, error) {
+	return memoryVersions(r).Latest(models.FunnelVersionActive)
+}
+
+func (r memoryFunnels) RecordMove(move *models.FunnelMove) error {
+	r.moves = append(r.moves, *move)
+	return nil
+}
+
+type memoryTags struct{ *memoryStore }
+
+func (r memoryTags) FindOrCreate(name string) (models.Tag, error) {
+	for _, tag := range r.tags {
+		if strings.EqualFold(tag.Name, name) {
+			return tag, nil
+		}
+	}
+	tag := models.Tag{Name: name}
+	tag.ID = r.id()
+	r.tags = append(r.tags, tag)
+	return tag, nil
+}
+
+type memoryRules struct{ *memoryStore }
+
+func (r memoryRules) Active() ([]models.EnrollmentRule, error) {
+	var rules []models.EnrollmentRule
+	for _, rule := range r.rules {
+		if !rule.Disabled {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+type memoryUsers struct{ *memoryStore }
+
+func (r memoryUsers) Get(id uint) (*models.User, error) {
+	for _, user := range r.users {
+		if user.ID == id {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryUsers) FindByEmail(email string) (*models.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryUsers) Count() (int64, error) {
+	return int64(len(r.users)), nil
+}
+
+func (r memoryUsers) Create(user *models.User) error {
+	user.ID = r.id()
+	r.users = append(r.users, user)
+	return nil
+}
+
+func (r memoryUsers) Update(user *models.User, updates map[string]interface{}) error {
+	if password, ok := updates["password"].(string); ok {
+		user.Password = password
+	}
+	return nil
+}
+
+type memoryViews struct{ *memoryStore }
+
+func (r memoryViews) visible(user *models.User, view *models.SavedView) bool {
+	return view.UserID == user.ID || view.Visibility == models.ViewEveryone ||
+		view.Visibility == models.ViewTeam && user.CompanyID != nil && view.CompanyID != nil && *view.CompanyID == *user.CompanyID
+}
+
+func (r memoryViews) Visible(user *models.User, resource string) ([]models.SavedView, error) {
+	var views []models.SavedView
+	for _, view := range r.views {
+		if r.visible(user, view) && (resource == "" || view.Resource == resource) {
+			views = append(views, *view)
+		}
+	}
+	return views, nil
+}
+
+func (r memoryViews) GetVisible(user *models.User, id uint) (*models.SavedView, error) {
+	for _, view := range r.views {
+		if view.ID == id && r.visible(user, view) {
+			return view, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryViews) Create(view *models.SavedView) error {
+	view.ID = r.id()
+	r.views = append(r.views, view)
+	return nil
+}
+
+func (r memoryViews) Save(view *models.SavedView) error {
+	for i, stored := range r.views {
+		if stored.ID == view.ID {
+			r.views[i] = view
+		}
+	}
+	return nil
+}
+
+func (r memoryViews) Delete(view *models.SavedView) error {
+	r.views = slices.DeleteFunc(r.views, func(v *models.SavedView) bool { return v.ID == view.ID })
+	return nil
+}
+
+type memoryJobs struct{ *memoryStore }
+
+func (r memoryJobs) Create(job *models.ImportJob) error {
+	job.ID = r.id()
+	r.jobs = append(r.jobs, job)
+	return nil
+}
+
+func (r memoryJobs) Get(id uint, ownerID *uint) (*models.ImportJob, error) {
+	for _, job := range r.jobs {
+		if job.ID == id && (ownerID == nil || job.UserID == *ownerID) {
+			return job, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+type memoryVersions struct{ *memoryStore }
+
+func (r memoryVersions) Get(id uint) (*models.FunnelVersion, error) {
+	for i := range r.versions {
+		if r.versions[i].ID == id {
+			return &r.versions[i], nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r memoryVersions) Latest(status models.FunnelVersionStatus) (*models.FunnelVersion, error) {
+	for i := len(r.versions) - 1; i >= 0; i-- {
+		if r.versions[i].Status == status {
+			return &r.versions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r memoryVersions) MaxNumber() (int, error) {
+	return len(r.versions), nil
+}
+
+func (r memoryVersions) Create(version *models.FunnelVersion) error {
+	version.ID = r.id()
+	r.versions = append(r.versions, *version)
+	return nil
+}
+
+func (r memoryVersions) Save(version *models.FunnelVersion) error {
+	for i := range r.versions {
+		if r.versions[i].ID == version.ID {
+			r.versions[i] = *version
+		}
+	}
+	return nil
+}
+
+func (r memoryVersions) ArchiveActive() error {
+	for i := range r.versions {
+		if r.versions[i].Status == models.FunnelVersionActive {
+			r.versions[i].Status = models.FunnelVersionArchived
+		}
+	}
+	return nil
+}
+
+func (r memoryVersions) LiveTransitions() ([]models.FunnelVersionTransition, error) {
+	var transitions []models.FunnelVersionTransition
+	for from, next := range r.next {
+		for _, to := range next {
+			transitions = append(transitions, models.FunnelVersionTransition{FromFunnelID: from, ToFunnelID: to})
+		}
+	}
+	return transitions, nil
+}
+
+func (r memoryVersions) CreateTransitions(transitions []models.FunnelVersionTransition) error {
+	for _, transition := range transitions {
+		r.transitions[transition.FunnelVersionID] = append(r.transitions[transition.FunnelVersionID], [2]uint{transition.FromFunnelID, transition.ToFunnelID})
+	}
+	return nil
+}
+
+func (r memoryVersions) Stages(versionID *uint) ([]uint, error) {
+	var ids []uint
+	if versionID == nil {
+		for id := range r.funnels {
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	for _, transition := range r.transitions[*versionID] {
+		ids = append(ids, transition[0], transition[1])
+	}
+	return ids, nil
+}
+
+func pinned(versionID, to *uint) bool {
+	if versionID == nil {
+		return to == nil
+	}
+	return to != nil && *to == *versionID
+}
+
+func (r memoryVersions) PinnedCustomers(versionID *uint) ([]models.Customer, error) {
+	var customers []models.Customer
+	for _, customer := range r.customers {
+		if pinned(customer.FunnelVersionID, versionID) {
+			customers = append(customers, *customer)
+		}
+	}
+	return customers, nil
+}
+
+func (r memoryVersions) PinnedMemberships(versionID *uint) ([]models.CustomerFunnel, error) {
+	var memberships []models.CustomerFunnel
+	for _, membership := range r.memberships {
+		if pinned(membership.FunnelVersionID, versionID) {
+			memberships = append(memberships, *membership)
+		}
+	}
+	return memberships, nil
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/mokan/flame-crm-backend/internal/patch"
+	"github.com/mokan/flame-crm-backend/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrInvalidCredentials = errors.New("Invalid credentials")
+
+// Managers are the roles that may change funnels, reassign records between
+// companies and publish funnel versions.
+var Managers = []models.Role{models.RoleAdmin, models.RoleHeadOfSales}
+
+// UserFields lists the user fields clients may write and the roles allowed
+// to write them.
+var UserFields = patch.Allowlist{
+	"name":       {Column: "name", Kind: patch.String, Required: true, Rules: "min=1"},
+	"email":      {Column: "email", Kind: patch.String, Required: true, Rules: "email"},
+	"password":   {Column: "password", Kind: patch.String, Required: true, Rules: "min=6"},
+	"role":       {Column: "role", Kind: patch.String, Required: true, Rules: "oneof=admin sales head_of_sales", Roles: []models.Role{models.RoleAdmin}},
+	"company_id": {Column: "company_id", Kind: patch.Uint, Nullable: true, Roles: []models.Role{models.RoleAdmin}},
+}
+
+type Users struct {
+	store repository.Store
+}
+
+func NewUsers(store repository.Store) *Users {
+	return &Users{store: store}
+}
+
+// Register creates a self-registered user. The first user becomes an admin,
+// everyone after that a sales user.
+func (s *Users) Register(user *models.User, password string) error {
+	count, err := s.store.Users().Count()
+	if err != nil {
+		return err
+	}
+	user.Role = models.RoleSales
+	if count == 0 {
+		user.Role = models.RoleAdmin
+	}
+	return s.Create(user, password)
+}
+
+// Create stores user with the hash of password.
+func (s *Users) Create(user *models.User, password string) error {
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	user.Password = hashed
+	return s.store.Users().Create(user)
+}
+
+// Update writes updates to user, hashing a new password first.
+func (s *Users) Update(user *models.User, updates map[string]interface{}) error {
+	if err := PrepareUserUpdates(updates); err != nil {
+		return err
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return s.store.Users().Update(user, updates)
+}
+
+// Authenticate returns the user with email when password matches theirs.
+func (s *Users) Authenticate(email, password string) (*models.User, error) {
+	user, err := s.store.Users().FindByEmail(email)
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+	return user, nil
+}
+
+// PrepareUserUpdates replaces a password in updates with its hash, so no
+// write path stores it in clear.
+func PrepareUserUpdates(updates map[string]interface{}) error {
+	password, ok := updates["password"].(string)
+	if !ok {
+		return nil
+	}
+	hashed, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+	updates["password"] = hashed
+	return nil
+}
+
+func hashPassword(password string) (string, error) {
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hashed), err
+}
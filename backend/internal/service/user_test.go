@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterMakesFirstUserAdmin(t *testing.T) {
+	store := newMemoryStore()
+	users := NewUsers(store)
+
+	first := models.User{Name: "Ada", Email: "ada@example.com"}
+	require.NoError(t, users.Register(&first, "secret"))
+	assert.Equal(t, models.RoleAdmin, first.Role)
+	assert.NotEqual(t, "secret", first.Password)
+
+	second := models.User{Name: "Bob", Email: "bob@example.com"}
+	require.NoError(t, users.Register(&second, "secret"))
+	assert.Equal(t, models.RoleSales, second.Role)
+}
+
+func TestAuthenticate(t *testing.T) {
+	store := newMemoryStore()
+	users := NewUsers(store)
+	user := models.User{Name: "Ada", Email: "ada@example.com", Role: models.RoleSales}
+	require.NoError(t, users.Create(&user, "secret"))
+
+	found, err := users.Authenticate("ada@example.com", "secret")
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, found.ID)
+
+	_, err = users.Authenticate("ada@example.com", "wrong")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	_, err = users.Authenticate("nobody@example.com", "secret")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+
+	require.NoError(t, users.Update(&user, map[string]interface{}{"password": "changed"}))
+	_, err = users.Authenticate("ada@example.com", "changed")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,193 @@
+// Package sparse implements ?fields= and ?include= on read endpoints: the
+// client picks which columns to load and which relations to preload, both
+// checked against a per-resource allowlist.
+package sparse
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+type Spec struct {
+	// Model is a value of the resource type, e.g. models.Company{}.
+	Model interface{}
+	// Fields are the columns a client may ask for. The primary key is always
+	// returned.
+	Fields []string
+	// Includes maps include names to relation fields on Model.
+	Includes map[string]string
+	// Default is preloaded when the request has no include parameter.
+	Default []string
+}
+
+// Selection is a parsed fields and include pair.
+type Selection struct {
+	columns  []string
+	preloads []string
+	// keep is the set of JSON keys to return; nil keeps every field.
+	keep map[string]bool
+	// drop lists JSON keys of relations that were not included.
+	drop     []string
+	explicit bool
+}
+
+// Parse reads fields and include from values and validates them against
+// spec.
+func Parse(tx *gorm.DB, values url.Values, spec Spec) (Selection, error) {
+	var sel Selection
+	stmt := &gorm.Statement{DB: tx}
+	if err := stmt.Parse(spec.Model); err != nil {
+		return sel, err
+	}
+	s := stmt.Schema
+
+	includes := spec.Default
+	if raw, ok := values["include"]; ok {
+		includes = split(raw[0])
+		sel.explicit = true
+	}
+	included := map[string]bool{}
+	for _, name := range includes {
+		field, ok := spec.Includes[name]
+		if !ok {
+			return sel, fmt.Errorf("unknown include %q", name)
+		}
+		included[name] = true
+		sel.preloads = append(sel.preloads, field)
+	}
+	for name, field := range spec.Includes {
+		if !included[name] {
+			sel.drop = append(sel.drop, jsonKey(s.Relationships.Relations[field].Field))
+		}
+	}
+
+	raw, ok := values["fields"]
+	if !ok {
+		return sel, nil
+	}
+	sel.explicit = true
+	allowed := map[string]bool{}
+	for _, name := range spec.Fields {
+		allowed[name] = true
+	}
+
+	primary := s.PrioritizedPrimaryField
+	sel.columns = []string{primary.DBName}
+	sel.keep = map[string]bool{jsonKey(primary): true}
+	for _, name := range split(raw[0]) {
+		if !allowed[name] {
+			return sel, fmt.Errorf("unknown field %q", name)
+		}
+		field := s.LookUpField(name)
+		if field == nil {
+			return sel, fmt.Errorf("unknown field %q", name)
+		}
+		sel.columns = append(sel.columns, field.DBName)
+		sel.keep[jsonKey(field)] = true
+	}
+	for _, name := range includes {
+		rel := s.Relationships.Relations[spec.Includes[name]]
+		sel.keep[jsonKey(rel.Field)] = true
+		// A belongs-to preload looks the parent up by our foreign key.
+		if rel.Type == schema.BelongsTo {
+			for _, ref := range rel.References {
+				sel.columns = append(sel.columns, ref.ForeignKey.DBName)
+			}
+		}
+	}
+	return sel, nil
+}
+
+// Apply selects the requested columns plus extra, which callers use for
+// columns they need themselves such as sort keys, and adds the preloads.
+func (sel Selection) Apply(tx *gorm.DB, extra ...string) *gorm.DB {
+	if sel.columns != nil {
+		tx = tx.Select(unique(append(sel.columns, extra...)))
+	}
+	for _, preload := range sel.preloads {
+		tx = tx.Preload(preload)
+	}
+	return tx
+}
+
+// Render trims v, a struct or slice of structs, to the selected keys. Without
+// fields or include in the request v is returned as is.
+func (sel Selection) Render(v interface{}) (interface{}, error) {
+	if !sel.explicit {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "[") {
+		var rows []map[string]json.RawMessage
+		if err := json.Unmarshal(data, &rows); err != nil {
+			return nil, err
+		}
+		if rows == nil {
+			rows = []map[string]json.RawMessage{}
+		}
+		for _, row := range rows {
+			sel.trim(row)
+		}
+		return rows, nil
+	}
+
+	var row map[string]json.RawMessage
+	if err := json.Unmarshal(data, &row); err != nil {
+		return nil, err
+	}
+	sel.trim(row)
+	return row, nil
+}
+
+func (sel Selection) trim(row map[string]json.RawMessage) {
+	for _, key := range sel.drop {
+		delete(row, key)
+	}
+	if sel.keep == nil {
+		return
+	}
+	for key := range row {
+		if !sel.keep[key] {
+			delete(row, key)
+		}
+	}
+}
+
+func jsonKey(field *schema.Field) string {
+	name, _, _ := strings.Cut(field.StructField.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func split(raw string) []string {
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func unique(names []string) []string {
+	seen := map[string]bool{}
+	result := names[:0:0]
+	for _, name := range names {
+		if !seen[name] {
+			seen[name] = true
+			result = append(result, name)
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package sparse
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/mokan/flame-crm-backend/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+var userSpec = Spec{
+	Model:    models.User{},
+	Fields:   []string{"name", "email", "created_at"},
+	Includes: map[string]string{"company": "Company"},
+	Default:  []string{"company"},
+}
+
+func setupSparseDB(t *testing.T) *gorm.DB {
+	database, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, database.AutoMigrate(&models.Company{}, &models.User{}))
+
+	company := models.Company{Name: "Acme"}
+	require.NoError(t, database.Create(&company).Error)
+	require.NoError(t, database.Create(&models.User{Name: "Ada", Email: "ada@acme.test", Password: "secret", Role: models.RoleAdmin, CompanyID: &company.ID}).Error)
+	return database
+}
+
+func render(t *testing.T, database *gorm.DB, query string) map[string]interface{} {
+	values, _ := url.ParseQuery(query)
+	sel, err := Parse(database, values, userSpec)
+	require.NoError(t, err)
+
+	var users []models.User
+	require.NoError(t, sel.Apply(database).Find(&users).Error)
+	require.Len(t, users, 1)
+	out, err := sel.Render(users)
+	require.NoError(t, err)
+
+	data, _ := json.Marshal(out)
+	var rows []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &rows))
+	return rows[0]
+}
+
+func TestSelection(t *testing.T) {
+	database := setupSparseDB(t)
+
+	row := render(t, database, "")
+	assert.Equal(t, "Acme", row["company"].(map[string]interface{})["name"])
+	assert.Contains(t, row, "role")
+
+	row = render(t, database, "include=")
+	assert.NotContains(t, row, "company")
+	assert.Equal(t, "ada@acme.test", row["email"])
+
+	row = render(t, database, "fields=name&include=company")
+	assert.ElementsMatch(t, []string{"ID", "name", "company"}, keys(row))
+	assert.Equal(t, "Acme", row["company"].(map[string]interface{})["name"])
+
+	row = render(t, database, "fields=created_at,email")
+	assert.ElementsMatch(t, []string{"ID", "CreatedAt", "email", "company"}, keys(row))
+}
+
+func TestSelectionRejectsUnknownNames(t *testing.T) {
+	database := setupSparseDB(t)
+	for query, message := range map[string]string{
+		"fields=password":  `unknown field "password"`,
+		"fields=name,nope": `unknown field "nope"`,
+		"include=tags":     `unknown include "tags"`,
+	} {
+		values, _ := url.ParseQuery(query)
+		_, err := Parse(database, values, userSpec)
+		if assert.Error(t, err, query) {
+			assert.Equal(t, message, err.Error())
+		}
+	}
+}
+
+func TestSelectionSelectsOnlyNeededColumns(t *testing.T) {
+	database := setupSparseDB(t)
+	values, _ := url.ParseQuery("fields=name&include=company")
+	sel, err := Parse(database, values, userSpec)
+	require.NoError(t, err)
+
+	sql := database.ToSQL(func(tx *gorm.DB) *gorm.DB {
+		return sel.Apply(tx, "created_at").Find(&[]models.User{})
+	})
+	assert.True(t, strings.HasPrefix(sql, "SELECT `id`,`name`,`company_id`,`created_at` FROM `users`"), sql)
+}
+
+func keys(row map[string]interface{}) []string {
+	var names []string
+	for name := range row {
+		names = append(names, name)
+	}
+	return names
+}
@@ -48,8 +48,8 @@ export default function Companies() {
   const fetchData = async () => {
       try {
         const [compRes, funnelRes] = await Promise.all([
-          api.get('/api/companies'),
-          api.get('/api/funnels')
+          api.get('/api/v1/companies?include=funnel'),
+          api.get('/api/v1/funnels')
         ]);
         setCompanies(compRes.data);
         setFunnels(funnelRes.data);
@@ -77,9 +77,9 @@ export default function Companies() {
           const payload = { ...data, funnel_id: data.funnel_id === 0 ? null : data.funnel_id };
 
           if (editingCompany) {
-              await api.put(`/api/companies/${editingCompany.ID}`, payload);
+              await api.put(`/api/v1/companies/${editingCompany.ID}`, payload);
           } else {
-              await api.post('/api/companies', payload);
+              await api.post('/api/v1/companies', payload);
           }
           await fetchData();
           setIsCreating(false);
@@ -19,7 +19,7 @@ export default function Customers() {
   useEffect(() => {
     const fetchCustomers = async () => {
       try {
-        const res = await api.get('/api/customers');
+        const res = await api.get('/api/v1/customers?fields=name,email,phone,funnel_stage&include=');
         setCustomers(res.data);
       } catch (error) {
         console.error("Failed to fetch customers", error);
@@ -41,7 +41,7 @@ export default function Funnels() {
 
   const fetchData = async () => {
     try {
-      const funnelsRes = await api.get('/api/funnels');
+      const funnelsRes = await api.get('/api/v1/funnels');
       setFunnels(funnelsRes.data);
     } catch (error) {
       console.error("Failed to fetch data", error);
@@ -65,7 +65,7 @@ export default function Funnels() {
   const onDelete = async (id: number) => {
       if (!confirm("Are you sure you want to delete this funnel?")) return;
       try {
-          await api.delete(`/api/funnels/${id}`);
+          await api.delete(`/api/v1/funnels/${id}`);
           fetchData();
       } catch (error) {
           console.error("Failed to delete funnel", error);
@@ -75,9 +75,9 @@ export default function Funnels() {
   const onSubmit = async (data: FunnelFormValues) => {
       try {
           if (editingFunnel) {
-              await api.put(`/api/funnels/${editingFunnel.ID}`, data);
+              await api.put(`/api/v1/funnels/${editingFunnel.ID}`, data);
           } else {
-              await api.post('/api/funnels', data);
+              await api.post('/api/v1/funnels', data);
           }
           await fetchData();
           setIsCreating(false);
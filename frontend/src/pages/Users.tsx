@@ -18,7 +18,7 @@ export default function Users() {
   useEffect(() => {
     const fetchUsers = async () => {
       try {
-        const res = await api.get('/api/users');
+        const res = await api.get('/api/v1/users');
         setUsers(res.data);
       } catch (error) {
         console.error("Failed to fetch users", error);